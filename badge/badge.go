@@ -0,0 +1,87 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package badge generates shields.io-style SVG status badges.
+package badge
+
+import (
+	"fmt"
+	"html"
+	"io"
+)
+
+// Colors used for the badge message.
+const (
+	Green = "#4c1"
+	Red   = "#e05d44"
+)
+
+// Badge describes the content of a status badge.
+type Badge struct {
+	Label   string // the text on the left-hand side of the badge
+	Message string // the text on the right-hand side of the badge
+	Color   string // the background color of the message
+}
+
+// ForViolations returns a license compliance Badge for the given number of
+// license violations.
+func ForViolations(count int) Badge {
+	switch count {
+	case 0:
+		return Badge{"license", "passing", Green}
+	case 1:
+		return Badge{"license", "1 violation", Red}
+	default:
+		return Badge{"license", fmt.Sprintf("%d violations", count), Red}
+	}
+}
+
+// textWidth returns an approximate width in pixels of s when rendered with
+// the 11px Verdana font used by the badge.
+func textWidth(s string) int {
+	const charWidth = 7
+	return len(s) * charWidth
+}
+
+// WriteSVG writes the badge as an SVG image to w.
+func (b Badge) WriteSVG(w io.Writer) error {
+	const padding = 10
+	labelWidth := textWidth(b.Label) + padding
+	messageWidth := textWidth(b.Message) + padding
+	width := labelWidth + messageWidth
+	label, message := html.EscapeString(b.Label), html.EscapeString(b.Message)
+
+	_, err := fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[4]v: %[5]v">
+  <title>%[4]v: %[5]v</title>
+  <linearGradient id="s" x2="0" y2="100%%">
+    <stop offset="0" stop-color="#bbb" stop-opacity=".1"/>
+    <stop offset="1" stop-opacity=".1"/>
+  </linearGradient>
+  <clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>
+  <g clip-path="url(#r)">
+    <rect width="%[2]d" height="20" fill="#555"/>
+    <rect x="%[2]d" width="%[3]d" height="20" fill="%[6]v"/>
+    <rect width="%[1]d" height="20" fill="url(#s)"/>
+  </g>
+  <g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+    <text x="%[7]d" y="15" fill="#010101" fill-opacity=".3">%[4]v</text>
+    <text x="%[7]d" y="14">%[4]v</text>
+    <text x="%[8]d" y="15" fill="#010101" fill-opacity=".3">%[5]v</text>
+    <text x="%[8]d" y="14">%[5]v</text>
+  </g>
+</svg>
+`, width, labelWidth, messageWidth, label, message, b.Color,
+		labelWidth/2, labelWidth+messageWidth/2)
+	return err
+}
@@ -0,0 +1,55 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package badge_test
+
+import (
+	"strings"
+	"testing"
+
+	badge "."
+)
+
+func TestForViolations(t *testing.T) {
+	for _, test := range []struct {
+		count  int
+		expect badge.Badge
+	}{
+		{0, badge.Badge{Label: "license", Message: "passing", Color: badge.Green}},
+		{1, badge.Badge{Label: "license", Message: "1 violation", Color: badge.Red}},
+		{12, badge.Badge{Label: "license", Message: "12 violations", Color: badge.Red}},
+	} {
+		if got := badge.ForViolations(test.count); got != test.expect {
+			t.Errorf("ForViolations(%v) returned %+v, expected %+v", test.count, got, test.expect)
+		}
+	}
+}
+
+func TestWriteSVG(t *testing.T) {
+	sb := strings.Builder{}
+	b := badge.Badge{Label: "license", Message: "<3 violations>", Color: badge.Red}
+	if err := b.WriteSVG(&sb); err != nil {
+		t.Fatalf("WriteSVG() returned %v", err)
+	}
+	svg := sb.String()
+	for _, expect := range []string{
+		`<svg xmlns="http://www.w3.org/2000/svg"`,
+		`<title>license: &lt;3 violations&gt;</title>`,
+		`fill="#e05d44"`,
+	} {
+		if !strings.Contains(svg, expect) {
+			t.Errorf("SVG did not contain '%v':\n%v", expect, svg)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// capi is a C shared library facade of the license checker, for build systems
+// that cannot run the license-checker executable for each file.
+//
+// Build with:
+//
+//	go build -buildmode=c-shared -o liblicensechecker.so ./capi
+//
+// which also generates the liblicensechecker.h header.
+//
+// Every function that returns a string returns a JSON object allocated with
+// malloc, which must be released with FreeString. On failure, the object holds
+// a single 'error' field.
+package main
+
+// #include <stdlib.h>
+import "C"
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"unsafe"
+
+	"../checker"
+	"../detector"
+	"../report"
+)
+
+// policies holds the loaded policies, keyed by the handle returned to C.
+var policies = struct {
+	sync.Mutex
+	next int
+	byID map[int]*checker.Policy
+}{byID: map[int]*checker.Policy{}}
+
+// LoadPolicy loads the config file at configPath, and the optional license
+// database at licenseDB (which may be NULL or empty). It returns
+// {"policy": <handle>}, where handle is passed to the Check functions.
+//
+//export LoadPolicy
+func LoadPolicy(configPath, licenseDB *C.char) *C.char {
+	body, err := ioutil.ReadFile(C.GoString(configPath))
+	if err != nil {
+		return failure(fmt.Errorf("Failed to read config file: %w", err))
+	}
+	return newPolicy(body, licenseDB)
+}
+
+// ParsePolicy is like LoadPolicy, but takes the content of the config file.
+//
+//export ParsePolicy
+func ParsePolicy(config, licenseDB *C.char) *C.char {
+	return newPolicy([]byte(C.GoString(config)), licenseDB)
+}
+
+// ReleasePolicy releases the policy with the given handle.
+//
+//export ReleasePolicy
+func ReleasePolicy(policy C.int) {
+	policies.Lock()
+	defer policies.Unlock()
+	delete(policies.byID, int(policy))
+}
+
+// CheckPath checks the file at path, which is either absolute or relative to
+// the project root directory. It returns the JSON report of the check, as
+// written by 'license-checker --format json'.
+//
+//export CheckPath
+func CheckPath(policy C.int, root, path *C.char) *C.char {
+	p, err := lookup(policy)
+	if err != nil {
+		return failure(err)
+	}
+	dir, err := filepath.Abs(C.GoString(root))
+	if err != nil {
+		return failure(err)
+	}
+	results, err := p.CheckFile(dir, C.GoString(path))
+	if err != nil {
+		return failure(err)
+	}
+	return writeReport(dir, results)
+}
+
+// CheckBytes checks the length bytes at data as the content of the file at the
+// project relative path. It returns the JSON report of the check.
+//
+//export CheckBytes
+func CheckBytes(policy C.int, path *C.char, data unsafe.Pointer, length C.int) *C.char {
+	p, err := lookup(policy)
+	if err != nil {
+		return failure(err)
+	}
+	body := C.GoBytes(data, length)
+	return writeReport("", p.CheckContent(C.GoString(path), body))
+}
+
+// FreeString releases a string returned by any of the library's functions.
+//
+//export FreeString
+func FreeString(s *C.char) {
+	C.free(unsafe.Pointer(s))
+}
+
+// newPolicy parses the config body, and returns the handle of a new Policy.
+func newPolicy(body []byte, licenseDB *C.char) *C.char {
+	cfgs, err := checker.ParseConfigs(body)
+	if err != nil {
+		return failure(fmt.Errorf("Failed to load config file: %w", err))
+	}
+	var db *detector.Database
+	if path := C.GoString(licenseDB); path != "" {
+		if db, err = detector.LoadDatabase(path); err != nil {
+			return failure(err)
+		}
+	}
+	p, err := checker.NewPolicy(cfgs, db)
+	if err != nil {
+		return failure(err)
+	}
+
+	policies.Lock()
+	defer policies.Unlock()
+	policies.next++
+	policies.byID[policies.next] = p
+	return marshal(map[string]int{"policy": policies.next})
+}
+
+// lookup returns the Policy with the given handle.
+func lookup(policy C.int) (*checker.Policy, error) {
+	policies.Lock()
+	defer policies.Unlock()
+	p, ok := policies.byID[int(policy)]
+	if !ok {
+		return nil, fmt.Errorf("Unknown policy handle %d", int(policy))
+	}
+	return p, nil
+}
+
+// writeReport returns the JSON report of the results.
+func writeReport(root string, results checker.Results) *C.char {
+	buf := bytes.Buffer{}
+	if err := report.Write(&buf, "json", report.Input{Root: root, Results: results}); err != nil {
+		return failure(err)
+	}
+	return C.CString(buf.String())
+}
+
+// failure returns the JSON error object for err.
+func failure(err error) *C.char {
+	return marshal(map[string]string{"error": err.Error()})
+}
+
+// marshal returns the JSON encoding of v as a C string.
+func marshal(v interface{}) *C.char {
+	body, err := json.Marshal(v)
+	if err != nil {
+		body = []byte(`{"error":"Failed to encode result"}`)
+	}
+	return C.CString(string(body))
+}
+
+func main() {}
@@ -0,0 +1,176 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+)
+
+// Baseline is the set of known violations of a project, committed to the
+// project so that only new violations fail the check. Baselines hold the
+// violation fingerprints, which only depend on the '/' separated project
+// relative path, the kind, and the trimmed lines of the file's leading
+// comment, so a baseline written by a scan of a Windows checkout, with CRLF
+// line endings, is identical to one written on Linux. Baselines written by
+// scans of different checkouts, such as of several CI lanes, can be combined
+// with MergeBaselines.
+//
+// The baseline file is a JSON object of the form:
+//
+//	{
+//	  "violations": [
+//	    { "path": "src/a.go", "kind": "no-license", "fingerprint": "5c8a2662b65a6538" }
+//	  ]
+//	}
+type Baseline struct {
+	Violations []BaselineEntry `json:"violations"`
+}
+
+// BaselineEntry is a single known violation of a Baseline.
+type BaselineEntry struct {
+	Path        string        `json:"path"` // project relative path, using '/' separators
+	Kind        ViolationKind `json:"kind"`
+	Fingerprint string        `json:"fingerprint"`
+}
+
+// Baseline returns the baseline of the violations of the results.
+func (r Results) Baseline() Baseline {
+	b := Baseline{}
+	for _, res := range r {
+		if res.Err != nil {
+			b.Violations = append(b.Violations, BaselineEntry{Path: filepath.ToSlash(res.Path), Kind: res.Kind, Fingerprint: res.Fingerprint})
+		}
+	}
+	return MergeBaselines(b)
+}
+
+// MergeBaselines returns the union of the baselines, sorted by path, kind and
+// fingerprint, so that the merged file does not change with the order of the
+// baselines.
+func MergeBaselines(baselines ...Baseline) Baseline {
+	seen := map[string]bool{}
+	out := Baseline{Violations: []BaselineEntry{}}
+	for _, b := range baselines {
+		for _, e := range b.Violations {
+			if !seen[e.Fingerprint] {
+				seen[e.Fingerprint] = true
+				out.Violations = append(out.Violations, e)
+			}
+		}
+	}
+	sort.Slice(out.Violations, func(i, j int) bool {
+		a, b := out.Violations[i], out.Violations[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Fingerprint < b.Fingerprint
+	})
+	return out
+}
+
+// Accept returns a copy of the results, with the violations of the baseline
+// marked as Advisory, so that they are reported as warnings.
+func (r Results) Accept(b Baseline) Results {
+	known := map[string]bool{}
+	for _, e := range b.Violations {
+		known[e.Fingerprint] = true
+	}
+	out := make(Results, len(r))
+	for i, res := range r {
+		if res.Err != nil && known[res.Fingerprint] {
+			res.Advisory = true
+		}
+		out[i] = res
+	}
+	return out
+}
+
+// LoadBaseline loads the baseline file at path.
+func LoadBaseline(path string) (Baseline, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Baseline{}, fmt.Errorf("Failed to read baseline: %w", err)
+	}
+	b := Baseline{}
+	if err := json.Unmarshal(body, &b); err != nil {
+		return Baseline{}, fmt.Errorf("Failed to parse baseline '%v': %w", path, err)
+	}
+	return b, nil
+}
+
+// Write writes the baseline to the file at path, with '\n' line endings on
+// every OS.
+func (b Baseline) Write(path string) error {
+	if b.Violations == nil {
+		b.Violations = []BaselineEntry{}
+	}
+	body, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, append(body, '\n'), 0666); err != nil {
+		return fmt.Errorf("Failed to write baseline: %w", err)
+	}
+	return nil
+}
+
+// BaselineSummary counts the violations of a scan against a Baseline, so
+// that progress on the known violations can be reported, and the check gated
+// on the new violations only.
+type BaselineSummary struct {
+	New       int // violations that are not in the baseline
+	Fixed     int // violations of the baseline that were not found
+	Remaining int // violations of the baseline that were found
+}
+
+// String returns the summary as a single line, such as
+// "2 new, 5 fixed, 40 remaining".
+func (s BaselineSummary) String() string {
+	return fmt.Sprintf("%d new, %d fixed, %d remaining", s.New, s.Fixed, s.Remaining)
+}
+
+// CompareBaseline returns the summary of the violations of the results
+// against the baseline. Violations are matched by fingerprint. As a partial
+// scan may not have examined the files of the baseline's violations, no
+// violation is counted as fixed for a partial scan.
+func (r Results) CompareBaseline(b Baseline) BaselineSummary {
+	known := map[string]bool{}
+	for _, e := range b.Violations {
+		known[e.Fingerprint] = true
+	}
+	s := BaselineSummary{}
+	found := map[string]bool{}
+	for _, res := range r {
+		switch {
+		case res.Err == nil:
+		case known[res.Fingerprint]:
+			found[res.Fingerprint] = true
+		default:
+			s.New++
+		}
+	}
+	s.Remaining = len(found)
+	if !r.Partial() {
+		s.Fixed = len(known) - len(found)
+	}
+	return s
+}
@@ -0,0 +1,223 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"../language"
+)
+
+// BuildManifest lists the files of a project with the attributes that the
+// build system, such as Bazel, already knows about them. A scan with
+// Options.BuildManifest examines the listed files instead of walking the
+// project, and uses their attributes instead of the heuristics that would
+// otherwise derive them.
+type BuildManifest struct {
+	// Config, if not empty, is the path of the config file to check the
+	// files with, relative to the manifest file.
+	Config string `json:"config"`
+
+	// Files are the files of the project.
+	Files []BuildFile `json:"files"`
+}
+
+// BuildFile is a file of a BuildManifest.
+type BuildFile struct {
+	// Path is the project relative path of the file, using '/' separators.
+	Path string `json:"path"`
+
+	// Language, if not empty, is the name of the language of the file, used
+	// by the language_policies and the language path rules instead of the
+	// language detected from the file's name and content.
+	Language string `json:"language"`
+
+	// Generated, if true, marks the file as the output of a build rule. A
+	// generated file is checked by its Source, if set, like a file matched by
+	// the generated_sources of the config. Otherwise it is not examined.
+	Generated bool `json:"generated"`
+
+	// Source is the project relative path of the source file that the
+	// generated file was generated from.
+	Source string `json:"source"`
+
+	// ThirdParty, if true, marks the file as third-party code, which is not
+	// examined. Vendored components are still checked by the config's
+	// vendored settings.
+	ThirdParty bool `json:"third_party"`
+}
+
+// LoadBuildManifest loads the build manifest at path, which holds either a
+// JSON BuildManifest object, or a JSON array of BuildFile objects.
+func LoadBuildManifest(path string) (*BuildManifest, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read build manifest: %w", err)
+	}
+	m := &BuildManifest{}
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+		err = json.Unmarshal(body, &m.Files)
+	} else {
+		err = json.Unmarshal(body, m)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse build manifest '%v': %w", path, err)
+	}
+	if m.Config != "" && !filepath.IsAbs(m.Config) {
+		m.Config = filepath.Join(filepath.Dir(path), filepath.FromSlash(m.Config))
+	}
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("Invalid build manifest '%v': %w", path, err)
+	}
+	return m, nil
+}
+
+// validate returns an error if a file of the manifest has an invalid path or
+// an unknown language.
+func (m BuildManifest) validate() error {
+	for i, f := range m.Files {
+		if !isProjectRelative(f.Path) {
+			return fmt.Errorf("files[%d] has path '%v', which is not a clean project relative path", i, f.Path)
+		}
+		if f.Source != "" && !isProjectRelative(f.Source) {
+			return fmt.Errorf("files[%d] '%v' has source '%v', which is not a clean project relative path", i, f.Path, f.Source)
+		}
+		if f.Source != "" && !f.Generated {
+			return fmt.Errorf("files[%d] '%v' has a source, but is not generated", i, f.Path)
+		}
+		if f.Language != "" {
+			if _, ok := language.ByName(f.Language); !ok {
+				return fmt.Errorf("files[%d] '%v' has unknown language '%v'", i, f.Path, f.Language)
+			}
+		}
+	}
+	return nil
+}
+
+// isProjectRelative returns true if p is a clean, relative path with '/'
+// separators that does not leave the project.
+func isProjectRelative(p string) bool {
+	return p != "" && !path.IsAbs(p) && !strings.Contains(p, "\\") && path.Clean(p) == p &&
+		p != ".." && !strings.HasPrefix(p, "../")
+}
+
+// under returns the manifest of the files under the project relative
+// directory dir, with paths relative to dir, for the scan of a subproject.
+// Generated files whose source is outside of dir lose their source.
+func (m BuildManifest) under(dir string) *BuildManifest {
+	out := &BuildManifest{}
+	for _, f := range m.Files {
+		if !strings.HasPrefix(f.Path, dir+"/") {
+			continue
+		}
+		f.Path = strings.TrimPrefix(f.Path, dir+"/")
+		if f.Source != "" {
+			if strings.HasPrefix(f.Source, dir+"/") {
+				f.Source = strings.TrimPrefix(f.Source, dir+"/")
+			} else {
+				f.Source = ""
+			}
+		}
+		out.Files = append(out.Files, f)
+	}
+	return out
+}
+
+// index returns the files of the manifest keyed by path. Later entries of a
+// path replace earlier ones.
+func (m BuildManifest) index() map[string]BuildFile {
+	out := make(map[string]BuildFile, len(m.Files))
+	for _, f := range m.Files {
+		out[f.Path] = f
+	}
+	return out
+}
+
+// gatherBuildFiles returns the project relative paths of the files of the
+// config's build manifest that Config.shouldExamineFile() returns true for,
+// like gatherFiles. Generated files without a source, and third-party files,
+// are skipped.
+func gatherBuildFiles(root string, cfg Config, opts Options) ([]string, Results) {
+	paths := make([]string, 0, len(cfg.buildFiles))
+	for p := range cfg.buildFiles {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	files, skipped := []string{}, Results{}
+	skip := func(rel, reason string) {
+		if opts.ListSkipped {
+			skipped = append(skipped, Result{Path: rel, Skipped: reason, skippedBy: map[int]string{cfg.index: reason}})
+		}
+	}
+	for _, rel := range paths {
+		f := cfg.buildFiles[rel]
+		switch {
+		case rel == opts.ConfigFile():
+			skip(rel, "config file")
+		case opts.inSubproject(rel):
+			// Examined by the scan of the subproject
+		case f.ThirdParty:
+			skip(rel, "third-party, as declared by the build manifest")
+		case f.Generated && f.Source == "":
+			skip(rel, "generated, as declared by the build manifest")
+		default:
+			abs := filepath.Join(root, filepath.FromSlash(rel))
+			if ok, reason := cfg.shouldExamineFile(&candidate{path: rel, absPath: abs, language: f.Language}); ok {
+				files = append(files, rel)
+			} else {
+				skip(rel, reason)
+			}
+		}
+	}
+	return files, skipped
+}
+
+// inSubproject returns true if the project relative path is under one of the
+// subprojects scanned with their own config.
+func (o Options) inSubproject(rel string) bool {
+	for dir := range o.subprojects {
+		if strings.HasPrefix(rel, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// buildLanguage returns the language of the file at the project relative path
+// declared by the build manifest, if any.
+func (c Config) buildLanguage(path string) (language.Language, bool) {
+	if f, ok := c.buildFiles[path]; ok && f.Language != "" {
+		return language.ByName(f.Language)
+	}
+	return language.Language{}, false
+}
+
+// buildSource returns the project relative path of the source file of the
+// generated file at the project relative path, as declared by the build
+// manifest, if any.
+func (c Config) buildSource(path string) (string, bool) {
+	if f, ok := c.buildFiles[path]; ok && f.Generated && f.Source != "" {
+		return f.Source, true
+	}
+	return "", false
+}
@@ -20,58 +20,499 @@ package checker
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"../detector"
+	"../language"
 	"../match"
-	"github.com/google/licensecheck"
+	"../sniff"
+	"../spdx"
 )
 
-// Check loads the config file with the filename ConfigFileName in dir, and then
-// scans all files for license correctness. Any license violations are returned
-// as an error.
+// Check loads the config file with the filename DefaultConfigFileName in dir,
+// and then scans all files for license correctness. Any license violations are
+// returned as an error.
 func Check(dir string) error {
+	return CheckWithOptions(dir, Options{})
+}
+
+// Options holds optional settings for CheckWithOptions.
+type Options struct {
+	// GroupByDepth, if greater than zero, aggregates the violations by the
+	// project directory truncated to GroupByDepth path components, instead of
+	// listing each violation individually.
+	GroupByDepth int
+
+	// ExplainRules, if true, logs the directories that are not walked as the
+	// config's path rules exclude everything in them.
+	ExplainRules bool
+
+	// WarnOnly, if true, reports all license violations as warnings, so that
+	// Check does not return an error for them.
+	WarnOnly bool
+
+	// Quiet, if true, suppresses the progress messages logged at the info
+	// level, and the message printed by Check when no issues are found.
+	Quiet bool
+
+	// Logger receives the diagnostic messages of the scan. Defaults to
+	// slog.Default().
+	Logger *slog.Logger
+
+	// AbsPaths, if true, uses absolute paths in messages and reports, instead
+	// of project relative paths.
+	AbsPaths bool
+
+	// LicenseDB, if not empty, is the path to a license database file, loaded
+	// with detector.LoadDatabase, holding licenses to add to the detectors.
+	LicenseDB string
+
+	// ListSkipped, if true, adds a Result for each file and directory that
+	// was not examined, with the reason it was skipped.
+	ListSkipped bool
+
+	// VerifyUpstream, if true, fetches the upstream copy of each vendored
+	// component's license files at the version pinned by the component's
+	// metadata, and reports the local copies that have been modified.
+	VerifyUpstream bool
+
+	// Config, if not empty, is the path of the config file to load instead of
+	// the ConfigFile() file in the project root.
+	Config string
+
+	// ConfigFileName, if not empty, is the file name of the config files in
+	// the project root and subprojects, instead of DefaultConfigFileName.
+	ConfigFileName string
+
+	// BuildManifest, if not nil, lists the files to examine, and their
+	// attributes, instead of walking the project. See BuildManifest.
+	BuildManifest *BuildManifest
+
+	// Output receives the messages printed by Results.Check. Defaults to
+	// os.Stdout.
+	Output io.Writer
+
+	// Submodules, if true, scans each subdirectory that holds its own config
+	// file, such as a git submodule or subtree, with that config instead of
+	// the parent's. The subproject's results are included with a
+	// Result.Project of the subdirectory.
+	Submodules bool
+
+	// DiscoverProjects, if true, groups the results of the files under each
+	// subdirectory that holds its own license file or package manifest, such
+	// as a LICENSE or go.mod file, into a project of that subdirectory. Unlike
+	// Submodules, discovered projects are checked with the parent's config,
+	// and only their Result.Project differs.
+	DiscoverProjects bool
+
+	// ExtraLicenses are licenses to permit in addition to those of the
+	// configs, for this scan only. Files that are only compliant due to an
+	// extra license are listed by Results.Extra.
+	ExtraLicenses []string
+
+	// Deadline, if not zero, is the time at which the scan stops examining
+	// files. The results hold the files examined so far, and an Incomplete
+	// violation counting those that were not. See Results.Partial.
+	Deadline time.Time
+
+	// Context, if not nil, stops the scan when it is cancelled, such as on
+	// SIGINT, in the same way as Deadline.
+	Context context.Context
+
+	// FileTimeout, if greater than zero, is the longest time spent examining
+	// a single file, so that pathological files, such as untrusted uploads,
+	// cannot stall the scan. A file that takes longer is reported as a
+	// FileTimeout violation. The detectors cannot be interrupted, so the
+	// examination carries on in the background, but its result is discarded.
+	FileTimeout time.Duration
+
+	// ContinueOnError, if true, reports the directories that cannot be read,
+	// and the submodules that cannot be scanned, as ReadError violations of
+	// those paths, and scans the rest of the project, instead of failing the
+	// scan with an error. The errors of individual files, including panics
+	// and FileTimeout, are always reported as violations of the file.
+	ContinueOnError bool
+
+	// Sink, if not nil, receives each violation as soon as it is found,
+	// before Scan returns. See ViolationSink.
+	Sink ViolationSink
+
+	// Progress, if not nil, records the compliant files as they are
+	// examined, and provides the results of the files recorded by a previous
+	// scan that was interrupted. See OpenProgress.
+	Progress *Progress
+
+	// subprojects is the set of project relative directories that are scanned
+	// as separate projects, and so are skipped by the parent's configs.
+	subprojects map[string]bool
+
+	// projects are the project relative directories of the projects found by
+	// DiscoverProjects.
+	projects []string
+
+	// prefix is prepended to the project relative paths shown in messages,
+	// when scanning a root of a Workspace.
+	prefix string
+}
+
+// DisplayPath returns the path that should be shown in messages and reports
+// for the file or directory with the project relative path rel, under the
+// project root directory root. Unless AbsPaths is true, DisplayPath returns
+// rel with '/' separators, regardless of the operating system.
+func (o Options) DisplayPath(root, rel string) string {
+	if o.AbsPaths {
+		return EscapePath(filepath.Join(root, filepath.FromSlash(rel)))
+	}
+	return EscapePath(o.prefix + filepath.ToSlash(rel))
+}
+
+// EscapePath returns the path p in a form that is safe to show in messages and
+// reports. Like git's core.quotepath, paths that are not valid UTF-8, or that
+// hold unprintable characters or double quotes, are returned as a
+// double-quoted string with those bytes and characters escaped, for example
+// "assets/caf\xe9.png". All other paths are returned unchanged.
+func EscapePath(p string) string {
+	if !utf8.ValidString(p) || strings.ContainsRune(p, '"') || strings.IndexFunc(p, func(r rune) bool { return !unicode.IsPrint(r) }) >= 0 {
+		return strconv.Quote(p)
+	}
+	return p
+}
+
+// ConfigFile returns the ConfigFileName, or DefaultConfigFileName if it is
+// empty.
+func (o Options) ConfigFile() string {
+	if o.ConfigFileName != "" {
+		return o.ConfigFileName
+	}
+	return DefaultConfigFileName
+}
+
+// output returns the Output, or os.Stdout if it is nil.
+func (o Options) output() io.Writer {
+	if o.Output != nil {
+		return o.Output
+	}
+	return os.Stdout
+}
+
+// logger returns the Logger, or slog.Default() if it is nil.
+func (o Options) logger() *slog.Logger {
+	if o.Logger != nil {
+		return o.Logger
+	}
+	return slog.Default()
+}
+
+// CheckWithOptions is the same as Check, but uses the given Options.
+func CheckWithOptions(dir string, opts Options) error {
+	results, err := Scan(dir, opts)
+	if err != nil {
+		return err
+	}
+	return results.Check(opts)
+}
+
+// Check returns an error listing the enforced license violations of the
+// results, or nil if there are none. Advisory violations, and all violations if
+// opts.WarnOnly is true, are printed to opts.Output as warnings instead.
+func (r Results) Check(opts Options) error {
+	warnings := r.Warnings(opts)
+	failures := r.Failures(opts)
+	w := opts.output()
+
+	if skipped := r.Skipped(); len(skipped) > 0 {
+		fmt.Fprintf(w, "%d skipped:\n%v", len(skipped), skipped.ListSkipped())
+	}
+	if extra := r.Extra(); len(extra) > 0 {
+		fmt.Fprintf(w, "%d files allowed by extra licenses:\n%v", len(extra), extra.ListExtra())
+	}
+	if generated := r.Generated(); opts.ListSkipped && len(generated) > 0 {
+		fmt.Fprintf(w, "%d generated files checked by their source:\n%v", len(generated), generated.ListGenerated())
+	}
+	if projects := r.Projects(opts); len(projects) > 0 {
+		fmt.Fprintf(w, "%d projects:\n", len(projects))
+		for _, p := range projects {
+			fmt.Fprintf(w, "* %v\n", p)
+		}
+	}
+	if n := len(warnings.Errs()); n > 0 {
+		fmt.Fprintf(w, "%d warnings:\n%v", n, warnings.List(opts))
+	}
+	if n := len(failures.Errs()); n > 0 {
+		return fmt.Errorf("%d errors:\n%v", n, failures.List(opts))
+	}
+	if len(warnings.Errs()) == 0 && !opts.Quiet {
+		fmt.Fprintf(w, "No license issues found\n")
+	}
+	return nil
+}
+
+// Failures returns a copy of the results with only the violations that fail
+// the check.
+func (r Results) Failures(opts Options) Results {
+	return r.filter(func(res Result) bool { return !opts.WarnOnly && !res.Advisory })
+}
+
+// Warnings returns a copy of the results with only the violations that are
+// reported as warnings.
+func (r Results) Warnings(opts Options) Results {
+	return r.filter(func(res Result) bool { return opts.WarnOnly || res.Advisory })
+}
+
+// filter returns a copy of the results, with the violations of the results
+// that pred returns false for removed.
+func (r Results) filter(pred func(Result) bool) Results {
+	out := make(Results, len(r))
+	for i, res := range r {
+		out[i] = res
+		if !pred(res) {
+			out[i].Err = nil
+		}
+	}
+	return out
+}
+
+// Examined returns the results for the files that were examined, removing
+// those for skipped files and directories.
+func (r Results) Examined() Results {
+	out := Results{}
+	for _, res := range r {
+		if res.Skipped == "" {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// Files returns the results for the files that were examined, removing those
+// for skipped files and directories, and those of project-wide checks, such as
+// min_coverage.
+func (r Results) Files() Results {
+	out := Results{}
+	for _, res := range r.Examined() {
+		if res.Kind.IsFile() {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// ConfigSources returns the project relative paths of the files that hold the
+// configs that examined the results, as recorded by their Decisions, by the
+// Project of the results.
+func (r Results) ConfigSources() map[string]string {
+	out := map[string]string{}
+	for _, res := range r {
+		if res.Decision != nil && res.Decision.Source != "" {
+			if _, ok := out[res.Project]; !ok {
+				out[res.Project] = res.Decision.Source
+			}
+		}
+	}
+	return out
+}
+
+// Skipped returns the results for the skipped files and directories.
+func (r Results) Skipped() Results {
+	out := Results{}
+	for _, res := range r {
+		if res.Skipped != "" {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// ListSkipped returns a bullet-point list of the skipped files and
+// directories of the results, with the reason each was skipped.
+func (r Results) ListSkipped() string {
+	msg := strings.Builder{}
+	for _, res := range r.Skipped() {
+		fmt.Fprintf(&msg, "* %v: %v\n", EscapePath(res.Path), res.Skipped)
+	}
+	return msg.String()
+}
+
+// Extra returns the results for the files that are only compliant because of
+// Options.ExtraLicenses.
+func (r Results) Extra() Results {
+	out := Results{}
+	for _, res := range r {
+		if res.Err == nil && len(res.ExtraLicenses) > 0 {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// ListExtra returns a bullet-point list of the files that are only compliant
+// because of Options.ExtraLicenses, with the extra licenses each file uses.
+func (r Results) ListExtra() string {
+	msg := strings.Builder{}
+	for _, res := range r.Extra() {
+		fmt.Fprintf(&msg, "* %v: %v\n", EscapePath(res.Path), strings.Join(res.ExtraLicenses, ", "))
+	}
+	return msg.String()
+}
+
+// List returns a bullet-point list of the violations of the results, one per
+// line, or one per directory if opts.GroupByDepth is greater than zero. When
+// grouped, the violations of project-wide checks, which have no directory, are
+// listed one per line after the directories.
+func (r Results) List(opts Options) string {
+	msg := strings.Builder{}
+	if opts.GroupByDepth > 0 {
+		for _, g := range r.groupByDir(opts.GroupByDepth) {
+			fmt.Fprintf(&msg, "* %v\n", g)
+		}
+	}
+	for _, res := range r {
+		if res.Err == nil || (opts.GroupByDepth > 0 && res.Kind.IsFile()) {
+			continue
+		}
+		if res.Attribution != nil {
+			fmt.Fprintf(&msg, "* %v [%v] (added by %v)\n", res.Err, res.Fingerprint, res.Attribution)
+		} else {
+			fmt.Fprintf(&msg, "* %v [%v]\n", res.Err, res.Fingerprint)
+		}
+	}
+	return msg.String()
+}
+
+// Scan loads the config file with the filename opts.ConfigFile() in dir, and
+// then scans all files for license correctness, returning the result of examining
+// each file. Unlike Check, license violations are not returned as an error.
+func Scan(dir string, opts Options) (Results, error) {
 	root, err := filepath.Abs(dir)
 	if err != nil {
-		return fmt.Errorf("Failed to get absolute working directory: %w", err)
+		return nil, fmt.Errorf("Failed to get absolute working directory: %w", err)
 	}
 
-	cfgs, err := loadConfigs(root)
+	cfgs, err := loadConfigs(root, opts)
 	if err != nil {
-		return fmt.Errorf("Failed to load config file: %w", err)
+		return nil, fmt.Errorf("Failed to load config file: %w", err)
 	}
+	opts.logger().Debug("Loaded config file", "root", root, "configs", len(cfgs))
 
+	if opts.Submodules {
+		if opts.subprojects, err = findSubprojects(root, opts); err != nil {
+			return nil, err
+		}
+	}
+	if opts.DiscoverProjects {
+		if opts.projects, err = discoverProjects(root, cfgs, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	out := Results{}
+	var db *detector.Database
+	if opts.LicenseDB != "" {
+		if db, err = detector.LoadDatabase(opts.LicenseDB); err != nil {
+			return nil, err
+		}
+	}
+
+	classifiers := map[string]*classifier{}
 	for _, cfg := range cfgs {
-		errs := runConfig(cfg, root)
-		if len(errs) > 0 {
-			msg := strings.Builder{}
-			fmt.Fprintf(&msg, "%d errors:\n", len(errs))
-			for _, err := range errs {
-				fmt.Fprintf(&msg, "* %v\n", err)
+		cfg.extraLicenses = opts.ExtraLicenses
+		if opts.BuildManifest != nil {
+			cfg.buildFiles = opts.BuildManifest.index()
+		}
+		cls, ok := classifiers[cfg.Detector]
+		if !ok {
+			d, err := detector.New(cfg.Detector, db)
+			if err != nil {
+				return nil, err
 			}
-			return fmt.Errorf("%v", msg.String())
+			cls = newClassifier(d)
+			classifiers[cfg.Detector] = cls
 		}
+		results, err := runConfig(cfg, root, cls, opts)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, results...)
 	}
+	if opts.ListSkipped {
+		out = out.dedupSkipped()
+	}
+	out = markIncomplete(root, cfgs, out, opts)
+	nested, err := scanSubprojects(root, opts.subprojects, opts)
+	if err != nil {
+		return nil, err
+	}
+	out = append(out, nested...)
+	if !out.Partial() { // Coverage of a partial scan is meaningless
+		res, err := checkCoverage(root, cfgs, out, opts)
+		if err != nil {
+			return nil, err
+		}
+		if res != nil {
+			opts.report(*res)
+			out = append(out, *res)
+		}
+		out = append(out, checkUncoveredTypes(root, cfgs, out, opts)...)
+	}
+	out.assignProjects(opts)
+	return out, nil
+}
 
-	fmt.Printf("No license issues found\n")
-
-	return nil
+// dedupSkipped returns the results with the skipped results removed for files
+// that were examined by another config, and for repeated paths. The first
+// skipped result for a path is kept, with the skippedBy of the others.
+func (r Results) dedupSkipped() Results {
+	examined := map[string]bool{}
+	for _, res := range r.Examined() {
+		examined[res.Path] = true
+	}
+	kept := map[string]int{} // index in out of the skipped result, by path
+	out := Results{}
+	for _, res := range r {
+		if res.Skipped != "" {
+			if examined[res.Path] {
+				continue
+			}
+			if i, ok := kept[res.Path]; ok {
+				if out[i].skippedBy == nil {
+					out[i].skippedBy = map[int]string{}
+				}
+				for config, reason := range res.skippedBy {
+					if _, ok := out[i].skippedBy[config]; !ok {
+						out[i].skippedBy[config] = reason
+					}
+				}
+				continue
+			}
+			kept[res.Path] = len(out)
+		}
+		out = append(out, res)
+	}
+	return out
 }
 
-var (
-	// ConfigFileName is the configuration filename to load.
-	ConfigFileName = "license-checker.cfg"
-)
+// DefaultConfigFileName is the file name of the config file loaded from the
+// project root, unless Options.ConfigFileName is set.
+const DefaultConfigFileName = "license-checker.cfg"
 
 // Configs is a slice of Config.
 type Configs []Config
 
-// Config is used to parse the JSON configuration file at ConfigFileName.
+// Config is used to parse the JSON configuration file at
+// DefaultConfigFileName.
 type Config struct {
 	// Paths holds a number of JSON objects that contain either a "includes" or
 	// "excludes" key to an array of path patterns.
@@ -82,17 +523,34 @@ type Config struct {
 	//  *  - matches any sequence of non-separator characters
 	//  ** - matches any sequence of characters including separators
 	//
+	// Objects with an "include_types" or "exclude_types" key instead hold an
+	// array of MIME type patterns, which are matched against the type sniffed
+	// from the first 512 bytes of each file. This allows files without an
+	// extension, such as scripts, to be selected. Scripts are recognized by
+	// their shebang line (e.g. "text/x-shellscript", "text/x-python") and
+	// C-family sources by their #include directives ("text/x-c"). Type patterns
+	// may also use wildcards, for example: "text/*".
+	//
+	// Objects with an "include_languages" or "exclude_languages" key hold an
+	// array of language names, such as "go", "cpp", "shell", "dockerfile",
+	// "make" or "bazel". Languages are recognized by file name (e.g.
+	// Dockerfile, Makefile, BUILD, WORKSPACE), by extension, and for scripts,
+	// by their shebang line. See the language package for the full list.
+	//
 	// Rules are processed in the order in which they are declared, with later
 	// rules taking precedence over earlier rules.
 	//
-	// All files are included before the first rule is evaluated.
+	// All files are included before the first rule is evaluated, unless Only
+	// is true.
 	//
 	// Example:
 	//
 	// {
 	//   "paths": [
 	// 	  { "exclude": [ "out/*", "build/*" ] },
-	// 	  { "include": [ "out/foo.txt" ] }
+	// 	  { "include": [ "out/foo.txt" ] },
+	// 	  { "include_types": [ "text/x-shellscript" ] },
+	// 	  { "include_languages": [ "dockerfile" ] }
 	//   ],
 	// }
 	Paths searchRules
@@ -106,23 +564,518 @@ type Config struct {
 	//   "licenses": [ "Apache-2.0-Header", "MIT" ]
 	// }
 	Licenses []string
+
+	// IncludeHidden, if true, walks hidden directories (those with names
+	// starting with '.'), which are skipped by default. Version control
+	// directories such as '.git' are always skipped.
+	//
+	// Example:
+	//
+	// {
+	//   "include_hidden": true
+	// }
+	IncludeHidden bool `json:"include_hidden"`
+
+	// Only, if true, excludes all files before the first path rule is
+	// evaluated, so that only the files explicitly included by the path rules
+	// are scanned. Directories that no include rule could match are not
+	// walked.
+	//
+	// Example:
+	//
+	// {
+	//   "only": true,
+	//   "paths": [ { "include": [ "src/**", "include/**" ] } ]
+	// }
+	Only bool
+
+	// LanguagePolicies overrides the license requirements for files of the
+	// given languages, keyed by language name. See LanguagePolicy.
+	//
+	// Example:
+	//
+	// {
+	//   "language_policies": {
+	//     "json":  { "require": "none" },
+	//     "yaml":  { "require": "none" },
+	//     "shell": { "require": "spdx" }
+	//   }
+	// }
+	LanguagePolicies map[string]LanguagePolicy `json:"language_policies"`
+
+	// Detector is the name of the license detection engine used to identify
+	// the licenses in files. One of "licensecheck" (default) or "regex".
+	// The "regex" detector has no dependencies, but only recognizes the
+	// unmodified standard notices of common licenses. Detected licenses are
+	// normalized to SPDX identifiers, as are the names in Licenses when
+	// compared against them.
+	//
+	// Example:
+	//
+	// {
+	//   "detector": "regex"
+	// }
+	Detector string `json:"detector"`
+
+	// Enforce, if set to false, reports the license violations found by this
+	// config as warnings that do not fail the check. This can be used to
+	// observe a project's compliance in CI before turning on enforcement.
+	//
+	// Example:
+	//
+	// {
+	//   "enforce": false
+	// }
+	Enforce *bool
+
+	// MinCoverage, if greater than zero, is the minimum percentage of the
+	// project's files that must be examined. If the path rules exclude too
+	// much of the tree, then a violation is reported against the config file.
+	// If multiple configs set MinCoverage, the highest value is used, and
+	// applies to the files examined by all configs.
+	//
+	// Example:
+	//
+	// {
+	//   "min_coverage": 80
+	// }
+	MinCoverage float64 `json:"min_coverage"`
+
+	// UncoveredTypes, if set, reports each type of file examined by this
+	// config that none of its LanguagePolicies cover, once enough files of
+	// the type are examined, so that the policies can be extended before the
+	// gap grows. See UncoveredTypes.
+	//
+	// Example:
+	//
+	// {
+	//   "uncovered_types": { "min_files": 50, "ignore": [ "markdown", ".txt" ] }
+	// }
+	UncoveredTypes *UncoveredTypes `json:"uncovered_types"`
+
+	// Vendored, if set, requires each vendored component directory to hold a
+	// metadata file declaring the component's upstream URL, version and
+	// license. The declared license is cross-checked against the licenses
+	// detected in the component's LICENSE, LICENCE or COPYING files.
+	// See Vendored.
+	//
+	// Example:
+	//
+	// {
+	//   "vendored": {
+	//     "dirs": [ "third_party/*" ],
+	//     "metadata_files": [ "METADATA", "version.json" ]
+	//   }
+	// }
+	Vendored *Vendored `json:"vendored"`
+
+	// Dependencies holds the license policy of the project's Go module
+	// dependencies, checked by CheckDependencies. See DependencyPolicy.
+	//
+	// Example:
+	//
+	// {
+	//   "dependencies": {
+	//     "licenses": [ "Apache-2.0", "BSD-3-Clause", "MIT" ],
+	//     "allow": [ "example.com/internal/**" ]
+	//   }
+	// }
+	Dependencies *DependencyPolicy `json:"dependencies"`
+
+	// Internal, if set, enables the Internal-Only pseudo-license for
+	// proprietary files, and requires the files matching its paths to carry
+	// the internal notice instead of an open source license. See Internal.
+	//
+	// Example:
+	//
+	// {
+	//   "internal": {
+	//     "markers": [ "Confidential and proprietary" ],
+	//     "paths": [ "src/**" ]
+	//   }
+	// }
+	Internal *Internal `json:"internal"`
+
+	// Vars declares variables that can be referenced as '${name}' in the
+	// other strings of the config, such as path patterns, to avoid repeating
+	// them. A variable holds either a string, which can be referenced within
+	// any string, or an array of strings, which can only be referenced by a
+	// whole array element, and is replaced with all of its strings.
+	//
+	// Example:
+	//
+	// {
+	//   "vars": {
+	//     "gen": "**/gen/**",
+	//     "generated": [ "**/*.pb.go", "**/*_string.go", "out/**" ]
+	//   },
+	//   "paths": [
+	//     { "exclude": [ "${gen}", "${generated}" ] },
+	//     { "include": [ "${gen}/keep.cpp" ] }
+	//   ],
+	//   "internal": { "paths": [ "src/**", "${generated}" ] }
+	// }
+	Vars map[string]interface{} `json:"vars"`
+
+	// StaleHeaders lists outdated entities or URLs, such as the name of a
+	// company before an acquisition, that must no longer appear in file
+	// headers. A file with a permitted license, but whose leading comment
+	// holds an old value, is reported as a stale header. These violations can
+	// be fixed with the --fix flag, which rewrites each old value with its
+	// new value. See HeaderReplacement.
+	//
+	// Example:
+	//
+	// {
+	//   "stale_headers": [
+	//     { "old": "Acme Inc.", "new": "Globex LLC" },
+	//     { "old": "http://acme.example.com/license", "new": "https://globex.example.com/license" }
+	//   ]
+	// }
+	StaleHeaders []HeaderReplacement `json:"stale_headers"`
+
+	// Quarantine, if set, is the project relative directory where unreviewed
+	// third-party imports may live, each in its own subdirectory, before they
+	// are reviewed and moved to their final location. The license violations
+	// of quarantined files are advisory, but files outside of the quarantine
+	// directory must not #include or import any quarantined file. Use the
+	// 'promote' command to verify a quarantined component with the full rules.
+	//
+	// Example:
+	//
+	// {
+	//   "quarantine": "quarantine"
+	// }
+	Quarantine string `json:"quarantine"`
+
+	// GeneratedSources maps generated files to the source files they are
+	// generated from, such as .proto or .idl files. A generated file is exempt
+	// from the license requirements, and is checked by examining its source
+	// file instead, which must exist and be compliant. The source of each
+	// generated file is recorded in Result.GeneratedFrom, so that the
+	// exemptions can be audited. See GeneratedSource.
+	//
+	// Example:
+	//
+	// {
+	//   "generated_sources": [
+	//     { "output": "gen/**/*.pb.go", "source": "proto/**/*.proto" }
+	//   ]
+	// }
+	GeneratedSources []GeneratedSource `json:"generated_sources"`
+
+	// Risk overrides the weights and thresholds used to compute the overall
+	// risk score of the project, which is summarized at the top of every
+	// report. Weights that are not listed keep their default value. If
+	// multiple configs set Risk, the first is used. See RiskPolicy.
+	//
+	// Example:
+	//
+	// {
+	//   "risk": {
+	//     "weights": { "weak-copyleft": 0.2, "unreviewed": 1 },
+	//     "medium": 5,
+	//     "high": 20
+	//   }
+	// }
+	Risk *RiskPolicy `json:"risk"`
+
+	// HeaderStyle, if set, checks the style of the license headers: the
+	// length of their lines, trailing whitespace, and consistent comment
+	// markers. Style violations are reported as warnings, and all but long
+	// lines are fixed by --fix. See HeaderStyle.
+	//
+	// Example:
+	//
+	// {
+	//   "header_style": {
+	//     "max_line_length": 80,
+	//     "trailing_whitespace": true,
+	//     "comment_markers": true
+	//   }
+	// }
+	HeaderStyle *HeaderStyle `json:"header_style"`
+
+	// EmptyFiles controls how files that are empty, or only hold whitespace,
+	// such as empty __init__.py files and .gitkeep placeholders, are checked.
+	// One of "require_header" (default), which reports them as having no
+	// license, "ignore", which accepts them, or "error", which reports them as
+	// EmptyFile violations.
+	//
+	// Example:
+	//
+	// {
+	//   "empty_files": "ignore"
+	// }
+	EmptyFiles EmptyFilePolicy `json:"empty_files"`
+
+	// MinLines and MinBytes, if greater than zero, exempt the files with fewer
+	// non-blank lines, or fewer bytes, from the license requirement, such as
+	// tiny config stubs and one-line re-exports. Any licenses the files hold
+	// must still be permitted. MinContentByExtension overrides both for the
+	// files with the given lowercase extensions.
+	//
+	// Example:
+	//
+	// {
+	//   "min_lines": 3,
+	//   "min_content_by_extension": {
+	//     ".py": { "min_lines": 5 },
+	//     ".json": { "min_bytes": 512 }
+	//   }
+	// }
+	MinLines              int                   `json:"min_lines"`
+	MinBytes              int                   `json:"min_bytes"`
+	MinContentByExtension map[string]MinContent `json:"min_content_by_extension"`
+
+	// NewFileHeader is the text of the license header of new files created by
+	// the 'new' command, without comment markers, which are added for the
+	// language of each file. '{year}' is replaced with the current year.
+	// Without NewFileHeader, new files that may carry an SPDX tag get the tag
+	// of the first of the licenses. NewFileHeader is also inserted into the
+	// existing files without a license by Results.Fix, at the position given
+	// by HeaderPlacement.
+	//
+	// Example:
+	//
+	// {
+	//   "new_file_header": "Copyright {year} Globex LLC\nSPDX-License-Identifier: Apache-2.0"
+	// }
+	NewFileHeader string `json:"new_file_header"`
+
+	// HeaderPlacement overrides, by language name, the placement rules that
+	// give the position at which NewFileHeader is inserted into a file without
+	// a license. The header is placed after the constructs named by the rules,
+	// which are looked for in order from the start of the file: "shebang",
+	// "magic-comment", "php-open-tag", "xml-declaration", "docstring" and
+	// "build-tags". By default, headers are placed after the shebang line, the
+	// '<?php' tag of PHP files, the magic comments and module docstring of
+	// Python files and the XML declaration of XML and HTML files. Go headers
+	// are placed at the top, above any build constraints. See
+	// language.Placement.
+	//
+	// Example:
+	//
+	// {
+	//   "header_placement": {
+	//     "python": [ "shebang", "magic-comment" ]
+	//   }
+	// }
+	HeaderPlacement map[string][]string `json:"header_placement"`
+
+	// MaxDepth and MaxFiles, if greater than zero, fail the scan with an error
+	// if it walks a directory nested deeper than MaxDepth directories below
+	// the project root, or walks more than MaxFiles files, whether or not they
+	// are examined. Directories that the path rules exclude are not walked,
+	// so do not count. The limits protect CI from runaway scans, such as of
+	// a tree nested without bound by a faulty build step, or of a home
+	// directory scanned by mistake.
+	//
+	// Example:
+	//
+	// {
+	//   "max_depth": 32,
+	//   "max_files": 100000
+	// }
+	MaxDepth int `json:"max_depth"`
+	MaxFiles int `json:"max_files"`
+
+	// extraLicenses is a copy of Options.ExtraLicenses of the scan.
+	extraLicenses []string
+
+	// source is the project relative path of the file that holds the config,
+	// as returned by ReadConfig. This is the config file, or the file that
+	// embeds the config, such as pyproject.toml. The path is absolute if the
+	// Options.Config file is outside of the project.
+	source string
+
+	// buildFiles are the files of the Options.BuildManifest of the scan,
+	// keyed by path, or nil if the scan has no build manifest.
+	buildFiles map[string]BuildFile
+
+	// index is the index of the config in its config file.
+	index int
+
+	// digest identifies the config in the Options.Progress file.
+	digest string
 }
 
+// enforced returns true if the license violations found by the config should
+// fail the check.
+func (c Config) enforced() bool {
+	return c.Enforce == nil || *c.Enforce
+}
+
+// ruleKind is the enumerator of things a rule's patterns can match.
+type ruleKind int
+
+const (
+	pathRule     ruleKind = iota // patterns match the project relative path
+	typeRule                     // patterns match the sniffed MIME type
+	languageRule                 // patterns match the detected language name
+)
+
 // rule is a search path predicate.
-// root is the project relative path.
-// cond is the value to return if the rule doesn't either include or exclude.
-type rule func(path string, cond bool) bool
+type rule struct {
+	include  bool         // true for an include rule, false for exclude
+	kind     ruleKind     // what the patterns match
+	patterns []string     // the patterns as declared in the config
+	tests    []match.Test // the compiled patterns
+	dirs     []match.Test // per pattern, tests a directory wholly matched by it
+}
+
+// candidate is a file considered for scanning by the search rules.
+// The file's content is only read if a rule requires it.
+type candidate struct {
+	path     string // project relative path
+	absPath  string // absolute path
+	language string // the language declared by a build manifest, if any
+	read     bool   // true if head has been populated
+	leading  []byte // the first sniff.Len bytes of the file
+}
+
+// head returns the first sniff.Len bytes of the file, or nil if the file could
+// not be read.
+func (c *candidate) head() []byte {
+	if !c.read {
+		c.read = true
+		if f, err := os.Open(c.absPath); err == nil {
+			defer f.Close()
+			buf := make([]byte, sniff.Len)
+			n, _ := io.ReadFull(f, buf)
+			c.leading = buf[:n]
+		}
+	}
+	return c.leading
+}
+
+// subject returns the string matched by the patterns of a rule of the given
+// kind.
+func (c *candidate) subject(kind ruleKind) string {
+	switch kind {
+	case typeRule:
+		if c.head() == nil {
+			return ""
+		}
+		return sniff.Type(c.head())
+	case languageRule:
+		if c.language != "" {
+			return c.language
+		}
+		l, _ := language.Detect(c.path, c.head)
+		return l.Name
+	default:
+		return c.path
+	}
+}
+
+// match returns the index of the first of the rule's patterns that matches
+// the candidate file, or -1 if none match.
+func (r rule) match(c *candidate) int {
+	subject := c.subject(r.kind)
+	for i, test := range r.tests {
+		if test(subject) {
+			return i
+		}
+	}
+	return -1
+}
+
+// covers returns the first pattern of the rule that matches every path under
+// the project relative directory dir, or an empty string if there is none.
+func (r rule) covers(dir string) string {
+	for i, test := range r.dirs {
+		if test != nil && test(dir) {
+			return r.patterns[i]
+		}
+	}
+	return ""
+}
+
+// mayMatchUnder returns true if any of the rule's patterns may match a path
+// under the project relative directory dir.
+func (r rule) mayMatchUnder(dir string) bool {
+	if r.kind != pathRule {
+		return true // Any file may be of a matching type or language
+	}
+	dir += "/"
+	for _, pattern := range r.patterns {
+		prefix := pattern
+		if i := strings.IndexAny(pattern, "*?"); i >= 0 {
+			prefix = pattern[:i]
+		}
+		if strings.HasPrefix(dir, prefix) || strings.HasPrefix(prefix, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// newRule returns a new include or exclude rule for the given path patterns.
+func newRule(include bool, patterns []string) (rule, error) {
+	r := rule{
+		include:  include,
+		patterns: patterns,
+		tests:    make([]match.Test, len(patterns)),
+		dirs:     make([]match.Test, len(patterns)),
+	}
+	for i, pattern := range patterns {
+		test, err := match.New(pattern)
+		if err != nil {
+			return rule{}, err
+		}
+		r.tests[i] = test
+
+		// A pattern of the form '<dir>/**' matches everything under <dir>.
+		switch {
+		case pattern == "**":
+			r.dirs[i] = func(string) bool { return true }
+		case strings.HasSuffix(pattern, "/**"):
+			dir, err := match.New(strings.TrimSuffix(pattern, "/**"))
+			if err != nil {
+				return rule{}, err
+			}
+			r.dirs[i] = dir
+		}
+	}
+	return r, nil
+}
+
+// newContentRule returns a new include or exclude rule for the given MIME type
+// or language patterns.
+func newContentRule(include bool, kind ruleKind, patterns []string) (rule, error) {
+	r := rule{
+		include:  include,
+		kind:     kind,
+		patterns: patterns,
+		tests:    make([]match.Test, len(patterns)),
+	}
+	for i, pattern := range patterns {
+		test, err := match.New(pattern)
+		if err != nil {
+			return rule{}, err
+		}
+		r.tests[i] = test
+	}
+	return r, nil
+}
 
 // searchRules is a ordered list of search rules.
 // searchRules is its own type as it has to perform custom JSON unmarshalling.
 type searchRules []rule
 
 // UnmarshalJSON unmarshals the array of rules in the form:
-// { "include": [ ... ] } or { "exclude": [ ... ] }
+// { "include": [ ... ] }, { "exclude": [ ... ] },
+// { "include_types": [ ... ] }, { "exclude_types": [ ... ] },
+// { "include_languages": [ ... ] } or { "exclude_languages": [ ... ] }
 func (l *searchRules) UnmarshalJSON(body []byte) error {
 	type parsed struct {
-		Include []string
-		Exclude []string
+		Include          []string
+		Exclude          []string
+		IncludeTypes     []string `json:"include_types"`
+		ExcludeTypes     []string `json:"exclude_types"`
+		IncludeLanguages []string `json:"include_languages"`
+		ExcludeLanguages []string `json:"exclude_languages"`
 	}
 
 	p := []parsed{}
@@ -131,110 +1084,321 @@ func (l *searchRules) UnmarshalJSON(body []byte) error {
 	}
 
 	*l = searchRules{}
-	for _, rule := range p {
-		rule := rule
-		switch {
-		case len(rule.Include) > 0 && len(rule.Exclude) > 0:
-			return fmt.Errorf("Rule cannot contain both include and exclude")
-		case len(rule.Include) > 0:
-			tests := make([]match.Test, len(rule.Include))
-			for i, pattern := range rule.Include {
-				test, err := match.New(pattern)
-				if err != nil {
-					return err
-				}
-				tests[i] = test
-			}
-			*l = append(*l, func(path string, cond bool) bool {
-				for _, test := range tests {
-					if test(path) {
-						return true
-					}
-				}
-				return cond
-			})
-		case len(rule.Exclude) > 0:
-			tests := make([]match.Test, len(rule.Exclude))
-			for i, pattern := range rule.Exclude {
-				test, err := match.New(pattern)
-				if err != nil {
-					return err
-				}
-				tests[i] = test
+	for _, entry := range p {
+		kinds := 0
+		for _, list := range [][]string{
+			entry.Include, entry.Exclude,
+			entry.IncludeTypes, entry.ExcludeTypes,
+			entry.IncludeLanguages, entry.ExcludeLanguages,
+		} {
+			if len(list) > 0 {
+				kinds++
 			}
-			*l = append(*l, func(path string, cond bool) bool {
-				for _, test := range tests {
-					if test(path) {
-						return false
-					}
-				}
-				return cond
-			})
 		}
+		if kinds > 1 {
+			return fmt.Errorf("Rule must contain only one of include, exclude, include_types, exclude_types, include_languages or exclude_languages")
+		}
+
+		var r rule
+		var err error
+		switch {
+		case len(entry.Include) > 0:
+			r, err = newRule(true, entry.Include)
+		case len(entry.Exclude) > 0:
+			r, err = newRule(false, entry.Exclude)
+		case len(entry.IncludeTypes) > 0:
+			r, err = newContentRule(true, typeRule, entry.IncludeTypes)
+		case len(entry.ExcludeTypes) > 0:
+			r, err = newContentRule(false, typeRule, entry.ExcludeTypes)
+		case len(entry.IncludeLanguages) > 0:
+			r, err = newContentRule(true, languageRule, entry.IncludeLanguages)
+		case len(entry.ExcludeLanguages) > 0:
+			r, err = newContentRule(false, languageRule, entry.ExcludeLanguages)
+		default:
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		*l = append(*l, r)
 	}
 	return nil
 }
 
-// shouldExamine returns true if the file at absPath should be scanned.
-func (c Config) shouldExamine(root, absPath string) bool {
-	root = filepath.ToSlash(root)       // Canonicalize
-	absPath = filepath.ToSlash(absPath) // Canonicalize
+// shouldExamine returns true if the file at absPath should be scanned. If not,
+// shouldExamine also returns a description of the deciding rule.
+func (c Config) shouldExamine(root, absPath string) (bool, string) {
 	relPath, err := filepath.Rel(root, absPath)
 	if err != nil {
-		return false
+		return false, err.Error()
 	}
+	return c.shouldExamineFile(&candidate{path: filepath.ToSlash(relPath), absPath: absPath})
+}
 
-	res := true
-	for _, rule := range c.Paths {
-		res = rule(relPath, res)
+// shouldExamineFile returns true if the candidate file should be scanned. If
+// not, shouldExamineFile also returns a description of the deciding rule.
+func (c Config) shouldExamineFile(file *candidate) (bool, string) {
+	res, reason := !c.Only, "not included by any rule in 'only' mode"
+	for i, rule := range c.Paths {
+		if j := rule.match(file); j >= 0 {
+			res = rule.include
+			reason = fmt.Sprintf("excluded by paths[%d] pattern '%v'", i, rule.patterns[j])
+		}
 	}
 
-	return res
+	return res, reason
 }
 
-// allowsLicense returns true if the license type with the given name is
-// permitted.
-func (c Config) allowsLicense(name string) bool {
-	for _, l := range c.Licenses {
-		if l == name {
-			return true
+// excludesDir returns true if the rules exclude every file under the project
+// relative directory dir, along with a description of the deciding rule.
+// excludesDir is conservative, and may return false for a directory that
+// contains no files that should be examined.
+func (c Config) excludesDir(dir string) (bool, string) {
+	excluded, reason := false, ""
+	if c.Only {
+		excluded, reason = true, "not included by any rule in 'only' mode"
+	}
+	for i, rule := range c.Paths {
+		switch {
+		case !rule.include && !excluded:
+			if pattern := rule.covers(dir); pattern != "" {
+				excluded, reason = true, fmt.Sprintf("excluded by paths[%d] pattern '%v'", i, pattern)
+			}
+		case rule.include && excluded:
+			if rule.mayMatchUnder(dir) {
+				excluded = false
+			}
 		}
 	}
-	return false
+	return excluded, reason
+}
+
+// extraLicenseRule returns the Decision rule that permits the license type
+// with the given name, and true, if the license is permitted by
+// Options.ExtraLicenses.
+func (c Config) extraLicenseRule(name string) (string, bool) {
+	for _, l := range c.extraLicenses {
+		if detector.Matches(l, name) {
+			return "extra_licenses: " + l, true
+		}
+	}
+	return "", false
+}
+
+// Result holds the outcome of examining a single file.
+type Result struct {
+	Path     string   // project relative path of the file, using '/' separators
+	Licenses []string // the license identifiers found in the file
+	Err      error    // the license violation, or nil if the file is compliant
+
+	// Kind and Fingerprint describe the violation, and are empty if Err is
+	// nil. Fingerprint identifies the violation across scans, and is stable
+	// while the file's path and leading comment block are unchanged.
+	Kind        ViolationKind
+	Fingerprint string
+
+	// Advisory is true if the file was examined by a Config with Enforce set
+	// to false, or if the violation is a BadHeaderStyle. Advisory violations
+	// are reported as warnings.
+	Advisory bool
+
+	// ExtraLicenses are the licenses of the file that are only permitted by
+	// Options.ExtraLicenses.
+	ExtraLicenses []string
+
+	// Project is the directory of the project whose config examined the file,
+	// or of the discovered project that holds the file, relative to the
+	// scanned root, or empty for the root project. See Options.Submodules,
+	// Options.DiscoverProjects and Workspace.
+	Project string
+
+	// GeneratedFrom, if not empty, is the project relative path of the source
+	// file that the generated file was checked by. See
+	// Config.GeneratedSources.
+	GeneratedFrom string
+
+	// Decision, if not nil, records the config rules that permitted or
+	// denied the file's licenses.
+	Decision *Decision
+
+	// Attribution, if not nil, identifies the commit that introduced the
+	// file with the violation. Scans do not set Attribution, see
+	// commits.Attribute.
+	Attribution *Attribution
+
+	// Skipped, if not empty, is the reason the file or directory was not
+	// examined. Skipped results are only produced if Options.ListSkipped is
+	// true.
+	Skipped string
+
+	// skippedBy holds the reason that each config skipped the file for, by
+	// config index, used by Results.CountExceptions. Results.dedupSkipped
+	// merges the skippedBy of the results for the same path.
+	skippedBy map[int]string
+
+	// lowConfidence is true if the licenses of the file were only declared by
+	// SPDX-License-Identifier tags, and not matched against any license text.
+	lowConfidence bool
+
+	// stale are the StaleHeaders of the config that examined the file, used
+	// by Results.Fix.
+	stale []HeaderReplacement
+
+	// style is the HeaderStyle of the config that examined the file, if its
+	// header has a fixable violation, used by Results.Fix. It is also set for
+	// suspicious character and stale header violations, which hide any style
+	// violation, so that a single fix also fixes the style.
+	style *HeaderStyle
+
+	// insert is the header that Results.Fix inserts into the file, if it has
+	// no license and the config has a NewFileHeader.
+	insert *headerInsertion
+}
+
+// Attribution identifies the commit that introduced a file, and its author, so
+// that the cleanup of the file's violations can be routed to them.
+type Attribution struct {
+	Commit string // the full commit hash
+	Author string // the name of the commit's author
+	Email  string // the email address of the commit's author
+}
+
+// String returns the author and the abbreviated commit hash.
+func (a Attribution) String() string {
+	commit := a.Commit
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	return fmt.Sprintf("%v <%v> in %v", a.Author, a.Email, commit)
+}
+
+// Decision records why the licenses of an examined file were permitted or
+// denied, so that an audit can verify the outcome of a check against the
+// config.
+type Decision struct {
+	// Config is the index of the config that examined the file, in the config
+	// file of the Result's project.
+	Config int
+
+	// Source is the project relative path of the file that holds the config,
+	// using '/' separators. This is the config file, or the file that embeds
+	// the config, such as pyproject.toml. The path is absolute if the
+	// Options.Config file is outside of the project.
+	Source string
+
+	// Rules holds the config rule that decided each of the file's licenses,
+	// in order, stopping at the first denied license. Each rule is the config
+	// setting and the matching entry, for example "licenses: Apache-2.0" or
+	// "extra_licenses: MIT". Denied licenses are recorded as
+	// "<setting>: denied <license>". Files without a license record the
+	// requirement of their policy, such as "default: require header" or
+	// "language_policies.json: require none".
+	Rules []string
+}
+
+// Results is a slice of Result.
+type Results []Result
+
+// Errs returns all the license violations of the results.
+func (r Results) Errs() []error {
+	errs := make([]error, len(r))
+	for i, res := range r {
+		errs[i] = res.Err
+	}
+	return removeNilErrs(errs)
 }
 
 // runConfig gathers the source files listed in the config, scans them for their
-// licenses, and returns an error if any license violations are found.
-func runConfig(cfg Config, root string) []error {
-	files, err := gatherFiles(root, cfg)
-	if err != nil {
-		return []error{fmt.Errorf("Failed to gather files: %w", err)}
+// licenses using cls, and returns the result of examining each file.
+func runConfig(cfg Config, root string, cls *classifier, opts Options) (Results, error) {
+	var files []string
+	var skipped Results
+	if cfg.buildFiles != nil {
+		files, skipped = gatherBuildFiles(root, cfg, opts)
+	} else {
+		var err error
+		if files, skipped, err = gatherFiles(root, cfg, opts); err != nil {
+			return nil, fmt.Errorf("Failed to gather files: %w", err)
+		}
+	}
+
+	opts.report(skipped...) // Directories that could not be read
+
+	if !opts.Quiet {
+		opts.logger().Info("Scanning files", "count", len(files))
 	}
 
-	fmt.Printf("Scanning %d files...\n", len(files))
+	if opts.Progress != nil {
+		if cfg.digest = progressDigest(cfg, opts); cfg.digest == "" {
+			opts.logger().Warn("Progress is not recorded, as the config digest could not be computed", "config", cfg.index)
+		}
+	}
 
 	var wg sync.WaitGroup
-	errs := make([]error, len(files))
+	out := make(Results, len(files))
 	for i, file := range files {
 		i, file := i, file
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			errs[i] = examine(root, file, cfg)
+			if opts.expired() {
+				out[i] = Result{Path: file, Skipped: deadlineReason}
+				return
+			}
+			out[i] = examineWithin(root, file, cfg, cls, opts)
+			out[i].Advisory = out[i].Advisory || !cfg.enforced() || cfg.quarantined(file)
+			opts.report(out[i])
 		}()
 	}
 	wg.Wait()
+	if opts.expired() {
+		return append(out, skipped...), nil
+	}
 
-	return removeNilErrs(errs)
+	quarantine := checkQuarantine(root, cfg, files, opts)
+	opts.report(quarantine...)
+	out = append(out, quarantine...)
+
+	vendored, err := checkVendored(root, cfg, cls, opts)
+	if err != nil {
+		return nil, err
+	}
+	opts.report(vendored...)
+	out = append(out, vendored...)
+
+	return append(out, skipped...), nil
 }
 
-// loadConfigs loads a config file at root.
-func loadConfigs(root string) (Configs, error) {
-	path := filepath.Join(root, ConfigFileName)
-	cfgBody, err := ioutil.ReadFile(path)
+// loadConfigs loads the config file at opts.Config, or the config of the
+// project at root, as read by ReadConfig, if opts.Config is empty.
+func loadConfigs(root string, opts Options) (Configs, error) {
+	path, cfgBody, err := ReadConfig(root, opts)
+	if err != nil {
+		return nil, err
+	}
+	cfgs, err := ParseConfigs(cfgBody)
 	if err != nil {
 		return nil, err
 	}
+	source := filepath.ToSlash(path)
+	if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		source = filepath.ToSlash(rel)
+	}
+	for i := range cfgs {
+		cfgs[i].source = source
+	}
+	return cfgs, nil
+}
+
+// source returns the project relative path of the file that holds the
+// configs. See Config.source.
+func (c Configs) source() string {
+	return c[0].source
+}
+
+// ParseConfigs parses and validates the content of a config file, which holds
+// either a single JSON config object, or an array of config objects.
+func ParseConfigs(cfgBody []byte) (Configs, error) {
 	d := json.NewDecoder(bytes.NewReader(cfgBody))
 	cfgs := Configs{}
 	if strings.HasPrefix(strings.TrimLeft(string(cfgBody), " \n\t"), "{") {
@@ -250,60 +1414,281 @@ func loadConfigs(root string) (Configs, error) {
 			return nil, err
 		}
 	}
+	for i, cfg := range cfgs {
+		if err := cfg.validate(); err != nil {
+			return nil, err
+		}
+		cfgs[i].index = i
+	}
 	return cfgs, nil
 }
 
-// gatherFiles walks all files and subdirectories from root, returning those
-// that Config.shouldExamine() returns true for.
-func gatherFiles(root string, cfg Config) ([]string, error) {
-	files := []string{}
-	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+// vcsDirs is the set of version control metadata directory names, which are
+// never walked.
+var vcsDirs = map[string]bool{
+	".git": true,
+	".hg":  true,
+	".svn": true,
+	".bzr": true,
+	"CVS":  true,
+}
+
+// gatherFiles walks all files and subdirectories from root, returning the
+// project relative paths, using '/' separators, of those that
+// Config.shouldExamine() returns true for. Directories that
+// Config.excludesDir() returns true for are not walked.
+// Files that are not regular files, such as named pipes, sockets and device
+// nodes, are skipped without being read.
+// If opts.ListSkipped is true, gatherFiles also returns a Result for each file
+// and directory that was skipped. Skipped directories have a trailing '/'.
+// If opts.ContinueOnError is true, the paths that cannot be read are returned
+// as ReadError violations with the skipped Results, otherwise gatherFiles
+// returns an error for the first.
+// gatherFiles returns an error if the walk exceeds the config's MaxDepth or
+// MaxFiles, regardless of opts.ContinueOnError.
+func gatherFiles(root string, cfg Config, opts Options) ([]string, Results, error) {
+	files, skipped := []string{}, Results{}
+	walked := 0 // number of files walked, for Config.MaxFiles
+	skip := func(rel, reason string) {
+		if opts.ListSkipped {
+			skipped = append(skipped, Result{Path: rel, Skipped: reason, skippedBy: map[int]string{cfg.index: reason}})
+		}
+	}
+	configRel := "" // the project relative path of opts.Config, if under root
+	if opts.Config != "" {
+		if abs, err := filepath.Abs(opts.Config); err == nil {
+			if rel, err := filepath.Rel(root, abs); err == nil {
+				configRel = filepath.ToSlash(rel)
+			}
+		}
+	}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, walkErr error) error {
 		rel, err := filepath.Rel(root, path)
 		if err != nil {
 			rel = path
 		}
+		rel = filepath.ToSlash(rel) // Canonicalize
 
-		switch rel {
-		case ".git":
-			return filepath.SkipDir
-		case ConfigFileName:
+		if walkErr != nil {
+			if !opts.ContinueOnError || rel == "." {
+				return fmt.Errorf("Failed to read '%v': %w", opts.DisplayPath(root, rel), walkErr)
+			}
+			if info != nil && info.IsDir() {
+				rel += "/"
+			}
+			skipped = append(skipped, Result{
+				Path:        rel,
+				Err:         fmt.Errorf("Failed to read '%v': %w", opts.DisplayPath(root, rel), walkErr),
+				Kind:        ReadError,
+				Fingerprint: fingerprint(rel, ReadError, nil),
+			})
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
-		if !cfg.shouldExamine(root, path) {
+		if rel == opts.ConfigFile() || rel == configRel {
+			skip(rel, "config file")
+			return nil
+		}
+
+		if info.IsDir() {
+			if rel == "." {
+				return nil
+			}
+			if name := info.Name(); vcsDirs[name] {
+				skip(rel+"/", "version control directory")
+				return filepath.SkipDir
+			} else if !cfg.IncludeHidden && strings.HasPrefix(name, ".") {
+				skip(rel+"/", "hidden directory")
+				return filepath.SkipDir
+			}
+			if opts.subprojects[rel] {
+				skip(rel+"/", "submodule with its own config")
+				return filepath.SkipDir
+			}
+			if excluded, reason := cfg.excludesDir(rel); excluded {
+				if opts.ExplainRules {
+					opts.logger().Info("Pruned directory", "dir", opts.DisplayPath(root, rel), "reason", reason)
+				}
+				skip(rel+"/", reason)
+				return filepath.SkipDir
+			}
+			if depth := strings.Count(rel, "/") + 1; cfg.MaxDepth > 0 && depth > cfg.MaxDepth {
+				return fmt.Errorf("'%v' is %d directories deep, deeper than the max_depth of %d. Exclude the directory, or raise max_depth",
+					opts.DisplayPath(root, rel), depth, cfg.MaxDepth)
+			}
 			return nil
 		}
 
-		if !info.IsDir() {
+		if walked++; cfg.MaxFiles > 0 && walked > cfg.MaxFiles {
+			return fmt.Errorf("The project has more than the max_files of %d files. Exclude the directories that should not be scanned, or raise max_files",
+				cfg.MaxFiles)
+		}
+		if special := specialFile(path, info); special != "" {
+			opts.logger().Debug("Skipped special file", "path", opts.DisplayPath(root, rel), "type", special)
+			skip(rel, special)
+			return nil
+		}
+		if ok, reason := cfg.shouldExamine(root, path); ok {
 			files = append(files, rel)
+		} else {
+			skip(rel, reason)
 		}
 
 		return nil
 	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return files, nil
+	return files, skipped, nil
 }
 
-// examine checks the file at path for any license violations.
-// examine will return an error if no license is found, or the license is not
-// accepted by the config.
-func examine(root, path string, cfg Config) error {
-	body, err := ioutil.ReadFile(filepath.Join(root, path))
+// examine checks the file at the project relative path for any license
+// violations, returning the licenses found in the file. The Result holds an
+// error if no license is found, or the license is not accepted by the config.
+func examine(root, path string, cfg Config, cls *classifier, opts Options) Result {
+	res := Result{Path: path}
+	fail := func(kind ViolationKind, body []byte, err error) Result {
+		res.Err, res.Kind, res.Fingerprint = err, kind, fingerprint(path, kind, body)
+		return res
+	}
+
+	if src, ok := cfg.buildSource(path); ok {
+		return examineGenerated(root, path, src, cfg, cls, opts)
+	}
+	if src, ok := cfg.generatedSource(path); ok {
+		return examineGenerated(root, path, src, cfg, cls, opts)
+	}
+	abs := filepath.Join(root, filepath.FromSlash(path))
+	body, err := ioutil.ReadFile(abs)
 	if err != nil {
-		return fmt.Errorf("Failed to read file '%v': %w", path, err)
+		return fail(ReadError, nil, fmt.Errorf("Failed to read file '%v': %w", opts.DisplayPath(root, path), err))
 	}
-	cov := licensecheck.Scan(body)
-	if len(cov.Match) == 0 {
-		return fmt.Errorf("%v has no license", path)
+	progress := opts.Progress != nil && cfg.digest != ""
+	if progress {
+		if res, ok := opts.Progress.lookup(opts.prefix+path, cfg.digest, body); ok {
+			res.Path = path
+			opts.logger().Debug("Verified by a previous scan", "path", opts.DisplayPath(root, path))
+			return res
+		}
 	}
-	for _, match := range cov.Match {
-		if !cfg.allowsLicense(match.ID) {
-			return fmt.Errorf("%v uses unsupported license '%v'", path, match.ID)
+	res = examineContent(path, opts.DisplayPath(root, path), body, cfg, cls)
+	opts.logger().Debug("Examined file", "path", opts.DisplayPath(root, path), "licenses", res.Licenses, "violation", res.Kind)
+	if progress {
+		if err := opts.Progress.record(opts.prefix+path, cfg.digest, body, res); err != nil {
+			opts.logger().Warn("Failed to record progress", "path", opts.DisplayPath(root, path), "error", err)
 		}
 	}
-	return nil
+	return res
+}
+
+// examineContent checks the content of the file at the project relative path
+// for any license violations. display is the path used in error messages.
+// A panic while examining the file, such as in a license detector, is
+// recovered and reported as a ScanFailure of the file, so that a single
+// pathological file does not lose the results of all others.
+func examineContent(path, display string, body []byte, cfg Config, cls *classifier) (res Result) {
+	defer func() {
+		if r := recover(); r != nil {
+			kind := ScanFailure
+			res = Result{Path: path, Kind: kind, Fingerprint: fingerprint(path, kind, nil),
+				Err: fmt.Errorf("Failed to examine '%v': panic: %v", display, r)}
+		}
+	}()
+
+	res = Result{Path: path}
+	fail := func(kind ViolationKind, body []byte, err error) Result {
+		res.Err, res.Kind, res.Fingerprint = err, kind, fingerprint(path, kind, body)
+		return res
+	}
+
+	if err := checkConfusables(path, display, body); err != nil {
+		res.stale, res.style = cfg.StaleHeaders, cfg.HeaderStyle
+		return fail(SuspiciousCharacters, body, err)
+	}
+
+	res.Decision = &Decision{Config: cfg.index, Source: cfg.source}
+	decide := func(rule string) { res.Decision.Rules = append(res.Decision.Rules, rule) }
+	if len(bytes.TrimSpace(body)) == 0 {
+		switch cfg.EmptyFiles {
+		case EmptyIgnore:
+			decide("empty_files: ignore")
+			return res
+		case EmptyError:
+			decide("empty_files: error")
+			return fail(EmptyFile, body, fmt.Errorf("%v is empty", display))
+		}
+	}
+
+	policy := cfg.languagePolicy(path, body)
+	ids := cls.licenses(path, body)
+	if policy.Require == RequireSPDX {
+		tags := spdx.Identifiers(body)
+		res.lowConfidence = len(ids) == 0 && len(tags) > 0
+		for _, id := range tags {
+			ids = append(ids, detector.Normalize(id))
+		}
+	}
+	if cfg.Internal != nil && cfg.Internal.marked(path, body) {
+		ids = append(ids, InternalLicense)
+	}
+	if len(ids) == 0 {
+		if cfg.Internal != nil && cfg.Internal.covers(path) && policy.Require != RequireNone {
+			decide("internal: require notice")
+			return fail(NoLicense, body, fmt.Errorf("%v has no internal notice", display))
+		}
+		if policy.Require != RequireNone {
+			if rule, ok := cfg.minContentRule(path, body); ok {
+				decide(rule)
+				return res
+			}
+		}
+		decide(fmt.Sprintf("%v: require %v", policy.setting(), policy.Require))
+		if policy.Require == RequireNone {
+			return res
+		}
+		if cfg.NewFileHeader != "" {
+			res.insert = &headerInsertion{header: cfg.NewFileHeader, placement: cfg.HeaderPlacement}
+		}
+		return fail(NoLicense, body, fmt.Errorf("%v has no license", display))
+	}
+	res.Licenses = ids
+	if cfg.Internal != nil && cfg.Internal.covers(path) {
+		for _, id := range ids {
+			if id != InternalLicense {
+				decide(fmt.Sprintf("internal: denied %v", id))
+				return fail(UnsupportedLicense, body, fmt.Errorf("%v is internal, but carries open source license '%v'", display, id))
+			}
+			decide("internal: " + InternalLicense)
+		}
+		return res
+	}
+	for _, id := range ids {
+		rule, ok := policy.licenseRule(cfg, id)
+		if !ok {
+			if extra, allowed := cfg.extraLicenseRule(id); allowed {
+				rule, ok = extra, true
+				res.ExtraLicenses = append(res.ExtraLicenses, id)
+			}
+		}
+		decide(rule)
+		if !ok {
+			return fail(UnsupportedLicense, body, fmt.Errorf("%v uses unsupported license '%v'", display, id))
+		}
+	}
+	if s := cfg.staleHeader(path, body); s != nil {
+		res.stale, res.style = cfg.StaleHeaders, cfg.HeaderStyle
+		return fail(StaleHeader, body, fmt.Errorf("%v header references outdated '%v', replace with '%v'", display, s.Old, s.New))
+	}
+	if cfg.HeaderStyle != nil {
+		if err := checkHeaderStyle(path, display, body, *cfg.HeaderStyle); err != nil {
+			res.style, res.Advisory = cfg.HeaderStyle, true
+			return fail(BadHeaderStyle, body, err)
+		}
+	}
+	return res
 }
 
 // removeNilErrs returns a new slice with all the non-nil errors of errs
@@ -15,13 +15,30 @@
 package checker_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	checker "."
+	"../deps"
+	"../gentree"
+	"../language"
 )
 
 var testcases = filepath.Join(sourceDirectory(), "testcases")
@@ -30,6 +47,13 @@ func TestGood(t *testing.T) {
 	for _, test := range []string{
 		"good-basic",
 		"good-filter",
+		"good-hidden",
+		"good-only",
+		"good-language-policies",
+		"good-not-enforced",
+		"good-regex-detector",
+		"good-public-domain",
+		"good-internal",
 	} {
 		if err := checker.Check(filepath.Join(testcases, test)); err != nil {
 			t.Errorf("Unexpected checker failure for '%v': %v", test, err)
@@ -44,6 +68,26 @@ func TestBad(t *testing.T) {
 	}{
 		{"bad-no-config", "Failed to load config file"},
 		{"bad-missing-license", "src/missing-license.cpp has no license"},
+		{"bad-include-hidden", ".github/missing-license.cpp has no license"},
+		{"bad-include-types", "1 errors:\n* scripts/build has no license"},
+		{"bad-language-policies", "2 errors:\n* build.sh uses unsupported license 'GPL-3.0"},
+		{"bad-language-policies-config", "language_policies: unknown language 'cobol'"},
+		{"bad-header-placement", "header_placement: 'python' has unknown rule 'docstrings'"},
+		{"bad-uncovered-types", "2 errors:\n* license-checker.cfg: 2 files with the extension '.xyz' are not covered by the language_policies of configs[0] [a6e70eaa44bf52d0]\n" +
+			"* license-checker.cfg: 2 kotlin files are not covered by the language_policies of configs[0] ["},
+		{"bad-min-coverage", "1 errors:\n* license-checker.cfg: only 33.3% of files (1/3) checked, below min_coverage of 75%"},
+		{"bad-vendored", "4 errors:\n* third_party/conflict has differing license files: third_party/conflict/COPYING, third_party/conflict/LICENSE [0f5e4591035d8f16]\n* third_party/invalid/version.json has an invalid url 'example.com/invalid' ["},
+		{"bad-vendored", "* third_party/mismatch/METADATA declares license 'Apache-2.0', but third_party/mismatch/LICENSE has [MIT] ["},
+		{"bad-vendored", "* third_party/nometa has no metadata file. Expected one of: METADATA, version.json ["},
+		{"bad-detector", "Unknown detector 'askalono'"},
+		{"bad-internal", "2 errors:\n* src/none.cpp has no internal notice ["},
+		{"bad-internal", "* src/oss.cpp is internal, but carries open source license 'Apache-2.0' ["},
+		{"bad-public-domain", "1 errors:\n* src/prose.py uses unsupported license 'LicenseRef-Public-Domain'"},
+		{"bad-stale-header", "1 errors:\n* src/old.cpp header references outdated 'Acme Inc.', replace with 'Globex LLC' [3dc3d491593e18df]"},
+		{"bad-suspicious-characters", "3 errors:\n* src/homoglyph.cpp header has look-alike character U+0430 ('\u0430') instead of 'a' on line 3 [3adf13ff9f9cca81]\n" +
+			"* src/invisible.cpp header has invisible character U+200B on line 3 [e89f612ab0b11859]\n" +
+			"* src/nbsp.cpp header has look-alike character U+00A0 ('\u00a0') instead of ' ' on line 4 [714af1a11c92e340]\n"},
+		{"bad-include-languages", "2 errors:\n* Makefile has no license [500b8e1acfd3a6cc]\n* docker/Dockerfile has no license [33764cd6478bf57e]"},
 	} {
 		err := checker.Check(filepath.Join(testcases, test.dir))
 		if !strings.Contains(err.Error(), test.expect) {
@@ -52,6 +96,2121 @@ func TestBad(t *testing.T) {
 	}
 }
 
+func TestUncoveredTypes(t *testing.T) {
+	dir := filepath.Join(testcases, "bad-uncovered-types")
+	results, err := checker.Scan(dir, checker.Options{Quiet: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	failures := results.Failures(checker.Options{}).Errs()
+	if got := len(failures); got != 2 {
+		t.Errorf("Scan() returned %v violations, expected 2", got)
+	}
+	for _, res := range results {
+		if res.Err != nil && (res.Kind != checker.UncoveredFileType || res.Kind.IsFile() || res.Advisory) {
+			t.Errorf("Scan() returned violation of kind '%v': %v", res.Kind, res.Err)
+		}
+	}
+
+	for _, test := range []struct {
+		config string
+		expect string
+	}{
+		{`{ "uncovered_types": { "level": "fatal" } }`, "uncovered_types: unknown level 'fatal'"},
+		{`{ "uncovered_types": { "min_files": -1 } }`, "uncovered_types: min_files must not be negative"},
+		{`{ "uncovered_types": { "ignore": [ "txt" ] } }`, "uncovered_types: unknown language 'txt'. Extensions must start with '.'"},
+	} {
+		if _, err := checker.ParseConfigs([]byte(test.config)); err == nil || !strings.Contains(err.Error(), test.expect) {
+			t.Errorf("ParseConfigs(%v) returned %v, expected '%v'", test.config, err, test.expect)
+		}
+	}
+}
+
+func TestWarnOnly(t *testing.T) {
+	opts := checker.Options{WarnOnly: true}
+	if err := checker.CheckWithOptions(filepath.Join(testcases, "bad-missing-license"), opts); err != nil {
+		t.Errorf("Unexpected checker failure with WarnOnly: %v", err)
+	}
+}
+
+func TestAbsPaths(t *testing.T) {
+	dir := filepath.Join(testcases, "bad-missing-license")
+	err := checker.CheckWithOptions(dir, checker.Options{AbsPaths: true})
+	if err == nil {
+		t.Fatalf("Expected checker failure")
+	}
+	if expect := filepath.Join(dir, "src", "missing-license.cpp") + " has no license"; !strings.Contains(err.Error(), expect) {
+		t.Errorf("Error did not contain '%v': %v", expect, err)
+	}
+}
+
+func TestLicenseDB(t *testing.T) {
+	dir := filepath.Join(testcases, "license-db")
+	if err := checker.Check(dir); err == nil || !strings.Contains(err.Error(), "src/acme.cpp has no license") {
+		t.Errorf("Check() without license database returned %v", err)
+	}
+	opts := checker.Options{LicenseDB: filepath.Join(dir, "licenses.json")}
+	if err := checker.CheckWithOptions(dir, opts); err != nil {
+		t.Errorf("Check() with license database returned %v", err)
+	}
+}
+
+func TestListSkipped(t *testing.T) {
+	for _, test := range []struct {
+		dir    string
+		expect string
+	}{
+		{"good-filter", "* license-checker.cfg: config file\n" +
+			"* src/ignore/: excluded by paths[0] pattern '**/ignore/**'\n" +
+			"* textfile.txt: excluded by paths[0] pattern '**.txt'\n"},
+		{"good-hidden", "* .cache/: hidden directory\n" +
+			"* license-checker.cfg: config file\n" +
+			"* src/.hg/: version control directory\n"},
+		{"good-only", "* README.cpp: not included by any rule in 'only' mode\n" +
+			"* data/: not included by any rule in 'only' mode\n" +
+			"* license-checker.cfg: config file\n"},
+	} {
+		results, err := checker.Scan(filepath.Join(testcases, test.dir), checker.Options{Quiet: true, ListSkipped: true})
+		if err != nil {
+			t.Fatalf("Scan(%v) returned %v", test.dir, err)
+		}
+		if got := results.ListSkipped(); got != test.expect {
+			t.Errorf("Skipped files of '%v' were:\n%v\nExpected:\n%v", test.dir, got, test.expect)
+		}
+		if n := len(results.Examined()); n == 0 {
+			t.Errorf("No files of '%v' were examined", test.dir)
+		}
+	}
+}
+
+func TestCheckContent(t *testing.T) {
+	cfgs, err := checker.ParseConfigs([]byte(`{
+		"licenses": [ "Apache-2.0" ],
+		"paths": [ { "exclude": [ "third_party/**" ] } ]
+	}`))
+	if err != nil {
+		t.Fatalf("ParseConfigs() returned %v", err)
+	}
+	apache := "// Licensed under the Apache License, Version 2.0 (the \"License\");\n"
+	mit := "// Permission is hereby granted, free of charge, to any person obtaining a copy\n"
+	for _, test := range []struct {
+		path, body string
+		expect     string // the expected error or skipped reason
+	}{
+		{"src/a.cpp", apache, ""},
+		{"src/b.cpp", mit, "src/b.cpp uses unsupported license 'MIT'"},
+		{"/src\\c.cpp", "int c;\n", "src/c.cpp has no license"},
+		{"third_party/d.cpp", mit, "excluded by paths[0] pattern 'third_party/**'"},
+	} {
+		results, err := checker.CheckContent(cfgs, test.path, []byte(test.body), nil)
+		if err != nil {
+			t.Fatalf("CheckContent(%v) returned %v", test.path, err)
+		}
+		if len(results) != 1 {
+			t.Fatalf("CheckContent(%v) returned %d results", test.path, len(results))
+		}
+		got := results[0].Skipped
+		if err := results[0].Err; err != nil {
+			got = err.Error()
+		}
+		if got != test.expect {
+			t.Errorf("CheckContent(%v) returned '%v', expected '%v'", test.path, got, test.expect)
+		}
+	}
+}
+
+func TestEmptyFiles(t *testing.T) {
+	for _, test := range []struct {
+		policy string
+		expect string // the expected error for an empty file
+	}{
+		{"", "pkg/__init__.py has no license"},
+		{"require_header", "pkg/__init__.py has no license"},
+		{"ignore", ""},
+		{"error", "pkg/__init__.py is empty"},
+	} {
+		cfgs, err := checker.ParseConfigs([]byte(`{ "licenses": [ "Apache-2.0" ], "empty_files": "` + test.policy + `" }`))
+		if err != nil {
+			t.Fatalf("ParseConfigs() returned %v", err)
+		}
+		for _, body := range []string{"", " \n\t\n"} {
+			results, err := checker.CheckContent(cfgs, "pkg/__init__.py", []byte(body), nil)
+			if err != nil {
+				t.Fatalf("CheckContent() returned %v", err)
+			}
+			got := ""
+			if err := results[0].Err; err != nil {
+				got = err.Error()
+			}
+			if got != test.expect {
+				t.Errorf("CheckContent(%q) with empty_files '%v' returned '%v', expected '%v'", body, test.policy, got, test.expect)
+			}
+		}
+		results, err := checker.CheckContent(cfgs, "pkg/a.py", []byte("x = 1\n"), nil)
+		if err != nil {
+			t.Fatalf("CheckContent() returned %v", err)
+		}
+		if results[0].Kind != checker.NoLicense {
+			t.Errorf("CheckContent() of a non-empty file with empty_files '%v' returned kind '%v'", test.policy, results[0].Kind)
+		}
+	}
+	if _, err := checker.ParseConfigs([]byte(`{ "empty_files": "skip" }`)); err == nil {
+		t.Errorf("ParseConfigs() with an unknown empty_files value returned no error")
+	}
+}
+
+func TestMinContent(t *testing.T) {
+	cfgs, err := checker.ParseConfigs([]byte(`{
+		"licenses": [ "Apache-2.0" ],
+		"min_lines": 3,
+		"min_content_by_extension": { ".json": { "min_bytes": 32 } }
+	}`))
+	if err != nil {
+		t.Fatalf("ParseConfigs() returned %v", err)
+	}
+	for _, test := range []struct {
+		path   string
+		body   string
+		expect string // the expected error, or empty if compliant
+		rule   string // the expected exempting rule, or empty if none
+	}{
+		{"a.py", "from x import y\n", "", "min_lines: 3"},
+		{"a.py", "import x\n\n\nimport y\n", "", "min_lines: 3"},
+		{"a.py", "import x\nimport y\nimport z\n", "a.py has no license", ""},
+		{"a.json", "{}\n", "", "min_content_by_extension[.json].min_bytes: 32"},
+		{"a.json", "{\n\"name\": \"a-much-longer-package-name\"\n}\n", "a.json has no license", ""},
+	} {
+		results, err := checker.CheckContent(cfgs, test.path, []byte(test.body), nil)
+		if err != nil {
+			t.Fatalf("CheckContent() returned %v", err)
+		}
+		res := results[0]
+		got := ""
+		if res.Err != nil {
+			got = res.Err.Error()
+		}
+		if got != test.expect {
+			t.Errorf("CheckContent(%v, %q) returned '%v', expected '%v'", test.path, test.body, got, test.expect)
+		}
+		if test.rule != "" && (res.Decision == nil || !strings.Contains(strings.Join(res.Decision.Rules, "\n"), test.rule)) {
+			t.Errorf("CheckContent(%v, %q) decision %+v does not contain '%v'", test.path, test.body, res.Decision, test.rule)
+		}
+	}
+	for _, cfg := range []string{
+		`{ "min_lines": -1 }`,
+		`{ "min_content_by_extension": { "py": { "min_lines": 2 } } }`,
+	} {
+		if _, err := checker.ParseConfigs([]byte(cfg)); err == nil {
+			t.Errorf("ParseConfigs(%v) returned no error", cfg)
+		}
+	}
+}
+
+func TestVars(t *testing.T) {
+	cfgs, err := checker.ParseConfigs([]byte(`{
+		"vars": {
+			"gen": "**/gen/**",
+			"generated": [ "**.pb.cc", "out/**" ]
+		},
+		"licenses": [ "Apache-2.0" ],
+		"paths": [
+			{ "exclude": [ "${gen}", "${generated}" ] },
+			{ "include": [ "${gen}/keep.cpp" ] }
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("ParseConfigs() returned %v", err)
+	}
+	for _, test := range []struct {
+		path   string
+		expect string // the expected skipped reason
+	}{
+		{"src/gen/a.cpp", "excluded by paths[0] pattern '**/gen/**'"},
+		{"src/a.pb.cc", "excluded by paths[0] pattern '**.pb.cc'"},
+		{"out/a.cpp", "excluded by paths[0] pattern 'out/**'"},
+		{"src/gen/x/keep.cpp", ""},
+		{"src/a.cpp", ""},
+	} {
+		results, err := checker.CheckContent(cfgs, test.path, []byte("int a;\n"), nil)
+		if err != nil {
+			t.Fatalf("CheckContent(%v) returned %v", test.path, err)
+		}
+		if got := results[0].Skipped; got != test.expect {
+			t.Errorf("CheckContent(%v) skipped with '%v', expected '%v'", test.path, got, test.expect)
+		}
+	}
+
+	for _, test := range []struct {
+		cfg    string
+		expect string
+	}{
+		{`{ "paths": [ { "exclude": [ "${gen}" ] } ] , "vars": {} }`, "vars: unknown variable '${gen}'"},
+		{`{ "paths": [ { "exclude": [ "src/${gen}" ] } ] , "vars": { "gen": [ "a", "b" ] } }`,
+			"vars: array variable '${gen}' must be used as a whole array element"},
+		{`{ "vars": { "gen": 1 } }`, "vars: 'gen' must be a string or an array of strings"},
+		{`{ "vars": [ "gen" ] }`, "vars must be an object"},
+	} {
+		if _, err := checker.ParseConfigs([]byte(test.cfg)); err == nil || err.Error() != test.expect {
+			t.Errorf("ParseConfigs(%v) returned %v, expected %v", test.cfg, err, test.expect)
+		}
+	}
+}
+
+func TestPolicyCheckFile(t *testing.T) {
+	cfgs, err := checker.ParseConfigs([]byte(`{ "licenses": [ "Apache-2.0" ] }`))
+	if err != nil {
+		t.Fatalf("ParseConfigs() returned %v", err)
+	}
+	p, err := checker.NewPolicy(cfgs, nil)
+	if err != nil {
+		t.Fatalf("NewPolicy() returned %v", err)
+	}
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "src"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "src", "a.cpp"), []byte("int a;\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{"src/a.cpp", filepath.Join(root, "src", "a.cpp")} {
+		results, err := p.CheckFile(root, file)
+		if err != nil {
+			t.Fatalf("CheckFile(%v) returned %v", file, err)
+		}
+		if errs := results.Errs(); len(errs) != 1 || errs[0].Error() != "src/a.cpp has no license" {
+			t.Errorf("CheckFile(%v) returned %v", file, errs)
+		}
+	}
+	if _, err := p.CheckFile(root, "../a.cpp"); err == nil {
+		t.Errorf("CheckFile() of a file outside of the root returned no error")
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	for _, test := range []struct {
+		dir      string
+		proposed string
+		expect   string
+	}{
+		{"bad-missing-license", `{ "paths": [{ "exclude": [ "src/missing-license.cpp" ] }], "licenses": [ "Apache-2.0" ] }`,
+			"Newly failing (0):\n" +
+				"Newly passing (1):\n" +
+				"* src/missing-license.cpp has no license [e8c82aa523351bfa]\n" +
+				"0 violations would fail with the proposed config.\n"},
+		{"good-basic", `{ "paths": [{ "exclude": [ "**.txt", "**.h" ] }], "licenses": [ "MIT" ] }`,
+			"Newly failing (1):\n" +
+				"* src/source.cpp uses unsupported license 'Apache-2.0' [27d772a245023669]\n" +
+				"Newly passing (0):\n" +
+				"1 violations would fail with the proposed config.\n"},
+	} {
+		proposed := filepath.Join(t.TempDir(), "proposed.cfg")
+		if err := ioutil.WriteFile(proposed, []byte(test.proposed), 0666); err != nil {
+			t.Fatal(err)
+		}
+		s, err := checker.Simulate(filepath.Join(testcases, test.dir), proposed, checker.Options{Quiet: true})
+		if err != nil {
+			t.Fatalf("Simulate(%v) returned %v", test.dir, err)
+		}
+		if got := s.String(); got != test.expect {
+			t.Errorf("Simulate(%v) returned:\n%v\nExpected:\n%v", test.dir, got, test.expect)
+		}
+	}
+}
+
+func TestWorkspace(t *testing.T) {
+	ws, err := checker.LoadWorkspace(filepath.Join(testcases, "workspace", "license-checker-workspace.json"))
+	if err != nil {
+		t.Fatalf("LoadWorkspace() returned %v", err)
+	}
+	results, err := ws.Scan(checker.Options{Quiet: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	expect := "* lib/src/missing-license.cpp has no license [3626a3fece11fb1c]\n"
+	if got := results.List(checker.Options{}); got != expect {
+		t.Errorf("Workspace results were:\n%v\nExpected:\n%v", got, expect)
+	}
+	c, err := ws.MeasureCoverage(results, checker.Options{})
+	if err != nil {
+		t.Fatalf("MeasureCoverage() returned %v", err)
+	}
+	if expect := (checker.Coverage{Examined: 3, Total: 3}); c != expect {
+		t.Errorf("MeasureCoverage() returned %+v, expected %+v", c, expect)
+	}
+}
+
+func TestSink(t *testing.T) {
+	mutex := sync.Mutex{}
+	streamed := []string{}
+	sink := checker.ViolationSinkFunc(func(res checker.Result) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		streamed = append(streamed, res.Path+" "+res.Fingerprint)
+	})
+	dir := filepath.Join(testcases, "submodules")
+	results, err := checker.Scan(dir, checker.Options{Quiet: true, Submodules: true, Sink: sink})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	expect := []string{}
+	for _, res := range results {
+		if res.Err != nil {
+			expect = append(expect, res.Path+" "+res.Fingerprint)
+		}
+	}
+	sort.Strings(streamed)
+	sort.Strings(expect)
+	if len(expect) == 0 || !reflect.DeepEqual(streamed, expect) {
+		t.Errorf("Sink received:\n%v\nexpected:\n%v", strings.Join(streamed, "\n"), strings.Join(expect, "\n"))
+	}
+}
+
+func TestPolicyTests(t *testing.T) {
+	cfgs, err := checker.ParseConfigs([]byte(`{
+		"licenses": [ "Apache-2.0" ],
+		"paths": [ { "exclude": [ "out/**" ] } ]
+	}`))
+	if err != nil {
+		t.Fatalf("ParseConfigs() returned %v", err)
+	}
+	policy, err := checker.NewPolicy(cfgs, nil)
+	if err != nil {
+		t.Fatalf("NewPolicy() returned %v", err)
+	}
+	root := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(root, "main.cpp"), []byte("// Licensed under the Apache License, Version 2.0 (the \"License\");\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(root, checker.DefaultPolicyTestsFileName)
+	if err := ioutil.WriteFile(file, []byte(`{ "tests": [
+		{ "path": "main.cpp", "expect": "scanned" },
+		{ "path": "out/gen.cpp", "expect": "skipped" },
+		{ "path": "src/mit.c", "expect": "violation:unsupported-license", "content": "// Permission is hereby granted, free of charge, to any person obtaining a copy\n" },
+		{ "path": "src/none.c", "expect": "violation:no-license" },
+		{ "path": "out/a.cpp", "expect": "scanned" },
+		{ "path": "main.cpp", "expect": "violation:no-license" }
+	] }`), 0666); err != nil {
+		t.Fatal(err)
+	}
+	tests, err := checker.LoadPolicyTests(file)
+	if err != nil {
+		t.Fatalf("LoadPolicyTests() returned %v", err)
+	}
+	got := []string{}
+	for _, err := range policy.Test(root, tests) {
+		got = append(got, err.Error())
+	}
+	expect := []string{
+		"tests[4] out/a.cpp: expected scanned, got skipped (excluded by paths[0] pattern 'out/**')",
+		"tests[5] main.cpp: expected violation:no-license, got scanned (licenses: Apache-2.0)",
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("Test() returned:\n%v\nexpected:\n%v", strings.Join(got, "\n"), strings.Join(expect, "\n"))
+	}
+
+	if err := ioutil.WriteFile(file, []byte(`{ "tests": [ { "path": "a.c", "expect": "checked" } ] }`), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := checker.LoadPolicyTests(file); err == nil {
+		t.Errorf("LoadPolicyTests() with an unknown expect returned no error")
+	}
+}
+
+func TestExclusions(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		checker.DefaultConfigFileName: `{ "licenses": [ "Apache-2.0" ], "paths": [ { "exclude": [ "out/**" ] } ] }`,
+		"src/a.cpp":                   "// Licensed under the Apache License, Version 2.0 (the \"License\");\n",
+		"out/b.cpp":                   "",
+	})
+	results, err := checker.Scan(root, checker.Options{Quiet: true, ListSkipped: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	got := results.Exclusions()
+	expect := checker.Exclusions{Excluded: []checker.Exclusion{
+		{Path: checker.DefaultConfigFileName, Reason: "config file"},
+		{Path: "out/", Reason: "excluded by paths[0] pattern 'out/**'"},
+	}}
+	if !reflect.DeepEqual(got, expect) {
+		t.Fatalf("Exclusions() returned %+v, expected %+v", got, expect)
+	}
+
+	file := filepath.Join(root, checker.DefaultExclusionsFileName)
+	if err := got.Write(file); err != nil {
+		t.Fatalf("Write() returned %v", err)
+	}
+	snapshot, err := checker.LoadExclusions(file)
+	if err != nil {
+		t.Fatalf("LoadExclusions() returned %v", err)
+	}
+	if diff := snapshot.Diff(got); len(diff) != 0 {
+		t.Errorf("Diff() of unchanged exclusions returned %v", diff)
+	}
+	changed := checker.Exclusions{Excluded: []checker.Exclusion{
+		{Path: "out/", Reason: "generated"},
+		{Path: "third_party/", Reason: "vendored"},
+	}}
+	diff := snapshot.Diff(changed)
+	expectDiff := []string{
+		"- " + checker.DefaultConfigFileName + ": config file",
+		"- out/: excluded by paths[0] pattern 'out/**'",
+		"+ out/: generated",
+		"+ third_party/: vendored",
+	}
+	if !reflect.DeepEqual(diff, expectDiff) {
+		t.Errorf("Diff() returned:\n%v\nexpected:\n%v", strings.Join(diff, "\n"), strings.Join(expectDiff, "\n"))
+	}
+}
+
+func TestNewFile(t *testing.T) {
+	cfgs, err := checker.ParseConfigs([]byte(`[
+		{
+			"licenses": [ "Apache-2.0" ],
+			"paths": [ { "exclude": [ "out/**", "py/**" ] } ],
+			"new_file_header": "Copyright {year} Globex LLC\n\nLicensed under the Apache License, Version 2.0 (the \"License\");"
+		},
+		{
+			"licenses": [ "permissive", "MIT" ],
+			"paths": [ { "exclude": [ "**" ] }, { "include": [ "py/**" ] } ],
+			"language_policies": { "python": { "require": "spdx" }, "yaml": { "require": "none" } }
+		}
+	]`))
+	if err != nil {
+		t.Fatalf("ParseConfigs() returned %v", err)
+	}
+	policy, err := checker.NewPolicy(cfgs, nil)
+	if err != nil {
+		t.Fatalf("NewPolicy() returned %v", err)
+	}
+	for _, test := range []struct {
+		path   string
+		lang   string
+		expect string // the expected content, or error
+	}{
+		{"src/foo.cc", "cpp", "// Copyright 2024 Globex LLC\n//\n// Licensed under the Apache License, Version 2.0 (the \"License\");\n\n"},
+		{"src/foo.html", "html", "<!--\n  Copyright 2024 Globex LLC\n\n  Licensed under the Apache License, Version 2.0 (the \"License\");\n-->\n\n"},
+		{"py/foo.py", "python", "# SPDX-License-Identifier: MIT\n\n"},
+		{"py/foo.yaml", "yaml", ""},
+		{"py/foo.sh", "shell", "configs[1] has no new_file_header, so the license header of py/foo.sh is unknown"},
+		{"src/foo.json", "json", "json files cannot hold a license header, as they have no comments"},
+		{"out/foo.go", "go", "out/foo.go is not checked (excluded by paths[0] pattern '**'), so it needs no license header"},
+	} {
+		lang, _ := language.ByName(test.lang)
+		got := ""
+		if body, err := policy.NewFile(test.path, lang, 2024); err != nil {
+			got = err.Error()
+		} else {
+			got = string(body)
+		}
+		if got != test.expect {
+			t.Errorf("NewFile(%v) returned:\n%v\nexpected:\n%v", test.path, got, test.expect)
+		}
+	}
+}
+
+func TestScanLimits(t *testing.T) {
+	root := t.TempDir()
+	for _, path := range []string{"a.cpp", "b/b.cpp", "b/c/c.cpp", "out/d/e/f.cpp"} {
+		file := filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(file), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte("// Licensed under the Apache License, Version 2.0 (the \"License\");\n"), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, test := range []struct {
+		limits string
+		expect string // the expected error, if any
+	}{
+		{`"max_depth": 2, "max_files": 3`, ""},
+		{`"max_depth": 1`, "'b/c' is 2 directories deep, deeper than the max_depth of 1"},
+		{`"max_files": 2`, "The project has more than the max_files of 2 files"},
+		{`"max_depth": -1`, "max_depth and max_files must not be negative"},
+	} {
+		cfg := `{ "licenses": [ "Apache-2.0" ], "paths": [ { "exclude": [ "out/**" ] } ], ` + test.limits + ` }`
+		if err := ioutil.WriteFile(filepath.Join(root, checker.DefaultConfigFileName), []byte(cfg), 0666); err != nil {
+			t.Fatal(err)
+		}
+		_, err := checker.Scan(root, checker.Options{Quiet: true})
+		switch {
+		case test.expect == "" && err != nil:
+			t.Errorf("Scan() with %v returned %v", test.limits, err)
+		case test.expect != "" && (err == nil || !strings.Contains(err.Error(), test.expect)):
+			t.Errorf("Scan() with %v returned %v, expected '%v'", test.limits, err, test.expect)
+		}
+	}
+}
+
+func TestWhatIf(t *testing.T) {
+	cfgs, err := checker.ParseConfigs([]byte(`{
+		"licenses": [ "Apache-2.0" ],
+		"paths": [ { "exclude": [ "out/**" ] } ],
+		"language_policies": { "json": { "require": "none" } }
+	}`))
+	if err != nil {
+		t.Fatalf("ParseConfigs() returned %v", err)
+	}
+	policy, err := checker.NewPolicy(cfgs, nil)
+	if err != nil {
+		t.Fatalf("NewPolicy() returned %v", err)
+	}
+	for _, test := range []struct {
+		path     string
+		licenses []string
+		expect   string // the expected error, skipped reason, or decision rules
+	}{
+		{"new/a.go", []string{"Apache-2.0"}, "licenses: Apache-2.0"},
+		{"new/a.go", []string{"Apache-2.0-Header"}, "licenses: Apache-2.0"},
+		{"new/a.go", []string{"GPL-3.0"}, "new/a.go uses unsupported license 'GPL-3.0'"},
+		{"new/a.go", nil, "new/a.go has no license"},
+		{"new/a.json", nil, "language_policies.json: require none"},
+		{"out/a.go", []string{"GPL-3.0"}, "excluded by paths[0] pattern 'out/**'"},
+	} {
+		results := policy.WhatIf(test.path, test.licenses)
+		got := results[0].Skipped
+		switch {
+		case results[0].Err != nil:
+			got = results[0].Err.Error()
+		case got == "":
+			got = strings.Join(results[0].Decision.Rules, ", ")
+		}
+		if got != test.expect {
+			t.Errorf("WhatIf(%v, %v) returned '%v', expected '%v'", test.path, test.licenses, got, test.expect)
+		}
+	}
+}
+
+func TestSubmodules(t *testing.T) {
+	dir := filepath.Join(testcases, "submodules")
+	results, err := checker.Scan(dir, checker.Options{Quiet: true, Submodules: true, ListSkipped: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	expect := "* third_party/lib/lib.cpp uses unsupported license 'Apache-2.0' [7ddebdf85d3e5302]\n"
+	if got := results.List(checker.Options{}); got != expect {
+		t.Errorf("Submodule results were:\n%v\nExpected:\n%v", got, expect)
+	}
+	expectSkipped := "* license-checker.cfg: config file\n" +
+		"* third_party/lib/: submodule with its own config\n" +
+		"* third_party/lib/license-checker.cfg: config file\n"
+	if got := results.ListSkipped(); got != expectSkipped {
+		t.Errorf("Skipped files were:\n%v\nExpected:\n%v", got, expectSkipped)
+	}
+	expectProjects := []checker.ProjectStatus{
+		{Path: "", Errors: 0},
+		{Path: "third_party/lib", Errors: 1},
+	}
+	if got := results.Projects(checker.Options{}); !reflect.DeepEqual(got, expectProjects) {
+		t.Errorf("Projects() returned %+v, expected %+v", got, expectProjects)
+	}
+}
+
+func TestDiscoverProjects(t *testing.T) {
+	licensed := "// Licensed under the Apache License, Version 2.0 (the \"License\");\n"
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		checker.DefaultConfigFileName: `{ "licenses": [ "Apache-2.0" ], "paths": [ { "exclude": [ "**go.mod", "**package.json", "vendor/**" ] } ] }`,
+		"main.cpp":                    licensed,
+		"vendor/dep/go.mod":           "module example.com/dep\n",
+		"services/api/go.mod":         "module example.com/api\n",
+		"services/api/api.cpp":        "int api;\n",
+		"libs/util/LICENSE":           licensed,
+		"libs/util/util.cpp":          licensed,
+		"libs/util/web/package.json":  "{}\n",
+		"libs/util/web/web.cpp":       "int web;\n",
+	})
+	plain, err := checker.Scan(dir, checker.Options{Quiet: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	if got := plain.Projects(checker.Options{}); got != nil {
+		t.Errorf("Projects() without DiscoverProjects returned %+v", got)
+	}
+	mutex := sync.Mutex{}
+	streamed := map[string]string{}
+	sink := checker.ViolationSinkFunc(func(res checker.Result) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		streamed[res.Path] = res.Project
+	})
+	results, err := checker.Scan(dir, checker.Options{Quiet: true, DiscoverProjects: true, Sink: sink})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	expectStreamed := map[string]string{
+		"services/api/api.cpp":  "services/api",
+		"libs/util/web/web.cpp": "libs/util/web",
+	}
+	if !reflect.DeepEqual(streamed, expectStreamed) {
+		t.Errorf("Sink received projects %v, expected %v", streamed, expectStreamed)
+	}
+	projects := map[string]string{}
+	for _, res := range results.Examined() {
+		projects[res.Path] = res.Project
+	}
+	expect := map[string]string{
+		"main.cpp":              "",
+		"services/api/api.cpp":  "services/api",
+		"libs/util/LICENSE":     "libs/util",
+		"libs/util/util.cpp":    "libs/util",
+		"libs/util/web/web.cpp": "libs/util/web",
+	}
+	if !reflect.DeepEqual(projects, expect) {
+		t.Errorf("Scan() returned projects %v, expected %v", projects, expect)
+	}
+	if got, expect := results.List(checker.Options{}), plain.List(checker.Options{}); got != expect {
+		t.Errorf("DiscoverProjects changed the violations:\n%v\nExpected:\n%v", got, expect)
+	}
+	status := []string{}
+	for _, p := range results.Projects(checker.Options{}) {
+		status = append(status, p.String())
+	}
+	expectStatus := []string{
+		".: PASS (0 errors, 0 warnings)",
+		"libs/util/web: FAIL (1 errors, 0 warnings)",
+		"libs/util: PASS (0 errors, 0 warnings)",
+		"services/api: FAIL (1 errors, 0 warnings)",
+	}
+	sort.Strings(status)
+	if !reflect.DeepEqual(status, expectStatus) {
+		t.Errorf("Projects() returned %v, expected %v", status, expectStatus)
+	}
+}
+
+func TestEmbeddedConfigSource(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		checker.PackageJSONFileName: `{ "name": "example", "license-checker": { "licenses": [ "Apache-2.0" ], "min_coverage": 100 } }`,
+		"a.cpp":                     "int a;\n",
+		"notes.txt":                 "notes\n",
+		"sub/license-checker.cfg":   `{ "licenses": [ "Apache-2.0" ] }`,
+		"sub/b.cpp":                 "int b;\n",
+	})
+	results, err := checker.Scan(dir, checker.Options{Quiet: true, Submodules: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	sources := map[string]string{}
+	for _, res := range results {
+		if res.Decision != nil {
+			sources[res.Path] = res.Decision.Source
+		}
+		if res.Kind == checker.LowCoverage {
+			sources[string(res.Kind)] = res.Path
+		}
+	}
+	expect := map[string]string{
+		"a.cpp":                     checker.PackageJSONFileName,
+		"notes.txt":                 checker.PackageJSONFileName,
+		checker.PackageJSONFileName: checker.PackageJSONFileName,
+		"sub/b.cpp":                 "sub/license-checker.cfg",
+		string(checker.LowCoverage): checker.PackageJSONFileName,
+	}
+	if !reflect.DeepEqual(sources, expect) {
+		t.Errorf("Scan() returned config sources %v, expected %v", sources, expect)
+	}
+	if got, expect := results.ConfigSources(), map[string]string{"": checker.PackageJSONFileName, "sub": "sub/license-checker.cfg"}; !reflect.DeepEqual(got, expect) {
+		t.Errorf("ConfigSources() returned %v, expected %v", got, expect)
+	}
+}
+
+func TestBuildManifest(t *testing.T) {
+	licensed := "// Licensed under the Apache License, Version 2.0 (the \"License\");\n"
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"tools/license.cfg":   `{ "licenses": [ "Apache-2.0" ], "language_policies": { "python": { "require": "none" } } }`,
+		"src/a.cpp":           licensed,
+		"src/b.cpp":           "int b;\n",
+		"gen/a.h":             "int a;\n",
+		"gen/other.h":         "int other;\n",
+		"third_party/x/x.cpp": "int x;\n",
+		"scripts/tool":        "print('tool')\n",
+		"build/manifest.json": `{
+	"config": "../tools/license.cfg",
+	"files": [
+		{ "path": "src/a.cpp", "language": "cpp" },
+		{ "path": "src/c.cpp" },
+		{ "path": "gen/a.h", "generated": true, "source": "src/a.cpp" },
+		{ "path": "gen/other.h", "generated": true },
+		{ "path": "third_party/x/x.cpp", "third_party": true },
+		{ "path": "scripts/tool", "language": "python" }
+	]
+}`,
+	})
+	manifest, err := checker.LoadBuildManifest(filepath.Join(dir, "build", "manifest.json"))
+	if err != nil {
+		t.Fatalf("LoadBuildManifest() returned %v", err)
+	}
+	if expect := filepath.Join(dir, "tools", "license.cfg"); manifest.Config != expect {
+		t.Errorf("LoadBuildManifest() returned config '%v', expected '%v'", manifest.Config, expect)
+	}
+	results, err := checker.Scan(dir, checker.Options{Quiet: true, ListSkipped: true, Config: manifest.Config, BuildManifest: manifest})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	if got := results.List(checker.Options{}); !strings.HasPrefix(got, "* Failed to read file 'src/c.cpp'") || strings.Count(got, "\n") != 1 {
+		t.Errorf("Scan() returned violations:\n%v\nExpected only the read error of src/c.cpp", got)
+	}
+	expectSkipped := "* gen/other.h: generated, as declared by the build manifest\n" +
+		"* third_party/x/x.cpp: third-party, as declared by the build manifest\n"
+	if got := results.ListSkipped(); got != expectSkipped {
+		t.Errorf("Skipped files were:\n%v\nExpected:\n%v", got, expectSkipped)
+	}
+	if got, expect := results.ListGenerated(), "* gen/a.h: generated from src/a.cpp\n"; got != expect {
+		t.Errorf("ListGenerated() returned:\n%v\nExpected:\n%v", got, expect)
+	}
+	for _, res := range results {
+		if res.Path == "scripts/tool" && (res.Decision == nil || !reflect.DeepEqual(res.Decision.Rules, []string{"language_policies.python: require none"})) {
+			t.Errorf("scripts/tool was decided by %+v, expected the python language policy", res.Decision)
+		}
+	}
+	manifestBody, err := ioutil.ReadFile(filepath.Join(dir, "build", "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exceptions := manifest.Exceptions("build/manifest.json", manifestBody)
+	results.CountExceptions(exceptions)
+	gotExceptions := []string{}
+	for _, e := range exceptions {
+		gotExceptions = append(gotExceptions, fmt.Sprintf("%v:%d %v: %v (%d)", e.Source, e.Line, e.Path, e.Rule, e.Matches))
+	}
+	expectExceptions := []string{
+		"build/manifest.json:6 gen/a.h: gen/a.h is generated, so it is checked by src/a.cpp (1)",
+		"build/manifest.json:7 gen/other.h: gen/other.h is generated, so it is not examined (1)",
+		"build/manifest.json:8 third_party/x/x.cpp: third_party/x/x.cpp is third-party, so it is not examined (1)",
+	}
+	if !reflect.DeepEqual(gotExceptions, expectExceptions) {
+		t.Errorf("Exceptions() returned:\n%v\nExpected:\n%v", strings.Join(gotExceptions, "\n"), strings.Join(expectExceptions, "\n"))
+	}
+
+	for _, test := range []struct {
+		manifest string
+		expect   string
+	}{
+		{`[ { "path": "src/a.cpp" } ]`, ""},
+		{`[ { "path": "../a.cpp" } ]`, "files[0] has path '../a.cpp', which is not a clean project relative path"},
+		{`[ { "path": "/a.cpp" } ]`, "files[0] has path '/a.cpp', which is not a clean project relative path"},
+		{`[ { "path": "a.cpp", "source": "a.proto" } ]`, "files[0] 'a.cpp' has a source, but is not generated"},
+		{`[ { "path": "a.cbl", "language": "cobol" } ]`, "files[0] 'a.cbl' has unknown language 'cobol'"},
+	} {
+		path := filepath.Join(t.TempDir(), "manifest.json")
+		if err := ioutil.WriteFile(path, []byte(test.manifest), 0666); err != nil {
+			t.Fatal(err)
+		}
+		_, err := checker.LoadBuildManifest(path)
+		if test.expect == "" && err != nil || test.expect != "" && (err == nil || !strings.Contains(err.Error(), test.expect)) {
+			t.Errorf("LoadBuildManifest(%v) returned %v, expected '%v'", test.manifest, err, test.expect)
+		}
+	}
+}
+
+func TestExtraLicenses(t *testing.T) {
+	dir := filepath.Join(testcases, "submodules", "third_party", "lib")
+	if err := checker.CheckWithOptions(dir, checker.Options{Quiet: true}); err == nil {
+		t.Fatalf("Check() without extra licenses returned no error")
+	}
+	results, err := checker.Scan(dir, checker.Options{Quiet: true, ExtraLicenses: []string{"apache-2.0"}})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	if errs := results.Errs(); len(errs) != 0 {
+		t.Errorf("Scan() with extra licenses returned violations: %v", errs)
+	}
+	if got, expect := results.ListExtra(), "* lib.cpp: Apache-2.0\n"; got != expect {
+		t.Errorf("ListExtra() returned:\n%v\nExpected:\n%v", got, expect)
+	}
+}
+
+func TestVerifyExport(t *testing.T) {
+	dir := filepath.Join(testcases, "export")
+	if err := checker.Check(dir); err == nil || !strings.Contains(err.Error(), "oss/link.cpp has no license") {
+		t.Errorf("Check() returned %v", err)
+	}
+	for _, test := range []struct {
+		subtree string
+		expect  string
+	}{
+		{"oss", "* oss/link.cpp has no license [5c8a2662b65a6538]\n" +
+			"* oss/leak.cpp is internal, and must not be exported [580f10d30bb69048]\n" +
+			"* oss/link.cpp links to 'internal/secret.cpp', outside of the exported tree [e1c904b5c004c391]\n" +
+			"* oss/notes.txt is exported, but was not checked by any config [4dafa3de0371a102]\n"},
+		{"internal", "* internal/secret.cpp is internal, and must not be exported [85d2fd1d0f1c5305]\n" +
+			"* internal has no LICENSE, LICENCE or COPYING file [dcdaac1c2bd467ea]\n"},
+	} {
+		results, err := checker.VerifyExport(dir, test.subtree, checker.Options{Quiet: true})
+		if err != nil {
+			t.Fatalf("VerifyExport(%v) returned %v", test.subtree, err)
+		}
+		if got := results.List(checker.Options{}); got != test.expect {
+			t.Errorf("VerifyExport(%v) returned:\n%v\nExpected:\n%v", test.subtree, got, test.expect)
+		}
+	}
+	if _, err := checker.VerifyExport(dir, "..", checker.Options{Quiet: true}); err == nil {
+		t.Errorf("VerifyExport() of a directory outside of the project returned no error")
+	}
+}
+
+func TestVerifyManifest(t *testing.T) {
+	dir := filepath.Join(testcases, "export")
+	manifest := checker.ParseManifest([]byte("# Files to export\noss/uses.cpp\n\noss/leak.cpp\noss/notes.txt\noss/missing.cpp\n"))
+	results, err := checker.VerifyManifest(dir, manifest, checker.Options{Quiet: true})
+	if err != nil {
+		t.Fatalf("VerifyManifest() returned %v", err)
+	}
+	expect := "* oss/uses.cpp references 'lib.h' (oss/lib.h), which is not in the manifest [60ad72a610719c54]\n" +
+		"* oss/uses.cpp references 'internal/secret.cpp' (internal/secret.cpp), which is not in the manifest [6d1e1e1543bfc41e]\n" +
+		"* oss/leak.cpp is internal, and must not be exported [580f10d30bb69048]\n" +
+		"* oss/notes.txt is exported, but was not checked by any config [4dafa3de0371a102]\n" +
+		"* oss/missing.cpp is listed in the manifest, but does not exist [2fd607c8d5dcf791]\n"
+	if got := results.List(checker.Options{}); got != expect {
+		t.Errorf("VerifyManifest() returned:\n%v\nExpected:\n%v", got, expect)
+	}
+}
+
+func TestQuarantine(t *testing.T) {
+	dir := filepath.Join(testcases, "quarantine")
+	results, err := checker.Scan(dir, checker.Options{Quiet: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	expect := "* src/main.cpp references 'quarantine/zlib/zlib.h' (quarantine/zlib/zlib.h), which is quarantined [a770c2956da7809b]\n" +
+		"* src/main.cpp references 'quarantine/good/good.h' (quarantine/good/good.h), which is quarantined [b11a7e4076c45b15]\n"
+	if got := results.Failures(checker.Options{}).List(checker.Options{}); got != expect {
+		t.Errorf("Scan() failures:\n%v\nExpected:\n%v", got, expect)
+	}
+	expect = "* quarantine/zlib/zlib.h has no license [a7a5290a132b8bc5]\n"
+	if got := results.Warnings(checker.Options{}).List(checker.Options{}); got != expect {
+		t.Errorf("Scan() warnings:\n%v\nExpected:\n%v", got, expect)
+	}
+
+	for _, test := range []struct {
+		component string
+		expect    string
+	}{
+		{"quarantine/good", ""},
+		{"quarantine/zlib", "* quarantine/zlib/zlib.h has no license [a7a5290a132b8bc5]\n" +
+			"* quarantine/zlib has no metadata file. Expected one of: METADATA, version.json [616137cc8b9ff586]\n" +
+			"* quarantine/zlib has no LICENSE, LICENCE or COPYING file [88c56f62473d1430]\n"},
+	} {
+		results, err := checker.VerifyPromotion(dir, test.component, checker.Options{Quiet: true})
+		if err != nil {
+			t.Fatalf("VerifyPromotion(%v) returned %v", test.component, err)
+		}
+		if got := results.List(checker.Options{}); got != test.expect {
+			t.Errorf("VerifyPromotion(%v) returned:\n%v\nExpected:\n%v", test.component, got, test.expect)
+		}
+	}
+	if _, err := checker.VerifyPromotion(dir, "src", checker.Options{Quiet: true}); err == nil {
+		t.Errorf("VerifyPromotion() of a directory outside of the quarantine returned no error")
+	}
+}
+
+func TestDecisions(t *testing.T) {
+	results, err := checker.Scan(filepath.Join(testcases, "good-language-policies"), checker.Options{Quiet: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	got := map[string]string{}
+	for _, res := range results {
+		if res.Decision != nil {
+			got[res.Path] = strings.Join(res.Decision.Rules, "; ")
+		}
+	}
+	expect := map[string]string{
+		"build.sh":       "licenses: Apache-2.0",
+		"data.json":      "language_policies.json: require none",
+		"data.yaml":      "language_policies.yaml: require none",
+		"src/source.cpp": "licenses: Apache-2.0",
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("Scan() decisions:\n%v\nExpected:\n%v", got, expect)
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	dir := filepath.Join(testcases, "good-basic")
+	results, err := checker.Scan(dir, checker.Options{Quiet: true, Deadline: time.Now()})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	if !results.Partial() {
+		t.Errorf("Scan() past the deadline returned results that are not partial")
+	}
+	expect := "* license-checker.cfg: partial results, the scan stopped at the deadline with 2 files not examined [f4be81116842891b]\n"
+	if got := results.List(checker.Options{}); got != expect {
+		t.Errorf("Scan() past the deadline returned:\n%v\nExpected:\n%v", got, expect)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err = checker.Scan(dir, checker.Options{Quiet: true, Context: ctx})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	expect = "* license-checker.cfg: partial results, the scan was interrupted with 2 files not examined [f4be81116842891b]\n"
+	if got := results.List(checker.Options{}); got != expect {
+		t.Errorf("Scan() with a cancelled context returned:\n%v\nExpected:\n%v", got, expect)
+	}
+
+	results, err = checker.Scan(dir, checker.Options{Quiet: true, Deadline: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	if results.Partial() || len(results.Errs()) != 0 {
+		t.Errorf("Scan() before the deadline returned:\n%v", results.List(checker.Options{}))
+	}
+}
+
+func TestProgress(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "license-checker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	dir := filepath.Join(testcases, "good-basic")
+	path := filepath.Join(tmp, "progress.json")
+
+	progress, err := checker.OpenProgress(path, false)
+	if err != nil {
+		t.Fatalf("OpenProgress() returned %v", err)
+	}
+	first, err := checker.Scan(dir, checker.Options{Quiet: true, Progress: progress})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	progress.Close()
+
+	progress, err = checker.OpenProgress(path, true)
+	if err != nil {
+		t.Fatalf("OpenProgress() returned %v", err)
+	}
+	defer progress.Close()
+	if got := progress.Verified(); got != 2 {
+		t.Errorf("Verified() returned %v, expected 2", got)
+	}
+	log := strings.Builder{}
+	logger := slog.New(slog.NewTextHandler(&log, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	resumed, err := checker.Scan(dir, checker.Options{Quiet: true, Progress: progress, Logger: logger})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	if got := strings.Count(log.String(), "Verified by a previous scan"); got != 2 {
+		t.Errorf("Resumed scan skipped %v files, expected 2:\n%v", got, log.String())
+	}
+	if !reflect.DeepEqual(first, resumed) {
+		t.Errorf("Resumed scan returned:\n%+v\nExpected:\n%+v", resumed, first)
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	// checkout writes the project to a new directory, with the given line
+	// endings, as a checkout on Linux or Windows would.
+	checkout := func(eol string, files map[string]string) string {
+		dir := t.TempDir()
+		converted := map[string]string{}
+		for path, body := range files {
+			converted[path] = strings.ReplaceAll(body, "\n", eol)
+		}
+		writeFiles(t, dir, converted)
+		return dir
+	}
+	files := map[string]string{
+		checker.DefaultConfigFileName: `{ "licenses": [ "Apache-2.0" ] }`,
+		"a.cpp":                       "// Licensed under the Apache License, Version 2.0 (the \"License\");\n",
+		"b.cpp":                       "// Copyright 2020 Globex LLC\nint b;\n",
+	}
+	linux, windows := checkout("\n", files), checkout("\r\n", files)
+	scan := func(dir string, progress *checker.Progress) checker.Results {
+		results, err := checker.Scan(dir, checker.Options{Quiet: true, Progress: progress})
+		if err != nil {
+			t.Fatalf("Scan() returned %v", err)
+		}
+		return results
+	}
+
+	progressFile := filepath.Join(t.TempDir(), "progress.json")
+	progress, err := checker.OpenProgress(progressFile, false)
+	if err != nil {
+		t.Fatalf("OpenProgress() returned %v", err)
+	}
+	baseline := scan(linux, progress).Baseline()
+	progress.Close()
+	if len(baseline.Violations) != 1 || baseline.Violations[0].Path != "b.cpp" {
+		t.Fatalf("Baseline() returned %+v, expected the violation of b.cpp", baseline)
+	}
+	if progress, err = checker.OpenProgress(progressFile, true); err != nil {
+		t.Fatalf("OpenProgress() returned %v", err)
+	}
+	log := strings.Builder{}
+	logger := slog.New(slog.NewTextHandler(&log, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	results, err := checker.Scan(windows, checker.Options{Quiet: true, Progress: progress, Logger: logger})
+	progress.Close()
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	if got := strings.Count(log.String(), "Verified by a previous scan"); got != 1 {
+		t.Errorf("Scan() of the CRLF checkout resumed %v files, expected 1:\n%v", got, log.String())
+	}
+	if got := results.Baseline(); !reflect.DeepEqual(got, baseline) {
+		t.Errorf("Baseline() of the CRLF checkout returned %+v, expected %+v", got, baseline)
+	}
+
+	file := filepath.Join(t.TempDir(), "baseline.json")
+	if err := baseline.Write(file); err != nil {
+		t.Fatalf("Write() returned %v", err)
+	}
+	loaded, err := checker.LoadBaseline(file)
+	if err != nil {
+		t.Fatalf("LoadBaseline() returned %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(linux, "c.cpp"), []byte("int c;\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	accepted := scan(linux, nil).Accept(loaded)
+	if got := accepted.Failures(checker.Options{}).List(checker.Options{}); !strings.Contains(got, "c.cpp") || strings.Contains(got, "b.cpp") {
+		t.Errorf("Accept() left the failures:\n%v\nexpected only c.cpp", got)
+	}
+
+	other := checker.Baseline{Violations: []checker.BaselineEntry{
+		{Path: "win/d.cpp", Kind: checker.NoLicense, Fingerprint: "0123456789abcdef"},
+		baseline.Violations[0],
+	}}
+	merged := checker.MergeBaselines(other, baseline)
+	if !reflect.DeepEqual(merged, checker.MergeBaselines(baseline, other)) || len(merged.Violations) != 2 ||
+		merged.Violations[0].Path != "b.cpp" || merged.Violations[1].Path != "win/d.cpp" {
+		t.Errorf("MergeBaselines() returned %+v", merged)
+	}
+
+	// c.cpp is new, b.cpp remains, and the violation of win/d.cpp is fixed.
+	expect := checker.BaselineSummary{New: 1, Fixed: 1, Remaining: 1}
+	if got := scan(linux, nil).CompareBaseline(merged); got != expect {
+		t.Errorf("CompareBaseline() returned %v, expected %v", got, expect)
+	}
+	if got := expect.String(); got != "1 new, 1 fixed, 1 remaining" {
+		t.Errorf("BaselineSummary.String() returned '%v'", got)
+	}
+}
+
+func TestEmbeddedConfig(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		files  map[string]string
+		source string // the file ReadConfig is expected to read the config from
+		expect string // the expected JSON config, or error
+	}{
+		{
+			name: "pyproject table",
+			files: map[string]string{checker.PyprojectFileName: `
+[project]
+name = "example"
+dependencies = [
+    "requests>=2", # a comment
+]
+
+[tool.license-checker]
+licenses = ["Apache-2.0", 'MIT']
+enforce = true
+paths = [ { exclude = [ "build/**" ] } ]
+
+[tool.license-checker.language_policies.json]
+require = "none"
+
+[tool.other]
+released = "2020-01-02"
+`},
+			source: checker.PyprojectFileName,
+			expect: `{"enforce":true,"language_policies":{"json":{"require":"none"}},"licenses":["Apache-2.0","MIT"],"paths":[{"exclude":["build/**"]}]}`,
+		},
+		{
+			name: "pyproject array of tables",
+			files: map[string]string{checker.PyprojectFileName: `
+[[tool.license-checker]]
+licenses = ["Apache-2.0"]
+
+[[tool.license-checker]]
+licenses = ["MIT"]
+[[tool.license-checker.paths]]
+include = ["third_party/**"]
+`},
+			source: checker.PyprojectFileName,
+			expect: `[{"licenses":["Apache-2.0"]},{"licenses":["MIT"],"paths":[{"include":["third_party/**"]}]}]`,
+		},
+		{
+			name: "package.json",
+			files: map[string]string{
+				checker.PyprojectFileName:   "[project]\nname = \"example\"\n",
+				checker.PackageJSONFileName: `{ "name": "example", "license-checker": { "licenses": [ "MIT" ] } }`,
+			},
+			source: checker.PackageJSONFileName,
+			expect: `{ "licenses": [ "MIT" ] }`,
+		},
+		{
+			name: "directives",
+			files: map[string]string{
+				"doc.go":   "// Package example is an example.\npackage example\n",
+				"tools.go": "package example\n\n//license-checker:{\n//license-checker:  \"licenses\": [ \"BSD-3-Clause\" ]\n//license-checker:}\n",
+			},
+			source: "tools.go",
+			expect: "{\n  \"licenses\": [ \"BSD-3-Clause\" ]\n}",
+		},
+		{
+			name: "config file first",
+			files: map[string]string{
+				checker.DefaultConfigFileName: `{ "licenses": [ "Apache-2.0" ] }`,
+				checker.PackageJSONFileName:   `{ "license-checker": { "licenses": [ "MIT" ] } }`,
+			},
+			source: checker.DefaultConfigFileName,
+			expect: `{ "licenses": [ "Apache-2.0" ] }`,
+		},
+		{
+			name: "multiple directive files",
+			files: map[string]string{
+				"a.go": "package example\n//license-checker:{}\n",
+				"b.go": "package example\n//license-checker:{}\n",
+			},
+			expect: "hold //license-checker: directives",
+		},
+		{
+			name:   "bad pyproject",
+			files:  map[string]string{checker.PyprojectFileName: "[tool.license-checker]\nlicenses = [\"MIT\"\n"},
+			expect: "Failed to parse",
+		},
+		{
+			name:   "unsupported pyproject",
+			files:  map[string]string{checker.PyprojectFileName: "[tool.license-checker]\nlicenses = [\"MIT\"]\n[tool.other]\nreleased = 2020-01-02\n"},
+			expect: "line 4: unsupported value '2020-01-02'",
+		},
+		{
+			name: "unsupported pyproject without config",
+			files: map[string]string{
+				checker.PyprojectFileName:   "[tool.other]\nreleased = 2020-01-02\n",
+				checker.PackageJSONFileName: `{ "name": "example", "license-checker": { "licenses": [ "MIT" ] } }`,
+			},
+			source: checker.PackageJSONFileName,
+			expect: `{ "licenses": [ "MIT" ] }`,
+		},
+		{
+			name: "bad package.json without config",
+			files: map[string]string{
+				checker.PackageJSONFileName: `{ "name": `,
+				"tools.go":                  "package example\n\n//license-checker:{ \"licenses\": [ \"MIT\" ] }\n",
+			},
+			source: "tools.go",
+			expect: `{ "licenses": [ "MIT" ] }`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, test.files)
+			path, body, err := checker.ReadConfig(dir, checker.Options{})
+			if test.source == "" {
+				if err == nil || !strings.Contains(err.Error(), test.expect) {
+					t.Fatalf("ReadConfig() returned error %v, expected '%v'", err, test.expect)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadConfig() returned %v", err)
+			}
+			if expect := filepath.Join(dir, test.source); path != expect {
+				t.Errorf("ReadConfig() returned path '%v', expected '%v'", path, expect)
+			}
+			if string(body) != test.expect {
+				t.Errorf("ReadConfig() returned:\n%v\nexpected:\n%v", string(body), test.expect)
+			}
+			if _, err := checker.ParseConfigs(body); err != nil {
+				t.Errorf("ParseConfigs() returned %v", err)
+			}
+		})
+	}
+}
+
+func TestExceptions(t *testing.T) {
+	config := `{
+	"licenses": [ "Apache-2.0" ],
+	"paths": [ { "exclude": [ "third_party/**" ] } ],
+	"language_policies": { "json": { "require": "none" } },
+	"enforce": false
+}`
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		checker.DefaultConfigFileName: config,
+		"third_party/lib.cpp":         "int lib;\n",
+		"a.json":                      "{}\n",
+		"b.cpp":                       "int b;\n",
+		"c.cpp":                       "// Licensed under the Apache License, Version 2.0 (the \"License\");\n",
+	})
+	cfgs, err := checker.ParseConfigs([]byte(config))
+	if err != nil {
+		t.Fatalf("ParseConfigs() returned %v", err)
+	}
+	results, err := checker.Scan(dir, checker.Options{Quiet: true, ListSkipped: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	baseline := checker.Baseline{Violations: []checker.BaselineEntry{
+		{Path: "b.cpp", Kind: checker.NoLicense, Fingerprint: results.Baseline().Violations[0].Fingerprint},
+		{Path: "gone.cpp", Kind: checker.NoLicense, Fingerprint: "0123456789abcdef"},
+	}}
+	baselineFile := filepath.Join(dir, "baseline.json")
+	if err := baseline.Write(baselineFile); err != nil {
+		t.Fatalf("Write() returned %v", err)
+	}
+	body, err := ioutil.ReadFile(baselineFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exceptions := append(cfgs.Exceptions(checker.DefaultConfigFileName, []byte(config)), baseline.Exceptions("baseline.json", body)...)
+	results.CountExceptions(exceptions)
+	type exception struct {
+		Kind    checker.ExceptionKind
+		Source  string
+		Line    int
+		Path    string
+		Matches int
+	}
+	got := []exception{}
+	for _, e := range exceptions {
+		got = append(got, exception{e.Kind, e.Source, e.Line, e.Path, e.Matches})
+	}
+	expect := []exception{
+		{checker.PathExclusion, checker.DefaultConfigFileName, 3, checker.DefaultConfigFileName, 1},
+		{checker.LanguageExemption, checker.DefaultConfigFileName, 4, checker.DefaultConfigFileName, 1},
+		{checker.AdvisoryConfig, checker.DefaultConfigFileName, 5, checker.DefaultConfigFileName, 1},
+		{checker.KnownViolation, "baseline.json", got[3].Line, "b.cpp", 1},
+		{checker.KnownViolation, "baseline.json", got[4].Line, "gone.cpp", 0},
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("Exceptions() returned:\n%+v\nexpected:\n%+v", got, expect)
+	}
+	if got[3].Line == 0 || got[4].Line <= got[3].Line {
+		t.Errorf("Exceptions() returned baseline lines %v and %v", got[3].Line, got[4].Line)
+	}
+	if rule := exceptions[0].Rule; rule != "configs[0].paths[0] excludes 'third_party/**'" {
+		t.Errorf("Exceptions() returned rule '%v'", rule)
+	}
+}
+
+func TestExceptionsPerConfig(t *testing.T) {
+	config := `[
+	{ "licenses": [ "Apache-2.0" ], "paths": [ { "include": [ "a/**" ] }, { "exclude": [ "**/gen/**" ] } ] },
+	{ "licenses": [ "MIT" ], "paths": [ { "exclude": [ "b/lib/**" ] }, { "exclude": [ "**/gen/**" ] } ] }
+]`
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		checker.DefaultConfigFileName: config,
+		"a/gen/x.cpp":                 "int x;\n",
+		"b/gen/y.cpp":                 "int y;\n",
+		"b/lib/gen/z.cpp":             "int z;\n",
+	})
+	cfgs, err := checker.ParseConfigs([]byte(config))
+	if err != nil {
+		t.Fatalf("ParseConfigs() returned %v", err)
+	}
+	results, err := checker.Scan(dir, checker.Options{Quiet: true, ListSkipped: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	exceptions := cfgs.Exceptions(checker.DefaultConfigFileName, []byte(config))
+	results.CountExceptions(exceptions)
+	got := map[string]int{}
+	for _, e := range exceptions {
+		got[e.Rule] = e.Matches
+	}
+	expect := map[string]int{
+		"configs[0].paths[1] excludes '**/gen/**'": 3,
+		"configs[1].paths[0] excludes 'b/lib/**'":  1,
+		"configs[1].paths[1] excludes '**/gen/**'": 2,
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("CountExceptions() counted %v, expected %v\nSkipped:\n%v", got, expect, results.Skipped().ListSkipped())
+	}
+}
+
+func TestExceptionKinds(t *testing.T) {
+	config := `{
+	"licenses": [ "Apache-2.0" ],
+	"quarantine": "quarantine",
+	"generated_sources": [ { "output": "gen/**/*.pb.h", "source": "proto/**/*.proto" } ],
+	"empty_files": "ignore",
+	"min_lines": 2,
+	"min_content_by_extension": { ".py": { "min_bytes": 64 } },
+	"internal": { "markers": [ "Confidential and proprietary" ], "paths": [ "secret/**" ] }
+}`
+	licensed := "// Licensed under the Apache License, Version 2.0 (the \"License\");\n"
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		checker.DefaultConfigFileName: config,
+		"quarantine/lib/a.cpp":        "int a;\nint b;\n",
+		"gen/a/a.pb.h":                "int a;\nint b;\n",
+		"proto/a/a.proto":             licensed,
+		"empty.cpp":                   "\n",
+		"short.cpp":                   "int a;\n",
+		"tool.py":                     "print('a')\nprint('b')\n",
+		"secret/s.cpp":                "// Confidential and proprietary\nint s;\n",
+	})
+	cfgs, err := checker.ParseConfigs([]byte(config))
+	if err != nil {
+		t.Fatalf("ParseConfigs() returned %v", err)
+	}
+	results, err := checker.Scan(dir, checker.Options{Quiet: true, ListSkipped: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	exceptions := cfgs.Exceptions(checker.DefaultConfigFileName, []byte(config))
+	results.CountExceptions(exceptions)
+	got := []string{}
+	for _, e := range exceptions {
+		got = append(got, fmt.Sprintf("%v:%d %v (%d)", e.Kind, e.Line, e.Rule, e.Matches))
+	}
+	expect := []string{
+		"quarantine:3 configs[0] quarantines 'quarantine', so its violations are warnings (1)",
+		"generated-source:4 configs[0].generated_sources[0] checks 'gen/**/*.pb.h' by 'proto/**/*.proto' (1)",
+		"empty-files:5 configs[0] accepts empty files without a license (1)",
+		"min-content:6 configs[0] requires no license of files with fewer than 2 non-blank lines (1)",
+		"min-content:7 configs[0].min_content_by_extension[.py] requires no license of files with fewer than 64 bytes (1)",
+		"internal:8 configs[0] accepts the internal notice instead of an open source license (1)",
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("Exceptions() returned:\n%v\nExpected:\n%v", strings.Join(got, "\n"), strings.Join(expect, "\n"))
+	}
+}
+
+func TestFileTypes(t *testing.T) {
+	results := checker.Results{
+		{Path: "a.go", Licenses: []string{"Apache-2.0"}},
+		{Path: "b.go", Licenses: []string{"MIT"}},
+		{Path: "c.GO", Licenses: []string{"MIT"}},
+		{Path: "d.sh", Err: errors.New("d.sh has no license"), Kind: checker.NoLicense},
+		{Path: "e.sh", Err: errors.New("e.sh has no license"), Kind: checker.NoLicense},
+		{Path: "Makefile", Licenses: []string{"Apache-2.0"}},
+		{Path: "third_party", Err: errors.New("no metadata"), Kind: checker.MissingMetadata},
+		{Path: "skipped.py", Skipped: "excluded"},
+	}
+	expect := []string{
+		"(no extension): 1 files, 0 violations, mostly Apache-2.0",
+		".go: 3 files, 0 violations, mostly MIT",
+		".sh: 2 files, 2 violations, no license",
+	}
+	got := []string{}
+	for _, s := range results.FileTypes() {
+		got = append(got, s.String())
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("FileTypes() returned:\n%v\nExpected:\n%v", strings.Join(got, "\n"), strings.Join(expect, "\n"))
+	}
+}
+
+func TestMeasureRisk(t *testing.T) {
+	dir := filepath.Join(testcases, "quarantine")
+	results, err := checker.Scan(dir, checker.Options{Quiet: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	risk, err := checker.MeasureRisk(dir, results, checker.Options{})
+	if err != nil {
+		t.Fatalf("MeasureRisk() returned %v", err)
+	}
+	expect := "high (score 40.0/100: 1 unknown, 3 unreviewed of 5 files)"
+	if got := risk.String(); got != expect {
+		t.Errorf("MeasureRisk() returned '%v', expected '%v'", got, expect)
+	}
+
+	for _, cfg := range []string{
+		`{ "risk": { "weights": { "copyleft": 1 } } }`,
+		`{ "risk": { "weights": { "unreviewed": 2 } } }`,
+		`{ "risk": { "medium": 50, "high": 40 } }`,
+	} {
+		if _, err := checker.ParseConfigs([]byte(cfg)); err == nil {
+			t.Errorf("ParseConfigs(%v) returned no error", cfg)
+		}
+	}
+}
+
+func TestGeneratedSources(t *testing.T) {
+	dir := filepath.Join(testcases, "generated")
+	results, err := checker.Scan(dir, checker.Options{Quiet: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	expect := "* gen/api/bad.pb.h is generated from proto/api/bad.proto, which is not compliant: proto/api/bad.proto has no license [d00ec29fc8e3d019]\n" +
+		"* gen/api/orphan.pb.h is generated from proto/api/orphan.proto, which does not exist [e6754375a25e9936]\n" +
+		"* proto/api/bad.proto has no license [5e24fc331d7dbb8c]\n"
+	if got := results.List(checker.Options{}); got != expect {
+		t.Errorf("Scan() returned:\n%v\nExpected:\n%v", got, expect)
+	}
+	expect = "* gen/api/bad.pb.h: generated from proto/api/bad.proto\n" +
+		"* gen/api/good.pb.h: generated from proto/api/good.proto\n" +
+		"* gen/api/orphan.pb.h: generated from proto/api/orphan.proto\n"
+	if got := results.ListGenerated(); got != expect {
+		t.Errorf("ListGenerated() returned:\n%v\nExpected:\n%v", got, expect)
+	}
+}
+
+func TestFix(t *testing.T) {
+	root := t.TempDir()
+	for _, test := range []string{"bad-stale-header", "bad-suspicious-characters"} {
+		src := filepath.Join(testcases, test)
+		err := filepath.Walk(src, func(file string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			rel, _ := filepath.Rel(src, file)
+			body, err := ioutil.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Join(root, test, filepath.Dir(rel)), 0777); err != nil {
+				return err
+			}
+			return ioutil.WriteFile(filepath.Join(root, test, rel), body, 0666)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, test := range []struct {
+		dir    string
+		fixed  int
+		file   string
+		expect []string
+	}{
+		{"bad-stale-header", 1, "src/old.cpp", []string{
+			"// Copyright 2018 Globex LLC\n// See https://globex.example.com/license\n",
+			`const char* vendor = "Acme Inc.";`,
+		}},
+		{"bad-suspicious-characters", 3, "src/homoglyph.cpp", []string{
+			"// Licensed under the Apache License, Version 2.0",
+		}},
+		{"bad-suspicious-characters", 0, "src/names.cpp", []string{
+			"\ufeff// Copyright 2020 Globex LLC, \u0418\u0432\u0430\u043d",
+		}},
+	} {
+		dir := filepath.Join(root, test.dir)
+		results, err := checker.Scan(dir, checker.Options{Quiet: true})
+		if err != nil {
+			t.Fatalf("Scan(%v) returned %v", test.dir, err)
+		}
+		if n, err := results.Fix(dir); err != nil || n != test.fixed {
+			t.Errorf("Fix(%v) returned (%v, %v), expected (%v, nil)", test.dir, n, err, test.fixed)
+		}
+		body, err := ioutil.ReadFile(filepath.Join(dir, test.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, expect := range test.expect {
+			if !strings.Contains(string(body), expect) {
+				t.Errorf("Fixed file %v does not contain '%v':\n%v", test.file, expect, string(body))
+			}
+		}
+		if err := checker.Check(dir); err != nil {
+			t.Errorf("Check(%v) after Fix() returned %v", test.dir, err)
+		}
+	}
+}
+
+func TestFixSymlink(t *testing.T) {
+	src := filepath.Join(testcases, "bad-stale-header")
+	root, shared := t.TempDir(), t.TempDir()
+	for _, file := range []string{"license-checker.cfg", "src/old.cpp"} {
+		body, err := ioutil.ReadFile(filepath.Join(src, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(shared, filepath.Base(file)), body, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Rename(filepath.Join(shared, "license-checker.cfg"), filepath.Join(root, "license-checker.cfg")); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(root, "old.cpp")
+	if err := os.Symlink(filepath.Join(shared, "old.cpp"), link); err != nil {
+		t.Skipf("Symbolic links are not supported: %v", err)
+	}
+	results, err := checker.Scan(root, checker.Options{Quiet: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	fixes, err := results.Fixes(root)
+	if err != nil || len(fixes) != 1 {
+		t.Fatalf("Fixes() returned (%v, %v), expected one fix", fixes, err)
+	}
+	if target, err := fixes[0].Target(root); err == nil || !strings.Contains(err.Error(), "outside of the project") {
+		t.Errorf("Target() of a link outside of the project returned (%v, %v)", target, err)
+	}
+	if n, err := results.Fix(root); err != nil || n != 1 {
+		t.Fatalf("Fix() returned (%v, %v), expected (1, nil)", n, err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("Fix() replaced the symbolic link: %v, %v", info, err)
+	}
+	body, err := ioutil.ReadFile(filepath.Join(shared, "old.cpp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), "Globex LLC") {
+		t.Errorf("Fix() did not fix the linked file:\n%v", string(body))
+	}
+
+	// A link to a file of the project targets the linked file.
+	writeFiles(t, root, map[string]string{"src/new.cpp": string(body)})
+	if err := os.Remove(link); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join("src", "new.cpp"), link); err != nil {
+		t.Fatal(err)
+	}
+	fix := checker.FileFix{Path: "old.cpp"}
+	if target, err := fix.Target(root); err != nil || target != "src/new.cpp" {
+		t.Errorf("Target() of a link within the project returned (%v, %v), expected (src/new.cpp, nil)", target, err)
+	}
+}
+
+func TestFixDiff(t *testing.T) {
+	dir := filepath.Join(testcases, "bad-stale-header")
+	results, err := checker.Scan(dir, checker.Options{Quiet: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	fixes, err := results.Fixes(dir)
+	if err != nil {
+		t.Fatalf("Fixes() returned %v", err)
+	}
+	if len(fixes) != 1 {
+		t.Fatalf("Fixes() returned %v fixes, expected 1", len(fixes))
+	}
+	expect := `--- a/src/old.cpp
++++ b/src/old.cpp
+@@ -1,5 +1,5 @@
+-// Copyright 2018 Acme Inc.
+-// See http://acme.example.com/license
++// Copyright 2018 Globex LLC
++// See https://globex.example.com/license
+ //
+ // Licensed under the Apache License, Version 2.0 (the "License");
+ // you may not use this file except in compliance with the License.
+`
+	if got := fixes[0].Diff(); got != expect {
+		t.Errorf("Diff() returned:\n%v\nexpected:\n%v", got, expect)
+	}
+}
+
+func TestFixIdempotent(t *testing.T) {
+	const header = "// Copyright 2018 Acme Inc.\n" +
+		"// Licensed\u200b under the Apache License, Version 2.0 (the \"License\");  \n"
+	for _, test := range []struct {
+		name   string
+		stale  string
+		expect string
+		err    string
+	}{
+		{
+			name:   "chain",
+			stale:  `{ "old": "Globex", "new": "Initech" }, { "old": "Acme Inc.", "new": "Globex" }`,
+			expect: "// Copyright 2018 Initech\n// Licensed under the Apache License, Version 2.0 (the \"License\");\n",
+		},
+		{
+			name:  "growing",
+			stale: `{ "old": "Acme Inc.", "new": "Globex Corp" }, { "old": "Corp", "new": "Acme Inc." }`,
+			err:   "Fix of 'a.cpp' is not idempotent",
+		},
+	} {
+		dir := t.TempDir()
+		cfg := `{ "licenses": [ "Apache-2.0" ], "stale_headers": [ ` + test.stale + ` ],
+			"header_style": { "trailing_whitespace": true } }`
+		if err := ioutil.WriteFile(filepath.Join(dir, checker.DefaultConfigFileName), []byte(cfg), 0666); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "a.cpp"), []byte(header), 0666); err != nil {
+			t.Fatal(err)
+		}
+		results, err := checker.Scan(dir, checker.Options{Quiet: true})
+		if err != nil {
+			t.Fatalf("%v: Scan() returned %v", test.name, err)
+		}
+		fixes, err := results.Fixes(dir)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%v: Fixes() returned %v, expected '%v'", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil || len(fixes) != 1 {
+			t.Fatalf("%v: Fixes() returned (%v, %v), expected 1 fix", test.name, len(fixes), err)
+		}
+		if got := string(fixes[0].After); got != test.expect {
+			t.Errorf("%v: Fixes() returned:\n%v\nexpected:\n%v", test.name, got, test.expect)
+		}
+		if err := fixes[0].Write(dir); err != nil {
+			t.Fatal(err)
+		}
+
+		// A second fix changes nothing.
+		results, err = checker.Scan(dir, checker.Options{Quiet: true})
+		if err != nil {
+			t.Fatalf("%v: Scan() returned %v", test.name, err)
+		}
+		if fixes, err := results.Fixes(dir); err != nil || len(fixes) != 0 {
+			t.Errorf("%v: second Fixes() returned (%v, %v), expected no fixes", test.name, len(fixes), err)
+		}
+	}
+}
+
+func TestInsertHeader(t *testing.T) {
+	const license = `Licensed under the Apache License, Version 2.0 (the "License");`
+	dir := t.TempDir()
+	files := map[string]string{
+		checker.DefaultConfigFileName: `{ "licenses": [ "Apache-2.0" ],
+			"new_file_header": "Copyright {year} Globex LLC\n` + strings.ReplaceAll(license, `"`, `\"`) + `",
+			"header_placement": { "ruby": [ "shebang" ] } }`,
+		"main.go":   "//go:build linux\n\npackage main\n",
+		"run.sh":    "#!/bin/sh\necho hi\n",
+		"gen.py":    "#!/usr/bin/env python3\n\"\"\"Generates code.\"\"\"\n\nimport os\n",
+		"index.php": "<?php\necho 'hi';\n",
+		"task.rb":   "# frozen_string_literal: true\nputs 'hi'\n",
+		"data.json": "{}\n",
+	}
+	writeFiles(t, dir, files)
+	results, err := checker.Scan(dir, checker.Options{Quiet: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	fixes, err := results.Fixes(dir)
+	if err != nil {
+		t.Fatalf("Fixes() returned %v", err)
+	}
+	header := func(marker string) string {
+		return fmt.Sprintf("%v Copyright %d Globex LLC\n%v %v\n", marker, time.Now().Year(), marker, license)
+	}
+	got := map[string]string{}
+	for _, f := range fixes {
+		got[f.Path] = string(f.After)
+	}
+	expect := map[string]string{
+		"main.go":   header("//") + "\n//go:build linux\n\npackage main\n",
+		"run.sh":    "#!/bin/sh\n\n" + header("#") + "\necho hi\n",
+		"gen.py":    "#!/usr/bin/env python3\n\"\"\"Generates code.\"\"\"\n\n" + header("#") + "\nimport os\n",
+		"index.php": "<?php\n\n" + header("//") + "\necho 'hi';\n",
+		"task.rb":   header("#") + "\n# frozen_string_literal: true\nputs 'hi'\n",
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("Fixes() returned:\n%v\nExpected:\n%v", got, expect)
+	}
+	if _, err := results.Fix(dir); err != nil {
+		t.Fatalf("Fix() returned %v", err)
+	}
+	results, err = checker.Scan(dir, checker.Options{Quiet: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	if errs := results.Errs(); len(errs) != 1 || !strings.Contains(errs[0].Error(), "data.json") {
+		t.Errorf("Scan() after Fix() returned %v, expected only data.json to have no license", errs)
+	}
+}
+
+func TestFixGoFiles(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not found")
+	}
+	const license = `// Licensed under the Apache License, Version 2.0 (the "License");`
+	dir := t.TempDir()
+	files := map[string]string{
+		checker.DefaultConfigFileName: `{ "licenses": [ "Apache-2.0" ],
+			"paths": [ { "exclude": [ "go.mod", "data.txt" ] } ],
+			"new_file_header": "` + strings.ReplaceAll(strings.TrimPrefix(license, "// "), `"`, `\"`) + `",
+			"header_style": { "comment_markers": true, "trailing_whitespace": true } }`,
+		"go.mod":     "module fixture\n\ngo 1.17\n",
+		"data.txt":   "data\n",
+		"doc.go":     "// Package fixture is a fixture.\npackage fixture\n",
+		"linux.go":   "//go:build linux\n// +build linux\n\npackage fixture\n\nconst OS = \"linux\"\n",
+		"other.go":   "//go:build !linux\n\npackage fixture\n\nconst OS = \"other\"\n",
+		"ignored.go": "//go:build ignore\n\npackage fixture\n\nthis does not compile\n",
+		"embed.go":   "package fixture\n\nimport _ \"embed\"\n\n//go:embed data.txt\nvar Data string\n",
+		// Already licensed, with a header style violation before the constraint.
+		"styled.go": "//" + strings.TrimPrefix(license, "// ") + "  \n//go:build linux\n\npackage fixture\n\nconst Styled = true\n",
+	}
+	writeFiles(t, dir, files)
+	results, err := checker.Scan(dir, checker.Options{Quiet: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	if n, err := results.Fix(dir); err != nil || n != 6 {
+		t.Fatalf("Fix() returned (%v, %v), expected (6, nil)", n, err)
+	}
+	body, err := ioutil.ReadFile(filepath.Join(dir, "styled.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := license + "\n//go:build linux\n"; !strings.HasPrefix(string(body), expect) {
+		t.Errorf("Fix() of styled.go returned:\n%v\nexpected it to start with:\n%v", string(body), expect)
+	}
+	if err := checker.Check(dir); err != nil {
+		t.Errorf("Check() after Fix() returned %v", err)
+	}
+
+	// The fixed package still builds for each OS, with the same files, and
+	// the headers do not become the package documentation.
+	for _, goos := range []string{"linux", "windows"} {
+		cmd := exec.Command("go", "list", "-f", "{{.GoFiles}} {{.EmbedFiles}} {{.Doc}}", ".")
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOOS="+goos, "GOFLAGS=", "GO111MODULE=on")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("go list (GOOS=%v) returned %v:\n%v", goos, err, string(out))
+		}
+		expect := "[doc.go embed.go other.go] [data.txt] Package fixture is a fixture.\n"
+		if goos == "linux" {
+			expect = "[doc.go embed.go linux.go styled.go] [data.txt] Package fixture is a fixture.\n"
+		}
+		if string(out) != expect {
+			t.Errorf("go list (GOOS=%v) returned '%v', expected '%v'", goos, string(out), expect)
+		}
+		cmd = exec.Command("go", "build", ".")
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOOS="+goos, "GOFLAGS=", "GO111MODULE=on")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("go build (GOOS=%v) returned %v:\n%v", goos, err, string(out))
+		}
+	}
+}
+
+func TestHeaderStyle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "license-checker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	const license = "//\n" +
+		"// Licensed under the Apache License, Version 2.0 (the \"License\");\n" +
+		"// you may not use this file except in compliance with the License.\n"
+	files := map[string]string{
+		checker.DefaultConfigFileName: `{ "licenses": [ "Apache-2.0" ], "header_style": {
+			"max_line_length": 80, "trailing_whitespace": true, "comment_markers": true } }`,
+		"good.cpp":     "// Copyright 2020 Acme Inc.\n" + license + "\nint a;  \n",
+		"trailing.cpp": "// Copyright 2020 Acme Inc. \t\n" + license,
+		"markers.cpp":  "// Copyright 2020 Acme Inc.\n" + strings.Replace(license, "// you", "/// you", 1),
+		"space.cpp":    "//Copyright 2020 Acme Inc.\n" + license,
+		"long.cpp":     "// Copyright 2020 Acme Inc., Globex LLC, Initech Corp., Umbrella Corp. and Hooli XYZ\n" + license,
+	}
+	writeFiles(t, dir, files)
+	results, err := checker.Scan(dir, checker.Options{Quiet: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	if n := len(results.Failures(checker.Options{}).Errs()); n != 0 {
+		t.Errorf("Scan() returned %v header style errors, expected them to be warnings", n)
+	}
+	expect := "* long.cpp header line 1 is 84 characters long, longer than 80 [db035fc3b1d4298c]\n" +
+		"* markers.cpp header line 4 uses comment marker '///', expected '//' [d6e2e21edec7e144]\n" +
+		"* space.cpp header line 1 has no space after the comment marker '//' [ffb4655f1e729e83]\n" +
+		"* trailing.cpp header has trailing whitespace on line 1 [e5f3f7c8c3361f31]\n"
+	if got := results.Warnings(checker.Options{}).List(checker.Options{}); got != expect {
+		t.Errorf("Scan() warnings:\n%v\nExpected:\n%v", got, expect)
+	}
+
+	fixes, err := results.Fixes(dir)
+	if err != nil {
+		t.Fatalf("Fixes() returned %v", err)
+	}
+	got := map[string]string{}
+	for _, f := range fixes {
+		got[f.Path] = string(f.After)
+	}
+	expectFixed := map[string]string{
+		"markers.cpp":  "// Copyright 2020 Acme Inc.\n" + license,
+		"space.cpp":    "// Copyright 2020 Acme Inc.\n" + license,
+		"trailing.cpp": "// Copyright 2020 Acme Inc.\n" + license,
+	}
+	if !reflect.DeepEqual(got, expectFixed) {
+		t.Errorf("Fixes() returned:\n%v\nExpected:\n%v", got, expectFixed)
+	}
+}
+
+func TestEscapePath(t *testing.T) {
+	for _, test := range []struct {
+		path, expect string
+	}{
+		{"src/a.cpp", "src/a.cpp"},
+		{"assets/caf\u00e9 \u6587.png", "assets/caf\u00e9 \u6587.png"},
+		{"assets/caf\xe9.png", `"assets/caf\xe9.png"`},
+		{"assets/new\nline.png", `"assets/new\nline.png"`},
+		{`assets/"quoted".png`, `"assets/\"quoted\".png"`},
+	} {
+		if got := checker.EscapePath(test.path); got != test.expect {
+			t.Errorf("EscapePath(%q) returned %v, expected %v", test.path, got, test.expect)
+		}
+	}
+}
+
+func TestUnusualFileNames(t *testing.T) {
+	root := t.TempDir()
+	cfg := `{ "licenses": [ "Apache-2.0" ], "paths": [ { "exclude": [ "assets/*.png" ] } ] }`
+	if err := ioutil.WriteFile(filepath.Join(root, "license-checker.cfg"), []byte(cfg), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "assets"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"caf\xe9.png", "new\nline.png", "caf\xe9.cpp"} {
+		if err := ioutil.WriteFile(filepath.Join(root, "assets", name), []byte("int a;\n"), 0666); err != nil {
+			t.Skipf("File system does not support the file name %q: %v", name, err)
+		}
+	}
+	results, err := checker.Scan(root, checker.Options{Quiet: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	errs := results.Errs()
+	if len(errs) != 1 || errs[0].Error() != `"assets/caf\xe9.cpp" has no license` {
+		t.Errorf("Scan() returned errors %v", errs)
+	}
+}
+
+func TestMeasureCoverage(t *testing.T) {
+	dir := filepath.Join(testcases, "good-filter")
+	results, err := checker.Scan(dir, checker.Options{Quiet: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	coverage, err := checker.MeasureCoverage(dir, results, checker.Options{})
+	if err != nil {
+		t.Fatalf("MeasureCoverage() returned %v", err)
+	}
+	if expect := (checker.Coverage{Examined: 2, Total: 4}); coverage != expect {
+		t.Errorf("MeasureCoverage() returned %+v, expected %+v", coverage, expect)
+	}
+	if got, expect := coverage.String(), "50.0% of files (2/4) checked"; got != expect {
+		t.Errorf("Coverage.String() returned '%v', expected '%v'", got, expect)
+	}
+}
+
+func TestVerifyUpstream(t *testing.T) {
+	upstream := "Upstream license text\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1.0/LICENSE" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, upstream)
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "license-checker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		checker.DefaultConfigFileName: `{ "paths": [{ "exclude": [ "**" ] }], "vendored": { "dirs": [ "third_party/*" ] } }`,
+		"third_party/good/METADATA":   "URL: https://example.com\nVersion: v1.0\nLicense: LicenseRef-Upstream\nLicense URL: " + server.URL + "/{version}/LICENSE\n",
+		"third_party/good/LICENSE":    upstream,
+		"third_party/edited/METADATA": "URL: https://example.com\nVersion: v1.0\nLicense: LicenseRef-Upstream\nLicense URL: " + server.URL + "/{version}/LICENSE\n",
+		"third_party/edited/LICENSE":  "Locally edited license text\n",
+		"third_party/gone/METADATA":   "URL: https://example.com\nVersion: v2.0\nLicense: LicenseRef-Upstream\nLicense URL: " + server.URL + "/{version}/LICENSE\n",
+		"third_party/gone/LICENSE":    upstream,
+	}
+	writeFiles(t, dir, files)
+
+	results, err := checker.Scan(dir, checker.Options{Quiet: true, VerifyUpstream: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	got := map[string]checker.ViolationKind{}
+	for _, res := range results {
+		if res.Kind == checker.ModifiedLicense || res.Kind == checker.UpstreamError {
+			got[res.Path] = res.Kind
+		}
+	}
+	expect := map[string]checker.ViolationKind{
+		"third_party/edited/LICENSE": checker.ModifiedLicense,
+		"third_party/gone/LICENSE":   checker.UpstreamError,
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("Upstream violations were %v, expected %v", got, expect)
+	}
+}
+
+func TestCheckDependencies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "license-checker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	mit, err := ioutil.ReadFile(filepath.Join(testcases, "bad-vendored", "third_party", "good", "LICENSE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		checker.DefaultConfigFileName:    `{ "licenses": [ "Apache-2.0" ], "dependencies": { "licenses": [ "Apache-2.0" ], "allow": [ "example.com/internal/**" ] } }`,
+		"cache/example.com/mit/LICENSE":  string(mit),
+		"cache/example.com/none/main.go": "package none\n",
+		"cache/example.com/internal/x/a": "internal\n",
+	}
+	writeFiles(t, dir, files)
+	mods := []deps.Module{
+		{Path: "example.com/internal/x", Version: "v1.0.0", Dir: filepath.Join(dir, "cache", "example.com", "internal", "x")},
+		{Path: "example.com/missing", Version: "v1.0.0", Dir: filepath.Join(dir, "cache", "example.com", "missing")},
+		{Path: "example.com/mit", Version: "v1.0.0", Dir: filepath.Join(dir, "cache", "example.com", "mit")},
+		{Path: "example.com/none", Version: "v0.1.0", Dir: filepath.Join(dir, "cache", "example.com", "none")},
+	}
+	results, err := checker.CheckDependencies(dir, mods, checker.Options{})
+	if err != nil {
+		t.Fatalf("CheckDependencies() returned %v", err)
+	}
+	expect := "3 errors:\n" +
+		"* example.com/missing@v1.0.0 is not in the module cache. Run 'go mod download' [40a32f94c2539964]\n" +
+		"* example.com/mit@v1.0.0 uses unsupported license 'MIT' [8c85cb3c7b86ca85]\n" +
+		"* example.com/none@v0.1.0 has no license [b56f513da760161d]\n"
+	if err := results.Check(checker.Options{}); err == nil || err.Error() != expect {
+		t.Errorf("Check() returned:\n%v\nExpected:\n%v", err, expect)
+	}
+}
+
+func TestGroupByDir(t *testing.T) {
+	opts := checker.Options{GroupByDepth: 1}
+	err := checker.CheckWithOptions(filepath.Join(testcases, "bad-missing-license"), opts)
+	if err == nil {
+		t.Fatalf("Expected checker failure")
+	}
+	if expect := "* src: 1/2 files (50.0%) have license issues"; !strings.Contains(err.Error(), expect) {
+		t.Errorf("Grouped error did not contain '%v': %v", expect, err)
+	}
+	if strings.Contains(err.Error(), "missing-license.cpp") {
+		t.Errorf("Grouped error should not list individual files: %v", err)
+	}
+
+	results := checker.Results{
+		{Path: "src/a.cpp", Licenses: []string{"Apache-2.0"}},
+		{Path: "src/b.cpp", Err: errors.New("src/b.cpp has no license"), Kind: checker.NoLicense, Fingerprint: "0000000000000001"},
+		{Path: "third_party/x", Err: errors.New("third_party/x has no METADATA"), Kind: checker.MissingMetadata, Fingerprint: "0000000000000002"},
+		{Path: checker.DefaultConfigFileName, Err: errors.New("license-checker.cfg: only 50% of files checked"), Kind: checker.LowCoverage, Fingerprint: "0000000000000003"},
+	}
+	expect := "3 errors:\n" +
+		"* src: 1/2 files (50.0%) have license issues\n" +
+		"* third_party/x has no METADATA [0000000000000002]\n" +
+		"* license-checker.cfg: only 50% of files checked [0000000000000003]\n"
+	if err := results.Check(opts); err == nil || err.Error() != expect {
+		t.Errorf("Check() returned:\n%v\nExpected:\n%v", err, expect)
+	}
+}
+
+func TestConcurrentOptions(t *testing.T) {
+	// Each project has its own config file name, and a file without a license.
+	dirs := map[string]string{} // config file name to project directory
+	for _, name := range []string{"a.cfg", "b.json"} {
+		dir := t.TempDir()
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(`{"licenses": ["Apache-2.0"]}`), 0666); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, strings.TrimSuffix(name, path.Ext(name))+".cpp"), []byte("int a;\n"), 0666); err != nil {
+			t.Fatal(err)
+		}
+		dirs[name] = dir
+	}
+
+	errs := make(chan error, 16)
+	for i := 0; i < cap(errs); i++ {
+		name := "a.cfg"
+		if i%2 == 1 {
+			name = "b.json"
+		}
+		go func() {
+			out := &strings.Builder{}
+			opts := checker.Options{ConfigFileName: name, Output: out, WarnOnly: true, Quiet: true}
+			results, err := checker.Scan(dirs[name], opts)
+			if err == nil {
+				err = results.Check(opts)
+			}
+			expect := "1 warnings:\n* " + strings.TrimSuffix(name, path.Ext(name)) + ".cpp has no license"
+			if err == nil && !strings.HasPrefix(out.String(), expect) {
+				err = errors.New("Check() with config file " + name + " printed:\n" + out.String())
+			}
+			errs <- err
+		}()
+	}
+	for i := 0; i < cap(errs); i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "license-checker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, checker.DefaultConfigFileName), []byte(`{"licenses": ["Apache-2.0"]}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	// scan writes body to the file at path, and returns the fingerprint of the
+	// file's violation.
+	scan := func(path, body string) string {
+		os.RemoveAll(filepath.Join(dir, "src"))
+		os.MkdirAll(filepath.Join(dir, "src"), 0777)
+		if err := ioutil.WriteFile(filepath.Join(dir, path), []byte(body), 0666); err != nil {
+			t.Fatal(err)
+		}
+		results, err := checker.Scan(dir, checker.Options{Quiet: true})
+		if err != nil {
+			t.Fatalf("Scan() returned %v", err)
+		}
+		if len(results) != 1 || results[0].Kind != checker.NoLicense || results[0].Fingerprint == "" {
+			t.Fatalf("Unexpected results: %+v", results)
+		}
+		return results[0].Fingerprint
+	}
+
+	original := scan("src/a.cpp", "// Copyright Bob\n\nint a() { return 1; }\n")
+	if got := scan("src/a.cpp", "// Copyright Bob\r\n\r\nint b;\nint a() { return 2; }\n"); got != original {
+		t.Errorf("Fingerprint changed with file body: %v != %v", got, original)
+	}
+	if got := scan("src/a.cpp", "// Copyright Alice\n\nint a() { return 1; }\n"); got == original {
+		t.Errorf("Fingerprint did not change with file header")
+	}
+	if got := scan("src/b.cpp", "// Copyright Bob\n\nint a() { return 1; }\n"); got == original {
+		t.Errorf("Fingerprint did not change with file path")
+	}
+}
+
+func BenchmarkScan(b *testing.B) {
+	dir, err := ioutil.TempDir("", "license-checker")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if _, err := gentree.Generate(dir, gentree.DefaultOptions()); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := checker.Scan(dir, checker.Options{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// writeFiles writes the files, keyed by their '/' separated path relative to
+// dir, creating their parent directories.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, body := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(body), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
 // sourceDirectory returns the path to the directory that holds this .go file
 func sourceDirectory() string {
 	_, filename, _, ok := runtime.Caller(1)
@@ -0,0 +1,116 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"regexp"
+	"strings"
+	"sync"
+
+	"../detector"
+)
+
+// digitsRE matches runs of decimal digits.
+var digitsRE = regexp.MustCompile(`[0-9]+`)
+
+// licenseHints are the lower-case words that license text, license notices
+// and public-domain dedications cannot avoid. Content without any of them
+// holds no license the full classifier could find.
+var licenseHints = [][]byte{
+	[]byte("licens"), []byte("licenc"), []byte("copyright"), []byte("permission"),
+	[]byte("redistribut"), []byte("spdx"), []byte("domain"), []byte("cc0"), []byte("dedicat"),
+}
+
+// classifier identifies the licenses in file content.
+// Most projects carry an identical license header in nearly every file, so
+// classifier classifies the file's leading comment block separately from the
+// rest of the file, caching the result by the block's content. Once a header
+// is cached, the rest of a file carrying it is only classified if it holds a
+// word of license text, so the common file costs a hash and a cheap search
+// rather than a full classification.
+type classifier struct {
+	scan    func(body []byte) []string // the full classifier
+	mutex   sync.Mutex
+	headers map[[sha256.Size]byte][]string
+}
+
+// newClassifier returns a new classifier that uses the detector d.
+func newClassifier(d detector.Detector) *classifier {
+	return &classifier{
+		scan:    d.Detect,
+		headers: map[[sha256.Size]byte][]string{},
+	}
+}
+
+// licenses returns the identifiers of the licenses found in the file at the
+// project relative path.
+func (c *classifier) licenses(path string, body []byte) []string {
+	header, end := SplitLeadingComment(path, body)
+	if header == "" {
+		return c.scan(body)
+	}
+
+	key := sha256.Sum256([]byte(headerKey(header)))
+	c.mutex.Lock()
+	ids, ok := c.headers[key]
+	c.mutex.Unlock()
+	if !ok {
+		ids = c.scan([]byte(header))
+		c.mutex.Lock()
+		c.headers[key] = ids
+		c.mutex.Unlock()
+	}
+	out := append([]string{}, ids...)
+	rest := body[end:]
+	if ok && !mayHoldLicense(rest) {
+		return out
+	}
+	if len(bytes.TrimSpace(rest)) > 0 {
+		for _, id := range c.scan(rest) {
+			if !containsString(out, id) {
+				out = append(out, id)
+			}
+		}
+	}
+	return out
+}
+
+// mayHoldLicense returns true if the content holds any of the licenseHints.
+func mayHoldLicense(content []byte) bool {
+	lower := bytes.ToLower(content)
+	for _, hint := range licenseHints {
+		if bytes.Contains(lower, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// headerKey returns the cache key of the header. Whitespace is ignored, as
+// are the digits of copyright lines, so that headers differing only by the
+// copyright year or formatting share a cache entry. Other digits, such as
+// license version numbers, are significant.
+func headerKey(header string) string {
+	lines := strings.Split(header, "\n")
+	for i, line := range lines {
+		if strings.Contains(strings.ToLower(line), "copyright") {
+			line = digitsRE.ReplaceAllString(line, "0")
+		}
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,163 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"context"
+	"crypto/sha256"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"../detector"
+)
+
+// detectorFunc is a detector.Detector implemented by a function.
+type detectorFunc func(body []byte) []string
+
+func (f detectorFunc) Detect(body []byte) []string { return f(body) }
+
+func TestClassifierCachesHeaders(t *testing.T) {
+	scans := []string{}
+	cls := newClassifier(detectorFunc(func(body []byte) []string {
+		scans = append(scans, string(body))
+		ids := []string{}
+		for text, id := range map[string]string{"Licensed under MIT": "MIT", "version 2": "GPL-2.0", "version 3": "GPL-3.0"} {
+			if strings.Contains(string(body), text) {
+				ids = append(ids, id)
+			}
+		}
+		return ids
+	}))
+
+	for _, test := range []struct {
+		path   string
+		body   string
+		expect []string
+		scans  int // the expected number of scans so far
+	}{
+		{"a.cpp", "// Copyright 2020 Bob\n// Licensed under MIT\n\nint a;\n", []string{"MIT"}, 2},
+		// The body of a file with a cached header is not classified unless
+		// it holds license text.
+		{"b.cpp", "// Copyright 2020 Bob\n// Licensed under MIT\n\nint b;\n", []string{"MIT"}, 2},
+		{"c.cpp", "// Copyright 2023 Bob\r\n//   Licensed under MIT\r\n\r\nint c;\r\n", []string{"MIT"}, 2},
+		{"d.sh", "#!/bin/sh\n# Copyright 2020 Bob\n# Licensed under MIT\n\necho d\n", []string{"MIT"}, 4},
+		{"e.cpp", "// Copyright 2020 Bob\n\nint e; // Licensed under MIT\n", []string{"MIT"}, 6},
+		{"f.cpp", "int f;\n", []string{}, 7},
+		// License versions are not folded like copyright years.
+		{"g.cpp", "// Copyright 2020 Bob\n// GPL version 2\n\nint g;\n", []string{"GPL-2.0"}, 9},
+		{"h.cpp", "// Copyright 2021 Bob\n// GPL version 3\n\nint h;\n", []string{"GPL-3.0"}, 11},
+		// A second license in the body is found with a cached header.
+		{"i.cpp", "// Copyright 2021 Bob\n// Licensed under MIT\n\nint i; /* Licensed under GPL version 3 */\n", []string{"MIT", "GPL-3.0"}, 12},
+		{"j.cpp", "// Copyright 2021 Bob\n// Licensed under MIT\n\nint j;\n/* Copyright 2019 Alice, GPL version 2 */\n", []string{"MIT", "GPL-2.0"}, 13},
+	} {
+		got := cls.licenses(test.path, []byte(test.body))
+		if !reflect.DeepEqual(got, test.expect) {
+			t.Errorf("licenses(%v) returned %v, expected %v", test.path, got, test.expect)
+		}
+		if len(scans) != test.scans {
+			t.Errorf("After licenses(%v), %d scans were performed, expected %d: %q", test.path, len(scans), test.scans, scans)
+		}
+	}
+}
+
+// BenchmarkClassifier compares classifying files that carry the same license
+// header with the classifier against classifying each file in full.
+func BenchmarkClassifier(b *testing.B) {
+	d, err := detector.New("", nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	header := "// Copyright 2020 Google LLC\n//\n" +
+		"// Licensed under the Apache License, Version 2.0 (the \"License\");\n" +
+		"// you may not use this file except in compliance with the License.\n" +
+		"// You may obtain a copy of the License at\n//\n" +
+		"//     http://www.apache.org/licenses/LICENSE-2.0\n//\n" +
+		"// Unless required by applicable law or agreed to in writing, software\n" +
+		"// distributed under the License is distributed on an \"AS IS\" BASIS,\n" +
+		"// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.\n" +
+		"// See the License for the specific language governing permissions and\n" +
+		"// limitations under the License.\n\n"
+	body := []byte(header + "package foo\n\n" + strings.Repeat("func f() int { return 42 }\n", 100))
+
+	b.Run("classifier", func(b *testing.B) {
+		cls := newClassifier(d)
+		for i := 0; i < b.N; i++ {
+			cls.licenses("foo.go", body)
+		}
+	})
+	b.Run("full", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			d.Detect(body)
+		}
+	})
+}
+
+func TestExamineContentPanic(t *testing.T) {
+	cls := &classifier{
+		scan:    func([]byte) []string { panic("pathological file") },
+		headers: map[[sha256.Size]byte][]string{},
+	}
+	res := examineContent("src/a.cpp", "src/a.cpp", []byte("// Header\nint a;\n"), Config{}, cls)
+	if res.Kind != ScanFailure || res.Err == nil || res.Err.Error() != "Failed to examine 'src/a.cpp': panic: pathological file" {
+		t.Errorf("examineContent() returned %+v", res)
+	}
+	if res.Fingerprint == "" {
+		t.Errorf("examineContent() returned a ScanFailure without a fingerprint")
+	}
+}
+
+func TestExamineWithin(t *testing.T) {
+	root := t.TempDir()
+	for _, file := range []string{"fast.cpp", "slow.cpp"} {
+		if err := ioutil.WriteFile(filepath.Join(root, file), []byte("// "+file+"\nint a;\n"), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	release := make(chan struct{})
+	defer close(release)
+	cls := &classifier{
+		scan: func(body []byte) []string {
+			if strings.Contains(string(body), "slow.cpp") {
+				<-release
+			}
+			return []string{"MIT"}
+		},
+		headers: map[[sha256.Size]byte][]string{},
+	}
+	cfg := Config{Licenses: []string{"MIT"}}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, test := range []struct {
+		file    string
+		opts    Options
+		kind    ViolationKind
+		skipped string
+	}{
+		{"fast.cpp", Options{}, "", ""},
+		{"fast.cpp", Options{FileTimeout: time.Minute}, "", ""},
+		{"slow.cpp", Options{FileTimeout: 10 * time.Millisecond}, FileTimeout, ""},
+		{"slow.cpp", Options{FileTimeout: time.Minute, Context: cancelled}, "", deadlineReason},
+	} {
+		res := examineWithin(root, test.file, cfg, cls, test.opts)
+		if res.Kind != test.kind || res.Skipped != test.skipped {
+			t.Errorf("examineWithin(%v) with FileTimeout %v returned %+v", test.file, test.opts.FileTimeout, res)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// bom is the UTF-8 byte order mark.
+const bom = "\ufeff"
+
+// invisibles are the characters that are not rendered, and so can hide in an
+// otherwise correct looking header.
+var invisibles = map[rune]bool{
+	'\u00ad': true, // soft hyphen
+	'\u180e': true, // mongolian vowel separator
+	'\u200b': true, // zero width space
+	'\u200c': true, // zero width non-joiner
+	'\u200d': true, // zero width joiner
+	'\u2060': true, // word joiner
+	'\u2061': true, // function application
+	'\u2062': true, // invisible times
+	'\u2063': true, // invisible separator
+	'\u2064': true, // invisible plus
+	'\ufeff': true, // zero width no-break space
+}
+
+// lookalikes maps the spaces that are commonly introduced by copying text
+// from rich-text sources, and that defeat text matching, to an ASCII space.
+var lookalikes = map[rune]rune{
+	'\u00a0': ' ', // no-break space
+	'\u2000': ' ', '\u2001': ' ', '\u2002': ' ', '\u2003': ' ', '\u2004': ' ',
+	'\u2005': ' ', '\u2006': ' ', '\u2007': ' ', '\u2008': ' ', '\u2009': ' ',
+	'\u200a': ' ', '\u202f': ' ', '\u205f': ' ', '\u3000': ' ',
+}
+
+// homoglyphs maps the Cyrillic and Greek letters that are indistinguishable
+// from Latin letters to their ASCII equivalents. As headers may legitimately
+// hold names written in these scripts, homoglyphs are only reported in words
+// that also hold ASCII letters.
+var homoglyphs = map[rune]rune{
+	// Cyrillic
+	'\u0430': 'a', '\u0435': 'e', '\u043e': 'o', '\u0440': 'p', '\u0441': 'c',
+	'\u0443': 'y', '\u0445': 'x', '\u0456': 'i', '\u0458': 'j', '\u0455': 's',
+	'\u0501': 'd', '\u04bb': 'h', '\u051b': 'q', '\u051d': 'w',
+	'\u0410': 'A', '\u0412': 'B', '\u0415': 'E', '\u041a': 'K', '\u041c': 'M',
+	'\u041d': 'H', '\u041e': 'O', '\u0420': 'P', '\u0421': 'C', '\u0422': 'T',
+	'\u0425': 'X', '\u0423': 'Y', '\u0406': 'I', '\u0408': 'J', '\u0405': 'S',
+	// Greek
+	'\u0391': 'A', '\u0392': 'B', '\u0395': 'E', '\u0396': 'Z', '\u0397': 'H',
+	'\u0399': 'I', '\u039a': 'K', '\u039c': 'M', '\u039d': 'N', '\u039f': 'O',
+	'\u03a1': 'P', '\u03a4': 'T', '\u03a5': 'Y', '\u03a7': 'X', '\u03bf': 'o',
+	'\u03bd': 'v',
+}
+
+// confusable is a character of a header that is not what it appears to be.
+type confusable struct {
+	offset  int    // the byte offset of the character
+	r       rune   // the character
+	replace string // the ASCII replacement, empty for invisible characters
+}
+
+// String returns a description of the character.
+func (c confusable) String() string {
+	if c.replace == "" {
+		return fmt.Sprintf("invisible character %U", c.r)
+	}
+	return fmt.Sprintf("look-alike character %U ('%c') instead of '%v'", c.r, c.r, c.replace)
+}
+
+// findConfusables returns the invisible and look-alike characters of text,
+// ordered by offset. A byte order mark at the start of text is ignored.
+func findConfusables(text []byte) []confusable {
+	out := []confusable{}
+	start := 0
+	if bytes.HasPrefix(text, []byte(bom)) {
+		start = len(bom)
+	}
+	pending, hasASCII := []confusable{}, false // the homoglyphs of the current word
+	endWord := func() {
+		if hasASCII {
+			out = append(out, pending...)
+		}
+		pending, hasASCII = pending[:0], false
+	}
+	for i := start; i < len(text); {
+		r, n := utf8.DecodeRune(text[i:])
+		switch {
+		case invisibles[r]:
+			out = append(out, confusable{offset: i, r: r})
+		case r >= '\uff01' && r <= '\uff5e': // fullwidth forms of ASCII
+			out = append(out, confusable{offset: i, r: r, replace: string(r - 0xFEE0)})
+		case lookalikes[r] != 0:
+			endWord()
+			out = append(out, confusable{offset: i, r: r, replace: string(lookalikes[r])})
+		case homoglyphs[r] != 0:
+			pending = append(pending, confusable{offset: i, r: r, replace: string(homoglyphs[r])})
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			hasASCII = hasASCII || r < utf8.RuneSelf
+		default:
+			endWord()
+		}
+		i += n
+	}
+	endWord()
+	sort.Slice(out, func(i, j int) bool { return out[i].offset < out[j].offset })
+	return out
+}
+
+// toASCII returns a copy of text with the characters reported by
+// findConfusables removed or replaced with their ASCII equivalents.
+func toASCII(text []byte) []byte {
+	out := make([]byte, 0, len(text))
+	last := 0
+	for _, c := range findConfusables(text) {
+		out = append(out, text[last:c.offset]...)
+		out = append(out, c.replace...)
+		last = c.offset + utf8.RuneLen(c.r)
+	}
+	return append(out, text[last:]...)
+}
+
+// checkConfusables returns an error describing the first invisible or
+// look-alike character in the leading comment of the file, or nil if the
+// header has none.
+func checkConfusables(path, display string, body []byte) error {
+	_, end := SplitLeadingComment(path, body)
+	found := findConfusables(body[:end])
+	if len(found) == 0 {
+		return nil
+	}
+	c := found[0]
+	line := bytes.Count(body[:c.offset], []byte("\n")) + 1
+	return fmt.Errorf("%v header has %v on line %d", display, c, line)
+}
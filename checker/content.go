@@ -0,0 +1,110 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"../detector"
+	"../sniff"
+)
+
+// Policy is a parsed set of configs, ready to check individual files without
+// walking a project tree. Policy caches its license classifiers, so reusing a
+// Policy for many files is faster than calling CheckContent for each. A Policy
+// is safe for concurrent use.
+type Policy struct {
+	Configs     Configs
+	classifiers map[string]*classifier // by Config.Detector
+}
+
+// NewPolicy returns a new Policy for the configs. db optionally holds licenses
+// to add to the detectors.
+func NewPolicy(cfgs Configs, db *detector.Database) (*Policy, error) {
+	p := &Policy{Configs: cfgs, classifiers: map[string]*classifier{}}
+	for _, cfg := range cfgs {
+		if _, ok := p.classifiers[cfg.Detector]; ok {
+			continue
+		}
+		d, err := detector.New(cfg.Detector, db)
+		if err != nil {
+			return nil, err
+		}
+		p.classifiers[cfg.Detector] = newClassifier(d)
+	}
+	return p, nil
+}
+
+// CheckContent checks the in-memory content of the file at the project
+// relative path against the configs, without accessing the file system. This
+// is used where no project tree is available, such as the WebAssembly build.
+// CheckContent returns the result of each config that examines the file. If no
+// config examines the file, then a single Result with the Skipped reason is
+// returned. db optionally holds licenses to add to the detectors.
+func CheckContent(cfgs Configs, relPath string, body []byte, db *detector.Database) (Results, error) {
+	p, err := NewPolicy(cfgs, db)
+	if err != nil {
+		return nil, err
+	}
+	return p.CheckContent(relPath, body), nil
+}
+
+// CheckContent checks the in-memory content of the file at the project
+// relative path, as described by the CheckContent function.
+func (p *Policy) CheckContent(relPath string, body []byte) Results {
+	relPath = strings.TrimPrefix(path.Clean(strings.ReplaceAll(relPath, "\\", "/")), "/")
+	head := body
+	if len(head) > sniff.Len {
+		head = head[:sniff.Len]
+	}
+
+	out, reason := Results{}, "no config"
+	for _, cfg := range p.Configs {
+		file := &candidate{path: relPath, read: true, leading: head}
+		ok, why := cfg.shouldExamineFile(file)
+		if !ok {
+			reason = why
+			continue
+		}
+		res := examineContent(relPath, EscapePath(relPath), body, cfg, p.classifiers[cfg.Detector])
+		res.Advisory = res.Advisory || !cfg.enforced()
+		out = append(out, res)
+	}
+	if len(out) == 0 {
+		return Results{{Path: relPath, Skipped: reason}}
+	}
+	return out
+}
+
+// CheckFile reads and checks the file, whose path is either absolute or
+// relative to the project root directory. Paths outside of root are an error.
+func (p *Policy) CheckFile(root, file string) (Results, error) {
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(root, file)
+	}
+	rel, err := filepath.Rel(root, file)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("File '%v' is not under the project root '%v'", file, root)
+	}
+	body, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read file '%v': %w", filepath.ToSlash(rel), err)
+	}
+	return p.CheckContent(filepath.ToSlash(rel), body), nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Coverage describes how much of the project tree was examined by a scan.
+type Coverage struct {
+	Examined int // number of distinct files examined
+	Total    int // number of files in the project, excluding VCS directories
+}
+
+// Percent returns the percentage of the project files that were examined.
+func (c Coverage) Percent() float64 {
+	if c.Total == 0 {
+		return 100
+	}
+	return 100 * float64(c.Examined) / float64(c.Total)
+}
+
+// String returns a one-line summary of the coverage.
+func (c Coverage) String() string {
+	return fmt.Sprintf("%.1f%% of files (%d/%d) checked", c.Percent(), c.Examined, c.Total)
+}
+
+// MeasureCoverage returns the Coverage of the results of scanning the project
+// in dir with opts. Every file in the project counts towards the total, except
+// for the config file, the contents of version control directories, and
+// special files, such as named pipes, which are never examined.
+func MeasureCoverage(dir string, results Results, opts Options) (Coverage, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return Coverage{}, fmt.Errorf("Failed to get absolute working directory: %w", err)
+	}
+
+	examined := map[string]bool{}
+	for _, res := range results.Files() {
+		examined[res.Path] = true
+	}
+
+	total := 0
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if vcsDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if specialFile(path, info) != "" {
+			return nil
+		}
+		if rel, err := filepath.Rel(root, path); err != nil || filepath.ToSlash(rel) != opts.ConfigFile() {
+			total++
+		}
+		return nil
+	})
+	if err != nil {
+		return Coverage{}, fmt.Errorf("Failed to count project files: %w", err)
+	}
+	return Coverage{Examined: len(examined), Total: total}, nil
+}
+
+// checkCoverage returns a Result with a LowCoverage violation if the coverage
+// of the results is below the highest MinCoverage of the configs, otherwise
+// nil.
+func checkCoverage(root string, cfgs Configs, results Results, opts Options) (*Result, error) {
+	var strictest *Config
+	for i, cfg := range cfgs {
+		if cfg.MinCoverage > 0 && (strictest == nil || cfg.MinCoverage > strictest.MinCoverage) {
+			strictest = &cfgs[i]
+		}
+	}
+	if strictest == nil {
+		return nil, nil
+	}
+
+	coverage, err := MeasureCoverage(root, results, opts)
+	if err != nil {
+		return nil, err
+	}
+	if coverage.Percent() >= strictest.MinCoverage {
+		return nil, nil
+	}
+	return &Result{
+		Path: strictest.source,
+		Err: fmt.Errorf("%v: only %v, below min_coverage of %v%%",
+			opts.DisplayPath(root, strictest.source), coverage, strictest.MinCoverage),
+		Kind:        LowCoverage,
+		Fingerprint: fingerprint(strictest.source, LowCoverage, nil),
+		Advisory:    !strictest.enforced(),
+	}, nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"fmt"
+	"time"
+)
+
+// deadlineReason is the Result.Skipped reason of the files that were not
+// examined as the scan reached Options.Deadline or was cancelled.
+const deadlineReason = "not examined before the scan stopped"
+
+// expired returns true if the scan has reached Options.Deadline, or if
+// Options.Context has been cancelled.
+func (o Options) expired() bool {
+	return o.stopReason() != ""
+}
+
+// stopReason returns why the scan stopped before examining all files, or an
+// empty string if it has not stopped.
+func (o Options) stopReason() string {
+	switch {
+	case o.Context != nil && o.Context.Err() != nil:
+		return "the scan was interrupted"
+	case !o.Deadline.IsZero() && !time.Now().Before(o.Deadline):
+		return "the scan stopped at the deadline"
+	}
+	return ""
+}
+
+// examineWithin calls examine for the file at the project relative path,
+// returning a FileTimeout violation if it does not complete within
+// opts.FileTimeout, or a skipped Result if the scan is stopped first.
+func examineWithin(root, path string, cfg Config, cls *classifier, opts Options) Result {
+	if opts.FileTimeout <= 0 {
+		return examine(root, path, cfg, cls, opts)
+	}
+	done := make(chan Result, 1) // Buffered, so an abandoned examine can finish
+	go func() { done <- examine(root, path, cfg, cls, opts) }()
+	var cancelled <-chan struct{}
+	if opts.Context != nil {
+		cancelled = opts.Context.Done()
+	}
+	timer := time.NewTimer(opts.FileTimeout)
+	defer timer.Stop()
+	select {
+	case res := <-done:
+		return res
+	case <-cancelled:
+		return Result{Path: path, Skipped: deadlineReason}
+	case <-timer.C:
+		return Result{
+			Path:        path,
+			Err:         fmt.Errorf("%v was not examined within the file timeout of %v", opts.DisplayPath(root, path), opts.FileTimeout),
+			Kind:        FileTimeout,
+			Fingerprint: fingerprint(path, FileTimeout, nil),
+		}
+	}
+}
+
+// Partial returns true if the scan stopped at Options.Deadline, or was
+// cancelled by Options.Context, before all files were examined, in which case
+// the results hold an Incomplete violation.
+func (r Results) Partial() bool {
+	for _, res := range r {
+		if res.Kind == Incomplete {
+			return true
+		}
+	}
+	return false
+}
+
+// markIncomplete returns the results with an Incomplete violation added if any
+// file was not examined before the deadline. The results of those files are
+// removed, unless opts.ListSkipped is true.
+func markIncomplete(root string, cfgs Configs, results Results, opts Options) Results {
+	out, missed := Results{}, 0
+	for _, res := range results {
+		if res.Skipped == deadlineReason {
+			missed++
+			if !opts.ListSkipped {
+				continue
+			}
+		}
+		out = append(out, res)
+	}
+	if missed == 0 {
+		return results
+	}
+	incomplete := Result{
+		Path: cfgs.source(),
+		Err: fmt.Errorf("%v: partial results, %v with %d files not examined",
+			opts.DisplayPath(root, cfgs.source()), opts.stopReason(), missed),
+		Kind:        Incomplete,
+		Fingerprint: fingerprint(cfgs.source(), Incomplete, nil),
+	}
+	opts.report(incomplete)
+	return append(out, incomplete)
+}
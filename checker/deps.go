@@ -0,0 +1,124 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"../deps"
+	"../detector"
+	"../match"
+)
+
+// DependencyPolicy holds the license requirements of the project's Go module
+// dependencies.
+type DependencyPolicy struct {
+	// Licenses is the list of licenses that dependencies may use. Defaults to
+	// the Config's Licenses.
+	Licenses []string `json:"licenses"`
+
+	// Allow is a list of module path patterns, using the same wildcards as
+	// the path rules, of modules that are exempt from the policy.
+	Allow []string `json:"allow"`
+}
+
+// CheckDependencies examines the license files of each of the Go module
+// dependencies mods, returning a Result for each module. The dependency
+// policy is taken from the first config of the config file in dir that has
+// one, or else the licenses of the first config are used. Result.Path holds
+// the module path and version.
+func CheckDependencies(dir string, mods []deps.Module, opts Options) (Results, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get absolute working directory: %w", err)
+	}
+	cfgs, err := loadConfigs(root, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load config file: %w", err)
+	}
+	if len(cfgs) == 0 {
+		return nil, fmt.Errorf("Config file has no configs")
+	}
+	cfg := cfgs[0]
+	for _, c := range cfgs {
+		if c.Dependencies != nil {
+			cfg = c
+			break
+		}
+	}
+	policy := DependencyPolicy{}
+	if cfg.Dependencies != nil {
+		policy = *cfg.Dependencies
+	}
+	if len(policy.Licenses) == 0 {
+		policy.Licenses = cfg.Licenses
+	}
+	allow := []match.Test{}
+	for _, pattern := range policy.Allow {
+		test, err := match.New(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("dependencies: invalid allow pattern '%v': %w", pattern, err)
+		}
+		allow = append(allow, test)
+	}
+
+	var db *detector.Database
+	if opts.LicenseDB != "" {
+		if db, err = detector.LoadDatabase(opts.LicenseDB); err != nil {
+			return nil, err
+		}
+	}
+	d, err := detector.New(cfg.Detector, db)
+	if err != nil {
+		return nil, err
+	}
+	cls := newClassifier(d)
+
+	out := make(Results, 0, len(mods))
+	for _, m := range mods {
+		res := Result{Path: m.String(), Advisory: !cfg.enforced()}
+		fail := func(kind ViolationKind, err error) {
+			res.Err, res.Kind, res.Fingerprint = err, kind, fingerprint(res.Path, kind, nil)
+		}
+		exempt := false
+		for _, test := range allow {
+			exempt = exempt || test(m.Path)
+		}
+		if _, err := os.Stat(m.Dir); err != nil {
+			if !exempt {
+				fail(ReadError, fmt.Errorf("%v is not in the module cache. Run 'go mod download'", m))
+			}
+			out = append(out, res)
+			continue
+		}
+		res.Licenses, _ = componentLicenses(m.Dir, ".", cls)
+		switch {
+		case exempt:
+		case len(res.Licenses) == 0:
+			fail(NoLicense, fmt.Errorf("%v has no license", m))
+		default:
+			for _, id := range res.Licenses {
+				if !(LanguagePolicy{Licenses: policy.Licenses}).allowsLicense(cfg, id) {
+					fail(UnsupportedLicense, fmt.Errorf("%v uses unsupported license '%v'", m, id))
+					break
+				}
+			}
+		}
+		out = append(out, res)
+	}
+	return out, nil
+}
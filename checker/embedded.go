@@ -0,0 +1,158 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"../toml"
+)
+
+// The files of the project root that may embed the config, for projects
+// without a config file.
+const (
+	// PyprojectFileName is the Python project file that may embed the config
+	// as the [tool.license-checker] table, or as [[tool.license-checker]]
+	// tables for multiple configs.
+	PyprojectFileName = "pyproject.toml"
+	// PackageJSONFileName is the npm package file that may embed the config as
+	// the value of its "license-checker" key.
+	PackageJSONFileName = "package.json"
+	// ConfigDirective is the prefix of the lines of a Go file of the project
+	// root that hold the config, in the style of a go tool directive. The text
+	// that follows the prefix on each line is joined to form the JSON config.
+	ConfigDirective = "//license-checker:"
+)
+
+// embeddedConfigKey is the name of the table or key that embeds the config.
+const embeddedConfigKey = "license-checker"
+
+// ReadConfig returns the path of the file that holds the config of the project
+// at root, and the config in the JSON format of a config file. The config is
+// read from the opts.Config file, or the opts.ConfigFile() file at root. If
+// the project has no config file, the config is read from the first of
+// pyproject.toml, package.json, or the Go files of the root with ConfigDirective
+// lines, that embeds one.
+func ReadConfig(root string, opts Options) (string, []byte, error) {
+	path := opts.Config
+	if path == "" {
+		path = filepath.Join(root, opts.ConfigFile())
+	}
+	body, err := ioutil.ReadFile(path)
+	if err == nil || opts.Config != "" || !os.IsNotExist(err) {
+		return path, body, err
+	}
+	for _, find := range []func(root string) (string, []byte, error){
+		embeddedInPyproject,
+		embeddedInPackageJSON,
+		embeddedInDirectives,
+	} {
+		embeddedPath, embedded, findErr := find(root)
+		if findErr != nil {
+			return embeddedPath, nil, findErr
+		}
+		if embedded != nil {
+			return embeddedPath, embedded, nil
+		}
+	}
+	return path, nil, err
+}
+
+// embeddedInPyproject returns the config embedded in the pyproject.toml file
+// of root, or nil if it has none. The file is only parsed if it mentions
+// embeddedConfigKey, so that the syntax that toml.Parse does not support only
+// fails the projects that embed their config in the file.
+func embeddedInPyproject(root string) (string, []byte, error) {
+	path := filepath.Join(root, PyprojectFileName)
+	body, err := ioutil.ReadFile(path)
+	if err != nil || !bytes.Contains(body, []byte(embeddedConfigKey)) {
+		return path, nil, nil
+	}
+	doc, err := toml.Parse(string(body))
+	if err != nil {
+		return path, nil, fmt.Errorf("Failed to parse '%v': %w", path, err)
+	}
+	tool, _ := doc["tool"].(map[string]interface{})
+	cfg, ok := tool[embeddedConfigKey]
+	if !ok {
+		return path, nil, nil
+	}
+	out, err := json.Marshal(cfg)
+	if err != nil {
+		return path, nil, fmt.Errorf("Failed to convert the config of '%v' to JSON: %w", path, err)
+	}
+	return path, out, nil
+}
+
+// embeddedInPackageJSON returns the config embedded in the package.json file
+// of root, or nil if it has none. Like embeddedInPyproject, the file is only
+// parsed if it mentions embeddedConfigKey.
+func embeddedInPackageJSON(root string) (string, []byte, error) {
+	path := filepath.Join(root, PackageJSONFileName)
+	body, err := ioutil.ReadFile(path)
+	if err != nil || !bytes.Contains(body, []byte(embeddedConfigKey)) {
+		return path, nil, nil
+	}
+	pkg := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &pkg); err != nil {
+		return path, nil, fmt.Errorf("Failed to parse '%v': %w", path, err)
+	}
+	cfg, ok := pkg[embeddedConfigKey]
+	if !ok {
+		return path, nil, nil
+	}
+	return path, []byte(cfg), nil
+}
+
+// embeddedInDirectives returns the config held by the ConfigDirective lines of
+// the Go files of root, or nil if there are none. It is an error for more
+// than one file to hold directives.
+func embeddedInDirectives(root string) (string, []byte, error) {
+	paths, err := filepath.Glob(filepath.Join(root, "*.go"))
+	if err != nil {
+		return "", nil, err
+	}
+	sort.Strings(paths)
+	found, out := "", []byte(nil)
+	for _, path := range paths {
+		body, err := ioutil.ReadFile(path)
+		if err != nil {
+			return path, nil, fmt.Errorf("Failed to read '%v': %w", path, err)
+		}
+		lines := []string{}
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); strings.HasPrefix(line, ConfigDirective) {
+				lines = append(lines, strings.TrimPrefix(line, ConfigDirective))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		if found != "" {
+			return path, nil, fmt.Errorf("Both '%v' and '%v' hold %v directives", found, path, ConfigDirective)
+		}
+		found, out = path, []byte(strings.Join(lines, "\n"))
+	}
+	return found, out, nil
+}
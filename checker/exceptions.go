@@ -0,0 +1,346 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ExceptionKind is the enumerator of the kinds of Exception.
+type ExceptionKind string
+
+const (
+	// PathExclusion is a paths rule that excludes files from the check.
+	PathExclusion ExceptionKind = "path-exclusion"
+	// LanguageExemption is a language policy that requires no license.
+	LanguageExemption ExceptionKind = "language-exemption"
+	// AdvisoryConfig is a config with enforce set to false, whose violations
+	// do not fail the check.
+	AdvisoryConfig ExceptionKind = "advisory-config"
+	// KnownViolation is a violation listed by a baseline, which does not fail
+	// the check.
+	KnownViolation ExceptionKind = "known-violation"
+	// QuarantineDir is the quarantine directory of a config, whose violations
+	// are advisory.
+	QuarantineDir ExceptionKind = "quarantine"
+	// GeneratedOutput is a generated_sources entry, whose output files are
+	// checked by their source file instead of their own content.
+	GeneratedOutput ExceptionKind = "generated-source"
+	// EmptyFileExemption is a config with empty_files set to ignore, which
+	// accepts empty files without a license.
+	EmptyFileExemption ExceptionKind = "empty-files"
+	// MinContentExemption is a min_lines, min_bytes or
+	// min_content_by_extension setting, which exempts small files from the
+	// license requirement.
+	MinContentExemption ExceptionKind = "min-content"
+	// InternalExemption is the internal setting of a config, which accepts the
+	// internal notice instead of an open source license.
+	InternalExemption ExceptionKind = "internal"
+	// ManifestExemption is a file that the build manifest declares
+	// third-party or generated, which is not examined, or is checked by its
+	// source file.
+	ManifestExemption ExceptionKind = "build-manifest"
+)
+
+// Exception is an active exemption from the license policy, as listed by the
+// 'exceptions' command for audits.
+type Exception struct {
+	Kind ExceptionKind
+	// Rule describes the exception, such as
+	// "configs[0].paths[1] excludes 'third_party/**'".
+	Rule string
+	// Source is the project relative path of the file that declares the
+	// exception, such as the config file, and Line is the line of Source
+	// that declares it, or 0 if unknown.
+	Source string
+	Line   int
+	// Path is the project relative path of the file with the violation of a
+	// KnownViolation, or of the file declared by a ManifestExemption,
+	// otherwise Source.
+	Path string
+	// Matches is the number of files, directories or violations of a scan
+	// that the exception applied to, as counted by Results.CountExceptions.
+	Matches int
+
+	reason      string           // the Result.Skipped reason of a PathExclusion or ManifestExemption
+	config      int              // the config index of the exception, if declared by a config
+	decision    string           // the Decision rule of a LanguageExemption, EmptyFileExemption, MinContentExemption or InternalExemption
+	fingerprint string           // the violation fingerprint of a KnownViolation
+	quarantine  string           // the directory of a QuarantineDir
+	generated   *GeneratedSource // the generated_sources entry of a GeneratedOutput
+	source      string           // the Result.GeneratedFrom of a ManifestExemption of a generated file with a source
+}
+
+// Exceptions returns the exceptions declared by the configs, parsed from
+// body, the content of the config file at the project relative path source.
+// The exceptions are the exclusions of the path rules, the language policies
+// that require no license, the configs that are not enforced, the quarantine
+// directories, the generated_sources entries, the empty_files: ignore
+// settings, the minimum content settings and the internal settings.
+func (c Configs) Exceptions(source string, body []byte) []Exception {
+	out := []Exception{}
+	lines := newLineFinder(body)
+	add := func(e Exception, declaration string) {
+		e.Source, e.Line, e.Path = source, lines.find(declaration), source
+		out = append(out, e)
+	}
+	for _, cfg := range c {
+		for i, r := range cfg.Paths {
+			if r.include {
+				continue
+			}
+			what := map[ruleKind]string{pathRule: "", typeRule: "files of type ", languageRule: "files of language "}[r.kind]
+			for _, p := range r.patterns {
+				add(Exception{
+					Kind:   PathExclusion,
+					Rule:   fmt.Sprintf("configs[%d].paths[%d] excludes %v'%v'", cfg.index, i, what, p),
+					config: cfg.index,
+					reason: fmt.Sprintf("excluded by paths[%d] pattern '%v'", i, p),
+				}, quoteJSON(p))
+			}
+		}
+		for _, name := range sortedKeys(cfg.LanguagePolicies) {
+			if cfg.LanguagePolicies[name].Require == RequireNone {
+				add(Exception{
+					Kind:     LanguageExemption,
+					Rule:     fmt.Sprintf("configs[%d].language_policies requires no license of %v files", cfg.index, name),
+					config:   cfg.index,
+					decision: fmt.Sprintf("language_policies.%v: require none", name),
+				}, quoteJSON(name))
+			}
+		}
+		if !cfg.enforced() {
+			add(Exception{
+				Kind:   AdvisoryConfig,
+				Rule:   fmt.Sprintf("configs[%d] is not enforced, so its violations are warnings", cfg.index),
+				config: cfg.index,
+			}, `"enforce"`)
+		}
+		if cfg.Quarantine != "" {
+			add(Exception{
+				Kind:       QuarantineDir,
+				Rule:       fmt.Sprintf("configs[%d] quarantines '%v', so its violations are warnings", cfg.index, cfg.quarantineDir()),
+				config:     cfg.index,
+				quarantine: cfg.quarantineDir(),
+			}, `"quarantine"`)
+		}
+		for i := range cfg.GeneratedSources {
+			g := &cfg.GeneratedSources[i]
+			add(Exception{
+				Kind:      GeneratedOutput,
+				Rule:      fmt.Sprintf("configs[%d].generated_sources[%d] checks '%v' by '%v'", cfg.index, i, g.Output, g.Source),
+				config:    cfg.index,
+				generated: g,
+			}, quoteJSON(g.Output))
+		}
+		if cfg.EmptyFiles == EmptyIgnore {
+			add(Exception{
+				Kind:     EmptyFileExemption,
+				Rule:     fmt.Sprintf("configs[%d] accepts empty files without a license", cfg.index),
+				config:   cfg.index,
+				decision: "empty_files: ignore",
+			}, `"empty_files"`)
+		}
+		// setting is the prefix of the Decision rules of min, as recorded by
+		// Config.minContentRule.
+		minContent := func(setting string, min MinContent, declaration string) {
+			rule := fmt.Sprintf("configs[%d].%v", cfg.index, strings.TrimSuffix(setting, "."))
+			if setting == "" {
+				rule = fmt.Sprintf("configs[%d]", cfg.index)
+			}
+			if min.MinLines > 0 {
+				add(Exception{
+					Kind:     MinContentExemption,
+					Rule:     fmt.Sprintf("%v requires no license of files with fewer than %d non-blank lines", rule, min.MinLines),
+					config:   cfg.index,
+					decision: fmt.Sprintf("%vmin_lines: %d", setting, min.MinLines),
+				}, declaration)
+			}
+			if min.MinBytes > 0 {
+				add(Exception{
+					Kind:     MinContentExemption,
+					Rule:     fmt.Sprintf("%v requires no license of files with fewer than %d bytes", rule, min.MinBytes),
+					config:   cfg.index,
+					decision: fmt.Sprintf("%vmin_bytes: %d", setting, min.MinBytes),
+				}, declaration)
+			}
+		}
+		minContent("", MinContent{MinLines: cfg.MinLines, MinBytes: cfg.MinBytes}, `"min_`)
+		exts := make([]string, 0, len(cfg.MinContentByExtension))
+		for ext := range cfg.MinContentByExtension {
+			exts = append(exts, ext)
+		}
+		sort.Strings(exts)
+		for _, ext := range exts {
+			minContent(fmt.Sprintf("min_content_by_extension[%v].", ext), cfg.MinContentByExtension[ext], quoteJSON(ext))
+		}
+		if cfg.Internal != nil {
+			add(Exception{
+				Kind:     InternalExemption,
+				Rule:     fmt.Sprintf("configs[%d] accepts the internal notice instead of an open source license", cfg.index),
+				config:   cfg.index,
+				decision: "internal: " + InternalLicense,
+			}, `"internal"`)
+		}
+	}
+	return out
+}
+
+// Exceptions returns the files that the build manifest declares third-party
+// or generated, parsed from body, the content of the manifest file at the
+// project relative path source.
+func (m BuildManifest) Exceptions(source string, body []byte) []Exception {
+	out := []Exception{}
+	lines := newLineFinder(body)
+	for _, f := range m.Files {
+		e := Exception{Kind: ManifestExemption, Source: source, Path: f.Path}
+		switch {
+		case f.ThirdParty:
+			e.Rule = fmt.Sprintf("%v is third-party, so it is not examined", EscapePath(f.Path))
+			e.reason = "third-party, as declared by the build manifest"
+		case f.Generated && f.Source == "":
+			e.Rule = fmt.Sprintf("%v is generated, so it is not examined", EscapePath(f.Path))
+			e.reason = "generated, as declared by the build manifest"
+		case f.Generated:
+			e.Rule = fmt.Sprintf("%v is generated, so it is checked by %v", EscapePath(f.Path), EscapePath(f.Source))
+			e.source = f.Source
+		default:
+			continue
+		}
+		e.Line = lines.find(quoteJSON(f.Path))
+		out = append(out, e)
+	}
+	return out
+}
+
+// Exceptions returns the known violations of the baseline, parsed from body,
+// the content of the baseline file at the project relative path source.
+func (b Baseline) Exceptions(source string, body []byte) []Exception {
+	out := []Exception{}
+	lines := newLineFinder(body)
+	for _, e := range b.Violations {
+		out = append(out, Exception{
+			Kind:   KnownViolation,
+			Rule:   fmt.Sprintf("%v %v violation [%v] is accepted", EscapePath(e.Path), e.Kind, e.Fingerprint),
+			Source: source,
+			Line:   lines.find(quoteJSON(e.Fingerprint)),
+			Path:   e.Path,
+
+			fingerprint: e.Fingerprint,
+		})
+	}
+	return out
+}
+
+// CountExceptions sets the Matches of each of the exceptions to the number of
+// results that it applied to: the files and directories that a PathExclusion
+// excluded, the files that a LanguageExemption, EmptyFileExemption,
+// MinContentExemption or InternalExemption accepted without an open source
+// license, the warnings of an AdvisoryConfig, the files of a QuarantineDir,
+// the files that a GeneratedOutput checked by their source, the file of a
+// ManifestExemption, and the violation of a KnownViolation, if still present.
+// The results must be of a scan with Options.ListSkipped set.
+func (r Results) CountExceptions(exceptions []Exception) {
+	for i := range exceptions {
+		e := &exceptions[i]
+		e.Matches = 0
+		for _, res := range r {
+			d := res.Decision
+			switch e.Kind {
+			case PathExclusion:
+				if res.skippedBy[e.config] == e.reason {
+					e.Matches++
+				}
+			case LanguageExemption, EmptyFileExemption, MinContentExemption, InternalExemption:
+				if d != nil && d.Config == e.config && containsString(d.Rules, e.decision) {
+					e.Matches++
+				}
+			case AdvisoryConfig:
+				if res.Err != nil && res.Advisory && d != nil && d.Config == e.config {
+					e.Matches++
+				}
+			case QuarantineDir:
+				if d != nil && d.Config == e.config && strings.HasPrefix(res.Path, e.quarantine+"/") {
+					e.Matches++
+				}
+			case GeneratedOutput:
+				if src, ok := e.generated.mapping(res.Path); ok && res.GeneratedFrom == src {
+					e.Matches++
+				}
+			case ManifestExemption:
+				if res.Path == e.Path && ((e.reason != "" && res.Skipped == e.reason) || (e.source != "" && res.GeneratedFrom == e.source)) {
+					e.Matches++
+				}
+			case KnownViolation:
+				if res.Err != nil && res.Fingerprint == e.fingerprint {
+					e.Matches++
+				}
+			}
+		}
+	}
+}
+
+// containsString returns true if list holds s.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// lineFinder finds the lines of a file that declare exceptions.
+type lineFinder struct {
+	body  []byte
+	found map[string]int // the offset after the last occurrence found, by text
+}
+
+func newLineFinder(body []byte) *lineFinder {
+	return &lineFinder{body: body, found: map[string]int{}}
+}
+
+// find returns the 1-based line of the next occurrence of text in the body,
+// after those returned by previous calls for the same text, or 0 if there is
+// none.
+func (f *lineFinder) find(text string) int {
+	from := f.found[text]
+	i := bytes.Index(f.body[from:], []byte(text))
+	if i < 0 {
+		return 0
+	}
+	f.found[text] = from + i + len(text)
+	return bytes.Count(f.body[:from+i], []byte("\n")) + 1
+}
+
+// quoteJSON returns s as a JSON string.
+func quoteJSON(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// sortedKeys returns the sorted keys of the language policies.
+func sortedKeys(m map[string]LanguagePolicy) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
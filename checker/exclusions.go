@@ -0,0 +1,111 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+)
+
+// DefaultExclusionsFileName is the conventional file name of the exclusions
+// snapshot, committed to the project root.
+const DefaultExclusionsFileName = "license-coverage.json"
+
+// Exclusions is a snapshot of the files and directories that a scan did not
+// examine, and why. Committing the snapshot to the project, and verifying it
+// on each change, makes changes to the exclusions visible in code review.
+//
+// The snapshot file is a JSON object of the form:
+//
+//	{
+//	  "excluded": [
+//	    { "path": ".git/", "reason": "version control directory" },
+//	    { "path": "out/", "reason": "excluded by paths[0] pattern 'out/**'" }
+//	  ]
+//	}
+type Exclusions struct {
+	Excluded []Exclusion `json:"excluded"`
+}
+
+// Exclusion is a single skipped file or directory of Exclusions.
+type Exclusion struct {
+	Path   string `json:"path"`   // project relative path, directories end with '/'
+	Reason string `json:"reason"` // the reason the path was skipped
+}
+
+// Exclusions returns the snapshot of the skipped files and directories of the
+// results, sorted by path. The results must be of a scan with
+// Options.ListSkipped set.
+func (r Results) Exclusions() Exclusions {
+	out := Exclusions{Excluded: []Exclusion{}}
+	for _, res := range r.Skipped() {
+		out.Excluded = append(out.Excluded, Exclusion{Path: res.Path, Reason: res.Skipped})
+	}
+	sort.Slice(out.Excluded, func(i, j int) bool { return out.Excluded[i].Path < out.Excluded[j].Path })
+	return out
+}
+
+// LoadExclusions loads the exclusions snapshot file at path.
+func LoadExclusions(path string) (Exclusions, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Exclusions{}, fmt.Errorf("Failed to read exclusions snapshot: %w", err)
+	}
+	e := Exclusions{}
+	if err := json.Unmarshal(body, &e); err != nil {
+		return Exclusions{}, fmt.Errorf("Failed to parse exclusions snapshot '%v': %w", path, err)
+	}
+	return e, nil
+}
+
+// Write writes the exclusions snapshot to the file at path.
+func (e Exclusions) Write(path string) error {
+	body, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, append(body, '\n'), 0666); err != nil {
+		return fmt.Errorf("Failed to write exclusions snapshot: %w", err)
+	}
+	return nil
+}
+
+// Diff returns the changes from the snapshot e to current, one per line. Paths
+// that are no longer excluded start with '-', newly excluded paths start with
+// '+', and paths excluded for a different reason are listed as both.
+func (e Exclusions) Diff(current Exclusions) []string {
+	old := map[string]string{}
+	for _, x := range e.Excluded {
+		old[x.Path] = x.Reason
+	}
+	now := map[string]string{}
+	for _, x := range current.Excluded {
+		now[x.Path] = x.Reason
+	}
+	out := []string{}
+	for _, x := range e.Excluded {
+		if reason, ok := now[x.Path]; !ok || reason != x.Reason {
+			out = append(out, fmt.Sprintf("- %v: %v", EscapePath(x.Path), x.Reason))
+		}
+	}
+	for _, x := range current.Excluded {
+		if reason, ok := old[x.Path]; !ok || reason != x.Reason {
+			out = append(out, fmt.Sprintf("+ %v: %v", EscapePath(x.Path), x.Reason))
+		}
+	}
+	return out
+}
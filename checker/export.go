@@ -0,0 +1,175 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// VerifyExport scans the project in dir, and returns the results for the files
+// under the project relative directory subtree, which is to be exported as a
+// standalone open source release. In addition to the usual checks, the
+// exported subtree must:
+//   - hold a LICENSE, LICENCE or COPYING file at its root
+//   - have no files that carry the internal marker of any config
+//   - have no files that were not examined by any config
+//   - have no symbolic links that resolve outside of the subtree
+//
+// All violations in the subtree fail the check, including those of configs
+// that are not enforced.
+func VerifyExport(dir, subtree string, opts Options) (Results, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get absolute working directory: %w", err)
+	}
+	subtree = path.Clean(filepath.ToSlash(subtree))
+	if subtree == "." || subtree == ".." || strings.HasPrefix(subtree, "../") || path.IsAbs(subtree) {
+		return nil, fmt.Errorf("Export subtree '%v' must be a subdirectory of the project", subtree)
+	}
+	exportDir := filepath.Join(root, filepath.FromSlash(subtree))
+	if info, err := os.Stat(exportDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("Export subtree '%v' is not a directory", subtree)
+	}
+
+	e, err := newExportCheck(root, opts, func(rel string) bool { return strings.HasPrefix(rel, subtree+"/") })
+	if err != nil {
+		return nil, err
+	}
+
+	hasLicense := false
+	err = filepath.Walk(exportDir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if info.IsDir() {
+			if vcsDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Dir(file) == exportDir && isLicenseFile(info.Name()) {
+			hasLicense = true
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := filepath.EvalSymlinks(file)
+			if err != nil {
+				target, _ = os.Readlink(file)
+			}
+			if t, err := filepath.Rel(exportDir, target); err != nil || t == ".." || strings.HasPrefix(t, ".."+string(filepath.Separator)) {
+				if t, err := filepath.Rel(root, target); err == nil && !strings.HasPrefix(t, "..") {
+					target = opts.DisplayPath(root, t)
+				}
+				e.violation(rel, ExternalLink, fmt.Errorf("%v links to '%v', outside of the exported tree", opts.DisplayPath(root, rel), target))
+				return nil
+			}
+		}
+		e.checkFile(rel)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to walk export subtree: %w", err)
+	}
+	if !hasLicense {
+		e.violation(subtree, MissingLicenseFile, fmt.Errorf("%v has no LICENSE, LICENCE or COPYING file", opts.DisplayPath(root, subtree)))
+	}
+	return e.out, nil
+}
+
+// exportCheck holds the state of a check of files that are to be exported.
+type exportCheck struct {
+	root     string
+	cfgs     Configs
+	opts     Options
+	examined map[string]bool // project relative paths of the examined files
+	out      Results
+}
+
+// newExportCheck scans the project at root, returning an exportCheck with the
+// results of the files for which inExport returns true.
+func newExportCheck(root string, opts Options, inExport func(rel string) bool) (*exportCheck, error) {
+	cfgs, err := loadConfigs(root, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load config file: %w", err)
+	}
+	results, err := Scan(root, opts)
+	if err != nil {
+		return nil, err
+	}
+	e := &exportCheck{root: root, cfgs: cfgs, opts: opts, examined: map[string]bool{}}
+	for _, res := range results {
+		if !inExport(res.Path) {
+			continue
+		}
+		if res.Skipped == "" {
+			e.examined[res.Path] = true
+		}
+		res.Advisory = false
+		e.out = append(e.out, res)
+	}
+	return e, nil
+}
+
+// violation adds a violation of the given kind for the project relative path.
+func (e *exportCheck) violation(rel string, kind ViolationKind, err error) {
+	e.out = append(e.out, Result{Path: rel, Err: err, Kind: kind, Fingerprint: fingerprint(rel, kind, nil)})
+}
+
+// checkFile adds violations if the exported file at the project relative path
+// was not examined, or is internal.
+func (e *exportCheck) checkFile(rel string) {
+	if !e.examined[rel] {
+		e.violation(rel, UncheckedExport, fmt.Errorf("%v is exported, but was not checked by any config", e.opts.DisplayPath(e.root, rel)))
+	}
+	if isInternal(e.cfgs, rel, filepath.Join(e.root, filepath.FromSlash(rel))) {
+		e.violation(rel, InternalInExport, fmt.Errorf("%v is internal, and must not be exported", e.opts.DisplayPath(e.root, rel)))
+	}
+}
+
+// isInternal returns true if the file at the project relative path rel and
+// absolute path file carries the internal marker of any of the configs.
+func isInternal(cfgs Configs, rel, file string) bool {
+	var body []byte
+	for _, cfg := range cfgs {
+		if cfg.Internal == nil {
+			continue
+		}
+		if body == nil {
+			var err error
+			if body, err = ioutil.ReadFile(file); err != nil {
+				return false
+			}
+		}
+		if cfg.Internal.marked(rel, body) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLicenseFile returns true if the file name is that of a license file.
+func isLicenseFile(name string) bool {
+	name = strings.ToUpper(name)
+	return strings.HasPrefix(name, "LICENSE") || strings.HasPrefix(name, "LICENCE") || strings.HasPrefix(name, "COPYING")
+}
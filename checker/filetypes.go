@@ -0,0 +1,77 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// FileTypeStats summarizes the results of the examined files with the same
+// extension.
+type FileTypeStats struct {
+	Extension  string // the lowercase file extension, such as ".go", or empty
+	Files      int    // number of examined files
+	Violations int    // number of files with a violation
+	License    string // the license found in the most files, or empty if none
+}
+
+// String returns a one-line summary of the statistics.
+func (s FileTypeStats) String() string {
+	ext := s.Extension
+	if ext == "" {
+		ext = "(no extension)"
+	}
+	license := "no license"
+	if s.License != "" {
+		license = "mostly " + s.License
+	}
+	return fmt.Sprintf("%v: %d files, %d violations, %v", ext, s.Files, s.Violations, license)
+}
+
+// FileTypes returns the statistics of the examined files, grouped by file
+// extension, and sorted by extension.
+func (r Results) FileTypes() []FileTypeStats {
+	stats := map[string]*FileTypeStats{}
+	licenses := map[string]map[string]int{} // extension to license to number of files
+	for _, res := range r.Files() {
+		ext := strings.ToLower(path.Ext(res.Path))
+		s, ok := stats[ext]
+		if !ok {
+			s = &FileTypeStats{Extension: ext}
+			stats[ext], licenses[ext] = s, map[string]int{}
+		}
+		s.Files++
+		if res.Err != nil {
+			s.Violations++
+		}
+		for _, l := range res.Licenses {
+			licenses[ext][l]++
+		}
+	}
+	out := make([]FileTypeStats, 0, len(stats))
+	for ext, s := range stats {
+		for l, n := range licenses[ext] {
+			if best := licenses[ext][s.License]; n > best || (n == best && l < s.License) {
+				s.License = l
+			}
+		}
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Extension < out[j].Extension })
+	return out
+}
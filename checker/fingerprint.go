@@ -0,0 +1,208 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"../language"
+)
+
+// ViolationKind is the type of a license violation.
+type ViolationKind string
+
+// Enumerator values for ViolationKind.
+const (
+	// NoLicense is the kind of violation for a file without a license.
+	NoLicense ViolationKind = "no-license"
+	// UnsupportedLicense is the kind of violation for a file with a license
+	// that is not permitted by the config.
+	UnsupportedLicense ViolationKind = "unsupported-license"
+	// ReadError is the kind of violation for a file that could not be read.
+	ReadError ViolationKind = "read-error"
+	// ScanFailure is the kind of violation for a file whose examination
+	// panicked.
+	ScanFailure ViolationKind = "scan-failure"
+	// LowCoverage is the kind of violation for a project where fewer files
+	// were examined than the config's min_coverage requires.
+	LowCoverage ViolationKind = "low-coverage"
+	// MissingMetadata is the kind of violation for a vendored component
+	// without a metadata file.
+	MissingMetadata ViolationKind = "missing-metadata"
+	// InvalidMetadata is the kind of violation for a vendored component with
+	// a metadata file that cannot be parsed, or is missing required fields.
+	InvalidMetadata ViolationKind = "invalid-metadata"
+	// MetadataMismatch is the kind of violation for a vendored component
+	// whose metadata declares a license that differs from its license files.
+	MetadataMismatch ViolationKind = "metadata-mismatch"
+	// ModifiedLicense is the kind of violation for a vendored component's
+	// license file that differs from the upstream copy.
+	ModifiedLicense ViolationKind = "modified-license"
+	// UpstreamError is the kind of violation for a vendored component's
+	// license file whose upstream copy could not be fetched.
+	UpstreamError ViolationKind = "upstream-error"
+	// InternalInExport is the kind of violation for an internal file in a
+	// subtree that is to be exported.
+	InternalInExport ViolationKind = "internal-in-export"
+	// UncheckedExport is the kind of violation for a file in a subtree that
+	// is to be exported, but that was not examined by any config.
+	UncheckedExport ViolationKind = "unchecked-export"
+	// ExternalLink is the kind of violation for a symbolic link in a subtree
+	// that is to be exported, that resolves outside of the subtree.
+	ExternalLink ViolationKind = "external-link"
+	// MissingLicenseFile is the kind of violation for a subtree that is to be
+	// exported, without a license file.
+	MissingLicenseFile ViolationKind = "missing-license-file"
+	// MissingFile is the kind of violation for a file listed by an export
+	// manifest that cannot be read.
+	MissingFile ViolationKind = "missing-file"
+	// ExternalReference is the kind of violation for a file listed by an
+	// export manifest that references a project file outside of the manifest.
+	ExternalReference ViolationKind = "external-reference"
+	// ConflictingLicenseFiles is the kind of violation for a vendored
+	// component with several license files that differ.
+	ConflictingLicenseFiles ViolationKind = "conflicting-license-files"
+	// StaleHeader is the kind of violation for a file with a header that
+	// references an outdated entity or URL. See Config.StaleHeaders.
+	StaleHeader ViolationKind = "stale-header"
+	// SuspiciousCharacters is the kind of violation for a file with a header
+	// that holds invisible or look-alike characters, which make the header
+	// appear correct, but defeat license detection.
+	SuspiciousCharacters ViolationKind = "suspicious-characters"
+	// QuarantineReference is the kind of violation for a file outside of the
+	// config's quarantine directory that references a quarantined file.
+	QuarantineReference ViolationKind = "quarantine-reference"
+	// MissingGeneratorSource is the kind of violation for a generated file
+	// whose source file, as mapped by the config's generated_sources, does
+	// not exist.
+	MissingGeneratorSource ViolationKind = "missing-generator-source"
+	// Incomplete is the kind of violation for a scan that stopped at
+	// Options.Deadline, or was cancelled by Options.Context, before all files
+	// were examined.
+	Incomplete ViolationKind = "incomplete"
+	// BadHeaderStyle is the kind of violation for a file with a license
+	// header that does not follow the config's header_style. See HeaderStyle.
+	BadHeaderStyle ViolationKind = "header-style"
+	// EmptyFile is the kind of violation for a file that is empty, or only
+	// holds whitespace, if the config's empty_files is "error".
+	EmptyFile ViolationKind = "empty-file"
+	// FileTimeout is the kind of violation for a file that was not examined
+	// within Options.FileTimeout.
+	FileTimeout ViolationKind = "file-timeout"
+	// UncoveredFileType is the kind of violation for a type of file that was
+	// examined more than the config's uncovered_types allows without a
+	// language policy. See UncoveredTypes.
+	UncoveredFileType ViolationKind = "uncovered-file-type"
+)
+
+// IsFile returns true if the kind of violation is found by examining a single
+// file, rather than by a project-wide check.
+func (k ViolationKind) IsFile() bool {
+	switch k {
+	case LowCoverage, MissingMetadata, InvalidMetadata, MetadataMismatch, ModifiedLicense, UpstreamError,
+		InternalInExport, UncheckedExport, ExternalLink, MissingLicenseFile, MissingFile, ExternalReference,
+		ConflictingLicenseFiles, QuarantineReference, Incomplete, UncoveredFileType:
+		return false
+	}
+	return true
+}
+
+// fallbackStyles are the comment styles used to find the header of a file
+// with an unrecognized language.
+var fallbackStyles = []language.Language{
+	{LineComment: "//", BlockStart: "/*", BlockEnd: "*/"},
+	{LineComment: "#"},
+	{LineComment: "--"},
+	{LineComment: ";"},
+	{BlockStart: "<!--", BlockEnd: "-->"},
+}
+
+// fingerprint returns a stable identifier for the violation of the given kind
+// in the file at the project relative path. The fingerprint only depends on
+// the path, the kind and the file's leading comment block, so it is unaffected
+// by changes to the rest of the file.
+func fingerprint(path string, kind ViolationKind, body []byte) string {
+	header := sha256.Sum256([]byte(LeadingComment(path, body)))
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%v\n%v\n%x", path, kind, header)))
+	return fmt.Sprintf("%x", sum[:8])
+}
+
+// LeadingComment returns the comment block at the start of body, ignoring any
+// shebang line. Each line is trimmed of surrounding whitespace, and blank lines
+// are dropped, so that the result is independent of line endings and
+// indentation.
+func LeadingComment(path string, body []byte) string {
+	comment, _ := SplitLeadingComment(path, body)
+	return comment
+}
+
+// SplitLeadingComment returns the comment block at the start of body, as
+// described by LeadingComment, and the byte offset of the end of the block in
+// body.
+func SplitLeadingComment(path string, body []byte) (string, int) {
+	styles := fallbackStyles
+	if l, ok := language.Detect(path, func() []byte { return body }); ok && l.HasComments() {
+		styles = []language.Language{l}
+	}
+
+	lines := strings.SplitAfter(string(body), "\n")
+	offset := 0
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "#!") {
+		offset += len(lines[0])
+		lines = lines[1:]
+	}
+
+	out := []string{}
+	blockEnd := "" // the terminator of the block comment being read, if any
+	for _, raw := range lines {
+		line := strings.TrimSpace(raw)
+		switch {
+		case line == "":
+			offset += len(raw)
+			continue
+		case blockEnd != "":
+			if strings.Contains(line, blockEnd) {
+				blockEnd = ""
+			}
+		default:
+			if !isComment(line, styles, &blockEnd) {
+				return strings.Join(out, "\n"), offset
+			}
+		}
+		out = append(out, line)
+		offset += len(raw)
+	}
+	return strings.Join(out, "\n"), offset
+}
+
+// isComment returns true if line starts with a comment in one of the styles.
+// If line opens a block comment that is not closed on the same line, then
+// blockEnd is set to the token that closes it.
+func isComment(line string, styles []language.Language, blockEnd *string) bool {
+	for _, s := range styles {
+		if s.LineComment != "" && strings.HasPrefix(line, s.LineComment) {
+			return true
+		}
+		if s.BlockStart != "" && strings.HasPrefix(line, s.BlockStart) {
+			if !strings.Contains(line[len(s.BlockStart):], s.BlockEnd) {
+				*blockEnd = s.BlockEnd
+			}
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,306 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"../language"
+)
+
+// IsFixable returns true if violations of the kind can be fixed by
+// Results.Fix. NoLicense violations are also fixed, by inserting the header,
+// if the config has a NewFileHeader.
+func (k ViolationKind) IsFixable() bool {
+	return k == StaleHeader || k == SuspiciousCharacters || k == BadHeaderStyle
+}
+
+// FileFix is the fixed content of a file with fixable violations.
+type FileFix struct {
+	Path   string // project relative path of the file
+	Before []byte // content of the file before the fix
+	After  []byte // content of the file after the fix
+	mode   os.FileMode
+	fixed  []Result // the fixable violations of the file
+}
+
+// Fixes returns the fixes of each file under root with a fixable violation,
+// without modifying any file. Invisible and look-alike characters of the
+// leading comment are replaced with their ASCII equivalents, the outdated text
+// of the config's StaleHeaders is replaced, and then the violations of the
+// config's HeaderStyle are fixed. The config's NewFileHeader is inserted into
+// files without a license, after the constructs named by the placement rules
+// of the file's language. Files that the fix would not change are
+// omitted. Fixes are idempotent: fixing the fixed content again changes
+// nothing, which Fixes verifies for every file, returning an error naming
+// the files that would otherwise change again on a second fix.
+func (r Results) Fixes(root string) ([]FileFix, error) {
+	fixes := []FileFix{}
+	byPath := map[string]int{}
+	for _, res := range r {
+		if !res.Kind.IsFixable() && res.insert == nil {
+			continue
+		}
+		idx, ok := byPath[res.Path]
+		if !ok {
+			file := filepath.Join(root, filepath.FromSlash(res.Path))
+			info, err := os.Stat(file)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to fix '%v': %w", res.Path, err)
+			}
+			body, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to fix '%v': %w", res.Path, err)
+			}
+			idx = len(fixes)
+			byPath[res.Path] = idx
+			fixes = append(fixes, FileFix{Path: res.Path, Before: body, After: body, mode: info.Mode()})
+		}
+		f := &fixes[idx]
+		f.After = fixContent(res, f.After)
+		f.fixed = append(f.fixed, res)
+	}
+	out := fixes[:0]
+	errs := []string{}
+	for _, f := range fixes {
+		if bytes.Equal(f.Before, f.After) {
+			continue
+		}
+		if err := f.Verify(); err != nil {
+			errs = append(errs, err.Error())
+		}
+		out = append(out, f)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%v", strings.Join(errs, "\n"))
+	}
+	return out, nil
+}
+
+// maxStalePasses is the maximum number of times that fixContent applies the
+// stale header replacements.
+const maxStalePasses = 16
+
+// fixContent returns the content of the file with the fixable violation res
+// fixed. The stale header replacements are repeated until none applies, so
+// that a replacement whose new value is the old value of an earlier one, such
+// as 'Acme' with 'Globex' after 'Globex' with 'Initech', is fully applied.
+// Replacements that never stop applying, such as 'Acme' with 'Globex Corp'
+// and 'Corp' with 'Acme', are left for FileFix.Verify to report.
+func fixContent(res Result, body []byte) []byte {
+	if res.insert != nil {
+		return res.insert.apply(res.Path, body)
+	}
+	_, end := SplitLeadingComment(res.Path, body)
+	header := toASCII(body[:end])
+	for i := 0; i < maxStalePasses && len(res.stale) > 0; i++ {
+		replaced := header
+		for _, s := range res.stale {
+			replaced = bytes.ReplaceAll(replaced, []byte(s.Old), []byte(s.New))
+		}
+		if bytes.Equal(replaced, header) {
+			break
+		}
+		header = replaced
+	}
+	body = append(header, body[end:]...)
+	if res.style != nil {
+		body = fixHeaderStyle(res.Path, body, *res.style)
+	}
+	return body
+}
+
+// Verify returns an error if fixing the fixed content of the file again would
+// change it, such as with stale headers whose replacements never stop
+// applying, with the diff of the second fix. Verify does not access the file system.
+func (f FileFix) Verify() error {
+	again := f.After
+	for _, res := range f.fixed {
+		again = fixContent(res, again)
+	}
+	if bytes.Equal(again, f.After) {
+		return nil
+	}
+	second := FileFix{Path: f.Path, Before: f.After, After: again}
+	return fmt.Errorf("Fix of '%v' is not idempotent, a second fix would change it:\n%v", EscapePath(f.Path), second.Diff())
+}
+
+// Write writes the fixed content of the file to the project at root. The
+// content is written to a temporary file that then replaces the file, so that
+// an interrupted fix never leaves a truncated file behind. If the file is a
+// symbolic link, the file it links to is rewritten, and the link is kept.
+func (f FileFix) Write(root string) error {
+	file, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(f.Path)))
+	if err != nil {
+		return fmt.Errorf("Failed to fix '%v': %w", f.Path, err)
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file)+".fix-*")
+	if err != nil {
+		return fmt.Errorf("Failed to fix '%v': %w", f.Path, err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+	_, err = tmp.Write(f.After)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), f.mode)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), file)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to fix '%v': %w", f.Path, err)
+	}
+	return nil
+}
+
+// Target returns the '/' separated project relative path of the file that
+// Write rewrites, which is the file it links to if the file is a symbolic
+// link. Target returns an error if that file is outside of the project at
+// root, as it cannot be committed to the project.
+func (f FileFix) Target(root string) (string, error) {
+	root, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("Failed to fix '%v': %w", f.Path, err)
+	}
+	file, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(f.Path)))
+	if err != nil {
+		return "", fmt.Errorf("Failed to fix '%v': %w", f.Path, err)
+	}
+	rel, err := filepath.Rel(root, file)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("Failed to fix '%v': it links to '%v', which is outside of the project", f.Path, file)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// Fix applies the Fixes of the files under root, returning the number of files
+// that were rewritten. The files should be scanned again to obtain the results
+// after the fix.
+func (r Results) Fix(root string) (int, error) {
+	fixes, err := r.Fixes(root)
+	if err != nil {
+		return 0, err
+	}
+	for i, f := range fixes {
+		if err := f.Write(root); err != nil {
+			return i, err
+		}
+	}
+	return len(fixes), nil
+}
+
+// Diff returns the unified diff of the fix, with up to three lines of context
+// around the changed lines. Fixes only rewrite the leading comment of a file,
+// so the diff has a single hunk.
+func (f FileFix) Diff() string {
+	const context = 3
+	a, b := splitLines(f.Before), splitLines(f.After)
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && bytes.Equal(a[prefix], b[prefix]) {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		bytes.Equal(a[len(a)-1-suffix], b[len(b)-1-suffix]) {
+		suffix++
+	}
+	start := prefix - context
+	if start < 0 {
+		start = 0
+	}
+	trailing := suffix
+	if trailing > context {
+		trailing = context
+	}
+	aEnd, bEnd := len(a)-suffix, len(b)-suffix
+
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "--- a/%v\n+++ b/%v\n", f.Path, f.Path)
+	fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", start+1, aEnd+trailing-start, start+1, bEnd+trailing-start)
+	writeLines(&sb, " ", a[start:prefix])
+	writeLines(&sb, "-", a[prefix:aEnd])
+	writeLines(&sb, "+", b[prefix:bEnd])
+	writeLines(&sb, " ", a[aEnd:aEnd+trailing])
+	return sb.String()
+}
+
+// splitLines splits body into lines, each retaining its line terminator.
+func splitLines(body []byte) [][]byte {
+	lines := bytes.SplitAfter(body, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// writeLines writes each of the lines to sb with the given diff prefix.
+func writeLines(sb *strings.Builder, prefix string, lines [][]byte) {
+	for _, l := range lines {
+		sb.WriteString(prefix)
+		sb.Write(l)
+		if !bytes.HasSuffix(l, []byte("\n")) {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// headerInsertion is the header inserted into a file without a license.
+type headerInsertion struct {
+	header    string              // Config.NewFileHeader
+	placement map[string][]string // Config.HeaderPlacement
+}
+
+// apply returns the content of the file at the project relative path with
+// the header inserted as a comment in the file's language, followed by a
+// blank line, and preceded by one if placed after other content. The file is
+// returned unchanged if its language has no comments, or if it already holds
+// the header.
+func (h headerInsertion) apply(path string, body []byte) []byte {
+	lang, ok := language.Detect(path, func() []byte { return body })
+	if !ok {
+		return body
+	}
+	header := strings.ReplaceAll(h.header, "{year}", fmt.Sprint(time.Now().Year()))
+	text, err := comment(header, lang)
+	if err != nil || bytes.Contains(body, bytes.TrimSuffix(text, []byte("\n"))) {
+		return body
+	}
+	rules, ok := h.placement[lang.Name]
+	if !ok {
+		rules = lang.Placement()
+	}
+	offset := language.HeaderOffset(body, rules)
+	before, after := body[:offset], bytes.TrimLeft(body[offset:], "\r\n")
+	out := append([]byte{}, before...)
+	if len(out) > 0 && !bytes.HasSuffix(out, []byte("\n")) {
+		out = append(out, '\n')
+	}
+	if len(out) > 0 && !bytes.HasSuffix(out, []byte("\n\n")) {
+		out = append(out, '\n')
+	}
+	if len(after) == 0 {
+		text = bytes.TrimSuffix(text, []byte("\n"))
+	}
+	out = append(out, text...)
+	return append(out, after...)
+}
@@ -0,0 +1,121 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"../match"
+)
+
+// GeneratedSource maps the files produced by a code generator, such as the Go
+// files generated from protobuf definitions, to the source file each is
+// generated from. Output and Source are path patterns with the same '**', '*'
+// and '?' wildcards, in the same order. The wildcards of Source are replaced
+// with the text matched by the wildcards of Output. For example:
+//
+//	{ "output": "gen/**/*.pb.go", "source": "proto/**/*.proto" }
+//
+// maps 'gen/api/v1/service.pb.go' to 'proto/api/v1/service.proto'.
+type GeneratedSource struct {
+	Output string `json:"output"`
+	Source string `json:"source"`
+
+	mapping match.Mapping
+}
+
+// UnmarshalJSON parses the JSON GeneratedSource, and compiles its patterns.
+func (g *GeneratedSource) UnmarshalJSON(body []byte) error {
+	type fields GeneratedSource
+	f := fields{}
+	if err := json.Unmarshal(body, &f); err != nil {
+		return err
+	}
+	if f.Output == "" || f.Source == "" {
+		return fmt.Errorf("generated_sources: each mapping requires an output and a source pattern")
+	}
+	m, err := match.NewMapping(f.Output, f.Source)
+	if err != nil {
+		return fmt.Errorf("generated_sources: %w", err)
+	}
+	*g = GeneratedSource(f)
+	g.mapping = m
+	return nil
+}
+
+// generatedSource returns the project relative path of the source file that
+// the file at the project relative path is generated from, according to the
+// first of the config's GeneratedSources whose output pattern matches.
+func (c Config) generatedSource(path string) (string, bool) {
+	for _, g := range c.GeneratedSources {
+		if src, ok := g.mapping(path); ok {
+			return src, true
+		}
+	}
+	return "", false
+}
+
+// examineGenerated checks the generated file at the project relative path by
+// examining the source file src instead. The generated file is exempt from the
+// license requirements, but holds the violation of its source, if any.
+func examineGenerated(root, path, src string, cfg Config, cls *classifier, opts Options) Result {
+	res := Result{Path: path, GeneratedFrom: src}
+	display, srcDisplay := opts.DisplayPath(root, path), opts.DisplayPath(root, src)
+	body, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(src)))
+	if err != nil {
+		kind := MissingGeneratorSource
+		res.Kind, res.Fingerprint = kind, fingerprint(path, kind, nil)
+		if os.IsNotExist(err) {
+			res.Err = fmt.Errorf("%v is generated from %v, which does not exist", display, srcDisplay)
+		} else {
+			res.Err = fmt.Errorf("%v is generated from %v, which cannot be read: %w", display, srcDisplay, err)
+		}
+		return res
+	}
+	srcRes := examineContent(src, srcDisplay, body, cfg, cls)
+	res.Licenses, res.ExtraLicenses = srcRes.Licenses, srcRes.ExtraLicenses
+	if srcRes.Err != nil {
+		res.Err = fmt.Errorf("%v is generated from %v, which is not compliant: %w", display, srcDisplay, srcRes.Err)
+		res.Kind, res.Fingerprint = srcRes.Kind, fingerprint(path, srcRes.Kind, body)
+	}
+	return res
+}
+
+// Generated returns the results for the files that were checked by their
+// generator's source file, instead of their own content.
+func (r Results) Generated() Results {
+	out := Results{}
+	for _, res := range r {
+		if res.GeneratedFrom != "" {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// ListGenerated returns a bullet-point list of the generated files that were
+// checked by their source file, with the source of each.
+func (r Results) ListGenerated() string {
+	msg := strings.Builder{}
+	for _, res := range r.Generated() {
+		fmt.Fprintf(&msg, "* %v: generated from %v\n", EscapePath(res.Path), EscapePath(res.GeneratedFrom))
+	}
+	return msg.String()
+}
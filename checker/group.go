@@ -0,0 +1,65 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// dirGroup holds the aggregated results for a single project directory.
+type dirGroup struct {
+	dir        string // project relative directory, using '/' separators
+	files      int    // number of files examined
+	violations int    // number of files with license violations
+}
+
+// String returns a one-line summary of the directory's license health.
+func (g dirGroup) String() string {
+	return fmt.Sprintf("%v: %d/%d files (%.1f%%) have license issues",
+		EscapePath(g.dir), g.violations, g.files, 100*float64(g.violations)/float64(g.files))
+}
+
+// groupByDir aggregates the results by the directory of each file, truncated to
+// at most depth path components. Files in the project root are grouped under
+// the directory '.'. The returned groups are sorted by directory.
+func (r Results) groupByDir(depth int) []dirGroup {
+	groups := map[string]*dirGroup{}
+	for _, res := range r.Files() {
+		dir := path.Dir(filepath.ToSlash(res.Path))
+		if parts := strings.Split(dir, "/"); len(parts) > depth {
+			dir = strings.Join(parts[:depth], "/")
+		}
+		g, ok := groups[dir]
+		if !ok {
+			g = &dirGroup{dir: dir}
+			groups[dir] = g
+		}
+		g.files++
+		if res.Err != nil {
+			g.violations++
+		}
+	}
+
+	out := make([]dirGroup, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].dir < out[j].dir })
+	return out
+}
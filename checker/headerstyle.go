@@ -0,0 +1,177 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"../language"
+)
+
+// HeaderStyle configures the style checks of the license headers. Style
+// violations are always reported as warnings.
+type HeaderStyle struct {
+	// MaxLineLength, if greater than zero, is the maximum number of
+	// characters of each header line. Long lines are not fixed by
+	// Results.Fix, as rewrapping the header may change its meaning.
+	MaxLineLength int `json:"max_line_length"`
+
+	// TrailingWhitespace, if true, reports header lines that end with spaces
+	// or tabs.
+	TrailingWhitespace bool `json:"trailing_whitespace"`
+
+	// CommentMarkers, if true, requires every line comment of the header to
+	// use the same marker as the first, such as '//' rather than '///', and
+	// to separate the marker from any text with a space.
+	CommentMarkers bool `json:"comment_markers"`
+}
+
+// headerLine is a line of the leading comment block of a file.
+type headerLine struct {
+	text   string // the line, without the line ending
+	ending string // the line ending, such as "\n" or "\r\n"
+	marker string // the line comment marker, or empty if not a line comment or a directive
+	indent string // the whitespace before the marker
+}
+
+// splitHeaderLines splits the leading comment block into lines, identifying
+// the line comment markers using the line comment token lc.
+func splitHeaderLines(header []byte, lc string) []headerLine {
+	out := []headerLine{}
+	inBlock := false
+	for _, raw := range strings.SplitAfter(string(header), "\n") {
+		if raw == "" {
+			continue
+		}
+		l := headerLine{text: strings.TrimRight(raw, "\r\n")}
+		l.ending = raw[len(l.text):]
+		trimmed := strings.TrimLeft(l.text, " \t")
+		switch {
+		case inBlock:
+			inBlock = !strings.Contains(trimmed, "*/") && !strings.Contains(trimmed, "-->")
+		case strings.HasPrefix(trimmed, "#!"):
+		case lc != "" && strings.HasPrefix(trimmed, lc):
+			l.indent = l.text[:len(l.text)-len(trimmed)]
+			l.marker = lc
+			for rest := trimmed[len(lc):]; strings.HasPrefix(rest, lc[len(lc)-1:]); rest = rest[1:] {
+				l.marker += lc[len(lc)-1:]
+			}
+			if l.marker == "//" && isDirective(trimmed[2:]) {
+				l.marker = "" // Not a comment, so must keep its form
+			}
+		case strings.HasPrefix(trimmed, "/*") || strings.HasPrefix(trimmed, "<!--"):
+			inBlock = !strings.Contains(trimmed[2:], "*/") && !strings.Contains(trimmed, "-->")
+		}
+		out = append(out, l)
+	}
+	return out
+}
+
+// isDirective returns true if the text of a '//' line comment, after the
+// marker, is a tool directive, such as 'go:build linux' or 'export F', which
+// tools only recognize without a space after the marker. As go/ast, a
+// directive is 'line ', 'extern ' or 'export ', or lower-case letters and
+// digits followed by a colon and a lower-case letter or digit.
+func isDirective(text string) bool {
+	for _, prefix := range []string{"line ", "extern ", "export "} {
+		if strings.HasPrefix(text, prefix) {
+			return true
+		}
+	}
+	colon := strings.Index(text, ":")
+	if colon <= 0 || colon+1 >= len(text) {
+		return false
+	}
+	for i := 0; i <= colon+1; i++ {
+		if c := text[i]; i != colon && !('a' <= c && c <= 'z' || '0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// lineCommentToken returns the line comment token of the file at the project
+// relative path, or an empty string if the file's language has none.
+func lineCommentToken(path string, body []byte) string {
+	if l, ok := language.Detect(path, func() []byte { return body }); ok && l.HasComments() {
+		return l.LineComment
+	}
+	first := strings.TrimSpace(strings.SplitN(LeadingComment(path, body), "\n", 2)[0])
+	for _, s := range fallbackStyles {
+		if s.LineComment != "" && strings.HasPrefix(first, s.LineComment) {
+			return s.LineComment
+		}
+	}
+	return ""
+}
+
+// checkHeaderStyle returns an error describing the first style violation of
+// the leading comment of the file, or nil if the header follows the style.
+func checkHeaderStyle(path, display string, body []byte, style HeaderStyle) error {
+	_, end := SplitLeadingComment(path, body)
+	expect := ""
+	for i, l := range splitHeaderLines(body[:end], lineCommentToken(path, body)) {
+		n := i + 1
+		if style.TrailingWhitespace && strings.TrimRight(l.text, " \t") != l.text {
+			return fmt.Errorf("%v header has trailing whitespace on line %d", display, n)
+		}
+		if c := utf8.RuneCountInString(l.text); style.MaxLineLength > 0 && c > style.MaxLineLength {
+			return fmt.Errorf("%v header line %d is %d characters long, longer than %d", display, n, c, style.MaxLineLength)
+		}
+		if !style.CommentMarkers || l.marker == "" {
+			continue
+		}
+		if expect == "" {
+			expect = l.marker
+		}
+		rest := strings.TrimLeft(l.text, " \t")[len(l.marker):]
+		switch {
+		case l.marker != expect:
+			return fmt.Errorf("%v header line %d uses comment marker '%v', expected '%v'", display, n, l.marker, expect)
+		case rest != "" && !strings.HasPrefix(rest, " ") && !strings.HasPrefix(rest, "\t"):
+			return fmt.Errorf("%v header line %d has no space after the comment marker '%v'", display, n, l.marker)
+		}
+	}
+	return nil
+}
+
+// fixHeaderStyle returns the content of the file at the project relative path
+// with the trailing whitespace of the leading comment removed, and its line
+// comment markers made consistent, as enabled by the style.
+func fixHeaderStyle(path string, body []byte, style HeaderStyle) []byte {
+	_, end := SplitLeadingComment(path, body)
+	sb := strings.Builder{}
+	expect := ""
+	for _, l := range splitHeaderLines(body[:end], lineCommentToken(path, body)) {
+		text := l.text
+		if style.CommentMarkers && l.marker != "" {
+			if expect == "" {
+				expect = l.marker
+			}
+			rest := strings.TrimLeft(text, " \t")[len(l.marker):]
+			if rest != "" && !strings.HasPrefix(rest, " ") && !strings.HasPrefix(rest, "\t") {
+				rest = " " + rest
+			}
+			text = l.indent + expect + rest
+		}
+		if style.TrailingWhitespace {
+			text = strings.TrimRight(text, " \t")
+		}
+		sb.WriteString(text + l.ending)
+	}
+	return append([]byte(sb.String()), body[end:]...)
+}
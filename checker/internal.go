@@ -0,0 +1,105 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"../match"
+)
+
+// InternalLicense is the identifier of the pseudo-license of files that carry
+// an Internal marker.
+const InternalLicense = "Internal-Only"
+
+// Internal configures the Internal-Only pseudo-license of proprietary files.
+// Files with a leading comment that holds one of the Markers are reported as
+// using the InternalLicense. Files matching Paths must carry a marker, and
+// must not carry any other license.
+type Internal struct {
+	// Markers is the list of notices that identify an internal file. Markers
+	// are matched by their words, ignoring case, punctuation, whitespace and
+	// comment tokens.
+	// Defaults to "Internal-Only".
+	Markers []string `json:"markers"`
+
+	// Paths is the list of path patterns, using the same syntax as the
+	// Config's paths, of the files that must be internal. Defaults to every
+	// examined file.
+	Paths []string `json:"paths"`
+
+	tests []match.Test // the compiled Paths
+}
+
+// defaultInternalMarkers is the default value of Internal.Markers.
+var defaultInternalMarkers = []string{InternalLicense}
+
+// UnmarshalJSON parses the Internal config, compiling its path patterns.
+func (i *Internal) UnmarshalJSON(body []byte) error {
+	type parsed Internal
+	p := parsed{}
+	if err := json.Unmarshal(body, &p); err != nil {
+		return err
+	}
+	*i = Internal(p)
+	for _, pattern := range i.Paths {
+		test, err := match.New(pattern)
+		if err != nil {
+			return fmt.Errorf("internal: invalid path pattern '%v': %w", pattern, err)
+		}
+		i.tests = append(i.tests, test)
+	}
+	return nil
+}
+
+// covers returns true if the file at the project relative path must be
+// internal.
+func (i *Internal) covers(path string) bool {
+	if len(i.tests) == 0 {
+		return true
+	}
+	for _, test := range i.tests {
+		if test(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// marked returns true if the leading comment of the file holds a marker.
+func (i *Internal) marked(path string, body []byte) bool {
+	header := words(LeadingComment(path, body))
+	markers := i.Markers
+	if len(markers) == 0 {
+		markers = defaultInternalMarkers
+	}
+	for _, m := range markers {
+		if strings.Contains(header, words(m)) {
+			return true
+		}
+	}
+	return false
+}
+
+// wordRE matches a single word.
+var wordRE = regexp.MustCompile(`[a-z0-9]+`)
+
+// words returns the lower-case words of s, each surrounded by single spaces.
+func words(s string) string {
+	return " " + strings.Join(wordRE.FindAllString(strings.ToLower(s), -1), " ") + " "
+}
@@ -0,0 +1,97 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"../imports"
+)
+
+// ParseManifest parses the content of an export manifest file, which lists
+// the project relative paths of the files to export, one per line. Blank lines
+// and lines starting with '#' are ignored.
+func ParseManifest(body []byte) []string {
+	out := []string{}
+	for _, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		out = append(out, path.Clean(filepath.ToSlash(line)))
+	}
+	return out
+}
+
+// VerifyManifest scans the project in dir, and returns the results for the
+// files listed by the manifest, which are to be exported as an open source
+// release. As with VerifyExport, each file must be examined by a config, must
+// pass the check and must not be internal. Additionally, the files must not
+// #include or import any file of the project that is not in the manifest. See
+// the imports package for the supported languages.
+func VerifyManifest(dir string, manifest []string, opts Options) (Results, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get absolute working directory: %w", err)
+	}
+	listed := map[string]bool{}
+	for _, rel := range manifest {
+		listed[rel] = true
+	}
+
+	e, err := newExportCheck(root, opts, func(rel string) bool { return listed[rel] })
+	if err != nil {
+		return nil, err
+	}
+	exists := func(rel string) bool {
+		info, err := os.Stat(filepath.Join(root, filepath.FromSlash(rel)))
+		return err == nil && !info.IsDir()
+	}
+
+	for _, rel := range manifest {
+		body, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+		if os.IsNotExist(err) {
+			e.violation(rel, MissingFile, fmt.Errorf("%v is listed in the manifest, but does not exist", e.opts.DisplayPath(root, rel)))
+			continue
+		} else if err != nil {
+			e.violation(rel, MissingFile, fmt.Errorf("%v is listed in the manifest, but cannot be read: %w", e.opts.DisplayPath(root, rel), err))
+			continue
+		}
+		e.checkFile(rel)
+		for _, ref := range imports.Find(rel, body) {
+			for _, candidate := range ref.Candidates {
+				if !exists(candidate) {
+					continue
+				}
+				if !listed[candidate] {
+					e.out = append(e.out, Result{
+						Path: rel,
+						Err: fmt.Errorf("%v references '%v' (%v), which is not in the manifest",
+							e.opts.DisplayPath(root, rel), ref.Spec, e.opts.DisplayPath(root, candidate)),
+						Kind:        ExternalReference,
+						Fingerprint: fingerprint(rel+" "+candidate, ExternalReference, nil),
+					})
+				}
+				break
+			}
+		}
+	}
+	return e.out, nil
+}
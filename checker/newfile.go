@@ -0,0 +1,115 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"../detector"
+	"../language"
+)
+
+// NewFile returns the content of a new file at the project relative path,
+// which holds the license header required of the file by the first config
+// that examines it, written in the comment syntax of lang. The header is the
+// config's NewFileHeader, with '{year}' replaced by year, or, if the file may
+// carry an SPDX tag instead, the SPDX-License-Identifier of the first of the
+// config's licenses. NewFile returns an error if no config examines the file,
+// if the header of the file is unknown, or if the new file would not pass the
+// check. Files that require no license are empty, unless the config has a
+// NewFileHeader.
+func (p *Policy) NewFile(relPath string, lang language.Language, year int) ([]byte, error) {
+	relPath = strings.TrimPrefix(path.Clean(strings.ReplaceAll(relPath, "\\", "/")), "/")
+	var cfg *Config
+	reason := "no config"
+	for i := range p.Configs {
+		ok, why := p.Configs[i].shouldExamineFile(&candidate{path: relPath, read: true})
+		if ok {
+			cfg = &p.Configs[i]
+			break
+		}
+		reason = why
+	}
+	if cfg == nil {
+		return nil, fmt.Errorf("%v is not checked (%v), so it needs no license header", relPath, reason)
+	}
+
+	policy := LanguagePolicy{Require: RequireHeader}
+	if lp, ok := cfg.LanguagePolicies[lang.Name]; ok {
+		policy = lp
+		if policy.Require == "" {
+			policy.Require = RequireHeader
+		}
+	}
+	header := ""
+	switch {
+	case cfg.NewFileHeader != "":
+		header = strings.ReplaceAll(cfg.NewFileHeader, "{year}", fmt.Sprint(year))
+	case policy.Require == RequireNone:
+		return []byte{}, nil
+	case policy.Require == RequireSPDX:
+		licenses := policy.Licenses
+		if len(licenses) == 0 {
+			licenses = cfg.Licenses
+		}
+		for _, l := range licenses {
+			switch detector.Category(strings.ToLower(strings.TrimSpace(l))) {
+			case detector.Permissive, detector.WeakCopyleft, detector.StrongCopyleft, detector.PublicDomain:
+				continue // Permits a category of licenses, not a single license
+			}
+			header = "SPDX-License-Identifier: " + detector.Normalize(l)
+			break
+		}
+		if header == "" {
+			return nil, fmt.Errorf("configs[%d] permits no license for %v", cfg.index, relPath)
+		}
+	default:
+		return nil, fmt.Errorf("configs[%d] has no new_file_header, so the license header of %v is unknown", cfg.index, relPath)
+	}
+
+	body, err := comment(header, lang)
+	if err != nil {
+		return nil, err
+	}
+	if failures := p.CheckContent(relPath, body).Errs(); len(failures) > 0 {
+		return nil, fmt.Errorf("The new file would not pass the check: %w", failures[0])
+	}
+	return body, nil
+}
+
+// comment returns the lines of text as the comment block of a file of lang,
+// followed by a blank line. Line comments are preferred over block comments.
+func comment(text string, lang language.Language) ([]byte, error) {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	sb := strings.Builder{}
+	switch {
+	case lang.LineComment != "":
+		for _, l := range lines {
+			sb.WriteString(strings.TrimRight(lang.LineComment+" "+l, " ") + "\n")
+		}
+	case lang.BlockStart != "":
+		sb.WriteString(lang.BlockStart + "\n")
+		for _, l := range lines {
+			sb.WriteString(strings.TrimRight("  "+l, " ") + "\n")
+		}
+		sb.WriteString(lang.BlockEnd + "\n")
+	default:
+		return nil, fmt.Errorf("%v files cannot hold a license header, as they have no comments", lang.Name)
+	}
+	sb.WriteString("\n")
+	return []byte(sb.String()), nil
+}
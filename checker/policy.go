@@ -0,0 +1,229 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"../detector"
+	"../language"
+)
+
+// Requirement is the enumerator of license declarations a file must carry.
+type Requirement string
+
+const (
+	// RequireHeader requires a license that is detected by the detector, such
+	// as a full license header. This is the default.
+	RequireHeader Requirement = "header"
+	// RequireSPDX additionally accepts an SPDX-License-Identifier tag line in
+	// place of a full license header.
+	RequireSPDX Requirement = "spdx"
+	// RequireNone does not require any license. Any licenses that are found
+	// must still be permitted.
+	RequireNone Requirement = "none"
+)
+
+// EmptyFilePolicy is the enumerator of the ways files that are empty, or only
+// hold whitespace, are checked.
+type EmptyFilePolicy string
+
+const (
+	// EmptyRequireHeader checks empty files like any other file, so they are
+	// reported as having no license. This is the default.
+	EmptyRequireHeader EmptyFilePolicy = "require_header"
+	// EmptyIgnore accepts empty files without a license.
+	EmptyIgnore EmptyFilePolicy = "ignore"
+	// EmptyError reports every empty file as an EmptyFile violation.
+	EmptyError EmptyFilePolicy = "error"
+)
+
+// MinContent holds the size below which files are exempt from the license
+// requirement. See Config.MinLines.
+type MinContent struct {
+	MinLines int `json:"min_lines"` // minimum number of non-blank lines
+	MinBytes int `json:"min_bytes"` // minimum number of bytes
+}
+
+// minContentRule returns the Decision rule that exempts the file at the
+// project relative path from the license requirement, and true, if the file is
+// smaller than the config's minimum content.
+func (c Config) minContentRule(path string, body []byte) (string, bool) {
+	setting, min := "", MinContent{MinLines: c.MinLines, MinBytes: c.MinBytes}
+	ext := strings.ToLower(filepath.Ext(path))
+	if m, ok := c.MinContentByExtension[ext]; ok {
+		setting, min = fmt.Sprintf("min_content_by_extension[%v].", ext), m
+	}
+	if min.MinBytes > 0 && len(body) < min.MinBytes {
+		return fmt.Sprintf("%vmin_bytes: %d", setting, min.MinBytes), true
+	}
+	if min.MinLines > 0 {
+		lines := 0
+		for _, l := range bytes.Split(body, []byte("\n")) {
+			if len(bytes.TrimSpace(l)) > 0 {
+				lines++
+			}
+		}
+		if lines < min.MinLines {
+			return fmt.Sprintf("%vmin_lines: %d", setting, min.MinLines), true
+		}
+	}
+	return "", false
+}
+
+// LanguagePolicy overrides the license requirements for files of a single
+// language.
+type LanguagePolicy struct {
+	// Require is the license declaration that files of the language must
+	// carry. One of "header" (default), "spdx" or "none".
+	Require Requirement
+
+	// Licenses, if not empty, replaces the Config's Licenses for files of the
+	// language.
+	Licenses []string
+
+	// language is the name of the language the policy applies to, or empty
+	// for the default policy.
+	language string
+}
+
+// allowsLicense returns true if the license type with the given name is
+// permitted by the policy, falling back to the Config's licenses if the policy
+// does not declare any.
+func (p LanguagePolicy) allowsLicense(cfg Config, name string) bool {
+	_, ok := p.licenseRule(cfg, name)
+	return ok
+}
+
+// setting returns the name of the config setting of the policy, as recorded by
+// Decision.Rules.
+func (p LanguagePolicy) setting() string {
+	if p.language == "" {
+		return "default"
+	}
+	return "language_policies." + p.language
+}
+
+// source returns the name of the config setting that holds the policy's
+// licenses, as recorded by Decision.Rules.
+func (p LanguagePolicy) source() string {
+	if len(p.Licenses) == 0 {
+		return "licenses"
+	}
+	return p.setting()
+}
+
+// licenseRule returns the Decision rule that permits the license type with the
+// given name, and true, or the rule that denies it and false.
+func (p LanguagePolicy) licenseRule(cfg Config, name string) (string, bool) {
+	licenses := p.Licenses
+	if len(licenses) == 0 {
+		licenses = cfg.Licenses
+	}
+	for _, l := range licenses {
+		if detector.Matches(l, name) {
+			return fmt.Sprintf("%v: %v", p.source(), l), true
+		}
+	}
+	return fmt.Sprintf("%v: denied %v", p.source(), name), false
+}
+
+// languagePolicy returns the LanguagePolicy for the file at the project
+// relative path with the given content. If the file's language has no policy,
+// then languagePolicy returns a policy that requires a license header.
+func (c Config) languagePolicy(path string, body []byte) LanguagePolicy {
+	if len(c.LanguagePolicies) > 0 {
+		l, ok := c.buildLanguage(path)
+		if !ok {
+			l, ok = language.Detect(path, func() []byte { return body })
+		}
+		if ok {
+			if p, ok := c.LanguagePolicies[l.Name]; ok {
+				if p.Require == "" {
+					p.Require = RequireHeader
+				}
+				p.language = l.Name
+				return p
+			}
+		}
+	}
+	return LanguagePolicy{Require: RequireHeader}
+}
+
+// validate returns an error if the config contains invalid settings.
+func (c Config) validate() error {
+	if _, err := detector.New(c.Detector, nil); err != nil {
+		return err
+	}
+	if c.MinCoverage < 0 || c.MinCoverage > 100 {
+		return fmt.Errorf("min_coverage must be between 0 and 100, got %v", c.MinCoverage)
+	}
+	if c.UncoveredTypes != nil {
+		if err := c.UncoveredTypes.validate(); err != nil {
+			return err
+		}
+	}
+	for name, p := range c.LanguagePolicies {
+		if _, ok := language.ByName(name); !ok {
+			return fmt.Errorf("language_policies: unknown language '%v'", name)
+		}
+		switch p.Require {
+		case "", RequireHeader, RequireSPDX, RequireNone:
+		default:
+			return fmt.Errorf("language_policies: '%v' has unknown require value '%v'. Must be one of 'header', 'spdx' or 'none'", name, p.Require)
+		}
+	}
+	for name, rules := range c.HeaderPlacement {
+		if _, ok := language.ByName(name); !ok {
+			return fmt.Errorf("header_placement: unknown language '%v'", name)
+		}
+		for _, rule := range rules {
+			if !language.IsPlacementRule(rule) {
+				return fmt.Errorf("header_placement: '%v' has unknown rule '%v'", name, rule)
+			}
+		}
+	}
+	if c.MaxDepth < 0 || c.MaxFiles < 0 {
+		return fmt.Errorf("max_depth and max_files must not be negative")
+	}
+	if c.MinLines < 0 || c.MinBytes < 0 {
+		return fmt.Errorf("min_lines and min_bytes must not be negative")
+	}
+	for ext, m := range c.MinContentByExtension {
+		if !strings.HasPrefix(ext, ".") || ext != strings.ToLower(ext) {
+			return fmt.Errorf("min_content_by_extension: '%v' must be a lowercase extension starting with '.'", ext)
+		}
+		if m.MinLines < 0 || m.MinBytes < 0 {
+			return fmt.Errorf("min_content_by_extension: '%v' min_lines and min_bytes must not be negative", ext)
+		}
+	}
+	switch c.EmptyFiles {
+	case "", EmptyRequireHeader, EmptyIgnore, EmptyError:
+	default:
+		return fmt.Errorf("empty_files has unknown value '%v'. Must be one of 'ignore', 'require_header' or 'error'", c.EmptyFiles)
+	}
+	if err := c.validateQuarantine(); err != nil {
+		return err
+	}
+	if c.Risk != nil {
+		if err := c.Risk.validate(); err != nil {
+			return err
+		}
+	}
+	return c.validateStaleHeaders()
+}
@@ -0,0 +1,166 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultPolicyTestsFileName is the file name of the policy tests, stored next
+// to the config file.
+const DefaultPolicyTestsFileName = "license-checker-tests.json"
+
+// Expectations of a PolicyTest.
+const (
+	// ExpectScanned expects the file to be examined by a config, without a
+	// violation.
+	ExpectScanned = "scanned"
+	// ExpectSkipped expects the file to not be examined by any config.
+	ExpectSkipped = "skipped"
+	// ExpectViolation, followed by a ViolationKind, such as
+	// "violation:no-license", expects the file to have a violation of the
+	// kind.
+	ExpectViolation = "violation:"
+)
+
+// PolicyTests is a set of expectations of how the configs treat files, which
+// is run by Policy.Test to validate changes to the configs.
+//
+// The policy tests file is a JSON object of the form:
+//
+//	{
+//	  "tests": [
+//	    { "path": "src/main.go", "expect": "scanned" },
+//	    { "path": "out/gen.go", "expect": "skipped" },
+//	    { "path": "src/mit.c", "expect": "violation:unsupported-license",
+//	      "content": "// Permission is hereby granted, free of charge, ..." }
+//	  ]
+//	}
+type PolicyTests struct {
+	Tests []PolicyTest `json:"tests"`
+}
+
+// PolicyTest is a single expectation of PolicyTests.
+type PolicyTest struct {
+	// Path is the project relative path of the file.
+	Path string `json:"path"`
+	// Expect is one of ExpectScanned, ExpectSkipped, or ExpectViolation
+	// followed by a ViolationKind.
+	Expect string `json:"expect"`
+	// Content, if not nil, is the content of the file to check. Otherwise the
+	// file is read from the project, or is empty if it does not exist.
+	Content *string `json:"content"`
+}
+
+// LoadPolicyTests loads and validates the policy tests file at path.
+func LoadPolicyTests(path string) (PolicyTests, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return PolicyTests{}, fmt.Errorf("Failed to read policy tests: %w", err)
+	}
+	t := PolicyTests{}
+	if err := json.Unmarshal(body, &t); err != nil {
+		return PolicyTests{}, fmt.Errorf("Failed to parse policy tests '%v': %w", path, err)
+	}
+	if len(t.Tests) == 0 {
+		return PolicyTests{}, fmt.Errorf("Policy tests '%v' has no tests", path)
+	}
+	for i, test := range t.Tests {
+		if test.Path == "" {
+			return PolicyTests{}, fmt.Errorf("tests[%d]: path is empty", i)
+		}
+		switch {
+		case test.Expect == ExpectScanned, test.Expect == ExpectSkipped:
+		case strings.HasPrefix(test.Expect, ExpectViolation) && len(test.Expect) > len(ExpectViolation):
+		default:
+			return PolicyTests{}, fmt.Errorf("tests[%d]: unknown expect '%v'. Must be one of: %v, %v, %v<kind>",
+				i, test.Expect, ExpectScanned, ExpectSkipped, ExpectViolation)
+		}
+	}
+	return t, nil
+}
+
+// Test runs the policy tests against the policy, checking the files of the
+// project at root, and returns an error for each test that fails.
+func (p *Policy) Test(root string, tests PolicyTests) []error {
+	errs := []error{}
+	for i, test := range tests.Tests {
+		var body []byte
+		if test.Content != nil {
+			body = []byte(*test.Content)
+		} else if b, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(test.Path))); err == nil {
+			body = b
+		} else if !os.IsNotExist(err) {
+			errs = append(errs, fmt.Errorf("tests[%d] %v: %w", i, test.Path, err))
+			continue
+		}
+		got, detail := outcome(p.CheckContent(test.Path, body))
+		passed := got == test.Expect
+		if !passed && strings.HasPrefix(test.Expect, ExpectViolation) {
+			for _, kind := range strings.Split(strings.TrimPrefix(got, ExpectViolation), ",") {
+				passed = passed || ExpectViolation+kind == test.Expect
+			}
+		}
+		if !passed {
+			errs = append(errs, fmt.Errorf("tests[%d] %v: expected %v, got %v (%v)", i, test.Path, test.Expect, got, detail))
+		}
+	}
+	return errs
+}
+
+// outcome returns the PolicyTest expectation met by the results of a file,
+// with the violation kinds separated by commas, and a description of the
+// results.
+func outcome(results Results) (string, string) {
+	if len(results) == 1 && results[0].Skipped != "" {
+		return ExpectSkipped, results[0].Skipped
+	}
+	kinds, errs := []string{}, []string{}
+	for _, res := range results {
+		if res.Err != nil {
+			kinds = append(kinds, string(res.Kind))
+			errs = append(errs, res.Err.Error())
+		}
+	}
+	if len(kinds) == 0 {
+		return ExpectScanned, fmt.Sprintf("licenses: %v", results.licenses())
+	}
+	return ExpectViolation + strings.Join(kinds, ","), strings.Join(errs, "; ")
+}
+
+// licenses returns the sorted, distinct licenses of the results, separated by
+// commas, or "none" if there are none.
+func (r Results) licenses() string {
+	seen, out := map[string]bool{}, []string{}
+	for _, res := range r {
+		for _, l := range res.Licenses {
+			if !seen[l] {
+				seen[l] = true
+				out = append(out, l)
+			}
+		}
+	}
+	if len(out) == 0 {
+		return "none"
+	}
+	sort.Strings(out)
+	return strings.Join(out, ", ")
+}
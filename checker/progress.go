@@ -0,0 +1,175 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Progress records the files that a scan has verified as compliant in a
+// progress file, as they are examined, so that a scan that is interrupted, or
+// that stops at Options.Deadline, can be resumed without examining those
+// files again. A file is only skipped on resume if its content and the config
+// that examined it are unchanged. Files are recorded by their '/' separated
+// project relative path, and the hash of their content with CRLF line endings
+// normalized to LF, so a progress file can be resumed from another checkout of
+// the project, even on another OS.
+type Progress struct {
+	path     string
+	mutex    sync.Mutex
+	file     *os.File
+	verified map[string]progressEntry // keyed by progressEntry.key()
+}
+
+// progressEntry is a single line of the progress file.
+type progressEntry struct {
+	Path          string    `json:"path"`   // project relative path of the file
+	Config        string    `json:"config"` // digest of the config that examined the file
+	SHA256        string    `json:"sha256"` // hash of the file content, see hashContent
+	Licenses      []string  `json:"licenses"`
+	ExtraLicenses []string  `json:"extra_licenses,omitempty"`
+	Decision      *Decision `json:"decision,omitempty"`
+	LowConfidence bool      `json:"low_confidence,omitempty"`
+}
+
+// key returns the key of the entry in Progress.verified.
+func (e progressEntry) key() string {
+	return e.Path + "\n" + e.Config + "\n" + e.SHA256
+}
+
+// OpenProgress opens the progress file at path. If resume is true, the files
+// recorded by the file are loaded, and new files are appended to it,
+// otherwise the file is truncated. A truncated last line, as written by an
+// interrupted scan, is ignored.
+func OpenProgress(path string, resume bool) (*Progress, error) {
+	p := &Progress{path: path, verified: map[string]progressEntry{}}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if resume {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		if f, err := os.Open(path); err == nil {
+			s := bufio.NewScanner(f)
+			s.Buffer(nil, 1<<20)
+			for s.Scan() {
+				e := progressEntry{}
+				if err := json.Unmarshal(s.Bytes(), &e); err == nil {
+					p.verified[e.key()] = e
+				}
+			}
+			f.Close()
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("Failed to read progress file: %w", err)
+		}
+	}
+	f, err := os.OpenFile(path, flags, 0666)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open progress file: %w", err)
+	}
+	p.file = f
+	return p, nil
+}
+
+// Verified returns the number of files loaded from the progress file.
+func (p *Progress) Verified() int {
+	return len(p.verified)
+}
+
+// Close closes the progress file.
+func (p *Progress) Close() error {
+	return p.file.Close()
+}
+
+// Remove closes and deletes the progress file, once a scan has completed.
+func (p *Progress) Remove() error {
+	p.Close()
+	return os.Remove(p.path)
+}
+
+// lookup returns the recorded result of the file at the project relative path,
+// with the given content, examined by the config with the given digest.
+func (p *Progress) lookup(path, config string, body []byte) (Result, bool) {
+	e, ok := p.verified[progressEntry{Path: path, Config: config, SHA256: hashContent(body)}.key()]
+	if !ok {
+		return Result{}, false
+	}
+	return Result{
+		Licenses:      e.Licenses,
+		ExtraLicenses: e.ExtraLicenses,
+		Decision:      e.Decision,
+		lowConfidence: e.LowConfidence,
+	}, true
+}
+
+// record appends the compliant result of the file at the project relative
+// path, with the given content, examined by the config with the given digest,
+// to the progress file.
+func (p *Progress) record(path, config string, body []byte, res Result) error {
+	if res.Err != nil {
+		return nil
+	}
+	line, err := json.Marshal(progressEntry{
+		Path:          path,
+		Config:        config,
+		SHA256:        hashContent(body),
+		Licenses:      res.Licenses,
+		ExtraLicenses: res.ExtraLicenses,
+		Decision:      res.Decision,
+		LowConfidence: res.lowConfidence,
+	})
+	if err != nil {
+		return err
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	_, err = p.file.Write(append(line, '\n'))
+	return err
+}
+
+// hashContent returns the hex SHA-256 of the file content, with CRLF line
+// endings normalized to LF, so that the hash is the same for checkouts with
+// either line ending.
+func hashContent(body []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(bytes.ReplaceAll(body, []byte("\r\n"), []byte("\n"))))
+}
+
+// progressDigest returns the digest of the config, of the path of the file
+// that holds it, and of the scan options that affect the licenses detected by
+// it, identifying the config in the progress file. The license database is
+// identified by its content, rather than its path, which may differ between
+// checkouts. "" is returned if the digest cannot be computed, which turns off
+// progress recording and lookup for the config.
+func progressDigest(cfg Config, opts Options) string {
+	body, err := json.Marshal(cfg)
+	if err != nil {
+		return ""
+	}
+	db := ""
+	if opts.LicenseDB != "" {
+		content, err := ioutil.ReadFile(opts.LicenseDB)
+		if err != nil {
+			return ""
+		}
+		db = hashContent(content)
+	}
+	sum := sha256.Sum256([]byte(string(body) + "\n" + strings.Join(cfg.extraLicenses, ",") + "\n" + db + "\n" + cfg.source))
+	return fmt.Sprintf("%x", sum[:8])
+}
@@ -0,0 +1,270 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ProjectStatus summarizes the results of a single project of a scan that
+// covers several projects, such as the submodules of a superproject or the
+// roots of a Workspace.
+type ProjectStatus struct {
+	Path     string // the Result.Project of the project's results
+	Errors   int    // number of violations failing the check
+	Warnings int    // number of advisory violations
+}
+
+// Passed returns true if the project has no violations that fail the check.
+func (s ProjectStatus) Passed() bool { return s.Errors == 0 }
+
+// String returns a one-line summary of the project's status.
+func (s ProjectStatus) String() string {
+	status := "PASS"
+	if !s.Passed() {
+		status = "FAIL"
+	}
+	path := EscapePath(s.Path)
+	if path == "" {
+		path = "."
+	}
+	return fmt.Sprintf("%v: %v (%d errors, %d warnings)", path, status, s.Errors, s.Warnings)
+}
+
+// Projects returns the status of each project of the results, in the order
+// they were scanned. Projects returns nil if the results are of a single
+// project.
+func (r Results) Projects(opts Options) []ProjectStatus {
+	out := []ProjectStatus{}
+	index := map[string]int{}
+	for _, res := range r {
+		i, ok := index[res.Project]
+		if !ok {
+			i = len(out)
+			index[res.Project] = i
+			out = append(out, ProjectStatus{Path: res.Project})
+		}
+		if res.Err != nil {
+			if opts.WarnOnly || res.Advisory {
+				out[i].Warnings++
+			} else {
+				out[i].Errors++
+			}
+		}
+	}
+	if len(out) < 2 && (len(out) == 0 || out[0].Path == "") {
+		return nil
+	}
+	return out
+}
+
+// findSubprojects returns the project relative paths of the directories under
+// root that hold their own config file, without descending into them. Version
+// control and hidden directories are not searched.
+func findSubprojects(root string, opts Options) (map[string]bool, error) {
+	out := map[string]bool{}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() || path == root {
+			return nil
+		}
+		if name := info.Name(); vcsDirs[name] || strings.HasPrefix(name, ".") {
+			return filepath.SkipDir
+		}
+		if _, err := os.Stat(filepath.Join(path, opts.ConfigFile())); err == nil {
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			out[filepath.ToSlash(rel)] = true
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to search for submodules: %w", err)
+	}
+	return out, nil
+}
+
+// projectManifests is the set of file names of package manifests and build
+// files that mark the root directory of a project for
+// Options.DiscoverProjects.
+var projectManifests = map[string]bool{
+	"build.gradle":     true,
+	"build.gradle.kts": true,
+	"Cargo.toml":       true,
+	"composer.json":    true,
+	"Gemfile":          true,
+	"go.mod":           true,
+	"mix.exs":          true,
+	"package.json":     true,
+	"Package.swift":    true,
+	"pom.xml":          true,
+	"pubspec.yaml":     true,
+	"pyproject.toml":   true,
+	"setup.py":         true,
+}
+
+// discoverProjects returns the project relative paths of the directories
+// under root that hold a license file or a package manifest. Unlike
+// findSubprojects, discovered projects may be nested. Version control and
+// hidden directories, the directories that every config excludes, and the
+// subprojects scanned with their own config, are not searched. If
+// opts.ContinueOnError is true, the paths that cannot be read are skipped, as
+// gatherFiles reports them.
+func discoverProjects(root string, cfgs Configs, opts Options) ([]string, error) {
+	found := map[string]bool{}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if !opts.ContinueOnError || path == root {
+				return err
+			}
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() {
+			if name := info.Name(); isLicenseFile(name) || projectManifests[name] {
+				dir, err := filepath.Rel(root, filepath.Dir(path))
+				if err != nil {
+					return err
+				}
+				if dir != "." {
+					found[filepath.ToSlash(dir)] = true
+				}
+			}
+			return nil
+		}
+		if path == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if name := info.Name(); vcsDirs[name] || strings.HasPrefix(name, ".") || opts.subprojects[rel] || cfgs.excludeDir(rel) {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to discover projects: %w", err)
+	}
+	out := make([]string, 0, len(found))
+	for dir := range found {
+		out = append(out, dir)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// excludeDir returns true if every config excludes the project relative
+// directory dir.
+func (c Configs) excludeDir(dir string) bool {
+	for _, cfg := range c {
+		if excluded, _ := cfg.excludesDir(dir); !excluded {
+			return false
+		}
+	}
+	return len(c) > 0
+}
+
+// withProject returns the result with its Project set to the deepest of the
+// discovered projects that holds the result's path, if the result is of the
+// root project.
+func (o Options) withProject(res Result) Result {
+	if res.Project != "" {
+		return res
+	}
+	for _, dir := range o.projects {
+		if strings.HasPrefix(res.Path, dir+"/") && len(dir) > len(res.Project) {
+			res.Project = dir
+		}
+	}
+	return res
+}
+
+// assignProjects sets the Project of each of the results with withProject.
+func (r Results) assignProjects(opts Options) {
+	for i, res := range r {
+		r[i] = opts.withProject(res)
+	}
+}
+
+// scanSubprojects scans each of the subprojects of root with its own config,
+// returning the results nested under the parent project.
+func scanSubprojects(root string, subprojects map[string]bool, opts Options) (Results, error) {
+	dirs := make([]string, 0, len(subprojects))
+	for rel := range subprojects {
+		dirs = append(dirs, rel)
+	}
+	sort.Strings(dirs)
+
+	out := Results{}
+	for _, rel := range dirs {
+		subOpts := opts.nested(rel)
+		subOpts.prefix = opts.prefix + rel + "/"
+		if opts.BuildManifest != nil {
+			subOpts.BuildManifest = opts.BuildManifest.under(rel)
+		}
+		results, err := Scan(filepath.Join(root, filepath.FromSlash(rel)), subOpts)
+		if err != nil {
+			err = fmt.Errorf("Failed to scan submodule '%v': %w", rel, err)
+			if !opts.ContinueOnError {
+				return nil, err
+			}
+			res := Result{Path: rel + "/", Err: err, Kind: ReadError, Fingerprint: fingerprint(rel+"/", ReadError, nil)}
+			opts.report(res)
+			out = append(out, res)
+			continue
+		}
+		out = append(out, results.nest(rel)...)
+	}
+	return out, nil
+}
+
+// nest returns a copy of the results of the project in the directory dir,
+// relative to the parent project, with paths and projects relative to the
+// parent. Fingerprints are derived from dir, so that violations in different
+// projects remain distinct.
+func (r Results) nest(dir string) Results {
+	prefix := rootPrefix(dir)
+	out := make(Results, len(r))
+	for i, res := range r {
+		res.Path = prefix + res.Path
+		res.Project = strings.TrimSuffix(prefix+res.Project, "/")
+		if res.Decision != nil && !filepath.IsAbs(filepath.FromSlash(res.Decision.Source)) {
+			d := *res.Decision
+			d.Source = prefix + d.Source
+			res.Decision = &d
+		}
+		if res.Fingerprint != "" {
+			sum := sha256.Sum256([]byte(dir + "\n" + res.Fingerprint))
+			res.Fingerprint = fmt.Sprintf("%x", sum[:8])
+		}
+		out[i] = res
+	}
+	return out
+}
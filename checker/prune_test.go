@@ -0,0 +1,54 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExcludesDir(t *testing.T) {
+	for _, test := range []struct {
+		only   bool
+		paths  string
+		dir    string
+		expect bool
+	}{
+		{false, `[{ "exclude": [ "node_modules/**" ] }]`, "node_modules", true},
+		{false, `[{ "exclude": [ "node_modules/**" ] }]`, "src", false},
+		{false, `[{ "exclude": [ "node_modules/**" ] }]`, "src/node_modules", false},
+		{false, `[{ "exclude": [ "**/node_modules/**" ] }]`, "src/node_modules", true},
+		{false, `[{ "exclude": [ "**" ] }]`, "src", true},
+		{false, `[{ "exclude": [ "out/*" ] }]`, "out", false},
+		{false, `[{ "exclude": [ "out/**" ] }, { "include": [ "out/foo.txt" ] }]`, "out", false},
+		{false, `[{ "exclude": [ "out/**" ] }, { "include": [ "src/foo.txt" ] }]`, "out", true},
+		{false, `[{ "exclude": [ "out/**" ] }, { "include": [ "**.txt" ] }]`, "out", false},
+		{false, `[{ "exclude": [ "out/**" ] }, { "include": [ "**.txt" ] }, { "exclude": [ "out/**" ] }]`, "out", true},
+		{true, `[{ "include": [ "src/**" ] }]`, "src", false},
+		{true, `[{ "include": [ "src/**" ] }]`, "src/foo", false},
+		{true, `[{ "include": [ "src/**" ] }]`, "data", true},
+		{true, `[{ "include": [ "a/b/*.cpp" ] }]`, "a", false},
+		{true, `[{ "include": [ "a/b/*.cpp" ] }]`, "a/c", true},
+		{true, `[{ "include": [ "**.cpp" ] }]`, "data", false},
+	} {
+		cfg := Config{Only: test.only}
+		if err := json.Unmarshal([]byte(test.paths), &cfg.Paths); err != nil {
+			t.Fatalf("Failed to parse '%v': %v", test.paths, err)
+		}
+		if got, _ := cfg.excludesDir(test.dir); got != test.expect {
+			t.Errorf("excludesDir('%v') with paths %v returned %v, expected %v", test.dir, test.paths, got, test.expect)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"../detector"
+	"../imports"
+)
+
+// quarantineDir returns the cleaned Quarantine directory of the config, or an
+// empty string if the config has no quarantine.
+func (c Config) quarantineDir() string {
+	if c.Quarantine == "" {
+		return ""
+	}
+	return path.Clean(filepath.ToSlash(c.Quarantine))
+}
+
+// quarantined returns true if the file or directory at the project relative
+// path is under the config's quarantine directory.
+func (c Config) quarantined(rel string) bool {
+	dir := c.quarantineDir()
+	return dir != "" && strings.HasPrefix(rel, dir+"/")
+}
+
+// validateQuarantine returns an error if the Quarantine directory is not a
+// subdirectory of the project.
+func (c Config) validateQuarantine() error {
+	dir := c.quarantineDir()
+	if dir == "." || dir == ".." || strings.HasPrefix(dir, "../") || path.IsAbs(dir) {
+		return fmt.Errorf("quarantine '%v' must be a subdirectory of the project", c.Quarantine)
+	}
+	return nil
+}
+
+// checkQuarantine returns a violation for each #include or import statement of
+// the examined files outside of the config's quarantine directory that
+// resolves to a quarantined file. See the imports package for the supported
+// languages.
+func checkQuarantine(root string, cfg Config, files []string, opts Options) Results {
+	if cfg.quarantineDir() == "" {
+		return nil
+	}
+	out := Results{}
+	for _, rel := range files {
+		if cfg.quarantined(rel) {
+			continue
+		}
+		body, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			continue // Reported by examine()
+		}
+		for _, ref := range imports.Find(rel, body) {
+			for _, candidate := range ref.Candidates {
+				info, err := os.Stat(filepath.Join(root, filepath.FromSlash(candidate)))
+				if err != nil || info.IsDir() {
+					continue
+				}
+				if cfg.quarantined(candidate) {
+					out = append(out, Result{
+						Path: rel,
+						Err: fmt.Errorf("%v references '%v' (%v), which is quarantined",
+							opts.DisplayPath(root, rel), ref.Spec, opts.DisplayPath(root, candidate)),
+						Kind:        QuarantineReference,
+						Fingerprint: fingerprint(rel+" "+candidate, QuarantineReference, nil),
+						Advisory:    !cfg.enforced(),
+					})
+				}
+				break
+			}
+		}
+	}
+	return out
+}
+
+// VerifyPromotion scans the project in dir, and returns the results for the
+// files of the quarantined component at the project relative directory
+// component, checked with the full rules of the config instead of the relaxed
+// rules of the quarantine. The component must also pass the checks of a
+// vendored component: it must hold a LICENSE, LICENCE or COPYING file, and a
+// valid metadata file that declares the license of that file. See Vendored.
+//
+// All violations of the component fail the check, including those of configs
+// that are not enforced.
+func VerifyPromotion(dir, component string, opts Options) (Results, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get absolute working directory: %w", err)
+	}
+	component = path.Clean(filepath.ToSlash(component))
+	cfgs, err := loadConfigs(root, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load config file: %w", err)
+	}
+	var cfg *Config
+	for i := range cfgs {
+		if cfgs[i].quarantined(component) {
+			cfg = &cfgs[i]
+			break
+		}
+	}
+	if cfg == nil {
+		return nil, fmt.Errorf("'%v' is not in the quarantine directory of any config", component)
+	}
+	if info, err := os.Stat(filepath.Join(root, filepath.FromSlash(component))); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("Quarantined component '%v' is not a directory", component)
+	}
+
+	e, err := newExportCheck(root, opts, func(rel string) bool { return strings.HasPrefix(rel, component+"/") })
+	if err != nil {
+		return nil, err
+	}
+	var db *detector.Database
+	if opts.LicenseDB != "" {
+		if db, err = detector.LoadDatabase(opts.LicenseDB); err != nil {
+			return nil, err
+		}
+	}
+	d, err := detector.New(cfg.Detector, db)
+	if err != nil {
+		return nil, err
+	}
+
+	vendored := Vendored{}
+	if cfg.Vendored != nil {
+		vendored = *cfg.Vendored
+	}
+	vendored.Dirs = []string{component}
+	promoted := *cfg
+	promoted.Vendored = &vendored
+	results, err := checkVendored(root, promoted, newClassifier(d), opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, res := range results {
+		res.Advisory = false
+		e.out = append(e.out, res)
+	}
+	if _, files := componentLicenses(root, component, newClassifier(d)); len(files) == 0 {
+		e.violation(component+"/", MissingLicenseFile, fmt.Errorf("%v has no LICENSE, LICENCE or COPYING file", opts.DisplayPath(root, component)))
+	}
+	return e.out, nil
+}
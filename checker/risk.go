@@ -0,0 +1,265 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"../detector"
+)
+
+// Risk factors that are not license categories, used as keys of
+// RiskPolicy.Weights.
+const (
+	// RiskLowConfidence is the risk factor of a file whose licenses are only
+	// declared by SPDX-License-Identifier tags, and were not matched against
+	// any license text.
+	RiskLowConfidence = "low-confidence"
+	// RiskUnreviewed is the risk factor of a file of a quarantined or vendored
+	// component that has not been reviewed, as the component is in the
+	// Config.Quarantine directory, or has no valid metadata file.
+	RiskUnreviewed = "unreviewed"
+)
+
+// defaultRiskWeights are the default values of RiskPolicy.Weights. Files with
+// no license have the weight of detector.Unknown.
+var defaultRiskWeights = map[string]float64{
+	string(detector.Permissive):     0,
+	string(detector.PublicDomain):   0,
+	string(detector.WeakCopyleft):   0.4,
+	string(detector.StrongCopyleft): 1,
+	string(detector.Unknown):        1,
+	RiskLowConfidence:               0.3,
+	RiskUnreviewed:                  0.5,
+}
+
+// Default values of RiskPolicy.Medium and RiskPolicy.High.
+const (
+	defaultRiskMedium = 10
+	defaultRiskHigh   = 30
+)
+
+// RiskPolicy holds the weights and thresholds used to compute the Risk of a
+// project.
+type RiskPolicy struct {
+	// Weights maps the risk factors to a weight between 0 and 1. The factors
+	// are the license categories of the detector package, where 'unknown'
+	// also covers files with no license, and RiskLowConfidence and
+	// RiskUnreviewed. A file scores the highest weight of the categories of
+	// its licenses, plus the weights of its other factors, up to 1.
+	Weights map[string]float64 `json:"weights"`
+
+	// Medium and High are the scores, between 0 and 100, from which the risk
+	// level is RiskMedium and RiskHigh. Default to 10 and 30.
+	Medium float64 `json:"medium"`
+	High   float64 `json:"high"`
+}
+
+// validate returns an error if the policy has unknown factors, or weights or
+// thresholds out of range.
+func (p RiskPolicy) validate() error {
+	for factor, weight := range p.Weights {
+		if _, ok := defaultRiskWeights[factor]; !ok {
+			factors := make([]string, 0, len(defaultRiskWeights))
+			for f := range defaultRiskWeights {
+				factors = append(factors, f)
+			}
+			sort.Strings(factors)
+			return fmt.Errorf("risk: unknown weight '%v'. Must be one of: %v", factor, strings.Join(factors, ", "))
+		}
+		if weight < 0 || weight > 1 {
+			return fmt.Errorf("risk: weight '%v' must be between 0 and 1, got %v", factor, weight)
+		}
+	}
+	p = p.withDefaults()
+	if p.Medium < 0 || p.High > 100 || p.Medium > p.High {
+		return fmt.Errorf("risk: thresholds must satisfy 0 <= medium <= high <= 100, got medium %v and high %v", p.Medium, p.High)
+	}
+	return nil
+}
+
+// withDefaults returns a copy of the policy with the unset weights and
+// thresholds replaced with their default values.
+func (p RiskPolicy) withDefaults() RiskPolicy {
+	weights := make(map[string]float64, len(defaultRiskWeights))
+	for factor, weight := range defaultRiskWeights {
+		weights[factor] = weight
+	}
+	for factor, weight := range p.Weights {
+		weights[factor] = weight
+	}
+	p.Weights = weights
+	if p.Medium == 0 {
+		p.Medium = defaultRiskMedium
+	}
+	if p.High == 0 {
+		p.High = defaultRiskHigh
+	}
+	return p
+}
+
+// RiskLevel is the coarse level of a Risk score.
+type RiskLevel string
+
+// Enumerator values for RiskLevel.
+const (
+	RiskLow    RiskLevel = "low"
+	RiskMedium RiskLevel = "medium"
+	RiskHigh   RiskLevel = "high"
+)
+
+// Risk is the overall licensing risk of a project, for dashboards. Each
+// examined file scores between 0 and 1 by the weights of its risk factors,
+// and Score is the mean of the file scores, as a percentage.
+type Risk struct {
+	Score float64   // the risk score, between 0 and 100
+	Level RiskLevel // the level of Score, by the policy thresholds
+	Files int       // number of files scored
+
+	// Factors holds the number of files with each risk factor. Files with
+	// several licenses count towards the category with the highest weight.
+	// Factors with a weight of zero are not counted.
+	Factors map[string]int
+
+	policy RiskPolicy // the policy the risk was scored with
+	points float64    // sum of the file scores
+}
+
+// String returns a one-line summary of the risk.
+func (r Risk) String() string {
+	factors := make([]string, 0, len(r.Factors))
+	for factor := range r.Factors {
+		factors = append(factors, factor)
+	}
+	sort.Strings(factors)
+	parts := make([]string, len(factors))
+	for i, factor := range factors {
+		parts[i] = fmt.Sprintf("%d %v", r.Factors[factor], factor)
+	}
+	detail := fmt.Sprintf("%d files", r.Files)
+	if len(parts) > 0 {
+		detail = fmt.Sprintf("%v of %d files", strings.Join(parts, ", "), r.Files)
+	}
+	return fmt.Sprintf("%v (score %.1f/100: %v)", r.Level, r.Score, detail)
+}
+
+// add adds the scored files of o to the risk, and rescores it.
+func (r *Risk) add(o Risk) {
+	if r.Factors == nil {
+		r.Factors = map[string]int{}
+	}
+	for factor, n := range o.Factors {
+		r.Factors[factor] += n
+	}
+	r.Files += o.Files
+	r.points += o.points
+	r.rescore()
+}
+
+// rescore updates Score and Level from the scored files.
+func (r *Risk) rescore() {
+	r.Score = 0
+	if r.Files > 0 {
+		r.Score = 100 * r.points / float64(r.Files)
+	}
+	switch {
+	case r.Score >= r.policy.High:
+		r.Level = RiskHigh
+	case r.Score >= r.policy.Medium:
+		r.Level = RiskMedium
+	default:
+		r.Level = RiskLow
+	}
+}
+
+// MeasureRisk returns the Risk of the results of scanning the project in dir,
+// with opts, using the Risk policy of the project's configs, or the default
+// policy.
+func MeasureRisk(dir string, results Results, opts Options) (Risk, error) {
+	cfgs, err := loadConfigs(dir, opts)
+	if err != nil {
+		return Risk{}, fmt.Errorf("Failed to load config: %w", err)
+	}
+	return scoreRisk(cfgs, results), nil
+}
+
+// scoreRisk returns the Risk of the results, examined by the configs.
+func scoreRisk(cfgs Configs, results Results) Risk {
+	policy := RiskPolicy{}
+	for _, cfg := range cfgs {
+		if cfg.Risk != nil {
+			policy = *cfg.Risk
+			break
+		}
+	}
+	policy = policy.withDefaults()
+
+	// Files of vendored components without valid metadata are unreviewed.
+	unreviewedDirs := []string{}
+	for _, res := range results {
+		if res.Kind == MissingMetadata || res.Kind == InvalidMetadata {
+			unreviewedDirs = append(unreviewedDirs, res.Path+"/")
+		}
+	}
+	unreviewed := func(path string) bool {
+		for _, cfg := range cfgs {
+			if cfg.quarantined(path) {
+				return true
+			}
+		}
+		for _, dir := range unreviewedDirs {
+			if strings.HasPrefix(path, dir) {
+				return true
+			}
+		}
+		return false
+	}
+
+	risk := Risk{Factors: map[string]int{}, policy: policy}
+	count := func(factor string) float64 {
+		weight := policy.Weights[factor]
+		if weight > 0 {
+			risk.Factors[factor]++
+		}
+		return weight
+	}
+	for _, res := range results.Files() {
+		category := string(detector.Unknown)
+		if len(res.Licenses) > 0 {
+			category = string(detector.CategoryOf(res.Licenses[0]))
+			for _, l := range res.Licenses[1:] {
+				if c := string(detector.CategoryOf(l)); policy.Weights[c] > policy.Weights[category] {
+					category = c
+				}
+			}
+		}
+		score := count(category)
+		if res.lowConfidence {
+			score += count(RiskLowConfidence)
+		}
+		if unreviewed(res.Path) {
+			score += count(RiskUnreviewed)
+		}
+		if score > 1 {
+			score = 1
+		}
+		risk.Files++
+		risk.points += score
+	}
+	risk.rescore()
+	return risk
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Simulation holds the effect of replacing a project's config with a proposed
+// config.
+type Simulation struct {
+	NewFailures Results // violations that fail only with the proposed config
+	NewPasses   Results // violations that fail only with the current config
+	Failures    int     // the number of failing violations with the proposed config
+}
+
+// Simulate scans the project in dir with its current config, and then with the
+// proposed config file at proposed, returning the violations that would newly
+// fail or pass. Violations are matched between the scans by their fingerprint.
+func Simulate(dir, proposed string, opts Options) (Simulation, error) {
+	current, err := Scan(dir, opts)
+	if err != nil {
+		return Simulation{}, err
+	}
+	opts.Config = proposed
+	next, err := Scan(dir, opts)
+	if err != nil {
+		return Simulation{}, err
+	}
+
+	before, after := current.Failures(opts), next.Failures(opts)
+	failing := func(r Results) map[string]bool {
+		out := map[string]bool{}
+		for _, res := range r {
+			if res.Err != nil {
+				out[res.Fingerprint] = true
+			}
+		}
+		return out
+	}
+	wasFailing, isFailing := failing(before), failing(after)
+
+	s := Simulation{Failures: len(isFailing)}
+	for _, res := range after {
+		if res.Err != nil && !wasFailing[res.Fingerprint] {
+			s.NewFailures = append(s.NewFailures, res)
+		}
+	}
+	for _, res := range before {
+		if res.Err != nil && !isFailing[res.Fingerprint] {
+			s.NewPasses = append(s.NewPasses, res)
+		}
+	}
+	return s, nil
+}
+
+// String returns a human readable summary of the simulation.
+func (s Simulation) String() string {
+	sb := strings.Builder{}
+	section := func(title string, r Results) {
+		fmt.Fprintf(&sb, "%v (%d):\n", title, len(r))
+		for _, res := range r {
+			fmt.Fprintf(&sb, "* %v [%v]\n", res.Err, res.Fingerprint)
+		}
+	}
+	section("Newly failing", s.NewFailures)
+	section("Newly passing", s.NewPasses)
+	fmt.Fprintf(&sb, "%d violations would fail with the proposed config.\n", s.Failures)
+	return sb.String()
+}
@@ -0,0 +1,65 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+// ViolationSink receives the violations of a scan as they are found, so that
+// programs that embed the checker can stream them into their own databases or
+// user interfaces during long scans. See Options.Sink.
+type ViolationSink interface {
+	// Violation is called once for each Result with a violation, with the
+	// same Path, Project and Fingerprint as the Result returned by Scan.
+	// Violation is called from the goroutines that examine the files, so it
+	// must be safe for concurrent use.
+	Violation(res Result)
+}
+
+// ViolationSinkFunc is a ViolationSink implemented by a function.
+type ViolationSinkFunc func(res Result)
+
+// Violation calls f(res).
+func (f ViolationSinkFunc) Violation(res Result) { f(res) }
+
+// nestedSink is the ViolationSink of the subproject or workspace root in dir,
+// which passes the violations to the parent's sink with the paths relative to
+// the parent, as returned by Results.nest.
+type nestedSink struct {
+	parent ViolationSink
+	dir    string
+}
+
+func (s nestedSink) Violation(res Result) {
+	s.parent.Violation(Results{res}.nest(s.dir)[0])
+}
+
+// nested returns a copy of the options for scanning the subproject or
+// workspace root in dir, with the Sink wrapped in a nestedSink.
+func (o Options) nested(dir string) Options {
+	if o.Sink != nil {
+		o.Sink = nestedSink{o.Sink, dir}
+	}
+	return o
+}
+
+// report passes the results that hold a violation to the Sink, if any.
+func (o Options) report(results ...Result) {
+	if o.Sink == nil {
+		return
+	}
+	for _, res := range results {
+		if res.Err != nil {
+			o.Sink.Violation(o.withProject(res))
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import "os"
+
+// specialFile returns a description of the file at path, such as
+// "named pipe", if it is not a regular file and so must not be read, otherwise
+// an empty string. Reading a named pipe blocks until a writer opens it, which
+// would hang the scan. info is the os.Lstat of the file, as passed by
+// filepath.Walk. Symbolic links are described by their target, and links whose
+// target does not exist are left to fail when read.
+func specialFile(path string, info os.FileInfo) string {
+	mode := info.Mode()
+	if mode&os.ModeSymlink != 0 {
+		target, err := os.Stat(path)
+		if err != nil {
+			return ""
+		}
+		mode = target.Mode()
+	}
+	switch {
+	case mode.IsRegular():
+		return ""
+	case mode&os.ModeNamedPipe != 0:
+		return "named pipe"
+	case mode&os.ModeSocket != 0:
+		return "socket"
+	case mode&os.ModeCharDevice != 0:
+		return "character device"
+	case mode&os.ModeDevice != 0:
+		return "device"
+	case mode.IsDir():
+		return "symbolic link to a directory"
+	default:
+		return "irregular file"
+	}
+}
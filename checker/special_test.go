@@ -0,0 +1,80 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package checker_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	checker "."
+)
+
+func TestSpecialFiles(t *testing.T) {
+	root := t.TempDir()
+	cfg := `{ "licenses": [ "Apache-2.0" ] }`
+	if err := ioutil.WriteFile(filepath.Join(root, checker.DefaultConfigFileName), []byte(cfg), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "a.cpp"), []byte("// Licensed under the Apache License, Version 2.0 (the \"License\");\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Mkfifo(filepath.Join(root, "pipe.cpp"), 0666); err != nil {
+		t.Skipf("Mkfifo() returned %v", err)
+	}
+	if err := os.Symlink("pipe.cpp", filepath.Join(root, "link.cpp")); err != nil {
+		t.Fatal(err)
+	}
+
+	type scan struct {
+		results checker.Results
+		err     error
+	}
+	done := make(chan scan, 1)
+	go func() {
+		results, err := checker.Scan(root, checker.Options{Quiet: true, ListSkipped: true})
+		done <- scan{results, err}
+	}()
+	var s scan
+	select {
+	case s = <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Scan() hung on a named pipe")
+	}
+	if s.err != nil {
+		t.Fatalf("Scan() returned %v", s.err)
+	}
+	if err := s.results.Errs(); len(err) > 0 {
+		t.Errorf("Scan() returned violations: %v", err)
+	}
+	skipped := map[string]string{}
+	for _, res := range s.results.Skipped() {
+		skipped[res.Path] = res.Skipped
+	}
+	for _, path := range []string{"pipe.cpp", "link.cpp"} {
+		if got := skipped[path]; got != "named pipe" {
+			t.Errorf("%v was skipped as '%v', expected 'named pipe'", path, got)
+		}
+	}
+	if n := len(s.results.Files()); n != 1 {
+		t.Errorf("Scan() examined %d files, expected 1", n)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HeaderReplacement maps an outdated entity or URL that may appear in file
+// headers, such as a company name from before an acquisition, to its
+// replacement.
+type HeaderReplacement struct {
+	Old string `json:"old"` // the outdated text
+	New string `json:"new"` // the text that replaces Old
+}
+
+// staleHeader returns the first of the config's StaleHeaders that appears in
+// the leading comment of the file at path, or nil if the header is up to date.
+func (c Config) staleHeader(path string, body []byte) *HeaderReplacement {
+	if len(c.StaleHeaders) == 0 {
+		return nil
+	}
+	header := LeadingComment(path, body)
+	for i, s := range c.StaleHeaders {
+		if strings.Contains(header, s.Old) {
+			return &c.StaleHeaders[i]
+		}
+	}
+	return nil
+}
+
+// validateStaleHeaders returns an error if any of the StaleHeaders is invalid.
+func (c Config) validateStaleHeaders() error {
+	for i, s := range c.StaleHeaders {
+		if s.Old == "" {
+			return fmt.Errorf("stale_headers[%d] has no old value", i)
+		}
+		if strings.Contains(s.New, s.Old) {
+			return fmt.Errorf("stale_headers[%d]: new value '%v' contains the old value '%v'", i, s.New, s.Old)
+		}
+	}
+	return nil
+}
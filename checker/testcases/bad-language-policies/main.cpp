@@ -0,0 +1,3 @@
+// This file is missing a license
+
+int main() { return 0; }
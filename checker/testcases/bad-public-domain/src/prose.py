@@ -0,0 +1,4 @@
+# Written in 2020 by Bob.
+# This file is dedicated to the public domain.
+
+print("pd")
@@ -0,0 +1 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
@@ -0,0 +1,3 @@
+// Confidential
+
+int secret;
@@ -0,0 +1 @@
+../internal/secret.cpp
\ No newline at end of file
@@ -0,0 +1,2 @@
+
+// This file is missing a license
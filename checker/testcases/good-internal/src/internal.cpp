@@ -0,0 +1,5 @@
+// Copyright 2020 Acme Inc.
+// CONFIDENTIAL AND
+//   PROPRIETARY - do not distribute.
+
+int internal;
@@ -0,0 +1,4 @@
+#!/bin/sh
+# SPDX-License-Identifier: Apache-2.0
+
+echo "hello"
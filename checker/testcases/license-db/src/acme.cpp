@@ -0,0 +1,4 @@
+// Copyright 2020 Acme Corporation
+// Acme Corporation Proprietary and Confidential
+
+int acme() { return 0; }
@@ -0,0 +1,136 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"../language"
+)
+
+// DefaultUncoveredMinFiles is the default UncoveredTypes.MinFiles.
+const DefaultUncoveredMinFiles = 100
+
+// UncoveredTypes configures the reporting of the types of the files examined
+// by a config that none of its language policies cover, such as thousands of
+// new Kotlin files in a project whose language policies predate them.
+type UncoveredTypes struct {
+	// MinFiles is the number of files of a type that must be examined before
+	// the type is reported. Defaults to DefaultUncoveredMinFiles.
+	MinFiles int `json:"min_files"`
+
+	// Level is "warning" (default) to report the uncovered types as warnings,
+	// or "error" to fail the check if the config is enforced.
+	Level string `json:"level"`
+
+	// Ignore lists the language names, such as "markdown", and the file
+	// extensions of unknown languages, such as ".txt", that are never
+	// reported.
+	Ignore []string `json:"ignore"`
+}
+
+// validate returns an error if the settings are invalid.
+func (u UncoveredTypes) validate() error {
+	if u.MinFiles < 0 {
+		return fmt.Errorf("uncovered_types: min_files must not be negative, got %v", u.MinFiles)
+	}
+	switch u.Level {
+	case "", "warning", "error":
+	default:
+		return fmt.Errorf("uncovered_types: unknown level '%v'. Must be one of 'warning' or 'error'", u.Level)
+	}
+	for _, name := range u.Ignore {
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		if _, ok := language.ByName(name); !ok {
+			return fmt.Errorf("uncovered_types: unknown language '%v'. Extensions must start with '.'", name)
+		}
+	}
+	return nil
+}
+
+// fileType returns the name of the language of the file at the project
+// relative path, or its lowercase extension if the language is unknown, and
+// whether it is a language.
+func fileType(rel string) (string, bool) {
+	if l, ok := language.ForPath(rel); ok {
+		return l.Name, true
+	}
+	return strings.ToLower(path.Ext(rel)), false
+}
+
+// checkUncoveredTypes returns an UncoveredFileType violation for each type of
+// file that was examined by a config with UncoveredTypes set at least MinFiles
+// times, and that none of the config's LanguagePolicies cover. Files without
+// an extension, whose language is unknown, are not counted. Only the results
+// of the project at root are counted, not those of its subprojects.
+func checkUncoveredTypes(root string, cfgs Configs, results Results, opts Options) Results {
+	out := Results{}
+	for _, cfg := range cfgs {
+		u := cfg.UncoveredTypes
+		if u == nil {
+			continue
+		}
+		ignored := map[string]bool{}
+		for _, name := range u.Ignore {
+			ignored[strings.ToLower(name)] = true
+		}
+		counts := map[string]int{}
+		isLanguage := map[string]bool{}
+		for _, res := range results.Files() {
+			if res.Project != "" || res.Decision == nil || res.Decision.Config != cfg.index {
+				continue
+			}
+			typ, ok := fileType(res.Path)
+			if _, covered := cfg.LanguagePolicies[typ]; typ == "" || covered || ignored[typ] {
+				continue
+			}
+			counts[typ]++
+			isLanguage[typ] = ok
+		}
+		minFiles := u.MinFiles
+		if minFiles == 0 {
+			minFiles = DefaultUncoveredMinFiles
+		}
+		types := []string{}
+		for typ, n := range counts {
+			if n >= minFiles {
+				types = append(types, typ)
+			}
+		}
+		sort.Strings(types)
+		for _, typ := range types {
+			what := fmt.Sprintf("files with the extension '%v'", typ)
+			if isLanguage[typ] {
+				what = fmt.Sprintf("%v files", typ)
+			}
+			res := Result{
+				Path: cfg.source,
+				Err: fmt.Errorf("%v: %d %v are not covered by the language_policies of configs[%d]",
+					opts.DisplayPath(root, cfg.source), counts[typ], what, cfg.index),
+				Kind:        UncoveredFileType,
+				Fingerprint: fingerprint(cfg.source+":"+typ, UncoveredFileType, nil),
+				Advisory:    u.Level != "error" || !cfg.enforced(),
+			}
+			opts.report(res)
+			out = append(out, res)
+		}
+	}
+	return out
+}
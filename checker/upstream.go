@@ -0,0 +1,81 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// httpClient is the client used to fetch upstream license files.
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// upstreamURL returns the URL of the upstream copy of the component's license
+// file with the given name, at the pinned version. If the metadata declares a
+// license URL, then it is used with any '{version}' replaced by the version.
+// Otherwise the URL is derived for components hosted on github.com or
+// gitlab.com. upstreamURL returns an empty string if no URL can be derived.
+func upstreamURL(m metadata, name string) string {
+	if m.LicenseURL != "" {
+		return strings.ReplaceAll(m.LicenseURL, "{version}", url.PathEscape(m.Version))
+	}
+	u, err := url.Parse(m.URL)
+	if err != nil {
+		return ""
+	}
+	repo := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if strings.Count(repo, "/") < 1 {
+		return ""
+	}
+	switch u.Host {
+	case "github.com":
+		return "https://raw.githubusercontent.com/" + path.Join(repo, url.PathEscape(m.Version), name)
+	case "gitlab.com":
+		return "https://gitlab.com/" + path.Join(repo, "-/raw", url.PathEscape(m.Version), name)
+	}
+	return ""
+}
+
+// fetchUpstream returns the content at the URL.
+func fetchUpstream(u string) ([]byte, error) {
+	resp, err := httpClient.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%v returned %v", u, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// sameText returns true if a and b hold the same text, ignoring differences
+// in line endings and trailing whitespace.
+func sameText(a, b []byte) bool {
+	normalize := func(s []byte) []byte {
+		lines := bytes.Split(bytes.ReplaceAll(s, []byte("\r\n"), []byte("\n")), []byte("\n"))
+		for i, l := range lines {
+			lines[i] = bytes.TrimRight(l, " \t")
+		}
+		return bytes.TrimRight(bytes.Join(lines, []byte("\n")), "\n")
+	}
+	return bytes.Equal(normalize(a), normalize(b))
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import "testing"
+
+func TestUpstreamURL(t *testing.T) {
+	for _, test := range []struct {
+		m      metadata
+		expect string
+	}{
+		{metadata{URL: "https://github.com/madler/zlib", Version: "v1.2.11"},
+			"https://raw.githubusercontent.com/madler/zlib/v1.2.11/LICENSE"},
+		{metadata{URL: "https://github.com/madler/zlib.git/", Version: "abc123"},
+			"https://raw.githubusercontent.com/madler/zlib/abc123/LICENSE"},
+		{metadata{URL: "https://gitlab.com/group/project", Version: "1.0"},
+			"https://gitlab.com/group/project/-/raw/1.0/LICENSE"},
+		{metadata{URL: "https://example.com/foo", Version: "1.0", LicenseURL: "https://example.com/foo/{version}/COPYING"},
+			"https://example.com/foo/1.0/COPYING"},
+		{metadata{URL: "https://example.com/foo", Version: "1.0"}, ""},
+		{metadata{URL: "https://github.com/madler", Version: "1.0"}, ""},
+	} {
+		if got := upstreamURL(test.m, "LICENSE"); got != test.expect {
+			t.Errorf("upstreamURL(%+v) returned '%v', expected '%v'", test.m, got, test.expect)
+		}
+	}
+}
+
+func TestSameText(t *testing.T) {
+	for _, test := range []struct {
+		a, b   string
+		expect bool
+	}{
+		{"MIT License\n\nCopyright\n", "MIT License\r\n\r\nCopyright  \r\n\r\n", true},
+		{"MIT License\n", "MIT License (modified)\n", false},
+		{"MIT License\n", "  MIT License\n", false},
+	} {
+		if got := sameText([]byte(test.a), []byte(test.b)); got != test.expect {
+			t.Errorf("sameText(%q, %q) returned %v, expected %v", test.a, test.b, got, test.expect)
+		}
+	}
+}
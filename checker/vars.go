@@ -0,0 +1,141 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// varRE matches a reference to a config variable, such as '${gen}'.
+var varRE = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// UnmarshalJSON parses the config, first expanding the references to its Vars.
+func (c *Config) UnmarshalJSON(body []byte) error {
+	expanded, err := expandVars(body)
+	if err != nil {
+		return err
+	}
+	type parsed Config
+	p := parsed{}
+	if err := json.Unmarshal(expanded, &p); err != nil {
+		return err
+	}
+	*c = Config(p)
+	return nil
+}
+
+// expandVars returns the JSON config object body with each reference to a
+// variable declared by its "vars" object replaced with the variable's value.
+// A variable holds either a string, which may be referenced anywhere in a
+// string, or an array of strings, which may only be referenced by an array
+// element that consists of just the reference. The array element is replaced
+// with all the strings of the variable.
+func expandVars(body []byte) ([]byte, error) {
+	cfg := map[string]interface{}{}
+	if err := json.Unmarshal(body, &cfg); err != nil {
+		return nil, err
+	}
+	raw, ok := cfg["vars"]
+	if !ok {
+		return body, nil
+	}
+	decls, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("vars must be an object")
+	}
+	vars := map[string][]string{}
+	lists := map[string]bool{}
+	for name, value := range decls {
+		switch value := value.(type) {
+		case string:
+			vars[name] = []string{value}
+		case []interface{}:
+			lists[name] = true
+			for _, v := range value {
+				s, ok := v.(string)
+				if !ok {
+					return nil, fmt.Errorf("vars: '%v' must be a string or an array of strings", name)
+				}
+				vars[name] = append(vars[name], s)
+			}
+		default:
+			return nil, fmt.Errorf("vars: '%v' must be a string or an array of strings", name)
+		}
+	}
+
+	var expand func(v interface{}) (interface{}, error)
+	expandString := func(s string) (string, error) {
+		var err error
+		out := varRE.ReplaceAllStringFunc(s, func(ref string) string {
+			name := varRE.FindStringSubmatch(ref)[1]
+			value, ok := vars[name]
+			switch {
+			case !ok:
+				err = fmt.Errorf("vars: unknown variable '%v'", ref)
+			case lists[name]:
+				err = fmt.Errorf("vars: array variable '%v' must be used as a whole array element", ref)
+			default:
+				return value[0]
+			}
+			return ref
+		})
+		return out, err
+	}
+	expand = func(v interface{}) (interface{}, error) {
+		switch v := v.(type) {
+		case string:
+			return expandString(v)
+		case []interface{}:
+			out := []interface{}{}
+			for _, e := range v {
+				if s, ok := e.(string); ok {
+					if m := varRE.FindStringSubmatch(s); m != nil && m[0] == s && lists[m[1]] {
+						for _, value := range vars[m[1]] {
+							out = append(out, value)
+						}
+						continue
+					}
+				}
+				e, err := expand(e)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, e)
+			}
+			return out, nil
+		case map[string]interface{}:
+			for key, value := range v {
+				var err error
+				if v[key], err = expand(value); err != nil {
+					return nil, err
+				}
+			}
+			return v, nil
+		}
+		return v, nil
+	}
+	for key, value := range cfg {
+		if key == "vars" {
+			continue
+		}
+		var err error
+		if cfg[key], err = expand(value); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(cfg)
+}
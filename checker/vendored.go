@@ -0,0 +1,281 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"../detector"
+	"../spdx"
+)
+
+// Vendored configures the provenance checks of vendored components.
+type Vendored struct {
+	// Dirs is a list of glob patterns, as accepted by filepath.Glob, that
+	// match the project relative directories of the vendored components.
+	// For example: "third_party/*".
+	Dirs []string `json:"dirs"`
+
+	// MetadataFiles is the list of accepted metadata file names, one of which
+	// must be present in each component directory. Defaults to "METADATA" and
+	// "version.json".
+	MetadataFiles []string `json:"metadata_files"`
+
+	// DualLicensed is a list of glob patterns, using the same syntax as Dirs,
+	// of the component directories that may hold several differing license
+	// files, such as LICENSE-MIT and LICENSE-APACHE. All other components
+	// with differing license files, which are often left behind by bad
+	// merges, are reported as having conflicting license files.
+	DualLicensed []string `json:"dual_licensed"`
+}
+
+// dualLicensed returns true if the project relative component directory
+// matches one of the DualLicensed patterns.
+func (v Vendored) dualLicensed(dir string) bool {
+	for _, pattern := range v.DualLicensed {
+		if ok, _ := path.Match(pattern, dir); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultMetadataFiles is the default value of Vendored.MetadataFiles.
+var defaultMetadataFiles = []string{"METADATA", "version.json"}
+
+// metadata is the provenance of a vendored component, as declared by its
+// metadata file.
+type metadata struct {
+	URL     string `json:"url"`
+	Version string `json:"version"`
+	License string `json:"license"`
+
+	// LicenseURL optionally holds the URL of the upstream license file, used
+	// by Options.VerifyUpstream. '{version}' is replaced with the version.
+	LicenseURL string `json:"license_url"`
+}
+
+// parseMetadata parses the metadata file with the given name. Files with a
+// '.json' extension hold a JSON object with "url", "version" and "license"
+// fields. Other files hold 'Key: value' lines with URL, Version and License
+// keys. Both may optionally declare a license URL.
+func parseMetadata(name string, body []byte) (metadata, error) {
+	m := metadata{}
+	if strings.HasSuffix(name, ".json") {
+		err := json.Unmarshal(body, &m)
+		return m, err
+	}
+	for _, line := range strings.Split(string(body), "\n") {
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+		value := strings.TrimSpace(line[i+1:])
+		switch strings.ToLower(strings.TrimSpace(line[:i])) {
+		case "url":
+			m.URL = value
+		case "version":
+			m.Version = value
+		case "license":
+			m.License = value
+		case "license url":
+			m.LicenseURL = value
+		}
+	}
+	return m, nil
+}
+
+// validate returns an error if the metadata is missing a field, or the URL is
+// not absolute.
+func (m metadata) validate() error {
+	missing := []string{}
+	for _, f := range []struct{ name, value string }{
+		{"url", m.URL}, {"version", m.Version}, {"license", m.License},
+	} {
+		if f.value == "" {
+			missing = append(missing, f.name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("is missing %v", strings.Join(missing, ", "))
+	}
+	if u, err := url.Parse(m.URL); err != nil || !u.IsAbs() {
+		return fmt.Errorf("has an invalid url '%v'", m.URL)
+	}
+	return nil
+}
+
+// checkVendored returns a Result with a violation for each vendored component
+// of the config that has a missing or invalid metadata file, or whose declared
+// license is not found in the component's license files.
+func checkVendored(root string, cfg Config, cls *classifier, opts Options) (Results, error) {
+	if cfg.Vendored == nil {
+		return nil, nil
+	}
+	names := cfg.Vendored.MetadataFiles
+	if len(names) == 0 {
+		names = defaultMetadataFiles
+	}
+
+	dirs := []string{}
+	for _, pattern := range cfg.Vendored.Dirs {
+		matches, err := filepath.Glob(filepath.Join(root, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, fmt.Errorf("vendored: invalid pattern '%v': %w", pattern, err)
+		}
+		for _, m := range matches {
+			if info, err := os.Stat(m); err == nil && info.IsDir() {
+				rel, _ := filepath.Rel(root, m)
+				dirs = append(dirs, filepath.ToSlash(rel))
+			}
+		}
+	}
+	sort.Strings(dirs)
+
+	out := Results{}
+	fail := func(rel string, kind ViolationKind, err error) {
+		out = append(out, Result{
+			Path:        rel,
+			Err:         err,
+			Kind:        kind,
+			Fingerprint: fingerprint(rel, kind, nil),
+			Advisory:    !cfg.enforced(),
+		})
+	}
+	for _, dir := range dirs {
+		file, body := "", []byte(nil)
+		for _, name := range names {
+			if b, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(dir), name)); err == nil {
+				file, body = path.Join(dir, name), b
+				break
+			}
+		}
+		if file == "" {
+			fail(dir+"/", MissingMetadata, fmt.Errorf("%v has no metadata file. Expected one of: %v",
+				opts.DisplayPath(root, dir), strings.Join(names, ", ")))
+			continue
+		}
+
+		m, err := parseMetadata(file, body)
+		if err == nil {
+			err = m.validate()
+		}
+		if err != nil {
+			fail(file, InvalidMetadata, fmt.Errorf("%v %v", opts.DisplayPath(root, file), err))
+			continue
+		}
+
+		detected, licenseFiles := componentLicenses(root, dir, cls)
+		if len(licenseFiles) == 0 {
+			continue // Nothing to cross-check against
+		}
+		if !cfg.Vendored.dualLicensed(dir) && differingFiles(root, licenseFiles) {
+			fail(dir+"/", ConflictingLicenseFiles, fmt.Errorf("%v has differing license files: %v",
+				opts.DisplayPath(root, dir), strings.Join(licenseFiles, ", ")))
+		}
+		declared := spdx.Identifiers([]byte("SPDX-License-Identifier: " + m.License))
+		if !anyNormalized(declared, detected) {
+			fail(file, MetadataMismatch, fmt.Errorf("%v declares license '%v', but %v has %v",
+				opts.DisplayPath(root, file), m.License, strings.Join(licenseFiles, ", "), detected))
+		}
+
+		if opts.VerifyUpstream {
+			for _, rel := range licenseFiles {
+				u := upstreamURL(m, path.Base(rel))
+				if u == "" {
+					continue
+				}
+				local, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+				if err != nil {
+					continue
+				}
+				upstream, err := fetchUpstream(u)
+				switch {
+				case err != nil:
+					fail(rel, UpstreamError, fmt.Errorf("Failed to fetch upstream %v: %w", opts.DisplayPath(root, rel), err))
+				case !sameText(local, upstream):
+					fail(rel, ModifiedLicense, fmt.Errorf("%v differs from the upstream copy at %v", opts.DisplayPath(root, rel), u))
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// componentLicenses returns the licenses detected in the license files at the
+// top of the vendored component directory, along with the project relative
+// paths of those files.
+func componentLicenses(root, dir string, cls *classifier) (ids, files []string) {
+	entries, err := ioutil.ReadDir(filepath.Join(root, filepath.FromSlash(dir)))
+	if err != nil {
+		return nil, nil
+	}
+	ids = []string{}
+	for _, e := range entries {
+		if e.IsDir() || !isLicenseFile(e.Name()) {
+			continue
+		}
+		rel := path.Join(dir, e.Name())
+		body, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			continue
+		}
+		files = append(files, rel)
+		ids = append(ids, cls.licenses(rel, body)...)
+	}
+	return ids, files
+}
+
+// differingFiles returns true if any of the files at the project relative
+// paths holds a different text to the others, ignoring differences in line
+// endings and trailing whitespace.
+func differingFiles(root string, files []string) bool {
+	var first []byte
+	for i, rel := range files {
+		body, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			continue
+		}
+		if i == 0 {
+			first = body
+		} else if !sameText(first, body) {
+			return true
+		}
+	}
+	return false
+}
+
+// anyNormalized returns true if any of the license names of a is also in b,
+// after normalization.
+func anyNormalized(a, b []string) bool {
+	set := map[string]bool{}
+	for _, l := range b {
+		set[detector.Normalize(l)] = true
+	}
+	for _, l := range a {
+		if set[detector.Normalize(l)] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import "../detector"
+
+// whatIfContent is the content of the hypothetical files of Policy.WhatIf.
+const whatIfContent = "license-checker what-if\n"
+
+// fixedDetector is a detector.Detector that finds the same licenses in any
+// content.
+type fixedDetector []string
+
+func (d fixedDetector) Detect([]byte) []string { return append([]string{}, d...) }
+
+// WhatIf returns the results of checking a hypothetical file at the project
+// relative path that holds the given licenses, as CheckContent would, so that
+// developers can tell whether code would pass the policy before importing it.
+// The licenses are normalized with detector.Normalize, and are taken as found,
+// without detecting them in any content. The Result.Decision of each config
+// that examines the file holds the rules that decided it. If no licenses are
+// given, the file holds no license.
+func (p *Policy) WhatIf(relPath string, licenses []string) Results {
+	ids := make(fixedDetector, len(licenses))
+	for i, l := range licenses {
+		ids[i] = detector.Normalize(l)
+	}
+	q := &Policy{Configs: p.Configs, classifiers: map[string]*classifier{}}
+	for name := range p.classifiers {
+		q.classifiers[name] = newClassifier(ids)
+	}
+	return q.CheckContent(relPath, []byte(whatIfContent))
+}
@@ -0,0 +1,145 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Workspace is a group of project roots, each with its own config file, that
+// are checked together to produce a single combined set of results.
+//
+// The workspace file is a JSON object of the form:
+//
+//	{ "roots": [ "app", "../shared-lib" ] }
+//
+// where each root is a directory path relative to the workspace file.
+type Workspace struct {
+	// Dir is the absolute path of the directory holding the workspace file.
+	Dir string `json:"-"`
+	// Roots are the paths of the project root directories, relative to Dir.
+	Roots []string `json:"roots"`
+}
+
+// LoadWorkspace loads the workspace file at file.
+func LoadWorkspace(file string) (Workspace, error) {
+	body, err := ioutil.ReadFile(file)
+	if err != nil {
+		return Workspace{}, fmt.Errorf("Failed to read workspace file: %w", err)
+	}
+	w := Workspace{}
+	if err := json.Unmarshal(body, &w); err != nil {
+		return Workspace{}, fmt.Errorf("Failed to parse workspace file: %w", err)
+	}
+	if len(w.Roots) == 0 {
+		return Workspace{}, fmt.Errorf("Workspace file '%v' lists no roots", file)
+	}
+	seen := map[string]bool{}
+	for i, root := range w.Roots {
+		root = path.Clean(filepath.ToSlash(root))
+		if filepath.IsAbs(root) {
+			return Workspace{}, fmt.Errorf("Workspace root '%v' must be relative to the workspace file", root)
+		}
+		if seen[root] {
+			return Workspace{}, fmt.Errorf("Workspace root '%v' is listed more than once", root)
+		}
+		seen[root] = true
+		w.Roots[i] = root
+	}
+	if w.Dir, err = filepath.Abs(filepath.Dir(file)); err != nil {
+		return Workspace{}, fmt.Errorf("Failed to get absolute workspace directory: %w", err)
+	}
+	return w, nil
+}
+
+// Scan scans each of the workspace's roots, returning the combined results.
+// Result paths are relative to the workspace directory, and each root is a
+// separate Result.Project.
+func (w Workspace) Scan(opts Options) (Results, error) {
+	out := Results{}
+	for _, root := range w.Roots {
+		rootOpts := opts.nested(root)
+		rootOpts.prefix = rootPrefix(root)
+		results, err := Scan(filepath.Join(w.Dir, filepath.FromSlash(root)), rootOpts)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to scan workspace root '%v': %w", root, err)
+		}
+		out = append(out, results.nest(root)...)
+	}
+	return out, nil
+}
+
+// MeasureCoverage returns the combined Coverage of the workspace's roots, for
+// the results returned by Scan with opts.
+func (w Workspace) MeasureCoverage(results Results, opts Options) (Coverage, error) {
+	total := Coverage{}
+	for _, root := range w.Roots {
+		prefix := rootPrefix(root)
+		rootResults := Results{}
+		for _, res := range results {
+			if strings.HasPrefix(res.Path, prefix) {
+				res.Path = strings.TrimPrefix(res.Path, prefix)
+				rootResults = append(rootResults, res)
+			}
+		}
+		c, err := MeasureCoverage(filepath.Join(w.Dir, filepath.FromSlash(root)), rootResults, opts)
+		if err != nil {
+			return Coverage{}, err
+		}
+		total.Examined += c.Examined
+		total.Total += c.Total
+	}
+	return total, nil
+}
+
+// MeasureRisk returns the combined Risk of the results of scanning the
+// workspace, scoring the files of each root with the Risk policy of that
+// root's configs. The risk level uses the thresholds of the first root.
+func (w Workspace) MeasureRisk(results Results, opts Options) (Risk, error) {
+	total := Risk{}
+	for i, root := range w.Roots {
+		prefix := rootPrefix(root)
+		rootResults := Results{}
+		for _, res := range results {
+			if strings.HasPrefix(res.Path, prefix) {
+				res.Path = strings.TrimPrefix(res.Path, prefix)
+				rootResults = append(rootResults, res)
+			}
+		}
+		r, err := MeasureRisk(filepath.Join(w.Dir, filepath.FromSlash(root)), rootResults, opts)
+		if err != nil {
+			return Risk{}, err
+		}
+		if i == 0 {
+			total.policy = r.policy
+		}
+		total.add(r)
+	}
+	return total, nil
+}
+
+// rootPrefix returns the prefix of the workspace relative paths of the files
+// under the workspace root.
+func rootPrefix(root string) string {
+	if root == "." {
+		return ""
+	}
+	return root + "/"
+}
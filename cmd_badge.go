@@ -0,0 +1,52 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"./badge"
+	"./checker"
+)
+
+// runBadge implements the 'badge' subcommand, which scans the project and
+// writes a shields.io-style SVG badge showing the license compliance status and
+// the number of violations.
+func runBadge(args []string) error {
+	flags := flag.NewFlagSet("badge", flag.ExitOnError)
+	dir := flags.String("dir", cwd(), "Project root directory to scan")
+	output := flags.String("output", "badge.svg", "Path of the SVG file to write")
+	licenseDB := flags.String("license-db", "", "Path to a JSON license database with licenses to add to the detectors")
+	flags.Parse(args)
+
+	opts := checker.Options{LicenseDB: *licenseDB}
+	results, err := checker.Scan(*dir, opts)
+	if err != nil {
+		return err
+	}
+	b := badge.ForViolations(len(results.Failures(opts).Errs()))
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("Failed to create badge file: %w", err)
+	}
+	if err := b.WriteSVG(f); err != nil {
+		f.Close()
+		return fmt.Errorf("Failed to write badge file: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,82 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"./checker"
+	"./gentree"
+)
+
+// runBench implements the 'bench' subcommand, which generates a synthetic
+// project tree and measures the scan throughput. Results are printed in the Go
+// benchmark format, so they can be compared across releases with benchstat.
+func runBench(args []string) error {
+	defaults := gentree.DefaultOptions()
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	files := flags.Int("files", defaults.Files, "Number of source files to generate")
+	depth := flags.Int("depth", defaults.Depth, "Depth of the generated directory tree")
+	fanout := flags.Int("fanout", defaults.Fanout, "Number of subdirectories per directory")
+	lines := flags.Int("lines", defaults.Lines, "Number of lines of code per source file")
+	unlicensed := flags.Float64("unlicensed", defaults.Unlicensed, "Fraction of source files without a license")
+	seed := flags.Int64("seed", defaults.Seed, "Random seed used to generate the tree")
+	runs := flags.Int("runs", 5, "Number of times to scan the tree")
+	keep := flags.String("keep", "", "Generate the tree into this directory and keep it, instead of using a temporary directory")
+	flags.Parse(args)
+
+	dir := *keep
+	if dir == "" {
+		tmp, err := ioutil.TempDir("", "license-checker-bench")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+		dir = tmp
+	}
+
+	opts := gentree.Options{
+		Files:      *files,
+		Depth:      *depth,
+		Fanout:     *fanout,
+		Lines:      *lines,
+		Unlicensed: *unlicensed,
+		Seed:       *seed,
+	}
+	expect, err := gentree.Generate(dir, opts)
+	if err != nil {
+		return fmt.Errorf("Failed to generate tree: %w", err)
+	}
+
+	name := fmt.Sprintf("BenchmarkScan/files=%d/depth=%d/fanout=%d/lines=%d", *files, *depth, *fanout, *lines)
+	for i := 0; i < *runs; i++ {
+		start := time.Now()
+		results, err := checker.Scan(dir, checker.Options{})
+		if err != nil {
+			return err
+		}
+		elapsed := time.Since(start)
+		if got := len(results.Errs()); got != expect {
+			return fmt.Errorf("Scan found %d violations, expected %d", got, expect)
+		}
+		fmt.Printf("%v\t1\t%d ns/op\t%.1f files/s\n",
+			name, elapsed.Nanoseconds(), float64(len(results))/elapsed.Seconds())
+	}
+	return nil
+}
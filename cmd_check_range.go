@@ -0,0 +1,71 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"./checker"
+	"./commits"
+)
+
+// runCheckRange implements the 'check-range' subcommand, which checks each
+// commit of a revision range, such as a merge queue batch, and reports the
+// commits that introduced new violations, so that the change that broke
+// compliance can be found without bisecting.
+func runCheckRange(args []string) error {
+	flags := flag.NewFlagSet("check-range", flag.ExitOnError)
+	dir := flags.String("dir", cwd(), "Directory of the git repository")
+	licenseDB := flags.String("license-db", "", "Path to a JSON license database with licenses to add to the detectors")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: license-checker check-range [flags] <revision-range>\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("check-range requires a single revision range, for example: origin/main..HEAD")
+	}
+
+	opts := checker.Options{Quiet: true, LicenseDB: *licenseDB}
+	checked, err := commits.CheckRange(*dir, flags.Arg(0), opts)
+	if err != nil {
+		return err
+	}
+	var first *commits.RangeCommit
+	broken := 0
+	for i, c := range checked {
+		failures := c.Introduced.Failures(opts).Errs()
+		warnings := c.Introduced.Warnings(opts).Errs()
+		switch {
+		case len(failures) > 0:
+			fmt.Printf("FAIL: %v %q introduced %d violations:\n%v", c.Commit[:12], c.Subject, len(failures), c.Introduced.List(opts))
+			if first == nil {
+				first = &checked[i]
+			}
+			broken++
+		case len(warnings) > 0:
+			fmt.Printf("WARN: %v %q introduced %d warnings:\n%v", c.Commit[:12], c.Subject, len(warnings), c.Introduced.List(opts))
+		default:
+			fmt.Printf("PASS: %v %q\n", c.Commit[:12], c.Subject)
+		}
+	}
+	if first != nil {
+		return fmt.Errorf("%d of %d commits introduced license violations, the first is %v %q",
+			broken, len(checked), first.Commit[:12], first.Subject)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"./commits"
+	"./provenance"
+)
+
+// runCommits implements the 'commits' subcommand, which checks that the
+// commits of a revision range that import third-party code carry license
+// trailers in their commit messages, that no commit removes lines of an
+// existing license header, and that no commit adds the text of another license
+// to a file. If a corpus of known code is given, then the files added without
+// a license header are also compared with it to find uncredited copies.
+func runCommits(args []string) error {
+	defaults := commits.DefaultOptions()
+	flags := flag.NewFlagSet("commits", flag.ExitOnError)
+	dir := flags.String("dir", cwd(), "Directory of the git repository")
+	importDirs := flags.String("import-dirs", strings.Join(defaults.ImportDirs, ","), "Comma-separated names of directories that hold third-party code")
+	trailers := flags.String("trailers", strings.Join(defaults.Trailers, ","), "Comma-separated trailer keys required on commits that import third-party code")
+	headers := flags.Bool("headers", true, "Report commits that delete or truncate the license header of an existing file")
+	snippets := flags.Bool("snippets", true, "Report blocks of added lines that hold the text of a license other than the file's")
+	corpus := flags.String("corpus", "", "Directory of known third-party code to compare the files added without a license header against")
+	threshold := flags.Float64("corpus-threshold", 0.5, "Fraction of an added file's fingerprints that must be found in a --corpus file to report it as a copy")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: license-checker commits [flags] <revision-range>\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("commits requires a single revision range, for example: origin/main..HEAD")
+	}
+
+	opts := commits.Options{
+		ImportDirs: strings.Split(*importDirs, ","),
+		Trailers:   strings.Split(*trailers, ","),
+	}
+	violations, err := commits.Check(*dir, flags.Arg(0), opts)
+	if err != nil {
+		return err
+	}
+	removals := []commits.HeaderRemoval{}
+	if *headers {
+		if removals, err = commits.CheckHeaders(*dir, flags.Arg(0)); err != nil {
+			return err
+		}
+	}
+	pasted := []commits.Snippet{}
+	if *snippets {
+		if pasted, err = commits.CheckSnippets(*dir, flags.Arg(0)); err != nil {
+			return err
+		}
+	}
+	copies := []commits.Copy{}
+	if *corpus != "" {
+		c, err := provenance.LoadCorpus(*corpus)
+		if err != nil {
+			return err
+		}
+		if copies, err = commits.CheckCopies(*dir, flags.Arg(0), c, *threshold); err != nil {
+			return err
+		}
+	}
+	if len(violations) == 0 && len(removals) == 0 && len(pasted) == 0 && len(copies) == 0 {
+		fmt.Printf("No commit license issues found\n")
+		return nil
+	}
+	msg := strings.Builder{}
+	if len(removals) > 0 {
+		fmt.Fprintf(&msg, "%d license header removals found:\n", len(removals))
+		for _, r := range removals {
+			fmt.Fprintf(&msg, "* %v\n", r)
+		}
+	}
+	if len(pasted) > 0 {
+		fmt.Fprintf(&msg, "%d added blocks of license text found:\n", len(pasted))
+		for _, s := range pasted {
+			fmt.Fprintf(&msg, "* %v\n", s)
+		}
+	}
+	if len(copies) > 0 {
+		fmt.Fprintf(&msg, "%d likely copies of third-party code found:\n", len(copies))
+		for _, c := range copies {
+			fmt.Fprintf(&msg, "* %v\n", c)
+		}
+	}
+	if len(violations) > 0 {
+		fmt.Fprintf(&msg, "%d commits are missing license trailers:\n", len(violations))
+		for _, v := range violations {
+			fmt.Fprintf(&msg, "* %v\n", v)
+		}
+	}
+	return fmt.Errorf("%v", strings.TrimSuffix(msg.String(), "\n"))
+}
@@ -0,0 +1,116 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"./checker"
+	"./deps"
+	"./detector"
+)
+
+// runDeps implements the 'deps' subcommand, which lists the licenses of the Go
+// module dependencies of the project, and with --gate, fails if any dependency
+// uses a license outside of the dependency policy.
+func runDeps(args []string) error {
+	if len(args) > 0 && args[0] == "diff" {
+		return runDepsDiff(args[1:])
+	}
+
+	flags := flag.NewFlagSet("deps", flag.ExitOnError)
+	dir := flags.String("dir", cwd(), "Project root directory, holding the config file and go.mod")
+	gate := flags.Bool("gate", false, "Fail if any dependency uses a license outside of the dependency policy")
+	licenseDB := flags.String("license-db", "", "Path to a JSON license database with licenses to add to the detectors")
+	flags.Parse(args)
+
+	mods, err := deps.Graph(*dir)
+	if err != nil {
+		return err
+	}
+	opts := checker.Options{WarnOnly: !*gate, LicenseDB: *licenseDB}
+	results, err := checker.CheckDependencies(*dir, mods, opts)
+	if err != nil {
+		return err
+	}
+	if !*gate {
+		for _, res := range results {
+			fmt.Printf("%v: %v\n", res.Path, strings.Join(res.Licenses, ", "))
+		}
+	}
+	return results.Check(opts)
+}
+
+// runDepsDiff implements the 'deps diff' subcommand, which prints the new
+// dependencies and license category changes between two lockfiles as markdown.
+// The licenses of Go modules are read from the module cache, if present.
+func runDepsDiff(args []string) error {
+	flags := flag.NewFlagSet("deps diff", flag.ExitOnError)
+	dir := flags.String("dir", cwd(), "Directory used to locate the Go module cache")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: license-checker deps diff [flags] <old-lockfile> <new-lockfile>\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("deps diff requires two lockfiles")
+	}
+
+	d, err := detector.New("", nil)
+	if err != nil {
+		return err
+	}
+	sets := [2][]deps.Module{}
+	for i, path := range flags.Args() {
+		body, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("Failed to read lockfile: %w", err)
+		}
+		mods, err := deps.ParseLockfile(path, body)
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(path, ".sum") {
+			if cache, err := deps.CacheDir(*dir); err == nil {
+				for j := range mods {
+					mods[j].License = deps.DetectLicenses(deps.Locate(cache, mods[j]), d)
+				}
+			}
+		} else {
+			nodeModules := filepath.Join(filepath.Dir(path), "node_modules")
+			unknown := 0
+			for j := range mods {
+				if mods[j].License == "" {
+					mods[j].License = deps.NodeModuleLicense(nodeModules, mods[j])
+				}
+				if mods[j].License == "" {
+					unknown++
+				}
+			}
+			if unknown > 0 {
+				slog.Warn("Licenses unavailable for packages not installed at their locked version", "lockfile", path, "node_modules", nodeModules, "packages", unknown)
+			}
+		}
+		sets[i] = mods
+	}
+	fmt.Print(deps.Diff(sets[0], sets[1]).Markdown())
+	return nil
+}
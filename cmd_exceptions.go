@@ -0,0 +1,230 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"./checker"
+	"./commits"
+	"./owners"
+)
+
+// exceptionReport is an active exception of the 'exceptions' report, with the
+// owners of its declaration and the commit that added it.
+type exceptionReport struct {
+	checker.Exception
+	Owners  []string
+	AddedBy *checker.Attribution
+	Added   time.Time
+}
+
+// runExceptions implements the 'exceptions' subcommand, which lists every
+// active exemption from the project's license policy in one report: the paths
+// excluded by the config, the languages that require no license, the configs
+// that are not enforced, the quarantine directories, the generated_sources,
+// the empty_files, minimum content and internal settings, the third-party and
+// generated files of the build manifest, and the known violations of the given
+// baselines. Each
+// exemption is listed with the number of files it applied to, the owners of
+// the file that declares it, and when and by whom it was added, so that stale
+// exemptions can be found and removed.
+func runExceptions(args []string) error {
+	flags := flag.NewFlagSet("exceptions", flag.ExitOnError)
+	dir := flags.String("dir", cwd(), "Project root directory to scan")
+	baselines := stringsFlag{}
+	flags.Var(&baselines, "baseline", "Path of a baseline file whose known violations are listed. May be repeated")
+	format := flags.String("format", "text", "Output format, one of text or json")
+	manifest := flags.String("build-manifest", "", "Path to the JSON build manifest of the scan, whose third-party and generated files are listed")
+	flags.Parse(args)
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("Unknown --format '%v', expected text or json", *format)
+	}
+
+	root, err := filepath.Abs(*dir)
+	if err != nil {
+		return fmt.Errorf("Failed to resolve '%v': %w", *dir, err)
+	}
+	opts := checker.Options{Quiet: true, ListSkipped: true}
+	if *manifest != "" {
+		if opts.BuildManifest, err = checker.LoadBuildManifest(*manifest); err != nil {
+			return err
+		}
+		opts.Config = opts.BuildManifest.Config
+	}
+	path, body, err := checker.ReadConfig(root, opts)
+	if err != nil {
+		return fmt.Errorf("Failed to read config file: %w", err)
+	}
+	cfgs, err := checker.ParseConfigs(body)
+	if err != nil {
+		return fmt.Errorf("Failed to parse config file '%v': %w", path, err)
+	}
+	// The lines of the exceptions are found in the file that holds the
+	// config, which may embed it.
+	if body, err = ioutil.ReadFile(path); err != nil {
+		return fmt.Errorf("Failed to read config file: %w", err)
+	}
+	exceptions := cfgs.Exceptions(projectRelative(root, path), body)
+	if opts.BuildManifest != nil {
+		body, err := ioutil.ReadFile(*manifest)
+		if err != nil {
+			return fmt.Errorf("Failed to read build manifest: %w", err)
+		}
+		exceptions = append(exceptions, opts.BuildManifest.Exceptions(projectRelative(root, *manifest), body)...)
+	}
+	for _, path := range baselines {
+		b, err := checker.LoadBaseline(path)
+		if err != nil {
+			return err
+		}
+		body, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("Failed to read baseline file: %w", err)
+		}
+		exceptions = append(exceptions, b.Exceptions(projectRelative(root, path), body)...)
+	}
+
+	results, err := checker.Scan(root, opts)
+	if err != nil {
+		return err
+	}
+	results.CountExceptions(exceptions)
+
+	o, err := owners.Load(root)
+	if err != nil {
+		return err
+	}
+	_, gitErr := exec.LookPath("git")
+	reports := make([]exceptionReport, len(exceptions))
+	for i, e := range exceptions {
+		reports[i] = exceptionReport{Exception: e, Owners: o.Of(e.Source)}
+		if gitErr != nil || e.Line == 0 || strings.HasPrefix(e.Source, "../") {
+			continue
+		}
+		a, added, err := commits.BlameLine(filepath.Join(root, filepath.FromSlash(e.Source)), e.Line)
+		if err != nil {
+			return err
+		}
+		reports[i].AddedBy, reports[i].Added = a, added
+	}
+
+	if *format == "json" {
+		return writeExceptionsJSON(reports, time.Now())
+	}
+	writeExceptionsText(reports, time.Now())
+	return nil
+}
+
+// projectRelative returns the slash separated path of path relative to root,
+// or path if it cannot be made relative.
+func projectRelative(root, path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// ageInDays returns the number of whole days from added to now.
+func ageInDays(added, now time.Time) int {
+	return int(now.Sub(added).Hours() / 24)
+}
+
+// writeExceptionsText writes the reports to stdout as a bulleted list.
+func writeExceptionsText(reports []exceptionReport, now time.Time) {
+	if len(reports) == 0 {
+		fmt.Printf("No active exceptions\n")
+		return
+	}
+	fmt.Printf("%d active exceptions:\n", len(reports))
+	for _, r := range reports {
+		fmt.Printf("* %v: %v (%d matches)\n", r.Kind, r.Rule, r.Matches)
+		declared := r.Source
+		if r.Line > 0 {
+			declared = fmt.Sprintf("%v:%d", r.Source, r.Line)
+		}
+		if r.AddedBy != nil {
+			fmt.Printf("    declared in %v, added %v by %v (%d days ago)\n",
+				declared, r.Added.Format("2006-01-02"), r.AddedBy, ageInDays(r.Added, now))
+		} else {
+			fmt.Printf("    declared in %v, not committed\n", declared)
+		}
+		if len(r.Owners) > 0 {
+			fmt.Printf("    owners: %v\n", strings.Join(r.Owners, " "))
+		} else {
+			fmt.Printf("    owners: none\n")
+		}
+	}
+}
+
+// writeExceptionsJSON writes the reports to stdout as a JSON array.
+func writeExceptionsJSON(reports []exceptionReport, now time.Time) error {
+	type jsonAttribution struct {
+		Commit string `json:"commit"`
+		Author string `json:"author"`
+		Email  string `json:"email"`
+	}
+	type jsonException struct {
+		Kind    checker.ExceptionKind `json:"kind"`
+		Rule    string                `json:"rule"`
+		Source  string                `json:"source"`
+		Line    int                   `json:"line,omitempty"`
+		Path    string                `json:"path"`
+		Matches int                   `json:"matches"`
+		Owners  []string              `json:"owners"`
+		AddedBy *jsonAttribution      `json:"added_by,omitempty"`
+		Added   *time.Time            `json:"added,omitempty"`
+		AgeDays *int                  `json:"age_days,omitempty"`
+	}
+	out := make([]jsonException, len(reports))
+	for i, r := range reports {
+		out[i] = jsonException{
+			Kind:    r.Kind,
+			Rule:    r.Rule,
+			Source:  r.Source,
+			Line:    r.Line,
+			Path:    r.Path,
+			Matches: r.Matches,
+			Owners:  r.Owners,
+		}
+		if out[i].Owners == nil {
+			out[i].Owners = []string{}
+		}
+		if r.AddedBy != nil {
+			added, age := r.Added, ageInDays(r.Added, now)
+			out[i].AddedBy = &jsonAttribution{Commit: r.AddedBy.Commit, Author: r.AddedBy.Author, Email: r.AddedBy.Email}
+			out[i].Added, out[i].AgeDays = &added, &age
+		}
+	}
+	body, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Failed to encode exceptions: %w", err)
+	}
+	_, err = fmt.Fprintf(os.Stdout, "%s\n", body)
+	return err
+}
@@ -0,0 +1,315 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"./checker"
+	"./forge"
+	"./owners"
+)
+
+// runFix implements the 'fix' subcommand, which fixes the headers of files
+// with stale header, suspicious character or header style violations, and
+// inserts the config's new_file_header into files without a license. Like a
+// code formatter, it either lists the files that would change (--check), prints
+// the patches (--diff), rewrites the files (--write) or only verifies that the
+// fixes are idempotent (--verify). With --patches or
+// --create-pr, the fixes are instead split into batches of files with the same
+// CODEOWNERS owners, and written as a patch file or sent as a pull request per
+// batch, so that each batch is reviewed by its owners.
+func runFix(args []string) error {
+	flags := flag.NewFlagSet("fix", flag.ExitOnError)
+	dir := flags.String("dir", cwd(), "Project root directory to scan")
+	licenseDB := flags.String("license-db", "", "Path to a JSON license database with licenses to add to the detectors")
+	check := flags.Bool("check", false, "List the files that would be changed, and fail if there are any")
+	diff := flags.Bool("diff", false, "Print a unified diff of the changes, without changing any file")
+	write := flags.Bool("write", false, "Rewrite the files")
+	verify := flags.Bool("verify", false, "Verify that fixing the files is idempotent, without changing any file")
+	patches := flags.String("patches", "", "Write a patch file per batch of fixes to this directory")
+	createPR := flags.Bool("create-pr", false, "Commit each batch of fixes to a new branch, push it to --remote and open a pull request for it")
+
+	b := fixBatches{}
+	flags.IntVar(&b.size, "batch-size", 100, "Maximum number of files of each batch of --patches and --create-pr")
+	flags.StringVar(&b.codeowners, "codeowners", "", "Path of the CODEOWNERS file used to batch fixes by owners. Defaults to the CODEOWNERS file of the project, if any")
+	flags.StringVar(&b.message, "message", "Fix license headers in {dir} ({batch}/{batches})", "Commit message and pull request title of --create-pr, and header of the --patches files. {batch}, {batches}, {files}, {dir} and {owners} are replaced by the batch number, the number of batches, and the number of files, common directory and owners of the batch")
+	flags.StringVar(&b.remote, "remote", "origin", "Git remote that --create-pr pushes to, and opens the pull requests on. Must be hosted on github.com or gitlab.com")
+	flags.StringVar(&b.branch, "branch", "license-checker/fix-headers", "Prefix of the branches created by --create-pr, which is followed by the batch number")
+	flags.Parse(args)
+
+	modes := 0
+	for _, m := range []bool{*check, *diff, *write, *verify, *patches != "", *createPR} {
+		if m {
+			modes++
+		}
+	}
+	if modes != 1 {
+		return fmt.Errorf("fix requires exactly one of --check, --diff, --write, --verify, --patches or --create-pr")
+	}
+	if b.size <= 0 {
+		return fmt.Errorf("--batch-size must be greater than zero")
+	}
+
+	root, err := filepath.Abs(*dir)
+	if err != nil {
+		return err
+	}
+	opts := checker.Options{Quiet: true, LicenseDB: *licenseDB}
+	results, err := checker.Scan(root, opts)
+	if err != nil {
+		return err
+	}
+	fixes, err := results.Fixes(root)
+	if err != nil {
+		return err
+	}
+	if *verify {
+		// Fixes verifies that each fix is idempotent.
+		fmt.Printf("Fixing %d files is idempotent\n", len(fixes))
+		return nil
+	}
+	if *patches != "" || *createPR {
+		if err := b.split(root, fixes); err != nil {
+			return err
+		}
+		if *patches != "" {
+			return b.writePatches(*patches)
+		}
+		return b.createPRs(root)
+	}
+	for _, f := range fixes {
+		switch {
+		case *check:
+			fmt.Println(opts.DisplayPath(root, f.Path))
+		case *diff:
+			fmt.Print(f.Diff())
+		case *write:
+			if err := f.Write(root); err != nil {
+				return err
+			}
+			fmt.Printf("Fixed %v\n", opts.DisplayPath(root, f.Path))
+		}
+	}
+	if *check && len(fixes) > 0 {
+		return fmt.Errorf("%d files would be changed by fix", len(fixes))
+	}
+	return nil
+}
+
+// fixBatches holds the settings of 'fix --patches' and 'fix --create-pr', and
+// the batches of fixes.
+type fixBatches struct {
+	size       int
+	codeowners string
+	message    string
+	remote     string
+	branch     string
+
+	batches []fixBatch
+}
+
+// fixBatch is a batch of fixes of files with the same owners.
+type fixBatch struct {
+	owners.Batch
+	fixes []checker.FileFix
+}
+
+// split splits the fixes into batches of at most fixBatches.size files with
+// the same CODEOWNERS owners, keeping the files of a directory together.
+func (b *fixBatches) split(root string, fixes []checker.FileFix) error {
+	var o *owners.Owners
+	var err error
+	if b.codeowners != "" {
+		o, err = owners.LoadFile(b.codeowners)
+	} else {
+		o, err = owners.Load(root)
+	}
+	if err != nil {
+		return err
+	}
+	byPath := map[string]checker.FileFix{}
+	paths := make([]string, len(fixes))
+	for i, f := range fixes {
+		paths[i] = filepath.ToSlash(f.Path)
+		byPath[paths[i]] = f
+	}
+	b.batches = nil
+	for _, batch := range o.Batch(paths, b.size) {
+		fb := fixBatch{Batch: batch}
+		for _, p := range batch.Paths {
+			fb.fixes = append(fb.fixes, byPath[p])
+		}
+		b.batches = append(b.batches, fb)
+	}
+	return nil
+}
+
+// title returns fixBatches.message with the placeholders of the i'th batch
+// replaced.
+func (b *fixBatches) title(i int) string {
+	batch := b.batches[i]
+	who := strings.Join(batch.Owners, " ")
+	if who == "" {
+		who = "no owners"
+	}
+	return strings.NewReplacer(
+		"{batch}", fmt.Sprint(i+1),
+		"{batches}", fmt.Sprint(len(b.batches)),
+		"{files}", fmt.Sprint(len(batch.fixes)),
+		"{dir}", batch.Dir,
+		"{owners}", who,
+	).Replace(b.message)
+}
+
+// description returns the commit message body and pull request description of
+// the i'th batch, which lists its owners and files.
+func (b *fixBatches) description(i int) string {
+	batch := b.batches[i]
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "Fixes the license headers of %d files with 'license-checker fix'.\n\n", len(batch.fixes))
+	if len(batch.Owners) > 0 {
+		fmt.Fprintf(&sb, "Owners: %v\n\n", strings.Join(batch.Owners, " "))
+	}
+	for _, f := range batch.fixes {
+		fmt.Fprintf(&sb, "* %v\n", checker.EscapePath(f.Path))
+	}
+	return sb.String()
+}
+
+// writePatches writes a patch file per batch to dir, named after the batch
+// number and directory, which can be applied with 'git apply'.
+func (b *fixBatches) writePatches(dir string) error {
+	if len(b.batches) == 0 {
+		fmt.Println("No files to fix")
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return fmt.Errorf("Failed to create patch directory: %w", err)
+	}
+	for i, batch := range b.batches {
+		name := strings.ReplaceAll(batch.Dir, "/", "-")
+		if name == "." {
+			name = "root"
+		}
+		path := filepath.Join(dir, fmt.Sprintf("%04d-%v.patch", i+1, name))
+		sb := strings.Builder{}
+		fmt.Fprintf(&sb, "%v\n\n%v\n", b.title(i), b.description(i))
+		for _, f := range batch.fixes {
+			sb.WriteString(f.Diff())
+		}
+		if err := ioutil.WriteFile(path, []byte(sb.String()), 0666); err != nil {
+			return fmt.Errorf("Failed to write patch: %w", err)
+		}
+		fmt.Printf("Wrote %v with %d files\n", path, len(batch.fixes))
+	}
+	return nil
+}
+
+// createPRs commits each batch to a new branch, branched from the current
+// branch of the repository at root, and pushes each branch and opens a pull
+// request for it. The work tree must have no uncommitted changes. The access
+// token is read from the GITHUB_TOKEN or GITLAB_TOKEN environment variable.
+// A fix of a symbolic link commits the file it links to, which must be in the
+// repository.
+func (b *fixBatches) createPRs(root string) error {
+	git := func(args ...string) (string, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			return "", fmt.Errorf("Failed to run 'git %v': %w\n%v", strings.Join(args, " "), err, string(out))
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+	if len(b.batches) == 0 {
+		fmt.Println("No files to fix")
+		return nil
+	}
+	targets := map[string]string{}
+	for _, batch := range b.batches {
+		for _, fix := range batch.fixes {
+			target, err := fix.Target(root)
+			if err != nil {
+				return err
+			}
+			targets[fix.Path] = target
+		}
+	}
+	if changes, err := git("status", "--porcelain", "--untracked-files=no"); err != nil {
+		return err
+	} else if changes != "" {
+		return fmt.Errorf("--create-pr requires a work tree without uncommitted changes")
+	}
+	base, err := git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return err
+	}
+	if base == "HEAD" {
+		return fmt.Errorf("--create-pr requires a checked out branch to branch from")
+	}
+	remoteURL, err := git("remote", "get-url", b.remote)
+	if err != nil {
+		return err
+	}
+	f, err := forge.FromRemote(remoteURL)
+	if err != nil {
+		return err
+	}
+	tokenVar := map[string]string{forge.GitHub: "GITHUB_TOKEN", forge.GitLab: "GITLAB_TOKEN"}[f.Kind]
+	if f.Token = os.Getenv(tokenVar); f.Token == "" {
+		return fmt.Errorf("--create-pr requires the %v environment variable", tokenVar)
+	}
+
+	defer git("checkout", "-q", base)
+	for i, batch := range b.batches {
+		title, body := b.title(i), b.description(i)
+		branch := fmt.Sprintf("%v-%d", b.branch, i+1)
+		if _, err := git("checkout", "-q", "-b", branch, base); err != nil {
+			return err
+		}
+		add := []string{"add", "--"}
+		for _, fix := range batch.fixes {
+			if err := fix.Write(root); err != nil {
+				return err
+			}
+			add = append(add, filepath.FromSlash(targets[fix.Path]))
+		}
+		if _, err := git(add...); err != nil {
+			return err
+		}
+		if _, err := git("commit", "-q", "-m", title+"\n\n"+body); err != nil {
+			return err
+		}
+		if _, err := git("checkout", "-q", base); err != nil {
+			return err
+		}
+		if _, err := git("push", "-q", b.remote, branch); err != nil {
+			return err
+		}
+		url, err := f.Open(forge.PullRequest{Head: branch, Base: base, Title: title, Body: body})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Opened %v with %d files: %v\n", branch, len(batch.fixes), url)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"./checker"
+)
+
+const testCodeowners = `
+*           @org/everyone
+/src/       @org/core
+src/gpu/**  @org/gpu
+`
+
+// batchPaths returns the fix paths of each batch.
+func batchPaths(b fixBatches) [][]string {
+	out := [][]string{}
+	for _, batch := range b.batches {
+		paths := []string{}
+		for _, f := range batch.fixes {
+			paths = append(paths, f.Path)
+		}
+		out = append(out, paths)
+	}
+	return out
+}
+
+// testFixes returns a fix per path, which appends a line to the file.
+func testFixes(paths ...string) []checker.FileFix {
+	out := []checker.FileFix{}
+	for _, p := range paths {
+		out = append(out, checker.FileFix{Path: p, Before: []byte("a\n"), After: []byte("a\nb\n")})
+	}
+	return out
+}
+
+func TestFixBatchesSplit(t *testing.T) {
+	root := t.TempDir()
+	codeowners := filepath.Join(root, "CODEOWNERS")
+	if err := ioutil.WriteFile(codeowners, []byte(testCodeowners), 0666); err != nil {
+		t.Fatal(err)
+	}
+	fixes := testFixes("main.go", "src/a.cc", "src/b.cc", "src/gpu/x.cc", "src/gpu/y.cc", "src/gpu/vk/z.cc", "tools/t.py")
+
+	for _, test := range []struct {
+		name       string
+		size       int
+		codeowners string
+		expect     [][]string
+		owners     [][]string
+	}{
+		{
+			"by owners", 100, codeowners,
+			[][]string{{"src/a.cc", "src/b.cc"}, {"main.go", "tools/t.py"}, {"src/gpu/x.cc", "src/gpu/y.cc", "src/gpu/vk/z.cc"}},
+			[][]string{{"@org/core"}, {"@org/everyone"}, {"@org/gpu"}},
+		},
+		{
+			"by size", 2, codeowners,
+			[][]string{{"src/a.cc", "src/b.cc"}, {"main.go", "tools/t.py"}, {"src/gpu/x.cc", "src/gpu/y.cc"}, {"src/gpu/vk/z.cc"}},
+			[][]string{{"@org/core"}, {"@org/everyone"}, {"@org/gpu"}, {"@org/gpu"}},
+		},
+		{
+			"without CODEOWNERS", 3, "",
+			[][]string{{"main.go", "src/a.cc", "src/b.cc"}, {"src/gpu/x.cc", "src/gpu/y.cc", "src/gpu/vk/z.cc"}, {"tools/t.py"}},
+			[][]string{nil, nil, nil},
+		},
+	} {
+		b := fixBatches{size: test.size, codeowners: test.codeowners}
+		// The project root has no CODEOWNERS file of its own.
+		if err := b.split(t.TempDir(), fixes); err != nil {
+			t.Errorf("%v: split() returned %v", test.name, err)
+			continue
+		}
+		if got := batchPaths(b); !reflect.DeepEqual(got, test.expect) {
+			t.Errorf("%v: split() returned batches %v, expected %v", test.name, got, test.expect)
+		}
+		owners := [][]string{}
+		for _, batch := range b.batches {
+			owners = append(owners, batch.Owners)
+		}
+		if !reflect.DeepEqual(owners, test.owners) {
+			t.Errorf("%v: split() returned owners %v, expected %v", test.name, owners, test.owners)
+		}
+	}
+
+	b := fixBatches{size: 100, codeowners: filepath.Join(root, "missing")}
+	if err := b.split(root, fixes); err == nil {
+		t.Errorf("split() with a missing --codeowners file returned nil")
+	}
+}
+
+func TestFixBatchesTitle(t *testing.T) {
+	b := fixBatches{size: 100}
+	if err := b.split(t.TempDir(), testFixes("src/a.cc", "src/b.cc")); err != nil {
+		t.Fatal(err)
+	}
+	b.batches = append(b.batches, b.batches[0])
+	b.batches[1].Owners = []string{"@org/core", "@alice"}
+
+	for _, test := range []struct {
+		message string
+		batch   int
+		expect  string
+	}{
+		{"Fix license headers in {dir} ({batch}/{batches})", 0, "Fix license headers in src (1/2)"},
+		{"Fix {files} files of {owners}", 0, "Fix 2 files of no owners"},
+		{"Fix {files} files of {owners}", 1, "Fix 2 files of @org/core @alice"},
+		{"Fix {unknown}", 1, "Fix {unknown}"},
+	} {
+		b.message = test.message
+		if got := b.title(test.batch); got != test.expect {
+			t.Errorf("title(%v) with message '%v' returned '%v', expected '%v'", test.batch, test.message, got, test.expect)
+		}
+	}
+}
+
+func TestFixBatchesWritePatches(t *testing.T) {
+	b := fixBatches{size: 2, message: "Fix {dir} ({batch}/{batches})"}
+	if err := b.split(t.TempDir(), testFixes("a.cc", "src/a.cc", "src/b.cc")); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(t.TempDir(), "patches")
+	if err := b.writePatches(dir); err != nil {
+		t.Fatalf("writePatches() returned %v", err)
+	}
+	for _, test := range []struct {
+		file   string
+		expect []string
+	}{
+		{"0001-root.patch", []string{"Fix . (1/2)\n\n", "* a.cc\n", "--- a/a.cc\n+++ b/a.cc\n", "+b\n"}},
+		{"0002-src.patch", []string{"Fix src (2/2)\n\n", "* src/a.cc\n* src/b.cc\n", "+++ b/src/a.cc\n", "+++ b/src/b.cc\n"}},
+	} {
+		body, err := ioutil.ReadFile(filepath.Join(dir, test.file))
+		if err != nil {
+			t.Errorf("writePatches() did not write %v: %v", test.file, err)
+			continue
+		}
+		for _, expect := range test.expect {
+			if !strings.Contains(string(body), expect) {
+				t.Errorf("%v does not contain %q:\n%v", test.file, expect, string(body))
+			}
+		}
+	}
+	if files, err := ioutil.ReadDir(dir); err != nil || len(files) != 2 {
+		t.Errorf("writePatches() wrote %d files, expected 2: %v", len(files), err)
+	}
+
+	// Without batches, no directory is created.
+	empty := fixBatches{size: 2}
+	none := filepath.Join(t.TempDir(), "none")
+	if err := empty.writePatches(none); err != nil {
+		t.Errorf("writePatches() without batches returned %v", err)
+	}
+	if _, err := ioutil.ReadDir(none); err == nil {
+		t.Errorf("writePatches() without batches created %v", none)
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"./checker"
+	"./commits"
+	"./deps"
+	"./detector"
+	"./report"
+)
+
+// riskLevels orders the checker.RiskLevels, from lowest to highest.
+var riskLevels = map[checker.RiskLevel]int{
+	checker.RiskLow:    0,
+	checker.RiskMedium: 1,
+	checker.RiskHigh:   2,
+}
+
+// gateEvidence is the summary of a release gate, written to gate.json in the
+// evidence directory.
+type gateEvidence struct {
+	Tag        string       `json:"tag"`
+	Commit     string       `json:"commit"`
+	Passed     bool         `json:"passed"`
+	Violations int          `json:"violations"`
+	Budgets    []gateBudget `json:"budgets"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// gateBudget is a single budget checked by the release gate.
+type gateBudget struct {
+	Name   string `json:"name"`
+	Limit  string `json:"limit"`
+	Actual string `json:"actual"`
+	Met    bool   `json:"met"`
+}
+
+// runGateRelease implements the 'gate-release' subcommand, which checks the
+// tree of a release tag, verifies the release budgets are met, and writes the
+// reports, SBOM and NOTICE of the release to an evidence directory.
+func runGateRelease(args []string) error {
+	flags := flag.NewFlagSet("gate-release", flag.ExitOnError)
+	dir := flags.String("dir", cwd(), "Git repository holding the release tag")
+	evidence := flags.String("evidence", "", "Directory to write the evidence bundle to. Defaults to license-evidence-<tag>")
+	licenseDB := flags.String("license-db", "", "Path to a JSON license database with licenses to add to the detectors")
+	maxRisk := flags.String("max-risk", string(checker.RiskMedium), "Highest risk level the release may have, one of low, medium or high")
+	minCoverage := flags.Float64("min-coverage", 0, "Lowest percentage of the release's files that must be checked")
+	maxWarnings := flags.Int("max-warnings", -1, "Highest number of warnings the release may have, or -1 for no limit")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: license-checker gate-release [flags] <tag>\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("gate-release requires the release tag")
+	}
+	tag := flags.Arg(0)
+	if _, ok := riskLevels[checker.RiskLevel(*maxRisk)]; !ok {
+		return fmt.Errorf("Unknown --max-risk level '%v'. Must be one of low, medium or high", *maxRisk)
+	}
+	if *evidence == "" {
+		*evidence = "license-evidence-" + strings.ReplaceAll(tag, "/", "-")
+	}
+
+	tree, err := ioutil.TempDir("", "license-checker-release")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tree)
+	commit, err := commits.ExportTree(*dir, tag, tree)
+	if err != nil {
+		return err
+	}
+	slog.Info("Checking release", "tag", tag, "commit", commit)
+
+	opts := checker.Options{Quiet: true, LicenseDB: *licenseDB}
+	results, err := checker.Scan(tree, opts)
+	if err != nil {
+		return err
+	}
+	cov, err := checker.MeasureCoverage(tree, results, opts)
+	if err != nil {
+		return err
+	}
+	risk, err := checker.MeasureRisk(tree, results, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(*evidence, 0777); err != nil {
+		return fmt.Errorf("Failed to create evidence directory: %w", err)
+	}
+	in := report.Input{Root: tree, Results: results, Options: opts, Coverage: &cov, Risk: &risk}
+	ev := gateEvidence{Tag: tag, Commit: commit}
+	for _, r := range []struct{ format, file string }{
+		{"json", "report.json"},
+		{"spdx", "sbom.spdx"},
+		{"obligations", "obligations.md"},
+		{"decisions", "decisions.ndjson"},
+	} {
+		if err := (reportRequest{format: r.format, output: filepath.Join(*evidence, r.file)}).write(in); err != nil {
+			return err
+		}
+		ev.Artifacts = append(ev.Artifacts, r.file)
+	}
+	if err := writeReleaseNotice(tree, filepath.Join(*evidence, "NOTICE"), *licenseDB); err != nil {
+		return err
+	}
+	ev.Artifacts = append(ev.Artifacts, "NOTICE", "gate.json")
+
+	ev.Violations = len(results.Failures(opts).Errs())
+	warnings := len(results.Warnings(opts).Errs())
+	ev.Budgets = []gateBudget{
+		{"violations", "0", fmt.Sprint(ev.Violations), ev.Violations == 0},
+		{"risk", *maxRisk, string(risk.Level), riskLevels[risk.Level] <= riskLevels[checker.RiskLevel(*maxRisk)]},
+		{"coverage", fmt.Sprintf("%.1f%%", *minCoverage), fmt.Sprintf("%.1f%%", cov.Percent()), cov.Percent() >= *minCoverage},
+	}
+	if *maxWarnings >= 0 {
+		ev.Budgets = append(ev.Budgets, gateBudget{"warnings", fmt.Sprint(*maxWarnings), fmt.Sprint(warnings), warnings <= *maxWarnings})
+	}
+	ev.Passed = true
+	unmet := []string{}
+	for _, b := range ev.Budgets {
+		if !b.Met {
+			ev.Passed = false
+			unmet = append(unmet, fmt.Sprintf("%v is %v, limit %v", b.Name, b.Actual, b.Limit))
+		}
+	}
+	body, err := json.MarshalIndent(ev, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(*evidence, "gate.json"), append(body, '\n'), 0666); err != nil {
+		return fmt.Errorf("Failed to write gate.json: %w", err)
+	}
+
+	fmt.Printf("Release %v (%v)\n", tag, commit)
+	fmt.Printf("Risk: %v\n", risk)
+	fmt.Printf("Coverage: %v\n", cov)
+	fmt.Printf("Evidence written to %v\n", *evidence)
+	if !ev.Passed {
+		return fmt.Errorf("Release %v does not meet its budgets:\n* %v\n%v", tag, strings.Join(unmet, "\n* "), results.List(opts))
+	}
+	fmt.Printf("Release %v passed\n", tag)
+	return nil
+}
+
+// writeReleaseNotice writes the notices of the Go module dependencies of the
+// release tree to path. The file is empty if the release has no go.mod.
+func writeReleaseNotice(tree, path, licenseDB string) error {
+	attributions := []deps.Attribution{}
+	if _, err := os.Stat(filepath.Join(tree, "go.mod")); err == nil {
+		var db *detector.Database
+		if licenseDB != "" {
+			if db, err = detector.LoadDatabase(licenseDB); err != nil {
+				return err
+			}
+		}
+		d, err := detector.New("", db)
+		if err != nil {
+			return err
+		}
+		mods, err := deps.Graph(tree)
+		if err != nil {
+			return err
+		}
+		attributions = deps.Attributions(mods, d)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("Failed to create NOTICE file: %w", err)
+	}
+	defer f.Close()
+	writeNotices(f, attributions)
+	return f.Close()
+}
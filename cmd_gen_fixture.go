@@ -0,0 +1,58 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"./fixture"
+)
+
+// runGenFixture implements the 'gen-fixture' subcommand, which writes minimal
+// compliant and non-compliant sample files for a license and language.
+func runGenFixture(args []string) error {
+	flags := flag.NewFlagSet("gen-fixture", flag.ExitOnError)
+	license := flags.String("license", "", "License of the compliant fixture, for example Apache-2.0-Header")
+	lang := flags.String("lang", "", "Language of the fixtures, for example go")
+	output := flags.String("output", "fixtures", "Directory to write the fixtures to")
+	flags.Parse(args)
+
+	if *license == "" || *lang == "" {
+		return fmt.Errorf("gen-fixture requires --license and --lang")
+	}
+	fixtures, err := fixture.Generate(*license, *lang)
+	if err != nil {
+		return err
+	}
+	for _, f := range fixtures {
+		path := filepath.Join(*output, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			return fmt.Errorf("Failed to create fixture directory: %w", err)
+		}
+		if err := ioutil.WriteFile(path, f.Body, 0666); err != nil {
+			return fmt.Errorf("Failed to write fixture: %w", err)
+		}
+		status := "non-compliant"
+		if f.Compliant {
+			status = "compliant"
+		}
+		fmt.Printf("%v (%v)\n", path, status)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"./checker"
+)
+
+// runMergeBaselines implements the 'merge-baselines' subcommand, which
+// combines the baselines written by the --update-baseline scans of several
+// checkouts, such as the Windows and Linux CI lanes, into a single baseline.
+func runMergeBaselines(args []string) error {
+	flags := flag.NewFlagSet("merge-baselines", flag.ExitOnError)
+	output := flags.String("output", "", "Path of the merged baseline file to write")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: license-checker merge-baselines --output <file> <baseline>...\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if *output == "" || flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("merge-baselines requires --output and at least one baseline")
+	}
+
+	baselines := []checker.Baseline{}
+	for _, path := range flags.Args() {
+		b, err := checker.LoadBaseline(path)
+		if err != nil {
+			return err
+		}
+		baselines = append(baselines, b)
+	}
+	merged := checker.MergeBaselines(baselines...)
+	if err := merged.Write(*output); err != nil {
+		return err
+	}
+	fmt.Printf("Merged %d baselines with %d violations into %v\n", len(baselines), len(merged.Violations), *output)
+	return nil
+}
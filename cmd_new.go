@@ -0,0 +1,90 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"./checker"
+	"./language"
+)
+
+// runNew implements the 'new' subcommand, which creates a new file holding
+// the license header that the project's config requires of the file, so that
+// editors and scripts can scaffold compliant files.
+func runNew(args []string) error {
+	flags := flag.NewFlagSet("new", flag.ExitOnError)
+	dir := flags.String("dir", cwd(), "Project root directory holding the config file")
+	config := flags.String("config", "", "Path of the config file. Defaults to the config file in --dir")
+	path := flags.String("path", "", "Project relative path of the new file, for example src/foo.cc")
+	lang := flags.String("lang", "", "Language of the new file, for example cpp. Defaults to the language of --path")
+	show := flags.Bool("print", false, "Print the content of the new file, instead of creating it")
+	flags.Parse(args)
+
+	if *path == "" {
+		return fmt.Errorf("new requires --path")
+	}
+	l, ok := language.ForPath(*path)
+	if *lang != "" {
+		if l, ok = language.ByName(*lang); !ok {
+			return fmt.Errorf("Unknown language '%v'", *lang)
+		}
+	} else if !ok {
+		return fmt.Errorf("Cannot determine the language of %v. Use --lang", *path)
+	}
+	cfgPath, body, err := checker.ReadConfig(*dir, checker.Options{Config: *config})
+	if err != nil {
+		return fmt.Errorf("Failed to read config file: %w", err)
+	}
+	*config = cfgPath
+	cfgs, err := checker.ParseConfigs(body)
+	if err != nil {
+		return fmt.Errorf("Failed to parse config file '%v': %w", *config, err)
+	}
+	policy, err := checker.NewPolicy(cfgs, nil)
+	if err != nil {
+		return err
+	}
+	content, err := policy.NewFile(*path, l, time.Now().Year())
+	if err != nil {
+		return err
+	}
+	if *show {
+		fmt.Print(string(content))
+		return nil
+	}
+
+	file := filepath.Join(*dir, filepath.FromSlash(*path))
+	if err := os.MkdirAll(filepath.Dir(file), 0777); err != nil {
+		return fmt.Errorf("Failed to create directory: %w", err)
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return fmt.Errorf("Failed to create new file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(content); err != nil {
+		return fmt.Errorf("Failed to write new file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("Failed to write new file: %w", err)
+	}
+	fmt.Printf("Created %v\n", *path)
+	return nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"./deps"
+	"./detector"
+)
+
+// runNotices implements the 'notices' subcommand, which prints the attribution
+// text required by each of the Go module dependencies of the project. With
+// --check, it instead verifies that the project's aggregate NOTICE file holds
+// the NOTICE content of each dependency whose license requires it, and prints
+// the text to add for those that are missing.
+func runNotices(args []string) error {
+	flags := flag.NewFlagSet("notices", flag.ExitOnError)
+	dir := flags.String("dir", cwd(), "Project root directory, holding go.mod")
+	format := flags.String("format", "text", "Output format, one of [text json]")
+	output := flags.String("output", "-", "Path of the file to write, or - for stdout")
+	licenseDB := flags.String("license-db", "", "Path to a JSON license database with licenses to add to the detectors")
+	check := flags.String("check", "", "Path of the project's aggregate NOTICE file to check for the NOTICE content required by the dependencies' licenses")
+	flags.Parse(args)
+
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("Unknown notices format '%v'", *format)
+	}
+
+	var db *detector.Database
+	if *licenseDB != "" {
+		var err error
+		if db, err = detector.LoadDatabase(*licenseDB); err != nil {
+			return err
+		}
+	}
+	d, err := detector.New("", db)
+	if err != nil {
+		return err
+	}
+
+	mods, err := deps.Graph(*dir)
+	if err != nil {
+		return err
+	}
+	attributions := deps.Attributions(mods, d)
+	if *check != "" {
+		return checkNotices(attributions, *check)
+	}
+
+	w := io.Writer(os.Stdout)
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("Failed to create notices file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if *format == "json" {
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		if err := e.Encode(attributions); err != nil {
+			return fmt.Errorf("Failed to write notices: %w", err)
+		}
+	} else {
+		writeNotices(w, attributions)
+	}
+	if f, ok := w.(*os.File); ok && f != os.Stdout {
+		return f.Close()
+	}
+	return nil
+}
+
+// checkNotices verifies that the aggregate NOTICE file at path holds the
+// NOTICE content required by the attributions. The text of each missing
+// notice is printed, ready to be appended to the file.
+// writeNotices writes the text notices of the dependencies to w.
+func writeNotices(w io.Writer, attributions []deps.Attribution) {
+	for _, a := range attributions {
+		fmt.Fprintf(w, "%v %v\n", a.Name, a.Version)
+		fmt.Fprintf(w, "License: %v\n", strings.Join(a.Licenses, ", "))
+		for _, c := range a.Copyrights {
+			fmt.Fprintln(w, c)
+		}
+		if a.Notice != "" {
+			fmt.Fprintf(w, "\n%v\n", strings.TrimSpace(a.Notice))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func checkNotices(attributions []deps.Attribution, path string) error {
+	body, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Failed to read NOTICE file: %w", err)
+	}
+	missing := deps.MissingNotices(attributions, body)
+	if len(missing) == 0 {
+		fmt.Printf("No missing notices found\n")
+		return nil
+	}
+	msg := strings.Builder{}
+	for _, m := range missing {
+		fmt.Fprintf(&msg, "* %v\n", m)
+	}
+	fmt.Printf("Add the following to %v:\n", path)
+	for _, m := range missing {
+		fmt.Printf("\n%v %v\n%v\n", m.Name, m.Version, m.Text)
+	}
+	return fmt.Errorf("%d dependency notices are missing from %v:\n%v", len(missing), path, msg.String())
+}
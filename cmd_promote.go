@@ -0,0 +1,48 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"./checker"
+)
+
+// runPromote implements the 'promote' subcommand, which verifies that a
+// component of the config's quarantine directory passes the full license
+// rules, so that it can be moved out of quarantine, for example into
+// third_party.
+func runPromote(args []string) error {
+	flags := flag.NewFlagSet("promote", flag.ExitOnError)
+	dir := flags.String("dir", cwd(), "Project root directory to scan")
+	licenseDB := flags.String("license-db", "", "Path to a JSON license database with licenses to add to the detectors")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: license-checker promote [flags] <component-dir>\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("promote requires the project relative directory of a quarantined component")
+	}
+
+	opts := checker.Options{Quiet: true, LicenseDB: *licenseDB}
+	results, err := checker.VerifyPromotion(*dir, flags.Arg(0), opts)
+	if err != nil {
+		return err
+	}
+	return results.Check(checker.Options{})
+}
@@ -0,0 +1,63 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	"./checker"
+)
+
+// runReleaseExport implements the 'release-export' subcommand, which verifies
+// that a subtree of the project, or the files listed by a manifest, are clean
+// and self-contained, so that they can be exported as a standalone open source
+// release.
+func runReleaseExport(args []string) error {
+	flags := flag.NewFlagSet("release-export", flag.ExitOnError)
+	dir := flags.String("dir", cwd(), "Project root directory to scan")
+	licenseDB := flags.String("license-db", "", "Path to a JSON license database with licenses to add to the detectors")
+	manifest := flags.String("manifest", "", "Path to a file listing the project relative paths of the files to export, instead of a subtree")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: license-checker release-export [flags] <subtree>\n")
+		fmt.Fprintf(flags.Output(), "       license-checker release-export [flags] --manifest <file>\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if (*manifest == "") != (flags.NArg() == 1) || flags.NArg() > 1 {
+		flags.Usage()
+		return fmt.Errorf("release-export requires either the subtree to export, or --manifest")
+	}
+
+	opts := checker.Options{Quiet: true, LicenseDB: *licenseDB}
+	var results checker.Results
+	if *manifest != "" {
+		body, err := ioutil.ReadFile(*manifest)
+		if err != nil {
+			return fmt.Errorf("Failed to read manifest: %w", err)
+		}
+		results, err = checker.VerifyManifest(*dir, checker.ParseManifest(body), opts)
+		if err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if results, err = checker.VerifyExport(*dir, flags.Arg(0), opts); err != nil {
+			return err
+		}
+	}
+	return results.Check(checker.Options{})
+}
@@ -0,0 +1,43 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"./checker"
+)
+
+// runSimulate implements the 'simulate' subcommand, which reports the
+// violations that would newly fail or pass if the project's config was replaced
+// with a proposed config.
+func runSimulate(args []string) error {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	dir := flags.String("dir", cwd(), "Project root directory to scan")
+	config := flags.String("config", "", "Path of the proposed config file")
+	licenseDB := flags.String("license-db", "", "Path to a JSON license database with licenses to add to the detectors")
+	flags.Parse(args)
+
+	if *config == "" {
+		return fmt.Errorf("simulate requires --config")
+	}
+	s, err := checker.Simulate(*dir, *config, checker.Options{Quiet: true, LicenseDB: *licenseDB})
+	if err != nil {
+		return err
+	}
+	fmt.Print(s)
+	return nil
+}
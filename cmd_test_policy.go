@@ -0,0 +1,75 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"./checker"
+	"./detector"
+)
+
+// runTestPolicy implements the 'test-policy' subcommand, which runs the
+// config against the declarative expectations of the policy tests file, so
+// that changes to the config can be validated by CI.
+func runTestPolicy(args []string) error {
+	flags := flag.NewFlagSet("test-policy", flag.ExitOnError)
+	dir := flags.String("dir", cwd(), "Project root directory holding the files of the tests without content")
+	config := flags.String("config", "", "Path of the config file to test. Defaults to the config file in --dir")
+	tests := flags.String("tests", "", "Path of the policy tests file. Defaults to "+checker.DefaultPolicyTestsFileName+" next to the config file")
+	licenseDB := flags.String("license-db", "", "Path to a JSON license database with licenses to add to the detectors")
+	flags.Parse(args)
+
+	cfgPath, body, err := checker.ReadConfig(*dir, checker.Options{Config: *config})
+	if err != nil {
+		return fmt.Errorf("Failed to read config file: %w", err)
+	}
+	*config = cfgPath
+	if *tests == "" {
+		*tests = filepath.Join(filepath.Dir(*config), checker.DefaultPolicyTestsFileName)
+	}
+	cfgs, err := checker.ParseConfigs(body)
+	if err != nil {
+		return fmt.Errorf("Failed to parse config file '%v': %w", *config, err)
+	}
+	t, err := checker.LoadPolicyTests(*tests)
+	if err != nil {
+		return err
+	}
+	var db *detector.Database
+	if *licenseDB != "" {
+		if db, err = detector.LoadDatabase(*licenseDB); err != nil {
+			return err
+		}
+	}
+	policy, err := checker.NewPolicy(cfgs, db)
+	if err != nil {
+		return err
+	}
+
+	errs := policy.Test(*dir, t)
+	if len(errs) > 0 {
+		msg := strings.Builder{}
+		for _, err := range errs {
+			fmt.Fprintf(&msg, "* %v\n", err)
+		}
+		return fmt.Errorf("%d of %d policy tests failed:\n%v", len(errs), len(t.Tests), msg.String())
+	}
+	fmt.Printf("All %d policy tests passed\n", len(t.Tests))
+	return nil
+}
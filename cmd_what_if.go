@@ -0,0 +1,84 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"./checker"
+)
+
+// runWhatIf implements the 'what-if' subcommand, which reports whether a
+// hypothetical file with the given licenses would pass the project's config,
+// and which config rules would decide it.
+func runWhatIf(args []string) error {
+	flags := flag.NewFlagSet("what-if", flag.ExitOnError)
+	dir := flags.String("dir", cwd(), "Project root directory holding the config file")
+	config := flags.String("config", "", "Path of the config file. Defaults to the config file in --dir")
+	path := flags.String("path", "", "Project relative path of the hypothetical file, for example new/dir/file.go")
+	licenses := stringsFlag{}
+	flags.Var(&licenses, "license", "License of the hypothetical file, for example GPL-3.0. May be repeated. Omit for a file without a license")
+	flags.Parse(args)
+
+	if *path == "" {
+		return fmt.Errorf("what-if requires --path")
+	}
+	cfgPath, body, err := checker.ReadConfig(*dir, checker.Options{Config: *config})
+	if err != nil {
+		return fmt.Errorf("Failed to read config file: %w", err)
+	}
+	*config = cfgPath
+	cfgs, err := checker.ParseConfigs(body)
+	if err != nil {
+		return fmt.Errorf("Failed to parse config file '%v': %w", *config, err)
+	}
+	policy, err := checker.NewPolicy(cfgs, nil)
+	if err != nil {
+		return err
+	}
+
+	with := "no license"
+	if len(licenses) > 0 {
+		with = strings.Join(licenses, ", ")
+	}
+	results := policy.WhatIf(*path, licenses)
+	if len(results) == 1 && results[0].Skipped != "" {
+		fmt.Printf("%v with %v would not be checked: %v\n", *path, with, results[0].Skipped)
+		return nil
+	}
+	failed := false
+	for _, res := range results {
+		status := "PASS"
+		switch {
+		case res.Err != nil && res.Advisory:
+			status = "WARN"
+		case res.Err != nil:
+			status, failed = "FAIL", true
+		}
+		fmt.Printf("%v: %v with %v\n", status, *path, with)
+		if d := res.Decision; d != nil {
+			fmt.Printf("  decided by configs[%d]: %v\n", d.Config, strings.Join(d.Rules, ", "))
+		}
+		if res.Err != nil {
+			fmt.Printf("  %v\n", res.Err)
+		}
+	}
+	if failed {
+		return fmt.Errorf("%v with %v would fail the license check", *path, with)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commits
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"../checker"
+)
+
+// Blame returns the Attribution of the first line of the file at path, which
+// is the commit that introduced the file unless its first line was changed
+// since. Unlike readLog, Blame always runs 'git blame', as go-git blames every
+// line of the file. The repository is found from the file's directory, so
+// files of submodules are blamed in the submodule. Blame returns nil if the
+// file, or its first line, is not committed, or if the file is empty.
+func Blame(path string) (*checker.Attribution, error) {
+	a, _, err := BlameLine(path, 1)
+	return a, err
+}
+
+// BlameLine returns the Attribution of the 1-based line of the file at path,
+// and the time the line was authored, as described by Blame. BlameLine returns
+// nil if the file, or the line, is not committed, or if the file has fewer
+// lines.
+func BlameLine(path string, line int) (*checker.Attribution, time.Time, error) {
+	cmd := exec.Command("git", "blame", "--porcelain", "-L", fmt.Sprintf("%d,%d", line, line), "--", filepath.Base(path))
+	cmd.Dir = filepath.Dir(path)
+	stderr := bytes.Buffer{}
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		for _, uncommitted := range []string{"no such path", "not a git repository", "has only "} {
+			if strings.Contains(stderr.String(), uncommitted) {
+				return nil, time.Time{}, nil
+			}
+		}
+		return nil, time.Time{}, fmt.Errorf("Failed to run 'git blame %v': %w\n%v", path, err, stderr.String())
+	}
+	a, authored := parseBlame(string(out))
+	return a, authored, nil
+}
+
+// parseBlame parses the 'git blame --porcelain' output of a single line,
+// returning nil if the line is not committed, and the author time.
+func parseBlame(out string) (*checker.Attribution, time.Time) {
+	a := checker.Attribution{}
+	authored := time.Time{}
+	for i, line := range strings.Split(out, "\n") {
+		switch {
+		case i == 0:
+			a.Commit = strings.SplitN(line, " ", 2)[0]
+		case strings.HasPrefix(line, "author "):
+			a.Author = strings.TrimPrefix(line, "author ")
+		case strings.HasPrefix(line, "author-mail "):
+			a.Email = strings.Trim(strings.TrimPrefix(line, "author-mail "), "<>")
+		case strings.HasPrefix(line, "author-time "):
+			if secs, err := strconv.ParseInt(strings.TrimPrefix(line, "author-time "), 10, 64); err == nil {
+				authored = time.Unix(secs, 0).UTC()
+			}
+		}
+	}
+	if a.Commit == "" || strings.Trim(a.Commit, "0") == "" {
+		return nil, time.Time{}
+	}
+	return &a, authored
+}
+
+// Attribute sets the Attribution of each result of a file with a violation,
+// found with Blame, so that the violations can be routed to the authors of the
+// files. root is the directory the results were scanned from.
+func Attribute(root string, results checker.Results) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("Attributing violations requires git: %w", err)
+	}
+	blamed := map[string]*checker.Attribution{}
+	for i, res := range results {
+		if res.Err == nil || !res.Kind.IsFile() || strings.HasSuffix(res.Path, "/") {
+			continue
+		}
+		a, ok := blamed[res.Path]
+		if !ok {
+			var err error
+			if a, err = Blame(filepath.Join(root, filepath.FromSlash(res.Path))); err != nil {
+				return err
+			}
+			blamed[res.Path] = a
+		}
+		results[i].Attribution = a
+	}
+	return nil
+}
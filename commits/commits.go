@@ -0,0 +1,133 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package commits verifies that the git commits importing third-party code
+// declare the license and origin of the code with commit message trailers.
+package commits
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Options control which commits are checked, and the trailers they need.
+type Options struct {
+	// ImportDirs is the list of directory names that hold third-party code.
+	// A commit that adds or modifies a file under a directory with one of
+	// these names, at any depth, is considered to import third-party code.
+	ImportDirs []string
+
+	// Trailers is the list of trailer keys that an importing commit's message
+	// must contain, for example "License" and "Origin".
+	Trailers []string
+}
+
+// DefaultOptions returns the default Options.
+func DefaultOptions() Options {
+	return Options{
+		ImportDirs: []string{"third_party"},
+		Trailers:   []string{"License", "Origin"},
+	}
+}
+
+// Violation describes a commit that imports third-party code without the
+// required trailers.
+type Violation struct {
+	Commit  string   // the full commit hash
+	Subject string   // the first line of the commit message
+	Files   []string // the imported files
+	Missing []string // the missing trailer keys
+}
+
+func (v Violation) Error() string {
+	return fmt.Sprintf("%v %q imports %v without trailers: %v",
+		v.Commit[:12], v.Subject, strings.Join(v.Files, ", "), strings.Join(v.Missing, ", "))
+}
+
+// Check returns the commits in the git revision range revs of the repository
+// in dir that import third-party code without all of the required trailers.
+// revs is a revision range such as 'origin/main..HEAD'. The history is read
+// without requiring git to be installed, but other revision range syntaxes
+// accepted by 'git log' need git. Merge commits are not checked.
+func Check(dir, revs string, opts Options) ([]Violation, error) {
+	log, err := readLog(dir, revs, false)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := []Violation{}
+	for _, c := range log {
+		imported := []string{}
+		for _, file := range c.files {
+			if isImport(file, opts.ImportDirs) {
+				imported = append(imported, file)
+			}
+		}
+		if len(imported) == 0 {
+			continue
+		}
+
+		present := Trailers(c.message)
+		missing := []string{}
+		for _, key := range opts.Trailers {
+			if _, ok := present[strings.ToLower(key)]; !ok {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) > 0 {
+			violations = append(violations, Violation{
+				Commit:  c.hash,
+				Subject: strings.SplitN(strings.TrimSpace(c.message), "\n", 2)[0],
+				Files:   imported,
+				Missing: missing,
+			})
+		}
+	}
+	return violations, nil
+}
+
+// isImport returns true if the '/' separated path is under a directory with
+// one of the given names.
+func isImport(path string, dirs []string) bool {
+	parts := strings.Split(path, "/")
+	for _, part := range parts[:len(parts)-1] {
+		for _, dir := range dirs {
+			if part == dir {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Trailers returns the trailers of the commit message, keyed by lower-case
+// trailer key. Trailers are the 'Key: value' lines of the message's last
+// paragraph, such as 'Signed-off-by: Alice <alice@example.com>'.
+func Trailers(message string) map[string]string {
+	paragraphs := strings.Split(strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n")), "\n\n")
+	out := map[string]string{}
+	if len(paragraphs) < 2 {
+		return out // A message with a single paragraph only has a subject
+	}
+	for _, line := range strings.Split(paragraphs[len(paragraphs)-1], "\n") {
+		i := strings.Index(line, ":")
+		if i <= 0 || strings.ContainsAny(line[:i], " \t") {
+			continue
+		}
+		if value := strings.TrimSpace(line[i+1:]); value != "" {
+			out[strings.ToLower(line[:i])] = value
+		}
+	}
+	return out
+}
@@ -0,0 +1,432 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commits_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	commits "."
+	"../checker"
+	"../provenance"
+)
+
+func TestExportTree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%v", args, err, string(out))
+		}
+		return strings.TrimSpace(string(out))
+	}
+	write := func(file, body string) {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(body), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	write("a.txt", "v1")
+	write("src/b.go", "package b")
+	git("add", "-A")
+	git("commit", "-q", "-m", "Release")
+	git("tag", "v1")
+	release := git("rev-parse", "HEAD")
+	write("a.txt", "v2")
+	git("commit", "-q", "-am", "Change")
+	write("c.txt", "uncommitted")
+
+	dst := t.TempDir()
+	hash, err := commits.ExportTree(dir, "v1", dst)
+	if err != nil {
+		t.Fatalf("ExportTree() returned %v", err)
+	}
+	if hash != release {
+		t.Errorf("ExportTree() returned hash %v, expected %v", hash, release)
+	}
+	for file, expect := range map[string]string{"a.txt": "v1", "src/b.go": "package b"} {
+		body, err := ioutil.ReadFile(filepath.Join(dst, file))
+		if err != nil || string(body) != expect {
+			t.Errorf("Exported %v is %q (%v), expected %q", file, body, err, expect)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst, "c.txt")); !os.IsNotExist(err) {
+		t.Errorf("ExportTree() exported an uncommitted file")
+	}
+	if got := git("status", "--porcelain"); got != "?? c.txt" {
+		t.Errorf("ExportTree() modified the work tree: %q", got)
+	}
+	if _, err := commits.ExportTree(dir, "no-such-tag", t.TempDir()); err == nil {
+		t.Errorf("ExportTree() of an unknown revision returned no error")
+	}
+}
+
+func TestAttribute(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir := t.TempDir()
+	git := func(name string, args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=" + name, "-c", "user.email=" + strings.ToLower(name) + "@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%v", args, err, string(out))
+		}
+		return strings.TrimSpace(string(out))
+	}
+	for file, body := range map[string]string{"a.go": "package a\n", "b.go": "package b\n", "c.go": "package c\n"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(body), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("Alice", "init", "-q")
+	git("Alice", "add", "a.go", "b.go")
+	git("Alice", "commit", "-q", "-m", "Add a and b")
+	first := git("Alice", "rev-parse", "HEAD")
+	if err := ioutil.WriteFile(filepath.Join(dir, "b.go"), []byte("package b\n\nvar B = 1\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	git("Bob", "commit", "-q", "-am", "Change b")
+
+	violation := errors.New("no license")
+	results := checker.Results{
+		{Path: "a.go", Err: violation, Kind: checker.NoLicense},
+		{Path: "b.go", Err: violation, Kind: checker.NoLicense},
+		{Path: "c.go", Err: violation, Kind: checker.NoLicense},
+		{Path: "d.go"},
+	}
+	if err := commits.Attribute(dir, results); err != nil {
+		t.Fatalf("Attribute() returned %v", err)
+	}
+	alice := &checker.Attribution{Commit: first, Author: "Alice", Email: "alice@example.com"}
+	for i, expect := range []*checker.Attribution{alice, alice, nil, nil} {
+		if got := results[i].Attribution; !reflect.DeepEqual(got, expect) {
+			t.Errorf("Attribution of %v is %+v, expected %+v", results[i].Path, got, expect)
+		}
+	}
+}
+
+func TestCheckRange(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%v", args, err, string(out))
+		}
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(file, body, subject string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(body), 0666); err != nil {
+			t.Fatal(err)
+		}
+		git("add", file)
+		git("commit", "-q", "-m", subject)
+	}
+	const apache = "// Licensed under the Apache License, Version 2.0 (the \"License\");\n"
+
+	git("init", "-q")
+	commit(checker.DefaultConfigFileName, `{ "licenses": [ "Apache-2.0" ] }`, "Add config")
+	commit("a.cpp", apache, "Add a")
+	base := git("rev-parse", "HEAD")
+	commit("b.cpp", "int b;\n", "Add b")
+	commit("c.cpp", apache, "Add c")
+	commit("b.cpp", apache+"int b;\n", "Fix b")
+
+	checked, err := commits.CheckRange(dir, base+"..HEAD", checker.Options{Quiet: true})
+	if err != nil {
+		t.Fatalf("CheckRange() returned %v", err)
+	}
+	got := []string{}
+	for _, c := range checked {
+		paths := []string{}
+		for _, res := range c.Introduced {
+			paths = append(paths, res.Path)
+		}
+		got = append(got, c.Subject+": "+strings.Join(paths, ", "))
+	}
+	expect := []string{"Add b: b.cpp", "Add c: ", "Fix b: "}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("CheckRange() returned %q, expected %q", got, expect)
+	}
+}
+
+func TestTrailers(t *testing.T) {
+	for _, test := range []struct {
+		message string
+		expect  map[string]string
+	}{
+		{"Subject\n\nBody\n\nLicense: MIT\nOrigin: https://example.com/zlib\n",
+			map[string]string{"license": "MIT", "origin": "https://example.com/zlib"}},
+		{"Subject\r\n\r\nlicense: BSD-3-Clause\r\n", map[string]string{"license": "BSD-3-Clause"}},
+		{"License: MIT\n", map[string]string{}},
+		{"Subject\n\nThe License: is not a trailer\n", map[string]string{}},
+	} {
+		if got := commits.Trailers(test.message); !reflect.DeepEqual(got, test.expect) {
+			t.Errorf("Trailers(%q) returned %v, expected %v", test.message, got, test.expect)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir, err := ioutil.TempDir("", "license-checker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%v", args, err, string(out))
+		}
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(message string, files ...string) {
+		for _, file := range files {
+			path := filepath.Join(dir, filepath.FromSlash(file))
+			os.MkdirAll(filepath.Dir(path), 0777)
+			if err := ioutil.WriteFile(path, []byte(file), 0666); err != nil {
+				t.Fatal(err)
+			}
+		}
+		git("add", "-A")
+		git("commit", "-q", "-m", message)
+	}
+
+	git("init", "-q")
+	commit("Initial commit", "README.md")
+	base := git("rev-parse", "HEAD")
+	commit("Add source", "src/main.cpp")
+	commit("Import zlib", "third_party/zlib/zlib.c", "src/zlib.cpp")
+	commit("Import foo\n\nLicense: MIT\nOrigin: https://example.com/foo", "third_party/foo/foo.c")
+	commit("Import bar\n\nLicense: MIT", "lib/third_party/bar/bar.c")
+	os.RemoveAll(filepath.Join(dir, "third_party", "zlib"))
+	commit("Remove zlib")
+
+	violations, err := commits.Check(dir, base+"..HEAD", commits.DefaultOptions())
+	if err != nil {
+		t.Fatalf("Check() returned %v", err)
+	}
+	if len(violations) != 2 {
+		t.Fatalf("Check() returned %d violations, expected 2: %v", len(violations), violations)
+	}
+	for i, expect := range []commits.Violation{
+		{Subject: "Import bar", Files: []string{"lib/third_party/bar/bar.c"}, Missing: []string{"Origin"}},
+		{Subject: "Import zlib", Files: []string{"third_party/zlib/zlib.c"}, Missing: []string{"License", "Origin"}},
+	} {
+		got := violations[i]
+		got.Commit = ""
+		if !reflect.DeepEqual(got, expect) {
+			t.Errorf("Violation %d was %+v, expected %+v", i, got, expect)
+		}
+	}
+}
+
+func TestCheckHeaders(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%v", args, err, string(out))
+		}
+		return strings.TrimSpace(string(out))
+	}
+	header := `// Copyright 2020 Acme Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+`
+	commit := func(message string, files map[string]string) {
+		for file, body := range files {
+			if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(body), 0666); err != nil {
+				t.Fatal(err)
+			}
+		}
+		git("add", "-A")
+		git("commit", "-q", "-m", message)
+	}
+
+	git("init", "-q")
+	commit("Initial commit", map[string]string{"a.cpp": header + "int a;\n", "b.cpp": header + "int b;\n", "c.cpp": "int c;\n"})
+	base := git("rev-parse", "HEAD")
+	commit("Update copyright", map[string]string{"a.cpp": strings.Replace(header, "2020", "2021", 1) + "int a;\n"})
+	commit("Edit code", map[string]string{"b.cpp": header + "int b = 1;\n", "c.cpp": "int c = 1;\n"})
+	commit("Trim header", map[string]string{"b.cpp": strings.Split(header, "//\n// Unless")[0] + "\nint b = 1;\n"})
+	commit("Add file", map[string]string{"d.cpp": "int d;\n"})
+
+	removals, err := commits.CheckHeaders(dir, base+"..HEAD")
+	if err != nil {
+		t.Fatalf("CheckHeaders() returned %v", err)
+	}
+	if len(removals) != 1 {
+		t.Fatalf("CheckHeaders() returned %d removals, expected 1: %v", len(removals), removals)
+	}
+	got := removals[0]
+	got.Commit = ""
+	expect := commits.HeaderRemoval{Subject: "Trim header", File: "b.cpp", Licenses: []string{"Apache-2.0"}, Removed: 6}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("Removal was %+v, expected %+v", got, expect)
+	}
+}
+
+func TestCheckSnippets(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%v", args, err, string(out))
+		}
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(message string, files map[string]string) {
+		for file, body := range files {
+			if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(body), 0666); err != nil {
+				t.Fatal(err)
+			}
+		}
+		git("add", "-A")
+		git("commit", "-q", "-m", message)
+	}
+	header := "// Licensed under the Apache License, Version 2.0 (the \"License\");\n\n"
+	gpl := `// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+`
+
+	git("init", "-q")
+	commit("Initial commit", map[string]string{"a.cpp": header + "int a;\nint b;\n"})
+	base := git("rev-parse", "HEAD")
+	commit("Edit code", map[string]string{"a.cpp": header + "int a = 1;\nint b;\n"})
+	commit("Paste code", map[string]string{
+		"a.cpp": header + "int a = 1;\n" + gpl + "int c;\nint b;\n",
+		"b.cpp": "int d;\n\n" + gpl,
+		"c.cpp": gpl + "\nint e;\n",
+	})
+
+	snippets, err := commits.CheckSnippets(dir, base+"..HEAD")
+	if err != nil {
+		t.Fatalf("CheckSnippets() returned %v", err)
+	}
+	expect := []commits.Snippet{
+		{Subject: "Paste code", File: "a.cpp", Line: 4, EndLine: 8, Licenses: []string{"GPL-3.0"}, FileLicenses: []string{"Apache-2.0"}},
+		{Subject: "Paste code", File: "b.cpp", Line: 1, EndLine: 6, Licenses: []string{"GPL-3.0"}, FileLicenses: []string{}},
+	}
+	for i := range snippets {
+		snippets[i].Commit = ""
+	}
+	if !reflect.DeepEqual(snippets, expect) {
+		t.Errorf("CheckSnippets() returned %+v, expected %+v", snippets, expect)
+	}
+}
+
+func TestCheckCopies(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%v", args, err, string(out))
+		}
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(message string, files map[string]string) {
+		for file, body := range files {
+			if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(body), 0666); err != nil {
+				t.Fatal(err)
+			}
+		}
+		git("add", "-A")
+		git("commit", "-q", "-m", message)
+	}
+	known := `int sum(const int *values, int count) {
+	int total = 0;
+	for (int i = 0; i < count; i++) {
+		total += values[i];
+	}
+	return total;
+}
+`
+	corpus := &provenance.Corpus{}
+	corpus.Add("libsum/sum.c", []byte(known))
+
+	git("init", "-q")
+	commit("Initial commit", map[string]string{"README.md": "readme"})
+	base := git("rev-parse", "HEAD")
+	commit("Add files", map[string]string{
+		"copied.c":   "#include <stdio.h>\n\n" + known,
+		"credited.c": "// Licensed under the Apache License, Version 2.0 (the \"License\");\n\n" + known,
+		"original.c": "int main() {\n\treturn 0;\n}\n",
+	})
+
+	copies, err := commits.CheckCopies(dir, base+"..HEAD", corpus, 0.5)
+	if err != nil {
+		t.Fatalf("CheckCopies() returned %v", err)
+	}
+	if len(copies) != 1 || copies[0].File != "copied.c" || copies[0].Source.File != "libsum/sum.c" {
+		t.Errorf("CheckCopies() returned %+v", copies)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commits
+
+import (
+	"fmt"
+	"strings"
+
+	"../checker"
+	"../detector"
+	"../provenance"
+)
+
+// Copy describes a file added by a commit without a license header, that is
+// likely to be an uncredited copy of a file of a corpus of known third-party
+// code.
+type Copy struct {
+	Commit  string           // the full commit hash
+	Subject string           // the first line of the commit message
+	File    string           // the path of the added file
+	Source  provenance.Match // the most similar corpus file
+}
+
+func (c Copy) Error() string {
+	return fmt.Sprintf("%v %q adds %v without a license header, which is %.0f%% similar to %v",
+		c.Commit[:12], c.Subject, c.File, c.Source.Similarity*100, c.Source.File)
+}
+
+// CheckCopies compares each file added without a license header by the
+// commits in the git revision range revs of the repository in dir with the
+// files of corpus, and returns the added files that share at least the
+// threshold fraction of their fingerprints with a corpus file. Merge commits
+// are not checked.
+func CheckCopies(dir, revs string, corpus *provenance.Corpus, threshold float64) ([]Copy, error) {
+	det, err := detector.New("", nil)
+	if err != nil {
+		return nil, err
+	}
+	log, err := readLog(dir, revs, true)
+	if err != nil {
+		return nil, err
+	}
+
+	copies := []Copy{}
+	for _, c := range log {
+		for _, m := range c.modified {
+			if m.before != nil {
+				continue // Not added by the commit
+			}
+			if len(det.Detect([]byte(checker.LeadingComment(m.path, m.after)))) > 0 {
+				continue
+			}
+			if matches := corpus.Match(m.after, threshold); len(matches) > 0 {
+				copies = append(copies, Copy{
+					Commit:  c.hash,
+					Subject: strings.SplitN(strings.TrimSpace(c.message), "\n", 2)[0],
+					File:    m.path,
+					Source:  matches[0],
+				})
+			}
+		}
+	}
+	return copies, nil
+}
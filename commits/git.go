@@ -0,0 +1,243 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commits
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/merkletrie"
+)
+
+// commit is a non-merge commit read from the repository's history.
+type commit struct {
+	hash    string
+	message string
+	files   []string // the files added or modified by the commit
+
+	// modified holds the content of the files added or modified by the
+	// commit, if requested from readLog.
+	modified []modification
+}
+
+// modification is the content of a file before and after a commit. before is
+// nil for an added file.
+type modification struct {
+	path          string
+	before, after []byte
+}
+
+// readLog returns the non-merge commits of the git revision range revs of the
+// repository in dir, newest first. The history is read with go-git, so that no
+// git installation is required. If go-git cannot read the repository, or does
+// not support the revision range syntax, readLog falls back to running
+// 'git log', if git is installed. If content is true, then the content of the
+// added and modified files is also read.
+func readLog(dir, revs string, content bool) ([]commit, error) {
+	commits, err := readLogGoGit(dir, revs, content)
+	if err == nil {
+		return commits, nil
+	}
+	if _, lookErr := exec.LookPath("git"); lookErr != nil {
+		return nil, err
+	}
+	return readLogBinary(dir, revs, content)
+}
+
+// readLogGoGit implements readLog with go-git. Only revision ranges of the
+// form '<rev>', '<from>..<to>', '<from>..' and '..<to>' are supported.
+func readLogGoGit(dir, revs string, content bool) ([]commit, error) {
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open git repository: %w", err)
+	}
+	if strings.Contains(revs, "...") {
+		return nil, fmt.Errorf("Unsupported revision range '%v'", revs)
+	}
+	resolve := func(rev string) (*plumbing.Hash, error) {
+		if rev == "" {
+			rev = "HEAD"
+		}
+		hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to resolve revision '%v': %w", rev, err)
+		}
+		return hash, nil
+	}
+
+	to, excluded := revs, map[plumbing.Hash]bool{}
+	if i := strings.Index(revs, ".."); i >= 0 {
+		to = revs[i+2:]
+		from, err := resolve(revs[:i])
+		if err != nil {
+			return nil, err
+		}
+		iter, err := repo.Log(&git.LogOptions{From: *from})
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read git history: %w", err)
+		}
+		iter.ForEach(func(c *object.Commit) error {
+			excluded[c.Hash] = true
+			return nil
+		})
+	}
+	head, err := resolve(to)
+	if err != nil {
+		return nil, err
+	}
+	iter, err := repo.Log(&git.LogOptions{From: *head})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read git history: %w", err)
+	}
+
+	out := []commit{}
+	err = iter.ForEach(func(c *object.Commit) error {
+		if excluded[c.Hash] || c.NumParents() > 1 {
+			return nil
+		}
+		files, modified, err := changedFiles(c, content)
+		if err != nil {
+			return fmt.Errorf("Failed to read the changes of commit %v: %w", c.Hash, err)
+		}
+		out = append(out, commit{hash: c.Hash.String(), message: c.Message, files: files, modified: modified})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// changedFiles returns the sorted paths of the files added or modified by the
+// commit, compared to its parent. All the files of a root commit are added.
+// If content is true, then changedFiles also returns the content of the
+// added and modified files.
+func changedFiles(c *object.Commit, content bool) ([]string, []modification, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, nil, err
+	}
+	parentTree := &object.Tree{}
+	if c.NumParents() == 1 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return nil, nil, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, nil, err
+		}
+	}
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return nil, nil, err
+	}
+	files, modified := []string{}, []modification{}
+	for _, change := range changes {
+		action, err := change.Action()
+		if err != nil {
+			return nil, nil, err
+		}
+		if action == merkletrie.Delete {
+			continue
+		}
+		files = append(files, change.To.Name)
+		if !content || (action == merkletrie.Modify && change.From.Name != change.To.Name) {
+			continue
+		}
+		from, to, err := change.Files()
+		if err != nil {
+			return nil, nil, err
+		}
+		m := modification{path: change.To.Name}
+		if action == merkletrie.Modify {
+			before, err := from.Contents()
+			if err != nil {
+				return nil, nil, err
+			}
+			m.before = []byte(before)
+		}
+		after, err := to.Contents()
+		if err != nil {
+			return nil, nil, err
+		}
+		m.after = []byte(after)
+		modified = append(modified, m)
+	}
+	sort.Strings(files)
+	sort.Slice(modified, func(i, j int) bool { return modified[i].path < modified[j].path })
+	return files, modified, nil
+}
+
+// readLogBinary implements readLog by running 'git log'.
+func readLogBinary(dir, revs string, content bool) ([]commit, error) {
+	cmd := exec.Command("git", "log", "--no-merges", "--no-renames", "--diff-filter=d",
+		"--format=%x1e%H%x00%B%x00", "--name-status", revs, "--")
+	cmd.Dir = dir
+	stderr := bytes.Buffer{}
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to run 'git log %v': %w\n%v", revs, err, stderr.String())
+	}
+
+	commits := []commit{}
+	for _, record := range strings.Split(string(out), "\x1e")[1:] {
+		parts := strings.SplitN(record, "\x00", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("Failed to parse 'git log' output: %q", record)
+		}
+		c := commit{hash: parts[0], message: parts[1], files: []string{}, modified: []modification{}}
+		for _, line := range strings.Split(parts[2], "\n") {
+			fields := strings.SplitN(strings.TrimSpace(line), "\t", 2)
+			if len(fields) != 2 {
+				continue
+			}
+			file := fields[1]
+			c.files = append(c.files, file)
+			if content {
+				m := modification{path: file}
+				if fields[0] == "M" {
+					if m.before, err = gitShow(dir, c.hash+"^:"+file); err != nil {
+						return nil, err
+					}
+				}
+				if m.after, err = gitShow(dir, c.hash+":"+file); err != nil {
+					return nil, err
+				}
+				c.modified = append(c.modified, m)
+			}
+		}
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
+
+// gitShow returns the content of the git object named by rev, such as
+// '<commit>:<path>'.
+func gitShow(dir, rev string) ([]byte, error) {
+	cmd := exec.Command("git", "show", rev)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to run 'git show %v': %w", rev, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commits
+
+import (
+	"fmt"
+	"strings"
+
+	"../checker"
+	"../detector"
+)
+
+// HeaderRemoval describes a commit that deletes or truncates the license
+// header of an existing file. Removing part of a header can change the terms
+// that the file is distributed under, even if a license is still recognized
+// in what remains, so each removal should be reviewed.
+type HeaderRemoval struct {
+	Commit   string   // the full commit hash
+	Subject  string   // the first line of the commit message
+	File     string   // the path of the modified file
+	Licenses []string // the licenses of the header before the commit
+	Removed  int      // the number of header lines that were removed
+}
+
+func (r HeaderRemoval) Error() string {
+	return fmt.Sprintf("%v %q removes %d lines of the %v license header of %v",
+		r.Commit[:12], r.Subject, r.Removed, strings.Join(r.Licenses, ", "), r.File)
+}
+
+// CheckHeaders returns the removals of license header lines by the commits in
+// the git revision range revs of the repository in dir. A header line is
+// removed if a modified file's leading comment held a license before the
+// commit, and the line is no longer in the leading comment afterwards.
+// Copyright lines are ignored, as updating the year or holder of a copyright
+// line is routine. Merge commits are not checked.
+func CheckHeaders(dir, revs string) ([]HeaderRemoval, error) {
+	det, err := detector.New("", nil)
+	if err != nil {
+		return nil, err
+	}
+	log, err := readLog(dir, revs, true)
+	if err != nil {
+		return nil, err
+	}
+
+	removals := []HeaderRemoval{}
+	for _, c := range log {
+		for _, m := range c.modified {
+			before := checker.LeadingComment(m.path, m.before)
+			licenses := det.Detect([]byte(before))
+			if len(licenses) == 0 {
+				continue
+			}
+			if n := removedLines(before, checker.LeadingComment(m.path, m.after)); n > 0 {
+				removals = append(removals, HeaderRemoval{
+					Commit:   c.hash,
+					Subject:  strings.SplitN(strings.TrimSpace(c.message), "\n", 2)[0],
+					File:     m.path,
+					Licenses: licenses,
+					Removed:  n,
+				})
+			}
+		}
+	}
+	return removals, nil
+}
+
+// removedLines returns the number of the non-copyright lines of the header
+// before that are not in the header after.
+func removedLines(before, after string) int {
+	remaining := map[string]int{}
+	for _, line := range strings.Split(after, "\n") {
+		remaining[line]++
+	}
+	removed := 0
+	for _, line := range strings.Split(before, "\n") {
+		switch {
+		case remaining[line] > 0:
+			remaining[line]--
+		case !strings.Contains(strings.ToLower(line), "copyright"):
+			removed++
+		}
+	}
+	return removed
+}
@@ -0,0 +1,129 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commits
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"../checker"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// RangeCommit is the outcome of checking a single commit with CheckRange.
+type RangeCommit struct {
+	Commit  string // the full commit hash
+	Subject string // the first line of the commit message
+
+	// Introduced holds the violations of the commit's tree that are not
+	// violations of its first parent's tree, matched by
+	// checker.Result.Fingerprint.
+	Introduced checker.Results
+}
+
+// CheckRange checks the tree of each non-merge commit in the git revision
+// range revs of the repository in dir, such as the batch of a merge queue, and
+// returns the violations that each commit introduced, oldest commit first. The
+// trees are scanned with opts, as exported by ExportTree, so the work tree of
+// the repository is not modified. A commit whose parent cannot be resolved,
+// such as the root commit or the oldest commit of a shallow clone, introduces
+// all of its violations.
+func CheckRange(dir, revs string, opts checker.Options) ([]RangeCommit, error) {
+	log, err := readLog(dir, revs, false)
+	if err != nil {
+		return nil, err
+	}
+	scanned := map[string]checker.Results{} // by commit hash
+	scan := func(hash string) (checker.Results, error) {
+		if results, ok := scanned[hash]; ok {
+			return results, nil
+		}
+		tree, err := ioutil.TempDir("", "license-checker-range")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(tree)
+		if _, err := ExportTree(dir, hash, tree); err != nil {
+			return nil, err
+		}
+		results, err := checker.Scan(tree, opts)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to check commit %v: %w", hash, err)
+		}
+		scanned[hash] = results
+		return results, nil
+	}
+
+	out := make([]RangeCommit, 0, len(log))
+	for i := len(log) - 1; i >= 0; i-- {
+		c := log[i]
+		results, err := scan(c.hash)
+		if err != nil {
+			return nil, err
+		}
+		before := map[string]bool{}
+		if parent, err := resolveRevision(dir, c.hash+"^"); err == nil {
+			parentResults, err := scan(parent)
+			if err != nil {
+				return nil, err
+			}
+			for _, res := range parentResults {
+				if res.Err != nil {
+					before[res.Fingerprint] = true
+				}
+			}
+		}
+		rc := RangeCommit{
+			Commit:     c.hash,
+			Subject:    strings.SplitN(strings.TrimSpace(c.message), "\n", 2)[0],
+			Introduced: checker.Results{},
+		}
+		for _, res := range results {
+			if res.Err != nil && !before[res.Fingerprint] {
+				rc.Introduced = append(rc.Introduced, res)
+			}
+		}
+		out = append(out, rc)
+	}
+	return out, nil
+}
+
+// resolveRevision returns the full commit hash of the git revision rev of the
+// repository in dir. Like readLog, the revision is resolved with go-git,
+// falling back to running 'git rev-parse' if git is installed.
+func resolveRevision(dir, rev string) (string, error) {
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err == nil {
+		var hash *plumbing.Hash
+		if hash, err = repo.ResolveRevision(plumbing.Revision(rev)); err == nil {
+			return hash.String(), nil
+		}
+	}
+	if _, lookErr := exec.LookPath("git"); lookErr != nil {
+		return "", err
+	}
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("Failed to resolve revision '%v': %w", rev, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commits
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"../checker"
+	"../detector"
+)
+
+// Snippet describes a block of lines added by a commit that holds the text of
+// a license other than the licenses of the file's header, such as GPL code
+// pasted into an Apache-2.0 file.
+type Snippet struct {
+	Commit       string   // the full commit hash
+	Subject      string   // the first line of the commit message
+	File         string   // the path of the file
+	Line         int      // the 1-based line of the file that the block starts on
+	EndLine      int      // the 1-based line of the file that the block ends on
+	Licenses     []string // the licenses found in the block
+	FileLicenses []string // the licenses of the file's header after the commit
+}
+
+func (s Snippet) Error() string {
+	file := "unlicensed"
+	if len(s.FileLicenses) > 0 {
+		file = strings.Join(s.FileLicenses, ", ")
+	}
+	return fmt.Sprintf("%v %q adds %v text to the %v file %v:%d-%d",
+		s.Commit[:12], s.Subject, strings.Join(s.Licenses, ", "), file, s.File, s.Line, s.EndLine)
+}
+
+// maxDiffCells is the largest number of line pairs compared to find the lines
+// added to a file. If exceeded, all of the lines between the unchanged prefix
+// and suffix of the file are considered added.
+const maxDiffCells = 1 << 22
+
+// CheckSnippets classifies each block of consecutive lines added by the commits
+// in the git revision range revs of the repository in dir, and returns the
+// blocks that hold the text of a license that is not one of the licenses of the
+// file's header. Lines added to the header itself are not classified. Merge
+// commits are not checked.
+func CheckSnippets(dir, revs string) ([]Snippet, error) {
+	det, err := detector.New("", nil)
+	if err != nil {
+		return nil, err
+	}
+	log, err := readLog(dir, revs, true)
+	if err != nil {
+		return nil, err
+	}
+
+	snippets := []Snippet{}
+	for _, c := range log {
+		for _, m := range c.modified {
+			header, end := checker.SplitLeadingComment(m.path, m.after)
+			headerEnd := len(splitLines(m.after[:end]))
+			fileLicenses := det.Detect([]byte(header))
+			permitted := map[string]bool{}
+			for _, l := range fileLicenses {
+				permitted[l] = true
+			}
+			after := splitLines(m.after)
+			for _, hunk := range addedLines(splitLines(m.before), after) {
+				if hunk[0] < headerEnd {
+					hunk[0] = headerEnd
+				}
+				if hunk[0] >= hunk[1] {
+					continue
+				}
+				found := []string{}
+				for _, l := range det.Detect(bytes.Join(after[hunk[0]:hunk[1]], nil)) {
+					if !permitted[l] {
+						found = append(found, l)
+					}
+				}
+				if len(found) > 0 {
+					snippets = append(snippets, Snippet{
+						Commit:       c.hash,
+						Subject:      strings.SplitN(strings.TrimSpace(c.message), "\n", 2)[0],
+						File:         m.path,
+						Line:         hunk[0] + 1,
+						EndLine:      hunk[1],
+						Licenses:     found,
+						FileLicenses: fileLicenses,
+					})
+				}
+			}
+		}
+	}
+	return snippets, nil
+}
+
+// splitLines splits body into lines, each retaining its line terminator.
+func splitLines(body []byte) [][]byte {
+	lines := bytes.SplitAfter(body, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// addedLines returns the [start, end) ranges of the consecutive lines of after
+// that are not in before, as found by the longest common subsequence of the
+// lines.
+func addedLines(before, after [][]byte) [][2]int {
+	prefix := 0
+	for prefix < len(before) && prefix < len(after) && bytes.Equal(before[prefix], after[prefix]) {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(before)-prefix && suffix < len(after)-prefix &&
+		bytes.Equal(before[len(before)-1-suffix], after[len(after)-1-suffix]) {
+		suffix++
+	}
+	a, b := before[prefix:len(before)-suffix], after[prefix:len(after)-suffix]
+
+	added := make([]bool, len(b))
+	if len(a) == 0 || len(a)*len(b) > maxDiffCells {
+		for i := range added {
+			added[i] = true
+		}
+	} else {
+		// lcs[i][j] is the length of the longest common subsequence of a[i:]
+		// and b[j:].
+		lcs := make([][]int, len(a)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				switch {
+				case bytes.Equal(a[i], b[j]):
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				case lcs[i+1][j] >= lcs[i][j+1]:
+					lcs[i][j] = lcs[i+1][j]
+				default:
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+		i, j := 0, 0
+		for j < len(b) {
+			switch {
+			case i < len(a) && bytes.Equal(a[i], b[j]):
+				i, j = i+1, j+1
+			case i < len(a) && lcs[i+1][j] >= lcs[i][j+1]:
+				i++
+			default:
+				added[j] = true
+				j++
+			}
+		}
+	}
+
+	hunks := [][2]int{}
+	for j := 0; j < len(added); j++ {
+		if !added[j] {
+			continue
+		}
+		start := j
+		for j < len(added) && added[j] {
+			j++
+		}
+		hunks = append(hunks, [2]int{prefix + start, prefix + j})
+	}
+	return hunks
+}
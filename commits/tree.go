@@ -0,0 +1,147 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commits
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// ExportTree writes the files of the tree of the git revision rev, such as a
+// tag or commit hash, of the repository in dir to the directory dst, and
+// returns the full hash of the revision's commit. The files are read from the
+// object database, so the work tree and index of the repository are not
+// modified. Like readLog, the tree is read with go-git, falling back to
+// running 'git archive' if git is installed. Submodules are not exported.
+func ExportTree(dir, rev, dst string) (string, error) {
+	hash, err := exportTreeGoGit(dir, rev, dst)
+	if err == nil {
+		return hash, nil
+	}
+	if _, lookErr := exec.LookPath("git"); lookErr != nil {
+		return "", err
+	}
+	if err := os.RemoveAll(dst); err != nil {
+		return "", err
+	}
+	return exportTreeBinary(dir, rev, dst)
+}
+
+// exportTreeGoGit implements ExportTree with go-git.
+func exportTreeGoGit(dir, rev, dst string) (string, error) {
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return "", fmt.Errorf("Failed to open git repository: %w", err)
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return "", fmt.Errorf("Failed to resolve revision '%v': %w", rev, err)
+	}
+	c, err := repo.CommitObject(*hash)
+	if err != nil {
+		return "", fmt.Errorf("Failed to read commit %v: %w", hash, err)
+	}
+	tree, err := c.Tree()
+	if err != nil {
+		return "", fmt.Errorf("Failed to read the tree of commit %v: %w", hash, err)
+	}
+	err = tree.Files().ForEach(func(f *object.File) error {
+		mode, err := f.Mode.ToOSFileMode()
+		if err != nil {
+			return err
+		}
+		body, err := f.Contents()
+		if err != nil {
+			return err
+		}
+		return writeTreeFile(dst, f.Name, mode, []byte(body))
+	})
+	if err != nil {
+		return "", fmt.Errorf("Failed to export the tree of commit %v: %w", hash, err)
+	}
+	return hash.String(), nil
+}
+
+// exportTreeBinary implements ExportTree by running 'git archive'.
+func exportTreeBinary(dir, rev, dst string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--verify", rev+"^{commit}")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("Failed to resolve revision '%v': %w", rev, err)
+	}
+	hash := strings.TrimSpace(string(out))
+
+	cmd = exec.Command("git", "archive", "--format=tar", hash)
+	cmd.Dir = dir
+	stderr := bytes.Buffer{}
+	cmd.Stderr = &stderr
+	archive, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("Failed to run 'git archive %v': %w\n%v", hash, err, stderr.String())
+	}
+	r := tar.NewReader(bytes.NewReader(archive))
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("Failed to read 'git archive' output: %w", err)
+		}
+		switch h.Typeflag {
+		case tar.TypeReg:
+			body, err := ioutil.ReadAll(r)
+			if err != nil {
+				return "", fmt.Errorf("Failed to read 'git archive' output: %w", err)
+			}
+			if err := writeTreeFile(dst, h.Name, h.FileInfo().Mode(), body); err != nil {
+				return "", err
+			}
+		case tar.TypeSymlink:
+			if err := writeTreeFile(dst, h.Name, os.ModeSymlink, []byte(h.Linkname)); err != nil {
+				return "", err
+			}
+		}
+	}
+	return hash, nil
+}
+
+// writeTreeFile writes the file at the slash separated path name of an
+// exported tree to the directory dst. body is the target of a symbolic link.
+func writeTreeFile(dst, name string, mode os.FileMode, body []byte) error {
+	path := filepath.Join(dst, filepath.FromSlash(name))
+	if rel, err := filepath.Rel(dst, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("Invalid path '%v' in git tree", name)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return err
+	}
+	if mode&os.ModeSymlink != 0 {
+		return os.Symlink(string(body), path)
+	}
+	return ioutil.WriteFile(path, body, mode.Perm()|0200)
+}
@@ -0,0 +1,133 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package deps lists the dependencies of a Go module, and locates their
+// sources in the module cache.
+package deps
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Module is a single dependency of a Go module.
+type Module struct {
+	Path    string // module path, for example 'golang.org/x/text'
+	Version string // module version, for example 'v0.3.0'
+	Dir     string // directory holding the module's source, if known
+	License string // the license declared by a lockfile, if known
+}
+
+func (m Module) String() string { return m.Path + "@" + m.Version }
+
+// Graph returns the dependencies of the Go module in dir, as listed by
+// 'go mod graph', with each module's directory in the module cache.
+func Graph(dir string) ([]Module, error) {
+	out, err := goCmd(dir, "mod", "graph")
+	if err != nil {
+		return nil, err
+	}
+	cache, err := CacheDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	mods := ParseGraph(out)
+	for i := range mods {
+		mods[i].Dir = Locate(cache, mods[i])
+	}
+	return mods, nil
+}
+
+// CacheDir returns the Go module cache directory, as used in dir.
+func CacheDir(dir string) (string, error) {
+	out, err := goCmd(dir, "env", "GOMODCACHE")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// goCmd runs the go tool in dir with the given arguments, returning stdout.
+func goCmd(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	stderr := bytes.Buffer{}
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to run 'go %v': %w\n%v", strings.Join(args, " "), err, stderr.String())
+	}
+	return out, nil
+}
+
+// ParseGraph returns the unique modules, sorted by path and version, that are
+// required in the output of 'go mod graph'. The main module and the 'go' and
+// 'toolchain' pseudo-modules are omitted.
+func ParseGraph(out []byte) []Module {
+	seen := map[Module]bool{}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		i := strings.LastIndex(fields[1], "@")
+		if i < 0 {
+			continue
+		}
+		m := Module{Path: fields[1][:i], Version: fields[1][i+1:]}
+		if m.Path != "go" && m.Path != "toolchain" {
+			seen[m] = true
+		}
+	}
+	mods := make([]Module, 0, len(seen))
+	for m := range seen {
+		mods = append(mods, m)
+	}
+	sortModules(mods)
+	return mods
+}
+
+// sortModules sorts the modules by path, and then by version.
+func sortModules(mods []Module) {
+	sort.Slice(mods, func(i, j int) bool {
+		if mods[i].Path != mods[j].Path {
+			return mods[i].Path < mods[j].Path
+		}
+		return compareVersions(mods[i].Version, mods[j].Version) < 0
+	})
+}
+
+// Locate returns the directory of the module in the module cache directory.
+func Locate(cache string, m Module) string {
+	return filepath.Join(cache, filepath.FromSlash(escape(m.Path)+"@"+escape(m.Version)))
+}
+
+// escape returns s with each upper-case letter replaced by '!' and the
+// lower-case letter, as done for module cache paths.
+func escape(s string) string {
+	sb := strings.Builder{}
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			sb.WriteRune('!')
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,130 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deps_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	deps "."
+	"../detector"
+)
+
+func TestParseGraph(t *testing.T) {
+	out := `example.com/main github.com/BurntSushi/toml@v0.3.1
+example.com/main golang.org/x/text@v0.3.0
+example.com/main go@1.21
+golang.org/x/text@v0.3.0 golang.org/x/tools@v0.0.0-20180917221912-90fa682c2a6e
+github.com/BurntSushi/toml@v0.3.1 golang.org/x/text@v0.3.0
+`
+	expect := []deps.Module{
+		{Path: "github.com/BurntSushi/toml", Version: "v0.3.1"},
+		{Path: "golang.org/x/text", Version: "v0.3.0"},
+		{Path: "golang.org/x/tools", Version: "v0.0.0-20180917221912-90fa682c2a6e"},
+	}
+	if got := deps.ParseGraph([]byte(out)); !reflect.DeepEqual(got, expect) {
+		t.Errorf("ParseGraph() returned %v, expected %v", got, expect)
+	}
+}
+
+func TestLocate(t *testing.T) {
+	m := deps.Module{Path: "github.com/BurntSushi/toml", Version: "v0.3.1"}
+	expect := filepath.Join("cache", "github.com", "!burnt!sushi", "toml@v0.3.1")
+	if got := deps.Locate("cache", m); got != expect {
+		t.Errorf("Locate() returned '%v', expected '%v'", got, expect)
+	}
+}
+
+func TestCopyrights(t *testing.T) {
+	text := `Copyright (c) 2009 The Go Authors. All rights reserved.
+
+Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are met:
+
+   * Redistributions of source code must retain the above copyright
+notice, this list of conditions and the following disclaimer.
+Copyright notice: see above.
+   Copyright [yyyy] [name of copyright owner]
+// Copyright 2015-2020 Alice
+# © Bob
+Copyright (c) 2009 The Go Authors. All rights reserved.
+`
+	expect := []string{
+		"Copyright (c) 2009 The Go Authors. All rights reserved.",
+		"Copyright 2015-2020 Alice",
+		"© Bob",
+	}
+	if got := deps.Copyrights(text); !reflect.DeepEqual(got, expect) {
+		t.Errorf("Copyrights() returned %q, expected %q", got, expect)
+	}
+}
+
+func TestAttributions(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(body), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("LICENSE", "Copyright 2020 Alice\n\nPermission is hereby granted, free of charge, to any person obtaining a copy\n")
+	write("NOTICE", "Widget\nCopyright 2021 Widget Inc.\n")
+	write("main.go", "package main\n")
+
+	mods := []deps.Module{
+		{Path: "example.com/widget", Version: "v1.0.0", Dir: dir},
+		{Path: "example.com/missing", Version: "v0.1.0", Dir: filepath.Join(dir, "missing")},
+	}
+	d, err := detector.New("regex", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []deps.Attribution{
+		{
+			Name:        "example.com/widget",
+			Version:     "v1.0.0",
+			Licenses:    []string{"MIT"},
+			Copyrights:  []string{"Copyright 2020 Alice", "Copyright 2021 Widget Inc."},
+			Notice:      "Widget\nCopyright 2021 Widget Inc.\n",
+			LicenseText: "Copyright 2020 Alice\n\nPermission is hereby granted, free of charge, to any person obtaining a copy\n",
+		},
+		{
+			Name:       "example.com/missing",
+			Version:    "v0.1.0",
+			Licenses:   []string{},
+			Copyrights: []string{},
+		},
+	}
+	if got := deps.Attributions(mods, d); !reflect.DeepEqual(got, expect) {
+		t.Errorf("Attributions() returned:\n%+v\nexpected:\n%+v", got, expect)
+	}
+}
+
+func TestMissingNotices(t *testing.T) {
+	attributions := []deps.Attribution{
+		{Name: "example.com/present", Version: "v1.0.0", Licenses: []string{"Apache-2.0"}, Notice: "Present\nCopyright 2020 Present Inc.\n"},
+		{Name: "example.com/missing", Version: "v1.2.0", Licenses: []string{"Apache-2.0"}, Notice: "Missing\nCopyright 2021 Missing Inc.\n"},
+		{Name: "example.com/mit", Version: "v0.1.0", Licenses: []string{"MIT"}, Notice: "MIT licensed\n"},
+		{Name: "example.com/no-notice", Version: "v0.2.0", Licenses: []string{"Apache-2.0"}},
+	}
+	notice := []byte("This product includes:\n\n  Present\n  Copyright 2020  Present Inc.\n")
+	expect := []deps.MissingNotice{
+		{Name: "example.com/missing", Version: "v1.2.0", License: "Apache-2.0", Text: "Missing\nCopyright 2021 Missing Inc."},
+	}
+	if got := deps.MissingNotices(attributions, notice); !reflect.DeepEqual(got, expect) {
+		t.Errorf("MissingNotices() returned:\n%+v\nexpected:\n%+v", got, expect)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deps
+
+import (
+	"fmt"
+	"strings"
+
+	"../detector"
+)
+
+// Changes describes the differences between two sets of dependencies.
+type Changes struct {
+	Added   []Module    // dependencies only in the new set
+	Removed []Module    // dependencies only in the old set
+	Updated [][2]Module // the old and new module of changed versions
+}
+
+// Diff returns the changes from the old to the new dependencies. Each set
+// must hold at most one module per path, as returned by ParseLockfile.
+func Diff(old, new []Module) Changes {
+	prev := map[string]Module{}
+	for _, m := range old {
+		prev[m.Path] = m
+	}
+	curr := map[string]bool{}
+	c := Changes{}
+	for _, m := range new {
+		curr[m.Path] = true
+		p, ok := prev[m.Path]
+		switch {
+		case !ok:
+			c.Added = append(c.Added, m)
+		case p.Version != m.Version || p.License != m.License:
+			c.Updated = append(c.Updated, [2]Module{p, m})
+		}
+	}
+	for _, m := range old {
+		if !curr[m.Path] {
+			c.Removed = append(c.Removed, m)
+		}
+	}
+	return c
+}
+
+// DetectLicenses returns the licenses found by the detector in the LICENSE,
+// LICENCE and COPYING files at the top of the module directory dir, joined
+// with ' AND ', or an empty string if there are none.
+func DetectLicenses(dir string, d detector.Detector) string {
+	licenses, _ := readLicenseFiles(dir)
+	ids := []string{}
+	seen := map[string]bool{}
+	for _, body := range licenses {
+		for _, id := range d.Detect([]byte(body)) {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return strings.Join(ids, " AND ")
+}
+
+// licenseCategory returns the license of the module with its category, for
+// example 'MIT (permissive)'.
+func licenseCategory(m Module) string {
+	if m.License == "" {
+		return "unknown"
+	}
+	return fmt.Sprintf("%v (%v)", m.License, category(m.License))
+}
+
+// category returns the most restrictive category of the licenses of a license
+// expression, such as 'MIT AND GPL-3.0'.
+func category(license string) detector.Category {
+	rank := map[detector.Category]int{
+		detector.PublicDomain:   0,
+		detector.Permissive:     1,
+		detector.WeakCopyleft:   2,
+		detector.StrongCopyleft: 3,
+		detector.Unknown:        4,
+	}
+	out := detector.PublicDomain
+	for _, f := range strings.FieldsFunc(license, func(r rune) bool { return r == ' ' || r == '(' || r == ')' }) {
+		switch f {
+		case "AND", "OR", "WITH", "and", "or", "with":
+			continue
+		}
+		if c := detector.CategoryOf(f); rank[c] > rank[out] {
+			out = c
+		}
+	}
+	return out
+}
+
+// Markdown returns the changes as markdown, for pasting into a code review
+// comment. Version updates are only listed if they change the license
+// category.
+func (c Changes) Markdown() string {
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "### Dependency license changes\n\n")
+
+	changed := [][2]Module{}
+	for _, u := range c.Updated {
+		if category(u[0].License) != category(u[1].License) || (u[0].License == "") != (u[1].License == "") {
+			changed = append(changed, u)
+		}
+	}
+	if len(c.Added)+len(changed)+len(c.Removed) == 0 {
+		fmt.Fprintf(&sb, "No new dependencies or license category changes.\n")
+		return sb.String()
+	}
+
+	if len(c.Added) > 0 {
+		fmt.Fprintf(&sb, "**%d new dependencies**\n\n", len(c.Added))
+		fmt.Fprintf(&sb, "| Dependency | Version | License |\n")
+		fmt.Fprintf(&sb, "| --- | --- | --- |\n")
+		for _, m := range c.Added {
+			fmt.Fprintf(&sb, "| `%v` | %v | %v |\n", m.Path, m.Version, licenseCategory(m))
+		}
+		fmt.Fprintf(&sb, "\n")
+	}
+	if len(changed) > 0 {
+		fmt.Fprintf(&sb, "**%d license category changes**\n\n", len(changed))
+		fmt.Fprintf(&sb, "| Dependency | Old | New |\n")
+		fmt.Fprintf(&sb, "| --- | --- | --- |\n")
+		for _, u := range changed {
+			fmt.Fprintf(&sb, "| `%v` | %v %v | %v %v |\n", u[1].Path,
+				u[0].Version, licenseCategory(u[0]), u[1].Version, licenseCategory(u[1]))
+		}
+		fmt.Fprintf(&sb, "\n")
+	}
+	if len(c.Removed) > 0 {
+		names := make([]string, len(c.Removed))
+		for i, m := range c.Removed {
+			names[i] = "`" + m.String() + "`"
+		}
+		fmt.Fprintf(&sb, "**%d removed dependencies**: %v\n", len(c.Removed), strings.Join(names, ", "))
+	}
+	return sb.String()
+}
@@ -0,0 +1,253 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deps
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ParseLockfile returns the dependencies listed in the lockfile with the given
+// file name and content. Supported lockfiles are go.sum, package-lock.json,
+// npm-shrinkwrap.json and yarn.lock. Where a package is listed with several
+// versions, only the highest version is returned. Module.License is set if the
+// lockfile declares the package's license.
+func ParseLockfile(name string, body []byte) ([]Module, error) {
+	var mods []Module
+	var err error
+	switch base := path.Base(strings.ReplaceAll(name, "\\", "/")); {
+	case base == "go.sum" || strings.HasSuffix(base, ".sum"):
+		mods = parseGoSum(body)
+	case base == "package-lock.json" || base == "npm-shrinkwrap.json":
+		mods, err = parsePackageLock(body)
+	case base == "yarn.lock":
+		mods = parseYarnLock(body)
+	default:
+		return nil, fmt.Errorf("Unsupported lockfile '%v'. Must be go.sum, package-lock.json, npm-shrinkwrap.json or yarn.lock", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse '%v': %w", name, err)
+	}
+	return latest(mods), nil
+}
+
+// parseGoSum returns the modules of a go.sum file.
+func parseGoSum(body []byte) []Module {
+	mods := []Module{}
+	for _, line := range strings.Split(string(body), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		version := strings.TrimSuffix(fields[1], "/go.mod")
+		mods = append(mods, Module{Path: fields[0], Version: version})
+	}
+	return mods
+}
+
+// parsePackageLock returns the packages of an npm package-lock.json file.
+// Lockfile version 1 files, which only have the 'dependencies' tree, and
+// version 2 and 3 files, with the 'packages' map, are supported.
+func parsePackageLock(body []byte) ([]Module, error) {
+	type dependency struct {
+		Version      string                     `json:"version"`
+		Dependencies map[string]json.RawMessage `json:"dependencies"`
+	}
+	lock := struct {
+		Packages map[string]struct {
+			Name    string          `json:"name"`
+			Version string          `json:"version"`
+			License json.RawMessage `json:"license"`
+		} `json:"packages"`
+		Dependencies map[string]json.RawMessage `json:"dependencies"`
+	}{}
+	if err := json.Unmarshal(body, &lock); err != nil {
+		return nil, err
+	}
+
+	mods := []Module{}
+	if len(lock.Packages) > 0 {
+		for key, pkg := range lock.Packages {
+			i := strings.LastIndex(key, "node_modules/")
+			if i < 0 {
+				continue // The root package, or a workspace
+			}
+			name := key[i+len("node_modules/"):]
+			if pkg.Name != "" {
+				name = pkg.Name
+			}
+			mods = append(mods, Module{Path: name, Version: pkg.Version, License: npmLicense(pkg.License)})
+		}
+		return mods, nil
+	}
+
+	var walk func(deps map[string]json.RawMessage) error
+	walk = func(deps map[string]json.RawMessage) error {
+		for name, raw := range deps {
+			d := dependency{}
+			if err := json.Unmarshal(raw, &d); err != nil {
+				return err
+			}
+			mods = append(mods, Module{Path: name, Version: d.Version})
+			if err := walk(d.Dependencies); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return mods, walk(lock.Dependencies)
+}
+
+// npmLicense returns the license of a package.json 'license' field, which is
+// either a string or, in older packages, an object with a 'type' field.
+func npmLicense(raw json.RawMessage) string {
+	s := ""
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	obj := struct{ Type string }{}
+	if json.Unmarshal(raw, &obj) == nil {
+		return obj.Type
+	}
+	return ""
+}
+
+// NodeModuleLicense returns the license of the package.json of m, installed in
+// the node_modules directory dir. Yarn lockfiles and version 1 npm lockfiles
+// do not record licenses, so they are read from the installed packages. ""
+// is returned if m is not installed, or is installed at another version.
+func NodeModuleLicense(dir string, m Module) string {
+	body, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(m.Path), "package.json"))
+	if err != nil {
+		return ""
+	}
+	pkg := struct {
+		Version string          `json:"version"`
+		License json.RawMessage `json:"license"`
+	}{}
+	if json.Unmarshal(body, &pkg) != nil || pkg.Version != m.Version {
+		return ""
+	}
+	return npmLicense(pkg.License)
+}
+
+// parseYarnLock returns the packages of a yarn.lock file, in either the yarn 1
+// or the yarn 2+ (berry) format.
+func parseYarnLock(body []byte) []Module {
+	mods := []Module{}
+	name := ""
+	for _, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case !strings.HasPrefix(line, " "):
+			// A package header: '"@scope/name@^1.0.0", "@scope/name@~1.1":'
+			spec := strings.TrimSpace(strings.Split(strings.TrimSuffix(line, ":"), ",")[0])
+			spec = strings.Trim(spec, `"`)
+			name = ""
+			if i := strings.LastIndex(spec, "@"); i > 0 {
+				name = spec[:i]
+			}
+			if name == "__metadata" {
+				name = ""
+			}
+		case name != "" && strings.HasPrefix(strings.TrimSpace(line), "version"):
+			version := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "version"))
+			version = strings.Trim(strings.TrimPrefix(version, ":"), ` "`)
+			mods = append(mods, Module{Path: name, Version: version})
+			name = ""
+		}
+	}
+	return mods
+}
+
+// latest returns the modules with only the highest version of each path,
+// sorted by path.
+func latest(mods []Module) []Module {
+	byPath := map[string]Module{}
+	for _, m := range mods {
+		if prev, ok := byPath[m.Path]; !ok || compareVersions(m.Version, prev.Version) > 0 {
+			byPath[m.Path] = m
+		}
+	}
+	out := make([]Module, 0, len(byPath))
+	for _, m := range byPath {
+		out = append(out, m)
+	}
+	sortModules(out)
+	return out
+}
+
+// compareVersions compares two semantic versions, with an optional 'v' prefix,
+// returning -1, 0 or 1 if a is less than, equal to or greater than b.
+// Versions with a pre-release suffix are less than the same version without.
+func compareVersions(a, b string) int {
+	split := func(v string) ([]string, string) {
+		v = strings.TrimPrefix(v, "v")
+		v = strings.SplitN(v, "+", 2)[0]
+		pre := ""
+		if i := strings.Index(v, "-"); i >= 0 {
+			v, pre = v[:i], v[i+1:]
+		}
+		return strings.Split(v, "."), pre
+	}
+	na, pa := split(a)
+	nb, pb := split(b)
+	for i := 0; i < len(na) || i < len(nb); i++ {
+		x, y := "0", "0"
+		if i < len(na) {
+			x = na[i]
+		}
+		if i < len(nb) {
+			y = nb[i]
+		}
+		if c := compareNumeric(x, y); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case pa == pb:
+		return 0
+	case pa == "":
+		return 1
+	case pb == "":
+		return -1
+	case pa < pb:
+		return -1
+	}
+	return 1
+}
+
+// compareNumeric compares two strings of decimal digits by value. Strings
+// that are not numbers are compared lexically.
+func compareNumeric(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	switch {
+	case len(a) != len(b):
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
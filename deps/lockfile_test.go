@@ -0,0 +1,161 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deps_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	deps "."
+)
+
+func TestParseLockfile(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		body   string
+		expect []deps.Module
+	}{
+		{"go.sum", `golang.org/x/text v0.3.0 h1:g61tztE5qeGQ89tm6NTjjM9VPIm088od1l6aSorWRWg=
+golang.org/x/text v0.3.0/go.mod h1:NqM8EUOU14njkJ3fqMW+pc6Ldnwhi/IjpwHt7yyuwOQ=
+golang.org/x/text v0.10.0/go.mod h1:NqM8EUOU14njkJ3fqMW+pc6Ldnwhi/IjpwHt7yyuwOQ=
+rsc.io/quote v1.5.2 h1:w5fcysjrx7yqtD/aO+QwRjYZOKnaM9Uh2b40tElTs3Y=
+`, []deps.Module{
+			{Path: "golang.org/x/text", Version: "v0.10.0"},
+			{Path: "rsc.io/quote", Version: "v1.5.2"},
+		}},
+		{"package-lock.json", `{
+  "lockfileVersion": 3,
+  "packages": {
+    "": { "name": "app" },
+    "node_modules/left-pad": { "version": "1.3.0", "license": "WTFPL" },
+    "node_modules/a/node_modules/left-pad": { "version": "1.1.0" },
+    "node_modules/@types/node": { "version": "20.1.0", "license": "MIT" }
+  }
+}`, []deps.Module{
+			{Path: "@types/node", Version: "20.1.0", License: "MIT"},
+			{Path: "left-pad", Version: "1.3.0", License: "WTFPL"},
+		}},
+		{"npm-shrinkwrap.json", `{
+  "lockfileVersion": 1,
+  "dependencies": {
+    "a": { "version": "1.0.0", "dependencies": { "b": { "version": "2.0.0-beta.1" } } },
+    "b": { "version": "2.0.0" }
+  }
+}`, []deps.Module{
+			{Path: "a", Version: "1.0.0"},
+			{Path: "b", Version: "2.0.0"},
+		}},
+		{"yarn.lock", `# THIS IS AN AUTOGENERATED FILE.
+# yarn lockfile v1
+
+
+"@babel/code-frame@^7.0.0", "@babel/code-frame@^7.10.4":
+  version "7.12.13"
+  resolved "https://registry.yarnpkg.com/@babel/code-frame/-/code-frame-7.12.13.tgz"
+
+lodash@^4.17.19:
+  version "4.17.21"
+`, []deps.Module{
+			{Path: "@babel/code-frame", Version: "7.12.13"},
+			{Path: "lodash", Version: "4.17.21"},
+		}},
+		{"yarn.lock", `__metadata:
+  version: 6
+
+"lodash@npm:^4.17.19":
+  version: 4.17.21
+  resolution: "lodash@npm:4.17.21"
+`, []deps.Module{
+			{Path: "lodash", Version: "4.17.21"},
+		}},
+	} {
+		got, err := deps.ParseLockfile(test.name, []byte(test.body))
+		if err != nil {
+			t.Errorf("ParseLockfile(%v) returned %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.expect) {
+			t.Errorf("ParseLockfile(%v) returned %+v, expected %+v", test.name, got, test.expect)
+		}
+	}
+}
+
+func TestNodeModuleLicense(t *testing.T) {
+	dir := t.TempDir()
+	for path, body := range map[string]string{
+		"left-pad/package.json":   `{ "name": "left-pad", "version": "1.3.0", "license": "WTFPL" }`,
+		"@scope/pkg/package.json": `{ "name": "@scope/pkg", "version": "2.0.0", "license": { "type": "MIT" } }`,
+		"old/package.json":        `{ "name": "old", "version": "0.1.0", "license": "ISC" }`,
+		"broken/package.json":     `{`,
+	} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, path), []byte(body), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, test := range []struct {
+		mod    deps.Module
+		expect string
+	}{
+		{deps.Module{Path: "left-pad", Version: "1.3.0"}, "WTFPL"},
+		{deps.Module{Path: "@scope/pkg", Version: "2.0.0"}, "MIT"},
+		{deps.Module{Path: "old", Version: "0.2.0"}, ""},
+		{deps.Module{Path: "broken", Version: "1.0.0"}, ""},
+		{deps.Module{Path: "missing", Version: "1.0.0"}, ""},
+	} {
+		if got := deps.NodeModuleLicense(dir, test.mod); got != test.expect {
+			t.Errorf("NodeModuleLicense('%v') returned '%v', expected '%v'", test.mod, got, test.expect)
+		}
+	}
+}
+
+func TestUnsupportedLockfile(t *testing.T) {
+	_, err := deps.ParseLockfile("Cargo.lock", nil)
+	if err == nil || !strings.Contains(err.Error(), "Unsupported lockfile 'Cargo.lock'") {
+		t.Errorf("ParseLockfile() returned %v", err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := []deps.Module{
+		{Path: "a", Version: "1.0.0", License: "MIT"},
+		{Path: "b", Version: "1.0.0", License: "MIT"},
+		{Path: "c", Version: "1.0.0", License: "Apache-2.0"},
+		{Path: "gone", Version: "0.1.0", License: "MIT"},
+	}
+	new := []deps.Module{
+		{Path: "a", Version: "1.0.0", License: "MIT"},
+		{Path: "b", Version: "2.0.0", License: "GPL-3.0"},
+		{Path: "c", Version: "1.1.0", License: "MIT"},
+		{Path: "d", Version: "0.5.0", License: "MPL-2.0"},
+	}
+	expect := "### Dependency license changes\n\n" +
+		"**1 new dependencies**\n\n" +
+		"| Dependency | Version | License |\n| --- | --- | --- |\n" +
+		"| `d` | 0.5.0 | MPL-2.0 (weak-copyleft) |\n\n" +
+		"**1 license category changes**\n\n" +
+		"| Dependency | Old | New |\n| --- | --- | --- |\n" +
+		"| `b` | 1.0.0 MIT (permissive) | 2.0.0 GPL-3.0 (strong-copyleft) |\n\n" +
+		"**1 removed dependencies**: `gone@0.1.0`\n"
+	if got := deps.Diff(old, new).Markdown(); got != expect {
+		t.Errorf("Markdown() returned:\n%v\nExpected:\n%v", got, expect)
+	}
+}
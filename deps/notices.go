@@ -0,0 +1,184 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deps
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"../detector"
+)
+
+// Attribution holds the text that must be reproduced to attribute a single
+// dependency, for example in an About screen.
+type Attribution struct {
+	Name        string   `json:"name"`
+	Version     string   `json:"version"`
+	Licenses    []string `json:"licenses"`
+	Copyrights  []string `json:"copyrights"`             // copyright lines of the license files
+	Notice      string   `json:"notice,omitempty"`       // content of the NOTICE files
+	LicenseText string   `json:"license_text,omitempty"` // content of the license files
+}
+
+var (
+	// copyrightRE matches a line holding a copyright statement, such as
+	// 'Copyright (c) 2009 The Go Authors. All rights reserved.'
+	copyrightRE = regexp.MustCompile(`(?i)^(copyright\b|\(c\)|©)`)
+	// yearRE matches a year, or year range.
+	yearRE = regexp.MustCompile(`\b(19|20)[0-9]{2}\b`)
+)
+
+// Copyrights returns the unique copyright lines of the text, in the order
+// they appear. Lines must start with 'Copyright', '(c)' or '©', and hold either
+// a year or a copyright sign, which excludes sentences of the license text
+// such as 'Copyright notice ...' and template lines such as
+// 'Copyright [yyyy] [name of copyright owner]'.
+func Copyrights(text string) []string {
+	out := []string{}
+	seen := map[string]bool{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#/*;-"))
+		if !copyrightRE.MatchString(line) || strings.Contains(line, "[yyyy]") {
+			continue
+		}
+		lower := strings.ToLower(line)
+		if !yearRE.MatchString(line) && !strings.Contains(lower, "(c)") && !strings.Contains(line, "©") {
+			continue
+		}
+		if !seen[line] {
+			seen[line] = true
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+// Attributions returns the Attribution for each of the modules, read from the
+// LICENSE, LICENCE, COPYING and NOTICE files at the top of each module's
+// directory. Modules without a directory have no license files.
+func Attributions(mods []Module, d detector.Detector) []Attribution {
+	out := make([]Attribution, len(mods))
+	for i, m := range mods {
+		a := Attribution{Name: m.Path, Version: m.Version, Licenses: []string{}, Copyrights: []string{}}
+		licenses, notices := readLicenseFiles(m.Dir)
+		seen := map[string]bool{}
+		for _, body := range licenses {
+			for _, id := range d.Detect([]byte(body)) {
+				if !seen[id] {
+					seen[id] = true
+					a.Licenses = append(a.Licenses, id)
+				}
+			}
+		}
+		if len(a.Licenses) == 0 && m.License != "" {
+			a.Licenses = append(a.Licenses, m.License)
+		}
+		a.LicenseText = strings.Join(licenses, "\n")
+		a.Notice = strings.Join(notices, "\n")
+		a.Copyrights = Copyrights(a.LicenseText + "\n" + a.Notice)
+		out[i] = a
+	}
+	return out
+}
+
+// readLicenseFiles returns the content of the license files and of the notice
+// files at the top of dir, each sorted by file name.
+func readLicenseFiles(dir string) (licenses, notices []string) {
+	if dir == "" {
+		return nil, nil
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, nil
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := strings.ToUpper(e.Name())
+		isLicense := strings.HasPrefix(name, "LICENSE") || strings.HasPrefix(name, "LICENCE") || strings.HasPrefix(name, "COPYING")
+		isNotice := strings.HasPrefix(name, "NOTICE")
+		if !isLicense && !isNotice {
+			continue
+		}
+		body, err := ioutil.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		if isLicense {
+			licenses = append(licenses, string(body))
+		} else {
+			notices = append(notices, string(body))
+		}
+	}
+	return licenses, notices
+}
+
+// noticeLicenses is the set of licenses that require the content of a
+// dependency's NOTICE file to be reproduced by derivative works.
+var noticeLicenses = map[string]bool{
+	"Apache-2.0": true,
+}
+
+// MissingNotice is a dependency whose license requires its NOTICE content to
+// be propagated, but whose NOTICE content is missing from the project's
+// aggregate NOTICE file.
+type MissingNotice struct {
+	Name    string
+	Version string
+	License string // the license that requires the NOTICE to be propagated
+	Text    string // the NOTICE content to add to the aggregate NOTICE file
+}
+
+func (m MissingNotice) Error() string {
+	return fmt.Sprintf("%v %v is licensed under %v, but its NOTICE is missing", m.Name, m.Version, m.License)
+}
+
+// MissingNotices returns the attributions with a license that requires NOTICE
+// propagation, such as Apache-2.0, and whose Notice is not found in the
+// aggregate NOTICE file content notice. Differences in whitespace and line
+// wrapping are ignored.
+func MissingNotices(attributions []Attribution, notice []byte) []MissingNotice {
+	aggregate := collapseSpace(string(notice))
+	out := []MissingNotice{}
+	for _, a := range attributions {
+		if strings.TrimSpace(a.Notice) == "" {
+			continue
+		}
+		license := ""
+		for _, l := range a.Licenses {
+			if noticeLicenses[detector.Normalize(l)] {
+				license = l
+				break
+			}
+		}
+		if license == "" || strings.Contains(aggregate, collapseSpace(a.Notice)) {
+			continue
+		}
+		out = append(out, MissingNotice{Name: a.Name, Version: a.Version, License: license, Text: strings.TrimSpace(a.Notice)})
+	}
+	return out
+}
+
+// collapseSpace returns s with each run of whitespace replaced with a single
+// space, and leading and trailing whitespace removed.
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detector
+
+import "strings"
+
+// Category is the broad class of a license's obligations.
+type Category string
+
+// Enumerator values for Category.
+const (
+	Permissive     Category = "permissive"
+	WeakCopyleft   Category = "weak-copyleft"
+	StrongCopyleft Category = "strong-copyleft"
+	PublicDomain   Category = "public-domain"
+	Unknown        Category = "unknown"
+)
+
+// categories maps SPDX identifiers to their Category.
+var categories = map[string]Category{
+	"0BSD":         Permissive,
+	"Apache-1.1":   Permissive,
+	"Apache-2.0":   Permissive,
+	"Artistic-2.0": Permissive,
+	"BSD-2-Clause": Permissive,
+	"BSD-3-Clause": Permissive,
+	"BSL-1.0":      Permissive,
+	"CC-BY-4.0":    Permissive,
+	"ISC":          Permissive,
+	"MIT":          Permissive,
+	"Zlib":         Permissive,
+	"EPL-1.0":      WeakCopyleft,
+	"EPL-2.0":      WeakCopyleft,
+	"LGPL-2.1":     WeakCopyleft,
+	"LGPL-3.0":     WeakCopyleft,
+	"MPL-2.0":      WeakCopyleft,
+	"AGPL-3.0":     StrongCopyleft,
+	"GPL-2.0":      StrongCopyleft,
+	"GPL-3.0":      StrongCopyleft,
+	"CC0-1.0":      PublicDomain,
+	"Unlicense":    PublicDomain,
+	PublicDomainID: PublicDomain,
+}
+
+// CategoryOf returns the Category of the license, or Unknown if the license
+// is not recognized. Identifiers with an '-only' or '-or-later' suffix have
+// the category of the base license.
+func CategoryOf(name string) Category {
+	id := Normalize(name)
+	id = strings.TrimSuffix(strings.TrimSuffix(id, "-only"), "-or-later")
+	id = Normalize(strings.TrimSuffix(id, "+"))
+	if c, ok := categories[id]; ok {
+		return c
+	}
+	return Unknown
+}
@@ -0,0 +1,87 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+)
+
+// Database holds license definitions that are loaded at runtime, adding to or
+// replacing the licenses built into the detectors.
+//
+// Example:
+//
+//	{
+//	  "licenses": [
+//	    {
+//	      "id": "LicenseRef-Acme",
+//	      "lre": "Copyright __1__ Acme Corporation. All rights reserved.",
+//	      "regex": "copyright [0-9 ]+ acme corporation all rights reserved"
+//	    }
+//	  ]
+//	}
+type Database struct {
+	// Replace, if true, replaces the detectors' built-in licenses with the
+	// database licenses, instead of adding to them.
+	Replace bool `json:"replace"`
+
+	// Licenses is the list of license definitions.
+	Licenses []License `json:"licenses"`
+}
+
+// License is a single license definition of a Database.
+type License struct {
+	// ID is the license identifier reported when the license is found.
+	ID string `json:"id"`
+
+	// LRE is the license pattern used by the "licensecheck" detector, written
+	// in the licensecheck license regular expression syntax.
+	LRE string `json:"lre"`
+
+	// Regex is the license pattern used by the "regex" detector. It is a Go
+	// regular expression matched against the lower-case words of the text,
+	// separated by single spaces.
+	Regex string `json:"regex"`
+
+	// URL optionally holds the license's web address.
+	URL string `json:"url"`
+}
+
+// LoadDatabase loads the license Database from the JSON file at path.
+func LoadDatabase(path string) (*Database, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read license database: %w", err)
+	}
+	db := &Database{}
+	if err := json.Unmarshal(body, db); err != nil {
+		return nil, fmt.Errorf("Failed to parse license database '%v': %w", path, err)
+	}
+	for i, l := range db.Licenses {
+		if l.ID == "" {
+			return nil, fmt.Errorf("License database '%v': licenses[%d] has no id", path, i)
+		}
+		if l.LRE == "" && l.Regex == "" {
+			return nil, fmt.Errorf("License database '%v': license '%v' has neither an lre or regex pattern", path, l.ID)
+		}
+		if _, err := regexp.Compile(l.Regex); err != nil {
+			return nil, fmt.Errorf("License database '%v': license '%v' has an invalid regex: %w", path, l.ID, err)
+		}
+	}
+	return db, nil
+}
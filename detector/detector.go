@@ -0,0 +1,121 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package detector provides the license detection engines used to identify
+// the licenses in file content.
+package detector
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Detector identifies the licenses in file content.
+type Detector interface {
+	// Detect returns the identifiers of the licenses found in body.
+	Detect(body []byte) []string
+}
+
+// Default is the name of the detector used when none is specified.
+const Default = "licensecheck"
+
+// detectors is a map of detector name to constructor. The constructor's
+// Database is nil if no database was loaded.
+var detectors = map[string]func(db *Database) (Detector, error){
+	"licensecheck": newLicensecheck,
+	"regex":        newRegex,
+}
+
+// Names returns the sorted list of supported detector names.
+func Names() []string {
+	out := make([]string, 0, len(detectors))
+	for name := range detectors {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// New returns the named detector, or the Default detector if name is empty.
+// If db is not nil, then the detector also recognizes the licenses of the
+// database. The identifiers returned by the detector are normalized with
+// Normalize. Every detector recognizes the Unlicense, CC0 and public-domain
+// prose, which is reported as PublicDomainID.
+func New(name string, db *Database) (Detector, error) {
+	if name == "" {
+		name = Default
+	}
+	create, ok := detectors[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown detector '%v'. Must be one of: %v", name, Names())
+	}
+	d, err := create(db)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create detector '%v': %w", name, err)
+	}
+	return normalized{d}, nil
+}
+
+// normalized is a Detector that normalizes the identifiers returned by the
+// wrapped Detector, and adds any public-domain dedication it did not find.
+type normalized struct{ Detector }
+
+func (n normalized) Detect(body []byte) []string {
+	ids := n.Detector.Detect(body)
+	for i, id := range ids {
+		ids[i] = Normalize(id)
+	}
+	return detectPublicDomain(ids, body)
+}
+
+// spdxIDs is the list of SPDX identifiers that Normalize corrects the case of.
+var spdxIDs = []string{
+	"0BSD", "AGPL-3.0", "Apache-1.1", "Apache-2.0", "Artistic-2.0",
+	"BSD-2-Clause", "BSD-3-Clause", "BSL-1.0", "CC-BY-4.0", "CC0-1.0",
+	"EPL-1.0", "EPL-2.0", "GPL-2.0", "GPL-3.0", "ISC", "LGPL-2.1", "LGPL-3.0",
+	"MIT", "MPL-2.0", "Unlicense", "Zlib", PublicDomainID,
+}
+
+// aliases maps lower-case, non-SPDX license names to their SPDX identifier.
+var aliases = map[string]string{
+	"apache-2.0-header": "Apache-2.0",
+	"apache 2.0":        "Apache-2.0",
+	"apache2":           "Apache-2.0",
+	"apache-2":          "Apache-2.0",
+	"bsd-2":             "BSD-2-Clause",
+	"bsd-3":             "BSD-3-Clause",
+	"expat":             "MIT",
+	"gplv2":             "GPL-2.0",
+	"gplv3":             "GPL-3.0",
+	"lgplv2.1":          "LGPL-2.1",
+	"lgplv3":            "LGPL-3.0",
+	"mpl2":              "MPL-2.0",
+}
+
+func init() {
+	for _, id := range spdxIDs {
+		aliases[strings.ToLower(id)] = id
+	}
+}
+
+// Normalize returns the SPDX identifier for the license name, correcting the
+// case of known identifiers and mapping common aliases, such as
+// 'Apache-2.0-Header' or 'GPLv3'. Unrecognized names are returned unaltered.
+func Normalize(name string) string {
+	if id, ok := aliases[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return id
+	}
+	return name
+}
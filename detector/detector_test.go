@@ -0,0 +1,213 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detector_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	detector "."
+)
+
+func TestNormalize(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		expect string
+	}{
+		{"Apache-2.0", "Apache-2.0"},
+		{"apache-2.0", "Apache-2.0"},
+		{"Apache-2.0-Header", "Apache-2.0"},
+		{"GPLv3", "GPL-3.0"},
+		{"mit", "MIT"},
+		{"Expat", "MIT"},
+		{"LicenseRef-Custom", "LicenseRef-Custom"},
+	} {
+		if got := detector.Normalize(test.name); got != test.expect {
+			t.Errorf("Normalize(%q) returned %q, expected %q", test.name, got, test.expect)
+		}
+	}
+}
+
+func TestCategoryOf(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		expect detector.Category
+	}{
+		{"MIT", detector.Permissive},
+		{"Apache-2.0-Header", detector.Permissive},
+		{"MPL-2.0", detector.WeakCopyleft},
+		{"GPL-3.0-or-later", detector.StrongCopyleft},
+		{"GPL-2.0+", detector.StrongCopyleft},
+		{"CC0-1.0", detector.PublicDomain},
+		{"LicenseRef-Custom", detector.Unknown},
+	} {
+		if got := detector.CategoryOf(test.name); got != test.expect {
+			t.Errorf("CategoryOf(%q) returned %v, expected %v", test.name, got, test.expect)
+		}
+	}
+}
+
+func TestObligationsOf(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		expect []detector.Obligation
+		ok     bool
+	}{
+		{"MIT", []detector.Obligation{detector.Attribution}, true},
+		{"GPL-2.0-or-later", []detector.Obligation{detector.Attribution, detector.SourceOffer, detector.StateChanges}, true},
+		{"CC0-1.0", []detector.Obligation{}, true},
+		{"LicenseRef-Custom", nil, false},
+	} {
+		if got, ok := detector.ObligationsOf(test.name); !reflect.DeepEqual(got, test.expect) || ok != test.ok {
+			t.Errorf("ObligationsOf(%q) returned (%v, %v), expected (%v, %v)", test.name, got, ok, test.expect, test.ok)
+		}
+	}
+}
+
+func TestRegex(t *testing.T) {
+	d, err := detector.New("regex", nil)
+	if err != nil {
+		t.Fatalf("New() returned %v", err)
+	}
+	for _, test := range []struct {
+		body   string
+		expect []string
+	}{
+		{`// Licensed under the Apache License, Version 2.0 (the "License");`, []string{"Apache-2.0"}},
+		{"# Permission is hereby granted, free of charge, to any person\n# obtaining a copy", []string{"MIT"}},
+		{` * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ * 2. Redistributions in binary form must reproduce the above copyright notice
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from`, []string{"BSD-3-Clause"}},
+		{`# it under the terms of the GNU General Public License as published by
+# the Free Software Foundation, either version 3 of the License, or`, []string{"GPL-3.0"}},
+		{"int main() {}", []string{}},
+	} {
+		if got := d.Detect([]byte(test.body)); !reflect.DeepEqual(got, test.expect) {
+			t.Errorf("Detect(%q) returned %v, expected %v", test.body, got, test.expect)
+		}
+	}
+}
+
+func TestPublicDomain(t *testing.T) {
+	for _, name := range detector.Names() {
+		d, err := detector.New(name, nil)
+		if err != nil {
+			t.Fatalf("New(%v) returned %v", name, err)
+		}
+		for _, test := range []struct {
+			body   string
+			expect []string
+		}{
+			{"// This is free and unencumbered software released into the public domain.", []string{"Unlicense"}},
+			{"# To the extent possible under law, Bob has waived all copyright and\n# related or neighboring rights to this work.", []string{"CC0-1.0"}},
+			{"/* SPDX: CC0 1.0 Universal */", []string{"CC0-1.0"}},
+			{"-- This file is placed in the\n-- Public Domain by its author.", []string{detector.PublicDomainID}},
+			{"// The public domain parts of this file are documented elsewhere.", []string{}},
+		} {
+			if got := d.Detect([]byte(test.body)); !reflect.DeepEqual(got, test.expect) {
+				t.Errorf("%v: Detect(%q) returned %v, expected %v", name, test.body, got, test.expect)
+			}
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	for _, test := range []struct {
+		allowed, id string
+		expect      bool
+	}{
+		{"Apache-2.0-Header", "Apache-2.0", true},
+		{"MIT", "Apache-2.0", false},
+		{"public-domain", "Unlicense", true},
+		{"Public-Domain", detector.PublicDomainID, true},
+		{"public-domain", "MIT", false},
+		{"permissive", "MIT", true},
+		{"unknown", "LicenseRef-Acme", false},
+	} {
+		if got := detector.Matches(test.allowed, test.id); got != test.expect {
+			t.Errorf("Matches(%q, %q) returned %v, expected %v", test.allowed, test.id, got, test.expect)
+		}
+	}
+}
+
+func TestDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "license-checker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "licenses.json")
+	err = ioutil.WriteFile(path, []byte(`{"licenses": [{
+		"id": "LicenseRef-Acme",
+		"lre": "Acme Corporation Proprietary and Confidential",
+		"regex": "acme corporation proprietary and confidential"
+	}]}`), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := detector.LoadDatabase(path)
+	if err != nil {
+		t.Fatalf("LoadDatabase() returned %v", err)
+	}
+
+	body := []byte("// Acme Corporation Proprietary and Confidential\n")
+	for _, name := range detector.Names() {
+		d, err := detector.New(name, db)
+		if err != nil {
+			t.Fatalf("New(%v) returned %v", name, err)
+		}
+		if got, expect := d.Detect(body), []string{"LicenseRef-Acme"}; !reflect.DeepEqual(got, expect) {
+			t.Errorf("%v detector returned %v, expected %v", name, got, expect)
+		}
+	}
+}
+
+func TestDatabaseErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "license-checker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, test := range []struct {
+		db     string
+		expect string
+	}{
+		{`{"licenses": [{"regex": "acme"}]}`, "licenses[0] has no id"},
+		{`{"licenses": [{"id": "Acme"}]}`, "license 'Acme' has neither an lre or regex pattern"},
+		{`{"licenses": [{"id": "Acme", "regex": "("}]}`, "license 'Acme' has an invalid regex"},
+		{`{"licenses": }`, "Failed to parse license database"},
+	} {
+		path := filepath.Join(dir, "licenses.json")
+		if err := ioutil.WriteFile(path, []byte(test.db), 0666); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := detector.LoadDatabase(path); err == nil || !strings.Contains(err.Error(), test.expect) {
+			t.Errorf("LoadDatabase(%v) returned %v, expected error containing '%v'", test.db, err, test.expect)
+		}
+	}
+}
+
+func TestUnknownDetector(t *testing.T) {
+	_, err := detector.New("askalono", nil)
+	if err == nil || !strings.Contains(err.Error(), "Unknown detector 'askalono'") {
+		t.Errorf("New() with unknown detector returned %v", err)
+	}
+}
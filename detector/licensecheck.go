@@ -0,0 +1,57 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detector
+
+import "github.com/google/licensecheck"
+
+// licensecheckDetector is a Detector that uses the
+// github.com/google/licensecheck classifier.
+type licensecheckDetector struct {
+	scanner *licensecheck.Scanner // nil for the built-in scanner
+}
+
+func newLicensecheck(db *Database) (Detector, error) {
+	if db == nil {
+		return licensecheckDetector{}, nil
+	}
+	licenses := []licensecheck.License{}
+	if !db.Replace {
+		licenses = licensecheck.BuiltinLicenses()
+	}
+	for _, l := range db.Licenses {
+		if l.LRE != "" {
+			licenses = append(licenses, licensecheck.License{ID: l.ID, LRE: l.LRE, URL: l.URL})
+		}
+	}
+	scanner, err := licensecheck.New(licenses)
+	if err != nil {
+		return nil, err
+	}
+	return licensecheckDetector{scanner}, nil
+}
+
+func (d licensecheckDetector) Detect(body []byte) []string {
+	var cov licensecheck.Coverage
+	if d.scanner != nil {
+		cov = d.scanner.Scan(body)
+	} else {
+		cov = licensecheck.Scan(body)
+	}
+	ids := []string{}
+	for _, match := range cov.Match {
+		ids = append(ids, match.ID)
+	}
+	return ids
+}
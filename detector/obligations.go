@@ -0,0 +1,84 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detector
+
+import "strings"
+
+// Obligation is a requirement that a license places on the distribution of the
+// licensed work.
+type Obligation string
+
+// Enumerator values for Obligation.
+const (
+	Attribution  Obligation = "attribution"
+	Notice       Obligation = "notice"
+	SourceOffer  Obligation = "source-offer"
+	PatentGrant  Obligation = "patent-grant"
+	StateChanges Obligation = "state-changes"
+)
+
+// Describe returns a one line summary of what the obligation requires.
+func (o Obligation) Describe() string {
+	switch o {
+	case Attribution:
+		return "Reproduce the copyright notices and the license text with every distribution"
+	case Notice:
+		return "Reproduce the content of the NOTICE files in the distribution's NOTICE"
+	case SourceOffer:
+		return "Make the source code of the licensed work, including modifications, available to recipients"
+	case PatentGrant:
+		return "Patent rights are granted, but terminate if a patent claim is brought over the work"
+	case StateChanges:
+		return "Mark modified files with a notice stating that they were changed"
+	}
+	return string(o)
+}
+
+// obligations maps SPDX identifiers to the obligations of the license.
+var obligations = map[string][]Obligation{
+	"0BSD":         {},
+	"Apache-1.1":   {Attribution},
+	"Apache-2.0":   {Attribution, Notice, PatentGrant, StateChanges},
+	"Artistic-2.0": {Attribution, PatentGrant, StateChanges},
+	"BSD-2-Clause": {Attribution},
+	"BSD-3-Clause": {Attribution},
+	"BSL-1.0":      {Attribution},
+	"CC-BY-4.0":    {Attribution, StateChanges},
+	"ISC":          {Attribution},
+	"MIT":          {Attribution},
+	"Zlib":         {Attribution, StateChanges},
+	"EPL-1.0":      {Attribution, SourceOffer, PatentGrant},
+	"EPL-2.0":      {Attribution, SourceOffer, PatentGrant},
+	"LGPL-2.1":     {Attribution, SourceOffer, StateChanges},
+	"LGPL-3.0":     {Attribution, SourceOffer, PatentGrant, StateChanges},
+	"MPL-2.0":      {Attribution, SourceOffer, PatentGrant},
+	"AGPL-3.0":     {Attribution, SourceOffer, PatentGrant, StateChanges},
+	"GPL-2.0":      {Attribution, SourceOffer, StateChanges},
+	"GPL-3.0":      {Attribution, SourceOffer, PatentGrant, StateChanges},
+	"CC0-1.0":      {},
+	"Unlicense":    {},
+	PublicDomainID: {},
+}
+
+// ObligationsOf returns the obligations of the license, and false if the
+// license's obligations are not known. Identifiers with an '-only' or
+// '-or-later' suffix have the obligations of the base license.
+func ObligationsOf(name string) ([]Obligation, bool) {
+	id := Normalize(name)
+	id = strings.TrimSuffix(strings.TrimSuffix(id, "-only"), "-or-later")
+	id = Normalize(strings.TrimSuffix(id, "+"))
+	o, ok := obligations[id]
+	return o, ok
+}
@@ -0,0 +1,72 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detector
+
+import (
+	"regexp"
+	"strings"
+)
+
+// PublicDomainID is the identifier of a public-domain dedication written as
+// prose, such as 'This file is released into the public domain', rather than
+// as a recognized license text such as the Unlicense or CC0.
+const PublicDomainID = "LicenseRef-Public-Domain"
+
+var (
+	// publicDomainHintRE cheaply matches content that may hold a public-domain
+	// dedication, so that most content is not split into words.
+	publicDomainHintRE = regexp.MustCompile(`(?i)public\s+domain|cc0|creative\s+commons\s+zero|waived\s+all\s+copyright`)
+
+	// publicDomainLicenses are the public-domain dedications recognized by
+	// every detector. Patterns are matched against the lower-case words of the
+	// text, as with regexLicenses.
+	publicDomainLicenses = []regexLicense{
+		{"Unlicense", regexp.MustCompile(`this is free and unencumbered software released into the public domain`)},
+		{"CC0-1.0", regexp.MustCompile(`\bcc0 1 0\b|creative commons zero|has waived all copyright and related or neighboring rights`)},
+		{PublicDomainID, regexp.MustCompile(`\b(released|dedicated|placed|put|is|are) (in|into|to) the public domain\b`)},
+	}
+)
+
+// detectPublicDomain returns ids with the public-domain dedication of body
+// appended, if body holds one and ids holds no public-domain license.
+func detectPublicDomain(ids []string, body []byte) []string {
+	for _, id := range ids {
+		if CategoryOf(id) == PublicDomain {
+			return ids
+		}
+	}
+	if !publicDomainHintRE.Match(body) {
+		return ids
+	}
+	words := strings.Join(wordRE.FindAllString(strings.ToLower(string(body)), -1), " ")
+	for _, l := range publicDomainLicenses {
+		if l.pattern.MatchString(words) {
+			return append(ids, l.id)
+		}
+	}
+	return ids
+}
+
+// Matches returns true if the license name allowed, as listed by a config,
+// permits the license id. allowed is either a license name, which is compared
+// with Normalize, or the name of a Category other than Unknown, such as
+// 'public-domain', which permits every license of the category.
+func Matches(allowed, id string) bool {
+	if Normalize(allowed) == Normalize(id) {
+		return true
+	}
+	c := Category(strings.ToLower(strings.TrimSpace(allowed)))
+	return c != Unknown && CategoryOf(id) == c
+}
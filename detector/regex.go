@@ -0,0 +1,88 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detector
+
+import (
+	"regexp"
+	"strings"
+)
+
+// wordRE matches a single word of license text.
+var wordRE = regexp.MustCompile(`[a-z0-9]+`)
+
+// regexLicense is a license recognized by the regex detector.
+type regexLicense struct {
+	id      string
+	pattern *regexp.Regexp
+}
+
+// regexLicenses is the list of built-in licenses of the regex detector.
+// Each pattern is matched against the lower-case words of the text, separated
+// by single spaces, so patterns are unaffected by comment markers,
+// punctuation and line wrapping.
+var regexLicenses = []regexLicense{
+	{"Apache-2.0", regexp.MustCompile(`licensed under the apache license version 2 0`)},
+	{"MIT", regexp.MustCompile(`permission is hereby granted free of charge to any person obtaining a copy`)},
+	{"BSD-3-Clause", regexp.MustCompile(`redistribution and use in source and binary forms .*neither the name of .*may be used to endorse or promote products`)},
+	{"BSD-2-Clause", regexp.MustCompile(`redistribution and use in source and binary forms .*redistributions in binary form must reproduce`)},
+	{"ISC", regexp.MustCompile(`permission to use copy modify and or distribute this software for any purpose with or without fee is hereby granted`)},
+	{"MPL-2.0", regexp.MustCompile(`subject to the terms of the mozilla public license v 2 0`)},
+	{"LGPL-2.1", regexp.MustCompile(`gnu lesser general public license as published by the free software foundation either version 2 1`)},
+	{"LGPL-3.0", regexp.MustCompile(`gnu lesser general public license as published by the free software foundation either version 3`)},
+	{"GPL-2.0", regexp.MustCompile(`gnu general public license as published by the free software foundation either version 2`)},
+	{"GPL-3.0", regexp.MustCompile(`gnu general public license as published by the free software foundation either version 3`)},
+}
+
+// regexDetector is a Detector that recognizes the standard notices of common
+// licenses with regular expressions. It has no dependencies, making it
+// suitable for minimal builds, but does not tolerate modified license text.
+type regexDetector struct {
+	licenses []regexLicense
+}
+
+func newRegex(db *Database) (Detector, error) {
+	if db == nil {
+		return regexDetector{regexLicenses}, nil
+	}
+	licenses := []regexLicense{}
+	if !db.Replace {
+		licenses = append(licenses, regexLicenses...)
+	}
+	for _, l := range db.Licenses {
+		if l.Regex != "" {
+			pattern, err := regexp.Compile(l.Regex)
+			if err != nil {
+				return nil, err
+			}
+			licenses = append(licenses, regexLicense{l.ID, pattern})
+		}
+	}
+	return regexDetector{licenses}, nil
+}
+
+func (d regexDetector) Detect(body []byte) []string {
+	words := strings.Join(wordRE.FindAllString(strings.ToLower(string(body)), -1), " ")
+	ids := []string{}
+	for _, l := range d.licenses {
+		// The BSD-3-Clause license contains the BSD-2-Clause license.
+		if l.id == "BSD-2-Clause" && len(ids) > 0 && ids[len(ids)-1] == "BSD-3-Clause" {
+			continue
+		}
+		if l.pattern.MatchString(words) {
+			ids = append(ids, l.id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,160 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package digest builds and emails a digest of the license violations that
+// were introduced or resolved since a previous run.
+package digest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/smtp"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Digest holds the changes in license violations between two runs.
+type Digest struct {
+	New      []string // violations not present in the previous run
+	Resolved []string // violations present in the previous run, but not now
+	Current  []string // all violations of the current run
+}
+
+// New returns the Digest of changes from the previous to the current list of
+// violations.
+func New(previous, current []string) Digest {
+	prev := toSet(previous)
+	curr := toSet(current)
+	d := Digest{Current: sorted(current)}
+	for _, v := range d.Current {
+		if !prev[v] {
+			d.New = append(d.New, v)
+		}
+	}
+	for _, v := range sorted(previous) {
+		if !curr[v] {
+			d.Resolved = append(d.Resolved, v)
+		}
+	}
+	return d
+}
+
+// Subject returns the email subject line for the digest.
+func (d Digest) Subject() string {
+	return fmt.Sprintf("license-checker: %d violations (%d new, %d resolved)",
+		len(d.Current), len(d.New), len(d.Resolved))
+}
+
+// Body returns the plain-text email body for the digest.
+func (d Digest) Body() string {
+	sb := strings.Builder{}
+	section := func(title string, list []string) {
+		fmt.Fprintf(&sb, "%v (%d):\n", title, len(list))
+		if len(list) == 0 {
+			fmt.Fprintf(&sb, "  none\n")
+		}
+		for _, v := range list {
+			fmt.Fprintf(&sb, "  * %v\n", v)
+		}
+		fmt.Fprintf(&sb, "\n")
+	}
+	section("New violations", d.New)
+	section("Resolved violations", d.Resolved)
+	fmt.Fprintf(&sb, "%d violations remain.\n", len(d.Current))
+	return sb.String()
+}
+
+// LoadState loads the list of violations saved by SaveState from the file at
+// path. If the file does not exist, LoadState returns an empty list.
+func LoadState(path string) ([]string, error) {
+	body, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := []string{}
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(&out); err != nil {
+		return nil, fmt.Errorf("Failed to parse digest state file '%v': %w", path, err)
+	}
+	return out, nil
+}
+
+// SaveState writes the list of violations to the file at path, so that it can
+// be loaded with LoadState by the next run.
+func SaveState(path string, violations []string) error {
+	body, err := json.MarshalIndent(sorted(violations), "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, body, 0666)
+}
+
+// SMTP holds the settings used to send a digest by email.
+type SMTP struct {
+	Addr     string   // the SMTP server address, in the form host:port
+	From     string   // the sender's email address
+	To       []string // the recipients' email addresses
+	Username string   // optional username for PLAIN authentication
+	Password string   // optional password for PLAIN authentication
+}
+
+// Send emails the digest to the recipients.
+func (s SMTP) Send(d Digest) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, err := net.SplitHostPort(s.Addr)
+		if err != nil {
+			return fmt.Errorf("Invalid SMTP server address '%v': %w", s.Addr, err)
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+	if err := smtp.SendMail(s.Addr, auth, s.From, s.To, s.Message(d)); err != nil {
+		return fmt.Errorf("Failed to send digest email: %w", err)
+	}
+	return nil
+}
+
+// Message returns the full RFC 822 email message for the digest.
+func (s SMTP) Message(d Digest) []byte {
+	sb := bytes.Buffer{}
+	fmt.Fprintf(&sb, "From: %v\r\n", s.From)
+	fmt.Fprintf(&sb, "To: %v\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(&sb, "Subject: %v\r\n", d.Subject())
+	fmt.Fprintf(&sb, "Content-Type: text/plain; charset=UTF-8\r\n")
+	fmt.Fprintf(&sb, "\r\n")
+	sb.WriteString(strings.ReplaceAll(d.Body(), "\n", "\r\n"))
+	return sb.Bytes()
+}
+
+// toSet returns a map with each of the strings in list set to true.
+func toSet(list []string) map[string]bool {
+	out := make(map[string]bool, len(list))
+	for _, s := range list {
+		out[s] = true
+	}
+	return out
+}
+
+// sorted returns a sorted copy of list.
+func sorted(list []string) []string {
+	out := append([]string{}, list...)
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package digest_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	digest "."
+)
+
+func TestNew(t *testing.T) {
+	d := digest.New([]string{"b", "a", "c"}, []string{"d", "c", "a"})
+	if expect := []string{"d"}; !reflect.DeepEqual(d.New, expect) {
+		t.Errorf("New was %v, expected %v", d.New, expect)
+	}
+	if expect := []string{"b"}; !reflect.DeepEqual(d.Resolved, expect) {
+		t.Errorf("Resolved was %v, expected %v", d.Resolved, expect)
+	}
+	if expect := []string{"a", "c", "d"}; !reflect.DeepEqual(d.Current, expect) {
+		t.Errorf("Current was %v, expected %v", d.Current, expect)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	d := digest.New([]string{"old.cpp has no license"}, []string{"new.cpp has no license"})
+	s := digest.SMTP{From: "checker@example.com", To: []string{"a@example.com", "b@example.com"}}
+	msg := string(s.Message(d))
+	for _, expect := range []string{
+		"To: a@example.com, b@example.com\r\n",
+		"Subject: license-checker: 1 violations (1 new, 1 resolved)\r\n",
+		"New violations (1):\r\n  * new.cpp has no license\r\n",
+		"Resolved violations (1):\r\n  * old.cpp has no license\r\n",
+	} {
+		if !strings.Contains(msg, expect) {
+			t.Errorf("Message did not contain '%v':\n%v", expect, msg)
+		}
+	}
+}
+
+func TestState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "digest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+
+	if got, err := digest.LoadState(path); err != nil || len(got) != 0 {
+		t.Errorf("LoadState() of missing file returned %v, %v", got, err)
+	}
+	if err := digest.SaveState(path, []string{"y", "x"}); err != nil {
+		t.Fatalf("SaveState() returned %v", err)
+	}
+	got, err := digest.LoadState(path)
+	if expect := []string{"x", "y"}; err != nil || !reflect.DeepEqual(got, expect) {
+		t.Errorf("LoadState() returned %v, %v. Expected %v", got, err, expect)
+	}
+}
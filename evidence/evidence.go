@@ -0,0 +1,217 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package evidence archives the inputs and outputs of a scan into a
+// compressed, optionally signed, bundle for audits.
+package evidence
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"../report"
+)
+
+// BundleName is the file name of the bundle in the evidence directory. The
+// signature is written next to it, with the '.sig' suffix.
+const BundleName = "evidence.tar.gz"
+
+// Manifest describes the scan that produced a bundle. It is stored in the
+// bundle as manifest.json.
+type Manifest struct {
+	Created   time.Time `json:"created"`
+	Args      []string  `json:"args"`       // the command line arguments
+	Tool      string    `json:"tool"`       // the module path and version of the tool
+	GoVersion string    `json:"go_version"` // the Go version the tool was built with
+	Modules   []string  `json:"modules"`    // the module dependencies of the tool, as path@version
+	Configs   []string  `json:"configs"`    // the project relative paths of the config files
+	Files     int       `json:"files"`      // number of examined files
+	Failures  int       `json:"failures"`   // number of violations
+	Warnings  int       `json:"warnings"`   // number of warnings
+}
+
+// Write writes the bundle of the scan described by in, with the command line
+// arguments args, to the directory dir, and returns the path of the bundle.
+// The bundle holds the manifest, the config files used by the scan, the json
+// report with the full inventory and violations, and a sha256sum compatible
+// list of the hashes of every examined file. If key is not nil, the bundle is
+// signed with it.
+func Write(dir string, in report.Input, args []string, key ed25519.PrivateKey) (string, error) {
+	m := Manifest{
+		Created:   time.Now().UTC(),
+		Args:      args,
+		GoVersion: runtime.Version(),
+		Files:     len(in.Results.Examined()),
+		Failures:  len(in.Results.Failures(in.Options).Errs()),
+		Warnings:  len(in.Results.Warnings(in.Options).Errs()),
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		m.Tool = info.Main.Path + "@" + info.Main.Version
+		for _, d := range info.Deps {
+			m.Modules = append(m.Modules, d.Path+"@"+d.Version)
+		}
+	}
+
+	files := map[string][]byte{}
+	for _, cfg := range in.Results.ConfigSources() {
+		file, name := filepath.Join(in.Root, filepath.FromSlash(cfg)), "configs/"+cfg
+		if filepath.IsAbs(filepath.FromSlash(cfg)) { // An Options.Config outside of the project
+			file, name = cfg, "configs/"+path.Base(cfg)
+		}
+		if _, ok := files[name]; ok {
+			continue
+		}
+		body, err := ioutil.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("Failed to read config file: %w", err)
+		}
+		files[name] = body
+		m.Configs = append(m.Configs, cfg)
+	}
+	sort.Strings(m.Configs)
+
+	hashes := []string{}
+	for _, res := range in.Results.Examined() {
+		body, err := ioutil.ReadFile(filepath.Join(in.Root, filepath.FromSlash(res.Path)))
+		if err != nil {
+			continue // Removed since the scan
+		}
+		hashes = append(hashes, fmt.Sprintf("%x  %v", sha256.Sum256(body), res.Path))
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i][66:] < hashes[j][66:] })
+	files["sha256sums.txt"] = []byte(strings.Join(hashes, "\n") + "\n")
+
+	rep := bytes.Buffer{}
+	if err := report.Write(&rep, "json", in); err != nil {
+		return "", fmt.Errorf("Failed to write json report: %w", err)
+	}
+	files["report.json"] = rep.Bytes()
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	files["manifest.json"] = manifest
+
+	bundle, err := archive(files, m.Created)
+	if err != nil {
+		return "", fmt.Errorf("Failed to create evidence bundle: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return "", fmt.Errorf("Failed to create evidence directory: %w", err)
+	}
+	out := filepath.Join(dir, BundleName)
+	if err := ioutil.WriteFile(out, bundle, 0666); err != nil {
+		return "", fmt.Errorf("Failed to write evidence bundle: %w", err)
+	}
+	if key != nil {
+		sig := base64.StdEncoding.EncodeToString(ed25519.Sign(key, bundle))
+		if err := ioutil.WriteFile(out+".sig", []byte(sig+"\n"), 0666); err != nil {
+			return "", fmt.Errorf("Failed to write evidence signature: %w", err)
+		}
+	}
+	return out, nil
+}
+
+// archive returns the gzip compressed tar of the files, keyed by path, in
+// path order.
+func archive(files map[string][]byte, modified time.Time) ([]byte, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	buf := bytes.Buffer{}
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		h := &tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name])), ModTime: modified}
+		if err := tw.WriteHeader(h); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// LoadKey loads the ed25519 private key used to sign bundles from the PEM
+// encoded PKCS #8 file at path, as written by
+// 'openssl genpkey -algorithm ed25519'.
+func LoadKey(path string) (ed25519.PrivateKey, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read signing key: %w", err)
+	}
+	block, _ := pem.Decode(body)
+	if block == nil {
+		return nil, fmt.Errorf("Failed to parse signing key '%v': not a PEM file", path)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse signing key '%v': %w", path, err)
+	}
+	ed, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("Signing key '%v' is not an ed25519 key", path)
+	}
+	return ed, nil
+}
+
+// Verify returns an error if the bundle at path has no signature, or if its
+// signature was not made by the private key of pub.
+func Verify(path string, pub ed25519.PublicKey) error {
+	bundle, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("Failed to read evidence bundle: %w", err)
+	}
+	body, err := ioutil.ReadFile(path + ".sig")
+	if err != nil {
+		return fmt.Errorf("Failed to read evidence signature: %w", err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(body)))
+	if err != nil {
+		return fmt.Errorf("Failed to decode evidence signature: %w", err)
+	}
+	if !ed25519.Verify(pub, bundle, sig) {
+		return fmt.Errorf("The signature of %v does not match", path)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package evidence_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	evidence "."
+	"../checker"
+	"../report"
+)
+
+func TestWrite(t *testing.T) {
+	root := t.TempDir()
+	cfg := `{ "licenses": [ "Apache-2.0" ] }`
+	src := "package main\n"
+	for file, body := range map[string]string{checker.DefaultConfigFileName: cfg, "main.go": src} {
+		if err := ioutil.WriteFile(filepath.Join(root, file), []byte(body), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	results, err := checker.Scan(root, checker.Options{Quiet: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	key, err := evidence.LoadKey(keyFile)
+	if err != nil {
+		t.Fatalf("LoadKey() returned %v", err)
+	}
+
+	dir := t.TempDir()
+	in := report.Input{Root: root, Results: results}
+	bundle, err := evidence.Write(dir, in, []string{"--evidence-dir", dir}, key)
+	if err != nil {
+		t.Fatalf("Write() returned %v", err)
+	}
+
+	files := readBundle(t, bundle)
+	if got := files["configs/"+checker.DefaultConfigFileName]; got != cfg {
+		t.Errorf("Bundle config is %q, expected %q", got, cfg)
+	}
+	if got, expect := files["sha256sums.txt"], fmt.Sprintf("%x  main.go\n", sha256.Sum256([]byte(src))); got != expect {
+		t.Errorf("Bundle sha256sums.txt is %q, expected %q", got, expect)
+	}
+	if !strings.Contains(files["report.json"], "main.go has no license") {
+		t.Errorf("Bundle report.json does not hold the violation:\n%v", files["report.json"])
+	}
+	m := evidence.Manifest{}
+	if err := json.Unmarshal([]byte(files["manifest.json"]), &m); err != nil {
+		t.Fatalf("Failed to parse manifest.json: %v", err)
+	}
+	if m.Files != 1 || m.Failures != 1 || m.GoVersion == "" || len(m.Args) != 2 {
+		t.Errorf("Bundle manifest is %+v", m)
+	}
+
+	if err := evidence.Verify(bundle, pub); err != nil {
+		t.Errorf("Verify() returned %v", err)
+	}
+	other, _, _ := ed25519.GenerateKey(rand.Reader)
+	if err := evidence.Verify(bundle, other); err == nil {
+		t.Errorf("Verify() with another key returned no error")
+	}
+	if err := ioutil.WriteFile(bundle, []byte("tampered"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := evidence.Verify(bundle, pub); err == nil {
+		t.Errorf("Verify() of a modified bundle returned no error")
+	}
+}
+
+func TestWriteEmbeddedConfig(t *testing.T) {
+	root := t.TempDir()
+	pkg := `{ "name": "example", "license-checker": { "licenses": [ "Apache-2.0" ] } }`
+	for file, body := range map[string]string{checker.PackageJSONFileName: pkg, "main.go": "package main\n"} {
+		if err := ioutil.WriteFile(filepath.Join(root, file), []byte(body), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	results, err := checker.Scan(root, checker.Options{Quiet: true})
+	if err != nil {
+		t.Fatalf("Scan() returned %v", err)
+	}
+	bundle, err := evidence.Write(t.TempDir(), report.Input{Root: root, Results: results}, nil, nil)
+	if err != nil {
+		t.Fatalf("Write() returned %v", err)
+	}
+	files := readBundle(t, bundle)
+	if got := files["configs/"+checker.PackageJSONFileName]; got != pkg {
+		t.Errorf("Bundle config is %q, expected %q", got, pkg)
+	}
+	if _, ok := files["configs/"+checker.DefaultConfigFileName]; ok {
+		t.Errorf("Bundle holds a config file that does not exist")
+	}
+}
+
+// readBundle returns the content of each of the files of the bundle, by name.
+func readBundle(t *testing.T, bundle string) map[string]string {
+	files := map[string]string{}
+	f, err := os.Open(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("Bundle is not gzip compressed: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read bundle: %v", err)
+		}
+		body, _ := ioutil.ReadAll(tr)
+		files[h.Name] = string(body)
+	}
+	return files
+}
@@ -0,0 +1,124 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fixture generates minimal compliant and non-compliant sample files
+// for a license and language, so that projects can test their own
+// license-checker configs with realistic files.
+package fixture
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"../detector"
+	"../language"
+)
+
+// Fixture is a generated sample file.
+type Fixture struct {
+	// Path is the relative path of the file, using '/' separators.
+	Path string
+	// Body is the content of the file.
+	Body []byte
+	// Compliant is true if the file is expected to pass a config that
+	// permits the fixture's license, and no other.
+	Compliant bool
+}
+
+// code holds a minimal snippet of code for some of the languages, which
+// follows the header of the generated files.
+var code = map[string]string{
+	"c":          "int example(void) { return 0; }\n",
+	"cpp":        "int example() { return 0; }\n",
+	"csharp":     "class Example {}\n",
+	"go":         "package example\n",
+	"java":       "class Example {}\n",
+	"javascript": "export const example = 0;\n",
+	"kotlin":     "fun example() = 0\n",
+	"python":     "def example():\n    return 0\n",
+	"rust":       "pub fn example() -> i32 { 0 }\n",
+	"shell":      "echo example\n",
+	"typescript": "export const example: number = 0;\n",
+}
+
+// Licenses returns the sorted identifiers of the licenses that fixtures can be
+// generated for.
+func Licenses() []string {
+	out := make([]string, 0, len(headers))
+	for id := range headers {
+		out = append(out, id)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Generate returns the fixtures for the license, in the language with the
+// given name. The license is normalized with detector.Normalize, so for
+// example "Apache-2.0-Header" is accepted. The fixtures are:
+//
+//	compliant/<file>     - has the license's standard header
+//	no-license/<file>    - has no header
+//	other-license/<file> - has the header of a different license
+func Generate(license, lang string) ([]Fixture, error) {
+	id := detector.Normalize(license)
+	header, ok := headers[id]
+	if !ok {
+		return nil, fmt.Errorf("No fixture header for license '%v'. Must be one of: %v", license, strings.Join(Licenses(), ", "))
+	}
+	l, ok := language.ByName(lang)
+	if !ok {
+		return nil, fmt.Errorf("Unknown language '%v'", lang)
+	}
+	if !l.HasComments() {
+		return nil, fmt.Errorf("Language '%v' has no comments, so cannot hold a license header", lang)
+	}
+	name, _ := language.SampleName(lang)
+
+	other := "MIT"
+	if id == other {
+		other = "Apache-2.0"
+	}
+	return []Fixture{
+		{Path: "compliant/" + name, Body: []byte(comment(l, copyright+"\n\n"+header) + "\n" + code[lang]), Compliant: true},
+		{Path: "no-license/" + name, Body: []byte(code[lang])},
+		{Path: "other-license/" + name, Body: []byte(comment(l, copyright+"\n\n"+headers[other]) + "\n" + code[lang])},
+	}, nil
+}
+
+// comment returns text as a comment block of the language, using line
+// comments if the language has them.
+func comment(l language.Language, text string) string {
+	lines := strings.Split(text, "\n")
+	sb := strings.Builder{}
+	switch {
+	case l.LineComment != "":
+		for _, line := range lines {
+			sb.WriteString(strings.TrimRight(l.LineComment+" "+line, " ") + "\n")
+		}
+	case l.BlockStart == "/*":
+		sb.WriteString("/*\n")
+		for _, line := range lines {
+			sb.WriteString(strings.TrimRight(" * "+line, " ") + "\n")
+		}
+		sb.WriteString(" */\n")
+	default:
+		sb.WriteString(l.BlockStart + "\n")
+		for _, line := range lines {
+			sb.WriteString(line + "\n")
+		}
+		sb.WriteString(l.BlockEnd + "\n")
+	}
+	return sb.String()
+}
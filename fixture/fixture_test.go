@@ -0,0 +1,64 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fixture_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	fixture "."
+	"../checker"
+)
+
+func TestGenerate(t *testing.T) {
+	for _, license := range fixture.Licenses() {
+		cfgs, err := checker.ParseConfigs([]byte(fmt.Sprintf(`{ "detector": "regex", "licenses": [ "%v" ] }`, license)))
+		if err != nil {
+			t.Fatalf("ParseConfigs() returned %v", err)
+		}
+		for _, lang := range []string{"go", "python", "css", "html", "sql", "dockerfile"} {
+			fixtures, err := fixture.Generate(license, lang)
+			if err != nil {
+				t.Fatalf("Generate(%v, %v) returned %v", license, lang, err)
+			}
+			for _, f := range fixtures {
+				results, err := checker.CheckContent(cfgs, f.Path, f.Body, nil)
+				if err != nil {
+					t.Fatalf("CheckContent(%v) returned %v", f.Path, err)
+				}
+				if passed := len(results.Errs()) == 0 && results[0].Skipped == ""; passed != f.Compliant {
+					t.Errorf("%v %v fixture %v passed: %v, expected %v. Results: %+v\n%v",
+						license, lang, f.Path, passed, f.Compliant, results, string(f.Body))
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	for _, test := range []struct {
+		license, lang string
+		expect        string
+	}{
+		{"Apache-2.0-Header", "cobol", "Unknown language 'cobol'"},
+		{"WTFPL", "go", "No fixture header for license 'WTFPL'"},
+		{"MIT", "json", "Language 'json' has no comments"},
+	} {
+		if _, err := fixture.Generate(test.license, test.lang); err == nil || !strings.Contains(err.Error(), test.expect) {
+			t.Errorf("Generate(%v, %v) returned %v, expected error containing '%v'", test.license, test.lang, err, test.expect)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package forge opens pull requests on GitHub, and merge requests on GitLab,
+// through their REST APIs.
+package forge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Kinds of Forge.
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+)
+
+// httpClient is the client used to call the forge APIs.
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// Forge is a repository hosted on GitHub or GitLab.
+type Forge struct {
+	Kind  string // GitHub or GitLab
+	API   string // the base URL of the REST API, such as https://api.github.com
+	Repo  string // the repository path, such as owner/name
+	Token string // the access token used to authenticate with the API
+}
+
+// PullRequest describes a pull request to open.
+type PullRequest struct {
+	Head  string // the branch holding the changes
+	Base  string // the branch the changes are to be merged into
+	Title string
+	Body  string
+}
+
+// FromRemote returns the Forge of the git remote URL, in either the
+// 'https://host/owner/name.git' or the 'git@host:owner/name.git' form, for
+// repositories hosted on github.com or gitlab.com. The Token is not set.
+func FromRemote(remote string) (Forge, error) {
+	host, repo := "", ""
+	if u, err := url.Parse(remote); err == nil && u.Host != "" {
+		host, repo = u.Hostname(), u.Path
+	} else if i := strings.Index(remote, ":"); i > 0 {
+		host, repo = remote[strings.Index(remote, "@")+1:i], remote[i+1:]
+	}
+	repo = strings.TrimSuffix(strings.Trim(repo, "/"), ".git")
+	if strings.Count(repo, "/") < 1 {
+		return Forge{}, fmt.Errorf("Cannot find the repository of remote '%v'", remote)
+	}
+	switch host {
+	case "github.com":
+		return Forge{Kind: GitHub, API: "https://api.github.com", Repo: repo}, nil
+	case "gitlab.com":
+		return Forge{Kind: GitLab, API: "https://gitlab.com/api/v4", Repo: repo}, nil
+	}
+	return Forge{}, fmt.Errorf("Remote '%v' is not hosted on github.com or gitlab.com", remote)
+}
+
+// Open opens the pull request, and returns its web URL.
+func (f Forge) Open(pr PullRequest) (string, error) {
+	var endpoint string
+	var request interface{}
+	var response struct {
+		HTMLURL string `json:"html_url"` // GitHub
+		WebURL  string `json:"web_url"`  // GitLab
+	}
+	switch f.Kind {
+	case GitHub:
+		endpoint = fmt.Sprintf("%v/repos/%v/pulls", f.API, f.Repo)
+		request = map[string]string{"head": pr.Head, "base": pr.Base, "title": pr.Title, "body": pr.Body}
+	case GitLab:
+		endpoint = fmt.Sprintf("%v/projects/%v/merge_requests", f.API, url.PathEscape(f.Repo))
+		request = map[string]string{"source_branch": pr.Head, "target_branch": pr.Base, "title": pr.Title, "description": pr.Body}
+	default:
+		return "", fmt.Errorf("Unknown forge '%v'", f.Kind)
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if f.Kind == GitHub {
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("Authorization", "Bearer "+f.Token)
+	} else {
+		req.Header.Set("PRIVATE-TOKEN", f.Token)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Failed to open pull request: %w", err)
+	}
+	defer resp.Body.Close()
+	reply, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("Failed to open pull request: %w", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("Failed to open pull request: %v returned %v\n%v", endpoint, resp.Status, string(reply))
+	}
+	if err := json.Unmarshal(reply, &response); err != nil {
+		return "", fmt.Errorf("Failed to parse the pull request response: %w", err)
+	}
+	if response.HTMLURL != "" {
+		return response.HTMLURL, nil
+	}
+	return response.WebURL, nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forge_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	forge "."
+)
+
+func TestFromRemote(t *testing.T) {
+	for _, test := range []struct {
+		remote string
+		kind   string
+		repo   string
+	}{
+		{"https://github.com/owner/name.git", forge.GitHub, "owner/name"},
+		{"https://github.com/owner/name", forge.GitHub, "owner/name"},
+		{"git@github.com:owner/name.git", forge.GitHub, "owner/name"},
+		{"https://gitlab.com/group/sub/name.git", forge.GitLab, "group/sub/name"},
+		{"git@gitlab.com:group/name.git", forge.GitLab, "group/name"},
+		{"https://example.com/owner/name.git", "", ""},
+		{"https://github.com/name", "", ""},
+	} {
+		f, err := forge.FromRemote(test.remote)
+		if test.kind == "" {
+			if err == nil {
+				t.Errorf("FromRemote(%v) returned no error", test.remote)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("FromRemote(%v) returned %v", test.remote, err)
+			continue
+		}
+		if f.Kind != test.kind || f.Repo != test.repo {
+			t.Errorf("FromRemote(%v) returned %v %v, expected %v %v", test.remote, f.Kind, f.Repo, test.kind, test.repo)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	for _, test := range []struct {
+		kind   string
+		path   string
+		header string
+		value  string
+		fields map[string]string
+		reply  string
+	}{
+		{
+			kind:   forge.GitHub,
+			path:   "/repos/owner/name/pulls",
+			header: "Authorization",
+			value:  "Bearer secret",
+			fields: map[string]string{"head": "fix", "base": "main", "title": "Fix", "body": "Body"},
+			reply:  `{ "html_url": "https://github.com/owner/name/pull/1" }`,
+		},
+		{
+			kind:   forge.GitLab,
+			path:   "/projects/owner%2Fname/merge_requests",
+			header: "PRIVATE-TOKEN",
+			value:  "secret",
+			fields: map[string]string{"source_branch": "fix", "target_branch": "main", "title": "Fix", "description": "Body"},
+			reply:  `{ "web_url": "https://gitlab.com/owner/name/-/merge_requests/1" }`,
+		},
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || r.URL.EscapedPath() != test.path {
+				t.Errorf("%v: got request %v %v, expected POST %v", test.kind, r.Method, r.URL.EscapedPath(), test.path)
+			}
+			if got := r.Header.Get(test.header); got != test.value {
+				t.Errorf("%v: header %v was '%v', expected '%v'", test.kind, test.header, got, test.value)
+			}
+			fields := map[string]string{}
+			if err := json.NewDecoder(r.Body).Decode(&fields); err != nil {
+				t.Errorf("%v: failed to decode request: %v", test.kind, err)
+			}
+			for k, v := range test.fields {
+				if fields[k] != v {
+					t.Errorf("%v: field %v was '%v', expected '%v'", test.kind, k, fields[k], v)
+				}
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(test.reply))
+		}))
+		f := forge.Forge{Kind: test.kind, API: server.URL, Repo: "owner/name", Token: "secret"}
+		url, err := f.Open(forge.PullRequest{Head: "fix", Base: "main", Title: "Fix", Body: "Body"})
+		server.Close()
+		if err != nil {
+			t.Errorf("%v: Open() returned %v", test.kind, err)
+			continue
+		}
+		var reply map[string]string
+		json.Unmarshal([]byte(test.reply), &reply)
+		if url != reply["html_url"]+reply["web_url"] {
+			t.Errorf("%v: Open() returned '%v'", test.kind, url)
+		}
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}))
+	defer failing.Close()
+	f := forge.Forge{Kind: forge.GitHub, API: failing.URL, Repo: "owner/name"}
+	if _, err := f.Open(forge.PullRequest{}); err == nil {
+		t.Errorf("Open() returned no error for a failed request")
+	}
+}
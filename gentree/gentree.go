@@ -0,0 +1,134 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gentree generates synthetic project trees for benchmarking the
+// license checker.
+package gentree
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Options controls the shape of the generated tree.
+type Options struct {
+	Files      int     // number of source files to generate
+	Depth      int     // number of directory levels below the root
+	Fanout     int     // number of subdirectories of each directory
+	Lines      int     // number of lines of code in each source file
+	Unlicensed float64 // fraction [0, 1] of source files without a license
+	Seed       int64   // seed for the random number generator
+}
+
+// DefaultOptions returns the Options used for a medium sized tree.
+func DefaultOptions() Options {
+	return Options{
+		Files:      1000,
+		Depth:      3,
+		Fanout:     4,
+		Lines:      100,
+		Unlicensed: 0.01,
+		Seed:       1,
+	}
+}
+
+// header is the license header prepended to the licensed source files.
+const header = `// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+`
+
+// config is the license-checker.cfg written to the root of the tree.
+const config = `{
+    "paths": [{ "exclude": [ "**.txt" ] }],
+    "licenses": [ "Apache-2.0" ]
+}
+`
+
+// extensions are the source file extensions that are cycled through.
+var extensions = []string{".cc", ".h", ".go", ".js"}
+
+// Generate writes a synthetic project tree to dir, along with a
+// license-checker.cfg config file that excludes the generated '.txt' files.
+// Generate returns the number of generated source files without a license.
+func Generate(dir string, opts Options) (int, error) {
+	if opts.Files < 0 || opts.Depth < 0 || opts.Fanout < 1 {
+		return 0, fmt.Errorf("Invalid options: %+v", opts)
+	}
+
+	dirs := []string{"."}
+	for level, parents := 0, dirs; level < opts.Depth; level++ {
+		children := []string{}
+		for _, parent := range parents {
+			for i := 0; i < opts.Fanout; i++ {
+				children = append(children, filepath.Join(parent, fmt.Sprintf("dir%d", i)))
+			}
+		}
+		dirs = append(dirs, children...)
+		parents = children
+	}
+	for _, d := range dirs {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0777); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "license-checker.cfg"), []byte(config), 0666); err != nil {
+		return 0, err
+	}
+
+	code := strings.Builder{}
+	for i := 0; i < opts.Lines; i++ {
+		fmt.Fprintf(&code, "int function_%d(int x) { return x * %d; }\n", i, i)
+	}
+
+	rnd := rand.New(rand.NewSource(opts.Seed))
+	unlicensed := 0
+	for i := 0; i < opts.Files; i++ {
+		d := dirs[i%len(dirs)]
+		name := fmt.Sprintf("file%d%v", i, extensions[i%len(extensions)])
+		body := code.String()
+		if rnd.Float64() < opts.Unlicensed {
+			unlicensed++
+		} else {
+			body = header + body
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, d, name), []byte(body), 0666); err != nil {
+			return 0, err
+		}
+		if i%len(dirs) == 0 {
+			// Sprinkle some excluded files through the tree
+			txt := filepath.Join(dir, d, fmt.Sprintf("notes%d.txt", i))
+			if err := ioutil.WriteFile(txt, []byte("Unlicensed notes\n"), 0666); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return unlicensed, nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gentree_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gentree "."
+)
+
+func TestGenerate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gentree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	opts := gentree.Options{Files: 50, Depth: 2, Fanout: 3, Lines: 5, Unlicensed: 0.5, Seed: 1}
+	unlicensed, err := gentree.Generate(dir, opts)
+	if err != nil {
+		t.Fatalf("Generate() returned %v", err)
+	}
+
+	sources, dirs, missing := 0, 0, 0
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		switch {
+		case info.IsDir():
+			dirs++
+		case filepath.Ext(path) != ".txt" && filepath.Base(path) != "license-checker.cfg":
+			sources++
+			body, _ := ioutil.ReadFile(path)
+			if !strings.Contains(string(body), "Apache License") {
+				missing++
+			}
+		}
+		return nil
+	})
+
+	if sources != opts.Files {
+		t.Errorf("Generated %v source files, expected %v", sources, opts.Files)
+	}
+	if expect := 1 + 3 + 9; dirs != expect {
+		t.Errorf("Generated %v directories, expected %v", dirs, expect)
+	}
+	if missing != unlicensed || unlicensed == 0 || unlicensed == opts.Files {
+		t.Errorf("Generate() returned %v unlicensed files, found %v", unlicensed, missing)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "license-checker.cfg")); err != nil {
+		t.Errorf("Config file was not generated: %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// golangci is a golangci-lint plugin that checks the license headers of the
+// linted Go files, reporting violations through golangci-lint's diagnostics.
+//
+// Build with:
+//
+//	go build -buildmode=plugin -o licensecheck.so ./golangci
+//
+// and reference it from .golangci.yml:
+//
+//	linters-settings:
+//	  custom:
+//	    licensecheck:
+//	      path: licensecheck.so
+//	      settings:
+//	        config: path/to/license-checker.cfg
+//	        license-db: path/to/licenses.json
+//
+// Both settings are optional. Without 'config', each file is checked with the
+// license-checker.cfg of the closest enclosing directory that has one.
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+
+	"../checker"
+	"../detector"
+)
+
+// settings holds the plugin settings of the .golangci.yml file.
+type settings struct {
+	config    string // path to the config file, or empty to search for one
+	licenseDB string // path to the license database, or empty
+}
+
+// policies holds the loaded policies, keyed by the config file path.
+type policies struct {
+	sync.Mutex
+	byPath map[string]*checker.Policy
+}
+
+// New is the entry point of the plugin, called by golangci-lint with the
+// plugin settings.
+func New(conf interface{}) ([]*analysis.Analyzer, error) {
+	s, err := parseSettings(conf)
+	if err != nil {
+		return nil, err
+	}
+	p := &policies{byPath: map[string]*checker.Policy{}}
+	return []*analysis.Analyzer{{
+		Name: "licensecheck",
+		Doc:  "checks that files carry license headers permitted by license-checker.cfg",
+		Run: func(pass *analysis.Pass) (interface{}, error) {
+			return nil, p.run(pass, s)
+		},
+	}}, nil
+}
+
+// parseSettings parses the plugin settings conf.
+func parseSettings(conf interface{}) (settings, error) {
+	s := settings{}
+	if conf == nil {
+		return s, nil
+	}
+	m, ok := conf.(map[string]interface{})
+	if !ok {
+		return s, fmt.Errorf("licensecheck settings must be a map, got %T", conf)
+	}
+	for k, v := range m {
+		str, ok := v.(string)
+		if !ok {
+			return s, fmt.Errorf("licensecheck setting '%v' must be a string", k)
+		}
+		switch k {
+		case "config":
+			s.config = str
+		case "license-db":
+			s.licenseDB = str
+		default:
+			return s, fmt.Errorf("Unknown licensecheck setting '%v'", k)
+		}
+	}
+	return s, nil
+}
+
+// run checks each of the files of the pass.
+func (p *policies) run(pass *analysis.Pass, s settings) error {
+	for _, f := range pass.Files {
+		if err := p.checkFile(pass, s, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkFile checks the file f, reporting a diagnostic at the start of the file
+// for each violation.
+func (p *policies) checkFile(pass *analysis.Pass, s settings, f *ast.File) error {
+	tf := pass.Fset.File(f.Pos())
+	if tf == nil {
+		return nil
+	}
+	file, err := filepath.Abs(tf.Name())
+	if err != nil {
+		return err
+	}
+	cfgPath := s.config
+	if cfgPath == "" {
+		if cfgPath = findConfig(filepath.Dir(file)); cfgPath == "" {
+			return nil // Not part of a project with a config
+		}
+	}
+	policy, err := p.load(cfgPath, s.licenseDB)
+	if err != nil {
+		return err
+	}
+	results, err := policy.CheckFile(filepath.Dir(cfgPath), file)
+	if err != nil {
+		return err
+	}
+	for _, res := range results {
+		if res.Err == nil || !res.Kind.IsFile() {
+			continue
+		}
+		pass.Report(analysis.Diagnostic{
+			Pos:      tf.Pos(0),
+			Category: string(res.Kind),
+			Message:  res.Err.Error(),
+		})
+	}
+	return nil
+}
+
+// findConfig returns the path of the config file in dir or its closest
+// ancestor that has one, or an empty string if there is none.
+func findConfig(dir string) string {
+	for {
+		path := filepath.Join(dir, checker.DefaultConfigFileName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// load returns the Policy of the config file at cfgPath, loading it on first
+// use.
+func (p *policies) load(cfgPath, licenseDB string) (*checker.Policy, error) {
+	p.Lock()
+	defer p.Unlock()
+	if policy, ok := p.byPath[cfgPath]; ok {
+		return policy, nil
+	}
+	body, err := ioutil.ReadFile(cfgPath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read config file: %w", err)
+	}
+	cfgs, err := checker.ParseConfigs(body)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load config file: %w", err)
+	}
+	var db *detector.Database
+	if licenseDB != "" {
+		if db, err = detector.LoadDatabase(licenseDB); err != nil {
+			return nil, err
+		}
+	}
+	policy, err := checker.NewPolicy(cfgs, db)
+	if err != nil {
+		return nil, err
+	}
+	p.byPath[cfgPath] = policy
+	return policy, nil
+}
+
+func main() {}
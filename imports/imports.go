@@ -0,0 +1,169 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package imports finds the files referenced by the #include and import
+// statements of C-family, Python and JavaScript / TypeScript source files.
+package imports
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Reference is a single #include or import statement.
+type Reference struct {
+	// Spec is the referenced name, as written in the statement.
+	Spec string
+	// Candidates are the project relative paths, using '/' separators, that
+	// the reference may resolve to, in order of preference.
+	Candidates []string
+}
+
+var (
+	// includeRE matches a C-family #include or #import directive.
+	includeRE = regexp.MustCompile(`(?m)^[ \t]*#[ \t]*(?:include|import)[ \t]*([<"])([^">\r\n]+)[">]`)
+	// pyImportRE matches a Python 'import a.b, c' statement.
+	pyImportRE = regexp.MustCompile(`(?m)^[ \t]*import[ \t]+([\w.]+(?:[ \t]*,[ \t]*[\w.]+)*)`)
+	// pyFromRE matches a Python 'from .a.b import c' statement.
+	pyFromRE = regexp.MustCompile(`(?m)^[ \t]*from[ \t]+(\.*)([\w.]*)[ \t]+import\b`)
+	// jsImportRE matches a JavaScript import, export-from, dynamic import or
+	// require of a module.
+	jsImportRE = regexp.MustCompile(`(?:\bfrom|\bimport|\brequire[ \t]*\(|\bimport[ \t]*\()[ \t]*['"]([^'"\r\n]+)['"]`)
+)
+
+// cExtensions is the set of C-family file extensions.
+var cExtensions = map[string]bool{
+	".c": true, ".cc": true, ".cpp": true, ".cxx": true, ".h": true,
+	".hh": true, ".hpp": true, ".hxx": true, ".inc": true, ".inl": true,
+	".m": true, ".mm": true,
+}
+
+// jsExtensions is the list of JavaScript and TypeScript file extensions, in
+// the order that module resolution tries them.
+var jsExtensions = []string{".js", ".ts", ".tsx", ".jsx", ".mjs", ".cjs"}
+
+// Find returns the references of the file at the project relative path with
+// the given content. Files of unsupported languages have no references.
+// Only references that may resolve to a file of the project are returned, so
+// for example '#include <vector>' has candidates, but Node.js packages do not.
+func Find(file string, body []byte) []Reference {
+	file = path.Clean(file)
+	dir := path.Dir(file)
+	ext := strings.ToLower(path.Ext(file))
+	switch {
+	case cExtensions[ext]:
+		return findC(dir, body)
+	case ext == ".py":
+		return findPython(dir, body)
+	case isJS(ext):
+		return findJS(dir, body)
+	}
+	return nil
+}
+
+// findC returns the references of a C-family file in dir. Quoted includes are
+// resolved relative to dir, and then to the project root. Angle-bracket
+// includes are resolved relative to the project root.
+func findC(dir string, body []byte) []Reference {
+	out := []Reference{}
+	for _, m := range includeRE.FindAllSubmatch(body, -1) {
+		spec := string(m[2])
+		candidates := []string{}
+		if string(m[1]) == `"` {
+			candidates = appendInProject(candidates, path.Join(dir, spec))
+		}
+		candidates = appendInProject(candidates, spec)
+		out = append(out, Reference{Spec: spec, Candidates: candidates})
+	}
+	return out
+}
+
+// findPython returns the references of a Python file in dir. Absolute imports
+// are resolved relative to the project root.
+func findPython(dir string, body []byte) []Reference {
+	out := []Reference{}
+	module := func(spec, base string) {
+		p := path.Join(base, strings.ReplaceAll(spec, ".", "/"))
+		candidates := appendInProject(nil, p+".py")
+		candidates = appendInProject(candidates, path.Join(p, "__init__.py"))
+		out = append(out, Reference{Spec: spec, Candidates: candidates})
+	}
+	for _, m := range pyImportRE.FindAllSubmatch(body, -1) {
+		for _, spec := range strings.Split(string(m[1]), ",") {
+			module(strings.TrimSpace(spec), ".")
+		}
+	}
+	for _, m := range pyFromRE.FindAllSubmatch(body, -1) {
+		dots, spec := string(m[1]), string(m[2])
+		if dots == "" {
+			module(spec, ".")
+			continue
+		}
+		base := dir
+		for i := 1; i < len(dots); i++ {
+			base = path.Dir(base)
+		}
+		if spec == "" {
+			spec = "__init__"
+		}
+		module(spec, base)
+	}
+	return out
+}
+
+// findJS returns the relative module references of a JavaScript or TypeScript
+// file in dir.
+func findJS(dir string, body []byte) []Reference {
+	out := []Reference{}
+	for _, m := range jsImportRE.FindAllSubmatch(body, -1) {
+		spec := string(m[1])
+		if !strings.HasPrefix(spec, "./") && !strings.HasPrefix(spec, "../") {
+			continue // A package, not a file of the project
+		}
+		p := path.Join(dir, spec)
+		candidates := []string{}
+		if isJS(strings.ToLower(path.Ext(p))) {
+			candidates = appendInProject(candidates, p)
+		}
+		for _, ext := range jsExtensions {
+			candidates = appendInProject(candidates, p+ext)
+		}
+		for _, ext := range jsExtensions {
+			candidates = appendInProject(candidates, path.Join(p, "index"+ext))
+		}
+		out = append(out, Reference{Spec: spec, Candidates: candidates})
+	}
+	return out
+}
+
+// appendInProject returns candidates with the project relative path p
+// appended, unless p is outside of the project.
+func appendInProject(candidates []string, p string) []string {
+	p = path.Clean(p)
+	if p == ".." || strings.HasPrefix(p, "../") || path.IsAbs(p) {
+		return candidates
+	}
+	return append(candidates, p)
+}
+
+// isJS returns true if ext is a JavaScript or TypeScript file extension.
+func isJS(ext string) bool {
+	for _, e := range jsExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imports_test
+
+import (
+	"reflect"
+	"testing"
+
+	imports "."
+)
+
+func TestFind(t *testing.T) {
+	for _, test := range []struct {
+		path   string
+		body   string
+		expect []imports.Reference
+	}{
+		{"src/a.cpp", "#include \"b.h\"\n  #  include <lib/c.h>\n#include \"../../etc/d.h\"\n", []imports.Reference{
+			{Spec: "b.h", Candidates: []string{"src/b.h", "b.h"}},
+			{Spec: "lib/c.h", Candidates: []string{"lib/c.h"}},
+			{Spec: "../../etc/d.h", Candidates: []string{}},
+		}},
+		{"pkg/mod.py", "import os, pkg.util\nfrom .sibling import x\nfrom .. import y\n", []imports.Reference{
+			{Spec: "os", Candidates: []string{"os.py", "os/__init__.py"}},
+			{Spec: "pkg.util", Candidates: []string{"pkg/util.py", "pkg/util/__init__.py"}},
+			{Spec: "sibling", Candidates: []string{"pkg/sibling.py", "pkg/sibling/__init__.py"}},
+			{Spec: "__init__", Candidates: []string{"__init__.py", "__init__/__init__.py"}},
+		}},
+		{"web/app.ts", "import { x } from './util';\nimport React from 'react';\nconst y = require(\"../lib/y.js\");\n", []imports.Reference{
+			{Spec: "./util", Candidates: []string{
+				"web/util.js", "web/util.ts", "web/util.tsx", "web/util.jsx", "web/util.mjs", "web/util.cjs",
+				"web/util/index.js", "web/util/index.ts", "web/util/index.tsx", "web/util/index.jsx", "web/util/index.mjs", "web/util/index.cjs",
+			}},
+			{Spec: "../lib/y.js", Candidates: []string{
+				"lib/y.js", "lib/y.js.js", "lib/y.js.ts", "lib/y.js.tsx", "lib/y.js.jsx", "lib/y.js.mjs", "lib/y.js.cjs",
+				"lib/y.js/index.js", "lib/y.js/index.ts", "lib/y.js/index.tsx", "lib/y.js/index.jsx", "lib/y.js/index.mjs", "lib/y.js/index.cjs",
+			}},
+		}},
+		{"README.md", "#include \"a.h\"\n", nil},
+	} {
+		if got := imports.Find(test.path, []byte(test.body)); !reflect.DeepEqual(got, test.expect) {
+			t.Errorf("Find(%v) returned:\n%+v\nexpected:\n%+v", test.path, got, test.expect)
+		}
+	}
+}
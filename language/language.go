@@ -0,0 +1,190 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package language recognizes the language of source files from their file
+// names, extensions and shebang lines, and describes how each language writes
+// comments.
+package language
+
+import (
+	"path"
+	"strings"
+
+	"../sniff"
+)
+
+// Language describes a source language.
+type Language struct {
+	// Name is the unique, lower-case name of the language. For example: "go".
+	Name string
+	// LineComment is the token that starts a comment which runs to the end of
+	// the line, or an empty string if the language has no line comments.
+	LineComment string
+	// BlockStart and BlockEnd are the tokens that start and end a block
+	// comment, or empty strings if the language has no block comments.
+	BlockStart, BlockEnd string
+}
+
+// HasComments returns true if the language supports any form of comment.
+func (l Language) HasComments() bool {
+	return l.LineComment != "" || l.BlockStart != ""
+}
+
+// Comment styles shared by multiple languages.
+var (
+	cStyle    = Language{LineComment: "//", BlockStart: "/*", BlockEnd: "*/"}
+	hashStyle = Language{LineComment: "#"}
+	dashStyle = Language{LineComment: "--"}
+	xmlStyle  = Language{BlockStart: "<!--", BlockEnd: "-->"}
+	cssStyle  = Language{BlockStart: "/*", BlockEnd: "*/"}
+	noStyle   = Language{}
+)
+
+// languages is the list of all recognized languages.
+var languages = []struct {
+	style      Language
+	name       string
+	extensions []string
+	filenames  []string // exact file names, or prefixes if ending in '.'
+	shebangs   []string // interpreter names
+}{
+	{cStyle, "c", []string{".c", ".h"}, nil, nil},
+	{cStyle, "cpp", []string{".cc", ".cpp", ".cxx", ".c++", ".hh", ".hpp", ".hxx", ".inl"}, nil, nil},
+	{cStyle, "csharp", []string{".cs"}, nil, nil},
+	{cStyle, "glsl", []string{".glsl", ".vert", ".frag", ".geom", ".comp", ".tesc", ".tese"}, nil, nil},
+	{cStyle, "go", []string{".go"}, nil, nil},
+	{cStyle, "hlsl", []string{".hlsl"}, nil, nil},
+	{cStyle, "java", []string{".java"}, nil, nil},
+	{cStyle, "javascript", []string{".js", ".mjs", ".cjs", ".jsx"}, nil, []string{"node"}},
+	{cStyle, "kotlin", []string{".kt", ".kts"}, nil, nil},
+	{cStyle, "objc", []string{".m", ".mm"}, nil, nil},
+	{cStyle, "php", []string{".php"}, nil, []string{"php"}},
+	{cStyle, "proto", []string{".proto"}, nil, nil},
+	{cStyle, "rust", []string{".rs"}, nil, nil},
+	{cStyle, "swift", []string{".swift"}, nil, nil},
+	{cStyle, "typescript", []string{".ts", ".tsx"}, nil, nil},
+	{cStyle, "wgsl", []string{".wgsl"}, nil, nil},
+	{cssStyle, "css", []string{".css"}, nil, nil},
+	{hashStyle, "bazel", []string{".bzl", ".bazel"}, []string{"BUILD", "WORKSPACE"}, nil},
+	{hashStyle, "cmake", []string{".cmake"}, []string{"CMakeLists.txt"}, nil},
+	{hashStyle, "dockerfile", []string{".dockerfile"}, []string{"Dockerfile", "Dockerfile.", "Containerfile"}, nil},
+	{hashStyle, "make", []string{".mk"}, []string{"Makefile", "makefile", "GNUmakefile"}, nil},
+	{hashStyle, "perl", []string{".pl", ".pm"}, nil, []string{"perl"}},
+	{hashStyle, "powershell", []string{".ps1", ".psm1"}, nil, []string{"pwsh"}},
+	{hashStyle, "python", []string{".py", ".pyi"}, nil, []string{"python", "python2", "python3"}},
+	{hashStyle, "r", []string{".r", ".R"}, nil, []string{"Rscript"}},
+	{hashStyle, "ruby", []string{".rb"}, []string{"Gemfile", "Rakefile"}, []string{"ruby"}},
+	{hashStyle, "shell", []string{".sh", ".bash", ".zsh", ".ksh"}, nil, []string{"sh", "bash", "dash", "ksh", "zsh"}},
+	{hashStyle, "toml", []string{".toml"}, nil, nil},
+	{hashStyle, "yaml", []string{".yml", ".yaml"}, nil, nil},
+	{dashStyle, "haskell", []string{".hs"}, nil, nil},
+	{dashStyle, "lua", []string{".lua"}, nil, []string{"lua"}},
+	{dashStyle, "sql", []string{".sql"}, nil, nil},
+	{xmlStyle, "html", []string{".html", ".htm"}, nil, nil},
+	{xmlStyle, "markdown", []string{".md"}, nil, nil},
+	{xmlStyle, "xml", []string{".xml", ".svg"}, nil, nil},
+	{noStyle, "json", []string{".json"}, nil, nil},
+}
+
+// Lookup tables built from languages.
+var (
+	byName      = map[string]Language{}
+	byExtension = map[string]Language{}
+	byFilename  = map[string]Language{}
+	byPrefix    = map[string]Language{}
+	byShebang   = map[string]Language{}
+)
+
+func init() {
+	for _, entry := range languages {
+		l := entry.style
+		l.Name = entry.name
+		byName[l.Name] = l
+		for _, ext := range entry.extensions {
+			byExtension[ext] = l
+		}
+		for _, name := range entry.filenames {
+			if strings.HasSuffix(name, ".") {
+				byPrefix[name] = l
+			} else {
+				byFilename[name] = l
+			}
+		}
+		for _, name := range entry.shebangs {
+			byShebang[name] = l
+		}
+	}
+}
+
+// ByName returns the Language with the given name.
+func ByName(name string) (Language, bool) {
+	l, ok := byName[name]
+	return l, ok
+}
+
+// SampleName returns a file name that is recognized as the language with the
+// given name, such as "example.go" or "example.bzl".
+func SampleName(name string) (string, bool) {
+	for _, entry := range languages {
+		if entry.name != name {
+			continue
+		}
+		if len(entry.extensions) > 0 {
+			return "example" + entry.extensions[0], true
+		}
+		return entry.filenames[0], true
+	}
+	return "", false
+}
+
+// ForPath returns the Language of the file at the given path, as determined by
+// the file name and extension.
+func ForPath(filepath string) (Language, bool) {
+	name := path.Base(strings.ReplaceAll(filepath, "\\", "/"))
+	if l, ok := byFilename[name]; ok {
+		return l, true
+	}
+	for prefix, l := range byPrefix {
+		if strings.HasPrefix(name, prefix) {
+			return l, true
+		}
+	}
+	if l, ok := byExtension[path.Ext(name)]; ok {
+		return l, true
+	}
+	return Language{}, false
+}
+
+// ForShebang returns the Language of a script from the shebang line at the
+// start of head.
+func ForShebang(head []byte) (Language, bool) {
+	name := sniff.Interpreter(head)
+	if l, ok := byShebang[name]; ok {
+		return l, true
+	}
+	if l, ok := byShebang[strings.TrimRight(name, "0123456789.")]; ok {
+		return l, true // For example: 'python3.8'
+	}
+	return Language{}, false
+}
+
+// Detect returns the Language of the file at the given path, first using
+// ForPath and then falling back to ForShebang with the file's leading bytes.
+// head is only called if the language cannot be determined from the path.
+func Detect(path string, head func() []byte) (Language, bool) {
+	if l, ok := ForPath(path); ok {
+		return l, true
+	}
+	return ForShebang(head())
+}
@@ -0,0 +1,126 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package language_test
+
+import (
+	"strings"
+	"testing"
+
+	language "."
+)
+
+func TestDetect(t *testing.T) {
+	for _, test := range []struct {
+		path   string
+		head   string
+		expect string
+	}{
+		{"src/main.go", "", "go"},
+		{"src/foo.cc", "", "cpp"},
+		{"include/foo.h", "", "c"},
+		{"Dockerfile", "", "dockerfile"},
+		{"docker/Dockerfile.release", "", "dockerfile"},
+		{"Makefile", "", "make"},
+		{"third_party/BUILD", "", "bazel"},
+		{"WORKSPACE", "", "bazel"},
+		{"BUILD.bazel", "", "bazel"},
+		{"CMakeLists.txt", "", "cmake"},
+		{"tools/roll", "#!/bin/bash\n", "shell"},
+		{"tools/gen", "#!/usr/bin/env python3.8\n", "python"},
+		{"tools/gen.py", "#!/bin/sh\n", "python"},
+		{"README", "Read me\n", ""},
+		{"notes.txt", "", ""},
+	} {
+		l, ok := language.Detect(test.path, func() []byte { return []byte(test.head) })
+		if ok != (test.expect != "") || l.Name != test.expect {
+			t.Errorf("Detect('%v') returned '%v', expected '%v'", test.path, l.Name, test.expect)
+		}
+	}
+}
+
+func TestCommentStyles(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		line        string
+		blockStart  string
+		blockEnd    string
+		hasComments bool
+	}{
+		{"go", "//", "/*", "*/", true},
+		{"shell", "#", "", "", true},
+		{"sql", "--", "", "", true},
+		{"html", "", "<!--", "-->", true},
+		{"json", "", "", "", false},
+	} {
+		l, ok := language.ByName(test.name)
+		if !ok {
+			t.Errorf("ByName('%v') did not find the language", test.name)
+			continue
+		}
+		if l.LineComment != test.line || l.BlockStart != test.blockStart || l.BlockEnd != test.blockEnd {
+			t.Errorf("Language '%v' has unexpected comment style: %+v", test.name, l)
+		}
+		if l.HasComments() != test.hasComments {
+			t.Errorf("Language '%v' HasComments() returned %v", test.name, l.HasComments())
+		}
+	}
+}
+
+func TestHeaderOffset(t *testing.T) {
+	// '|' marks the expected offset of the header.
+	for _, test := range []struct {
+		lang string
+		body string
+	}{
+		{"go", "|package main\n"},
+		{"go", "|//go:build linux\n// +build linux\n\npackage main\n"},
+		{"go", "|// Package x does y.\npackage x\n"},
+		{"shell", "#!/bin/sh\n|echo hi\n"},
+		{"shell", "|echo hi\n"},
+		{"php", "<?php\n|echo 'hi';\n"},
+		{"php", "#!/usr/bin/env php\n<?php\n|echo 'hi';\n"},
+		{"python", "#!/usr/bin/env python3\n# -*- coding: utf-8 -*-\n\"\"\"Module docs.\n\nMore docs.\n\"\"\"\n|import os\n"},
+		{"python", "\"\"\"Module docs.\"\"\"\n|\nimport os\n"},
+		{"python", "r'''Raw docs.'''\n|import os\n"},
+		{"python", "|\"\"\"Unterminated\n"},
+		{"python", "|import os\n\"\"\"Not a docstring.\"\"\"\n"},
+		{"ruby", "# frozen_string_literal: true\n|puts 'hi'\n"},
+		{"xml", "<?xml version=\"1.0\"?>\n|<root/>\n"},
+		{"html", "<!doctype html>\n|<html></html>\n"},
+		{"cpp", "|#include <a>\n"},
+	} {
+		l, ok := language.ByName(test.lang)
+		if !ok {
+			t.Fatalf("Unknown language '%v'", test.lang)
+		}
+		expect := strings.Index(test.body, "|")
+		body := strings.Replace(test.body, "|", "", 1)
+		if got := language.HeaderOffset([]byte(body), l.Placement()); got != expect {
+			t.Errorf("HeaderOffset(%v, %q) returned %v, expected %v", test.lang, body, got, expect)
+		}
+	}
+
+	// Build constraints are only skipped by the build-tags rule.
+	body := "//go:build linux\n// +build linux\n\npackage main\n"
+	if got := language.HeaderOffset([]byte(body), []string{language.BuildTags}); got != len("//go:build linux\n// +build linux\n") {
+		t.Errorf("HeaderOffset() with build-tags returned %v", got)
+	}
+
+	// Rules are applied in the given order.
+	body = "#!/bin/sh\n<?php\n"
+	if got := language.HeaderOffset([]byte(body), []string{language.PHPOpenTag, language.Shebang}); got != len("#!/bin/sh\n") {
+		t.Errorf("HeaderOffset() with reordered rules returned %v", got)
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package language
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Placement rules name a construct at the start of a file that must stay above
+// a license header inserted into the file, as inserting the header at the very
+// top would break the file.
+const (
+	// Shebang is the '#!' interpreter line of a script.
+	Shebang = "shebang"
+	// MagicComment is a Python or Ruby magic comment line, such as
+	// '# -*- coding: utf-8 -*-' or '# frozen_string_literal: true'.
+	MagicComment = "magic-comment"
+	// PHPOpenTag is the '<?php' line that opens the PHP code of the file.
+	PHPOpenTag = "php-open-tag"
+	// XMLDeclaration is the '<?xml ... ?>' declaration and '<!DOCTYPE ...>'
+	// lines of an XML or HTML document.
+	XMLDeclaration = "xml-declaration"
+	// Docstring is the triple-quoted module docstring of a Python file.
+	Docstring = "docstring"
+	// BuildTags are the '//go:build' and '// +build' constraint lines of a Go
+	// file. Go headers are placed above the constraints by default, as in the
+	// Go source tree, which is valid as constraints may follow line comments.
+	BuildTags = "build-tags"
+)
+
+// placementRules are the placement rules, by name. Each returns the offset of
+// body after its construct, if the construct is found at offset, ignoring
+// blank lines, otherwise offset.
+var placementRules = map[string]func(body []byte, offset int) int{
+	Shebang: func(body []byte, offset int) int {
+		return skipLines(body, offset, func(line string) bool {
+			return strings.HasPrefix(line, "#!")
+		})
+	},
+	MagicComment: func(body []byte, offset int) int {
+		return skipLines(body, offset, func(line string) bool {
+			return strings.HasPrefix(line, "#") &&
+				(strings.Contains(line, "coding:") || strings.Contains(line, "coding=") ||
+					strings.Contains(line, "frozen_string_literal:"))
+		})
+	},
+	PHPOpenTag: func(body []byte, offset int) int {
+		return skipLines(body, offset, func(line string) bool {
+			return strings.HasPrefix(line, "<?php")
+		})
+	},
+	XMLDeclaration: func(body []byte, offset int) int {
+		return skipLines(body, offset, func(line string) bool {
+			return strings.HasPrefix(line, "<?xml") || strings.HasPrefix(strings.ToUpper(line), "<!DOCTYPE")
+		})
+	},
+	Docstring: func(body []byte, offset int) int {
+		start := skipBlankLines(body, offset)
+		line, _ := lineAt(body, start)
+		quoted := strings.TrimLeft(line, "rRuU")
+		for _, quote := range []string{`"""`, `'''`} {
+			if !strings.HasPrefix(quoted, quote) {
+				continue
+			}
+			open := start + len(line) - len(quoted) + len(quote)
+			end := bytes.Index(body[open:], []byte(quote))
+			if end < 0 {
+				return offset // Unterminated
+			}
+			_, next := lineAt(body, open+end)
+			return next
+		}
+		return offset
+	},
+	BuildTags: func(body []byte, offset int) int {
+		return skipLines(body, offset, func(line string) bool {
+			return strings.HasPrefix(line, "//go:build") || strings.HasPrefix(line, "// +build")
+		})
+	},
+}
+
+// defaultPlacement are the placement rules of each language, by name. The
+// rules of other languages are just Shebang. Go headers are placed at the top
+// of the file, above any build constraints, and so never separate a directive
+// from the declaration that it applies to.
+var defaultPlacement = map[string][]string{
+	"go":     {},
+	"html":   {XMLDeclaration},
+	"php":    {Shebang, PHPOpenTag},
+	"python": {Shebang, MagicComment, Docstring},
+	"ruby":   {Shebang, MagicComment},
+	"xml":    {XMLDeclaration},
+}
+
+// IsPlacementRule returns true if name is a placement rule, such as Shebang.
+func IsPlacementRule(name string) bool {
+	_, ok := placementRules[name]
+	return ok
+}
+
+// Placement returns the default placement rules of the language, which name
+// the constructs that a license header inserted into a file of the language
+// is placed after.
+func (l Language) Placement() []string {
+	if rules, ok := defaultPlacement[l.Name]; ok {
+		return rules
+	}
+	return []string{Shebang}
+}
+
+// HeaderOffset returns the byte offset of body at which a license header is
+// inserted: after the constructs named by the placement rules, which are
+// looked for in order, each after the construct of the previous rule. The
+// rules whose construct is not found are skipped, as are unknown rules.
+func HeaderOffset(body []byte, rules []string) int {
+	offset := 0
+	for _, name := range rules {
+		if rule, ok := placementRules[name]; ok {
+			offset = rule(body, offset)
+		}
+	}
+	return offset
+}
+
+// skipLines returns the offset of body after the lines that start at offset,
+// ignoring blank lines, and whose trimmed text matches, or offset if the
+// first non-blank line does not match.
+func skipLines(body []byte, offset int, match func(line string) bool) int {
+	end := offset
+	for next := skipBlankLines(body, end); next < len(body); next = skipBlankLines(body, end) {
+		line, after := lineAt(body, next)
+		if !match(strings.TrimSpace(line)) {
+			break
+		}
+		end = after
+	}
+	return end
+}
+
+// skipBlankLines returns the offset of the first non-blank line of body at or
+// after offset.
+func skipBlankLines(body []byte, offset int) int {
+	for offset < len(body) {
+		line, next := lineAt(body, offset)
+		if strings.TrimSpace(line) != "" {
+			break
+		}
+		offset = next
+	}
+	return offset
+}
+
+// lineAt returns the text of the line of body that holds offset, from offset
+// to the line end, and the offset of the next line.
+func lineAt(body []byte, offset int) (string, int) {
+	end := bytes.IndexByte(body[offset:], '\n')
+	if end < 0 {
+		return string(body[offset:]), len(body)
+	}
+	return string(body[offset : offset+end]), offset + end + 1
+}
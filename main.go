@@ -23,17 +23,97 @@
 package main
 
 import (
+	"context"
+	"crypto/ed25519"
+	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
+	"log/slog"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"./checker"
+	"./commits"
+	"./digest"
+	"./evidence"
+	"./report"
+	"./telemetry"
 )
 
 var (
-	wd = flag.String("dir", cwd(), "Project root directory to scan")
+	wd        = flag.String("dir", cwd(), "Project root directory to scan")
+	groupBy   = flag.String("group-by", "", "Aggregate violations by directory. Format: dir[:depth]")
+	enforce   = flag.Bool("enforce", true, "If false, report license violations as warnings and exit with a success code")
+	absPaths  = flag.Bool("abs-paths", false, "Use absolute paths in messages and reports, instead of project relative paths")
+	licenseDB = flag.String("license-db", "", "Path to a JSON license database with licenses to add to the detectors")
+	manifest  = flag.String("build-manifest", "", "Path to a JSON manifest of the files to check and their attributes, generated by the build system, instead of walking the project")
+	annotate  = flag.Bool("annotate", false, "Print the violations as GitHub Actions annotations")
+	summary   = flag.Bool("summary", false, "Append a markdown summary of the check to the GitHub Actions job summary file, $GITHUB_STEP_SUMMARY")
+	upstream  = flag.Bool("verify-upstream", false, "Fetch the upstream license files of vendored components and report local modifications")
+	coverage  = flag.Bool("coverage", false, "Report the percentage of the project's files that were checked")
+	skipped   = flag.Bool("list-skipped", false, "List the files and directories that were not examined, and why")
+	explain   = flag.Bool("explain-rules", false, "Print the directories that are not walked as the path rules exclude them")
+	subs      = flag.Bool("submodules", false, "Check subdirectories that have their own config file, such as submodules, with that config")
+	discover  = flag.Bool("discover-projects", false, "Report each subdirectory with its own license file or package manifest, such as go.mod, as a separate project")
+	workspace = flag.String("workspace", "", "Path to a workspace file listing project roots to check together, instead of --dir")
+	fix       = flag.Bool("fix", false, "Rewrite the headers of files with stale header, suspicious character or header style violations, insert the config's new_file_header into files without a license, and check again")
+	deadline  = flag.Duration("deadline", 0, "Stop examining files after this duration, such as 5m, and report the partial results with exit code 3")
+	fileTime  = flag.Duration("file-timeout", 0, "Report the files that take longer than this duration, such as 30s, to examine as violations, and move on")
+	progress  = flag.String("progress", "", "Record the files verified by the scan to this file, so an interrupted scan can be resumed with --resume")
+	resume    = flag.Bool("resume", false, "Skip the files verified by the interrupted scan recorded by --progress")
+	rev       = flag.String("rev", "", "Scan the tree of this git revision, such as a tag or commit hash, of the --dir repository, read from the object database without a checkout")
+	blame     = flag.Bool("blame", false, "Annotate each violation with the author and commit that introduced the file, found with git blame of its first line")
+	decisions = flag.String("decision-log", "", "Write every allow and deny decision, with the file, detected licenses and config rule, to this newline delimited JSON file")
+
+	evidenceDir = flag.String("evidence-dir", "", "Archive the configs, tool versions, inventory, violations and file hashes of the scan to a compressed bundle in this directory, for audits")
+	evidenceKey = flag.String("evidence-key", "", "Sign the --evidence-dir bundle with the ed25519 private key in this PEM file")
+
+	exclusions       = flag.String("exclusions", "", "Compare the files and directories that were not examined, and why, to this committed snapshot, such as "+checker.DefaultExclusionsFileName+", and fail if they differ")
+	updateExclusions = flag.Bool("update-exclusions", false, "Write the --exclusions snapshot of the scan, instead of comparing to it")
+
+	baseline       = flag.String("baseline", "", "Report the known violations listed by this committed baseline file as warnings, so only new violations fail the check")
+	updateBaseline = flag.Bool("update-baseline", false, "Write the violations of the scan to the --baseline file")
+
+	digestSMTP  = flag.String("digest-smtp", "", "SMTP server host:port used to email a digest of new and resolved violations")
+	digestFrom  = flag.String("digest-from", "", "Sender address of the digest email")
+	digestTo    = flag.String("digest-to", "", "Comma-separated list of digest email recipients")
+	digestState = flag.String("digest-state", "license-checker-digest.json", "File used to remember the violations between digest runs")
+
+	telemetryURL = flag.String("telemetry", "", "Post anonymous usage metrics of the run, such as its duration, file counts and the names of the flags used, but no paths, content or flag values, to this URL. Off unless set, or set by the "+telemetry.EnvVar+" environment variable")
+
+	reports       reportRequests
+	extraLicenses stringsFlag
+
+	cpuProfile = flag.String("cpuprofile", "", "Write a pprof CPU profile of the scan to this file")
+	memProfile = flag.String("memprofile", "", "Write a pprof heap profile to this file once the scan has completed")
+	traceFile  = flag.String("trace", "", "Write an execution trace of the scan to this file")
+
+	logLevel  = flag.String("log-level", "info", "Minimum level of the diagnostic messages logged to stderr, one of debug, info, warn or error")
+	logFormat = flag.String("log-format", "text", "Format of the diagnostic messages logged to stderr, one of text or json")
 )
 
+func init() {
+	flag.Var(formatFlag{&reports}, "format", fmt.Sprintf("Report format, one of %v. May be repeated to write multiple reports", report.Formats()))
+	flag.Var(outputFlag{&reports}, "output", "Output file for the report of the preceding --format. Defaults to stdout")
+	flag.Var(&extraLicenses, "extra-license", "License to permit for this run only, in addition to the config's licenses. May be repeated")
+}
+
+// stringsFlag is a flag.Value for a flag that may be repeated, collecting each
+// of the values.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string { return strings.Join(*f, ",") }
+func (f *stringsFlag) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
 // cwd returns the current working directory, or an empty string if it cannot
 // be determined.
 func cwd() string {
@@ -44,11 +124,441 @@ func cwd() string {
 	return wd
 }
 
+// parseGroupBy parses the --group-by flag value, returning the directory depth
+// to group by, or 0 if grouping is disabled.
+func parseGroupBy(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	parts := strings.SplitN(s, ":", 2)
+	if parts[0] != "dir" {
+		return 0, fmt.Errorf("Unknown --group-by value '%v'. Must be of the form dir[:depth]", s)
+	}
+	if len(parts) == 1 {
+		return 1, nil
+	}
+	depth, err := strconv.Atoi(parts[1])
+	if err != nil || depth < 1 {
+		return 0, fmt.Errorf("Invalid --group-by depth '%v'. Must be a positive integer", parts[1])
+	}
+	return depth, nil
+}
+
+// commands is a map of subcommand name to the function that implements it.
+// The function is passed the command line arguments that follow the subcommand
+// name.
+var commands = map[string]func(args []string) error{
+	"badge":           runBadge,
+	"bench":           runBench,
+	"check-range":     runCheckRange,
+	"commits":         runCommits,
+	"deps":            runDeps,
+	"exceptions":      runExceptions,
+	"fix":             runFix,
+	"gate-release":    runGateRelease,
+	"gen-fixture":     runGenFixture,
+	"merge-baselines": runMergeBaselines,
+	"new":             runNew,
+	"notices":         runNotices,
+	"promote":         runPromote,
+	"release-export":  runReleaseExport,
+	"simulate":        runSimulate,
+	"test-policy":     runTestPolicy,
+	"what-if":         runWhatIf,
+}
+
+// Exit codes of a check that stopped before all files were examined.
+const (
+	exitPartial     = 3   // stopped at the --deadline
+	exitInterrupted = 130 // stopped by SIGINT or SIGTERM
+)
+
+var (
+	// errPartial is returned by run if the check stopped at the --deadline.
+	errPartial = errors.New("The scan stopped at the --deadline, so the results are partial")
+	// errInterrupted is returned by run if the check was stopped by a signal.
+	errInterrupted = errors.New("The scan was interrupted, so the results are partial")
+)
+
 // main is the entry point for the program.
 func main() {
-	flag.Parse()
-	if err := checker.Check(*wd); err != nil {
+	if err := run(); err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
+		switch {
+		case errors.Is(err, errInterrupted):
+			os.Exit(exitInterrupted)
+		case errors.Is(err, errPartial):
+			os.Exit(exitPartial)
+		}
 		os.Exit(1)
 	}
 }
+
+// run parses the command line and runs the requested command.
+func run() error {
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			return cmd(os.Args[2:])
+		}
+	}
+
+	start := time.Now()
+	flag.Parse()
+	if err := setupLogging(os.Stderr, *logLevel, *logFormat); err != nil {
+		return err
+	}
+	if *telemetryURL == "" {
+		*telemetryURL = os.Getenv(telemetry.EnvVar)
+	}
+	if *telemetryURL != "" {
+		if err := telemetry.ValidateEndpoint(*telemetryURL); err != nil {
+			return err
+		}
+	}
+	depth, err := parseGroupBy(*groupBy)
+	if err != nil {
+		return err
+	}
+
+	if *annotate {
+		reports = append(reports, reportRequest{format: "github", output: "-"})
+	}
+	if *summary {
+		path := os.Getenv("GITHUB_STEP_SUMMARY")
+		if path == "" {
+			return fmt.Errorf("--summary requires the GITHUB_STEP_SUMMARY environment variable")
+		}
+		reports = append(reports, reportRequest{format: "markdown", output: path, append: true})
+	}
+
+	stopProfiling, err := startProfiling()
+	if err != nil {
+		return err
+	}
+	defer stopProfiling()
+
+	opts := checker.Options{
+		GroupByDepth:     depth,
+		ExplainRules:     *explain,
+		WarnOnly:         !*enforce,
+		AbsPaths:         *absPaths,
+		LicenseDB:        *licenseDB,
+		ListSkipped:      *skipped,
+		VerifyUpstream:   *upstream,
+		Submodules:       *subs,
+		DiscoverProjects: *discover,
+		ExtraLicenses:    extraLicenses,
+		FileTimeout:      *fileTime,
+	}
+	if *manifest != "" {
+		if opts.BuildManifest, err = checker.LoadBuildManifest(*manifest); err != nil {
+			return err
+		}
+		opts.Config = opts.BuildManifest.Config
+	}
+	if *deadline > 0 {
+		opts.Deadline = time.Now().Add(*deadline)
+	}
+	// On SIGINT or SIGTERM, stop the scan, and write the reports of the files
+	// examined so far. A second signal terminates immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	opts.Context = ctx
+	var signingKey ed25519.PrivateKey
+	if *evidenceKey != "" {
+		if *evidenceDir == "" {
+			return fmt.Errorf("--evidence-key requires --evidence-dir")
+		}
+		if signingKey, err = evidence.LoadKey(*evidenceKey); err != nil {
+			return err
+		}
+	}
+	if *updateBaseline && *baseline == "" {
+		return fmt.Errorf("--update-baseline requires --baseline")
+	}
+	if *updateExclusions && *exclusions == "" {
+		return fmt.Errorf("--update-exclusions requires --exclusions")
+	}
+	if *exclusions != "" {
+		opts.ListSkipped = true
+	}
+	if *resume && *progress == "" {
+		return fmt.Errorf("--resume requires --progress")
+	}
+	if *progress != "" {
+		p, err := checker.OpenProgress(*progress, *resume)
+		if err != nil {
+			return err
+		}
+		defer p.Close()
+		if *resume {
+			slog.Info("Resuming scan", "verified", p.Verified())
+		}
+		opts.Progress = p
+	}
+	root, err := filepath.Abs(*wd)
+	if err != nil {
+		return err
+	}
+	var ws *checker.Workspace
+	if *workspace != "" {
+		w, err := checker.LoadWorkspace(*workspace)
+		if err != nil {
+			return err
+		}
+		root, ws = w.Dir, &w
+	}
+	if *rev != "" {
+		if ws != nil || *fix || *blame {
+			return fmt.Errorf("--rev cannot be used with --workspace, --fix or --blame")
+		}
+		tree, err := ioutil.TempDir("", "license-checker-rev")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tree)
+		hash, err := commits.ExportTree(root, *rev, tree)
+		if err != nil {
+			return err
+		}
+		slog.Info("Scanning git revision", "rev", *rev, "commit", hash)
+		root = tree
+	}
+	results, err := scan(root, ws, opts)
+	if err != nil {
+		return err
+	}
+	if opts.Progress != nil && !results.Partial() {
+		if err := opts.Progress.Remove(); err != nil {
+			return fmt.Errorf("Failed to remove progress file: %w", err)
+		}
+		opts.Progress = nil
+	}
+	if *fix && ctx.Err() == nil {
+		n, err := results.Fix(root)
+		if err != nil {
+			return err
+		}
+		slog.Info("Fixed file headers", "count", n)
+		if n > 0 {
+			if results, err = scan(root, ws, opts); err != nil {
+				return err
+			}
+		}
+	}
+	var baselineSummary *checker.BaselineSummary
+	if *baseline != "" {
+		if *updateBaseline {
+			b := results.Baseline()
+			if err := b.Write(*baseline); err != nil {
+				return err
+			}
+			slog.Info("Wrote baseline", "path", *baseline, "violations", len(b.Violations))
+		}
+		b, err := checker.LoadBaseline(*baseline)
+		if err != nil {
+			return err
+		}
+		s := results.CompareBaseline(b)
+		baselineSummary = &s
+		results = results.Accept(b)
+	}
+	if *blame {
+		if err := commits.Attribute(root, results); err != nil {
+			return err
+		}
+	}
+	var exclusionsErr error
+	if *exclusions != "" && !results.Partial() {
+		if exclusionsErr = checkExclusions(root, results); exclusionsErr != nil && *updateExclusions {
+			return exclusionsErr
+		}
+	}
+	if *digestSMTP != "" && !results.Partial() {
+		if err := sendDigest(results); err != nil {
+			return err
+		}
+	}
+	var cov *checker.Coverage
+	if *coverage || *summary {
+		var c checker.Coverage
+		if ws != nil {
+			c, err = ws.MeasureCoverage(results, opts)
+		} else {
+			c, err = checker.MeasureCoverage(root, results, opts)
+		}
+		if err != nil {
+			return err
+		}
+		cov = &c
+	}
+	var risk checker.Risk
+	if ws != nil {
+		risk, err = ws.MeasureRisk(results, opts)
+	} else {
+		risk, err = checker.MeasureRisk(root, results, opts)
+	}
+	if err != nil {
+		return err
+	}
+	in := report.Input{Root: root, Results: results, Options: opts, Coverage: cov, Risk: &risk, Baseline: baselineSummary}
+	if *decisions != "" {
+		if err := (reportRequest{format: "decisions", output: *decisions}).write(in); err != nil {
+			return err
+		}
+	}
+	if *evidenceDir != "" {
+		bundle, err := evidence.Write(*evidenceDir, in, os.Args[1:], signingKey)
+		if err != nil {
+			return err
+		}
+		slog.Info("Wrote evidence bundle", "path", bundle, "signed", signingKey != nil)
+	}
+	if len(reports) == 0 {
+		fmt.Printf("Risk: %v\n", risk)
+		if cov != nil {
+			fmt.Printf("Coverage: %v\n", cov)
+		}
+		if baselineSummary != nil {
+			fmt.Printf("Baseline: %v\n", baselineSummary)
+		}
+		err = results.Check(opts)
+	} else {
+		if s := baselineSummary; s != nil {
+			slog.Info("Baseline", "new", s.New, "fixed", s.Fixed, "remaining", s.Remaining)
+		}
+		for _, r := range reports {
+			if err := r.write(in); err != nil {
+				return err
+			}
+		}
+		if n := len(results.Failures(opts).Errs()); n > 0 {
+			err = fmt.Errorf("%d license violations found", n)
+		}
+	}
+	if exclusionsErr != nil {
+		if err != nil {
+			err = fmt.Errorf("%v\n%v", err, exclusionsErr)
+		} else {
+			err = exclusionsErr
+		}
+	}
+	if *telemetryURL != "" {
+		sendTelemetry(start, results, opts, err == nil && !results.Partial())
+	}
+	if results.Partial() {
+		partial := errPartial
+		if ctx.Err() != nil {
+			partial = errInterrupted
+		}
+		if err != nil {
+			return fmt.Errorf("%v\n%w", err, partial)
+		}
+		return partial
+	}
+	return err
+}
+
+// scan scans the workspace, or the project at root if ws is nil.
+func scan(root string, ws *checker.Workspace, opts checker.Options) (checker.Results, error) {
+	if ws != nil {
+		return ws.Scan(opts)
+	}
+	return checker.Scan(root, opts)
+}
+
+// checkExclusions writes the exclusions snapshot of the results to the
+// --exclusions file if --update-exclusions is set, otherwise it returns an
+// error listing the differences between the snapshot and the results. The
+// snapshot file itself is left out, so that adding it to the project does not
+// change the snapshot.
+func checkExclusions(root string, results checker.Results) error {
+	current := checker.Exclusions{Excluded: []checker.Exclusion{}}
+	self := ""
+	if abs, err := filepath.Abs(*exclusions); err == nil {
+		if rel, err := filepath.Rel(root, abs); err == nil {
+			self = filepath.ToSlash(rel)
+		}
+	}
+	for _, x := range results.Exclusions().Excluded {
+		if x.Path != self {
+			current.Excluded = append(current.Excluded, x)
+		}
+	}
+	if *updateExclusions {
+		if err := current.Write(*exclusions); err != nil {
+			return err
+		}
+		slog.Info("Wrote exclusions snapshot", "path", *exclusions, "excluded", len(current.Excluded))
+		return nil
+	}
+	snapshot, err := checker.LoadExclusions(*exclusions)
+	if err != nil {
+		return err
+	}
+	if diff := snapshot.Diff(current); len(diff) > 0 {
+		return fmt.Errorf("The exclusions differ from the snapshot %v. Run with --update-exclusions to update it:\n%v",
+			*exclusions, strings.Join(diff, "\n"))
+	}
+	return nil
+}
+
+// sendTelemetry posts the anonymous usage metrics of the run to the
+// --telemetry endpoint. Only the names of the flags are sent, never their
+// values. Failures are logged, and never fail the run.
+func sendTelemetry(start time.Time, results checker.Results, opts checker.Options, passed bool) {
+	flags := []string{}
+	flag.Visit(func(f *flag.Flag) { flags = append(flags, f.Name) })
+	m := telemetry.New("check", flags, time.Since(start))
+	m.Files = len(results.Examined())
+	m.Errors = len(results.Failures(opts).Errs())
+	m.Warnings = len(results.Warnings(opts).Errs())
+	m.Partial = results.Partial()
+	m.Passed = passed
+	if err := telemetry.Send(*telemetryURL, m); err != nil {
+		slog.Warn("Telemetry was not sent", "err", err)
+		return
+	}
+	slog.Debug("Sent telemetry", "endpoint", *telemetryURL)
+}
+
+// sendDigest emails a digest of the violations that are new or resolved since
+// the last run, as recorded in the --digest-state file, and then updates the
+// state file. The SMTP credentials are read from the environment variables
+// LICENSE_CHECKER_SMTP_USERNAME and LICENSE_CHECKER_SMTP_PASSWORD.
+func sendDigest(results checker.Results) error {
+	to := []string{}
+	for _, addr := range strings.Split(*digestTo, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+	if *digestFrom == "" || len(to) == 0 {
+		return fmt.Errorf("--digest-smtp requires --digest-from and --digest-to")
+	}
+	previous, err := digest.LoadState(*digestState)
+	if err != nil {
+		return err
+	}
+	current := []string{}
+	for _, res := range results {
+		if res.Err != nil {
+			current = append(current, fmt.Sprintf("%v [%v]", res.Err, res.Fingerprint))
+		}
+	}
+	smtp := digest.SMTP{
+		Addr:     *digestSMTP,
+		From:     *digestFrom,
+		To:       to,
+		Username: os.Getenv("LICENSE_CHECKER_SMTP_USERNAME"),
+		Password: os.Getenv("LICENSE_CHECKER_SMTP_PASSWORD"),
+	}
+	if err := smtp.Send(digest.New(previous, current)); err != nil {
+		return err
+	}
+	return digest.SaveState(*digestState, current)
+}
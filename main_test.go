@@ -0,0 +1,45 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "testing"
+
+func TestParseGroupBy(t *testing.T) {
+	for _, test := range []struct {
+		value  string
+		expect int
+		err    string
+	}{
+		{"", 0, ""},
+		{"dir", 1, ""},
+		{"dir:1", 1, ""},
+		{"dir:3", 3, ""},
+		{"file", 0, "Unknown --group-by value 'file'. Must be of the form dir[:depth]"},
+		{"file:2", 0, "Unknown --group-by value 'file:2'. Must be of the form dir[:depth]"},
+		{"dir:0", 0, "Invalid --group-by depth '0'. Must be a positive integer"},
+		{"dir:-1", 0, "Invalid --group-by depth '-1'. Must be a positive integer"},
+		{"dir:x", 0, "Invalid --group-by depth 'x'. Must be a positive integer"},
+		{"dir:", 0, "Invalid --group-by depth ''. Must be a positive integer"},
+	} {
+		got, err := parseGroupBy(test.value)
+		errStr := ""
+		if err != nil {
+			errStr = err.Error()
+		}
+		if got != test.expect || errStr != test.err {
+			t.Errorf("parseGroupBy(%q) returned (%v, %v), expected (%v, %v)", test.value, got, errStr, test.expect, test.err)
+		}
+	}
+}
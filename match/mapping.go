@@ -0,0 +1,96 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package match
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Mapping is the path transformation function returned by NewMapping.
+type Mapping func(path string) (string, bool)
+
+// NewMapping returns a Mapping function that transforms the paths that match
+// the pattern from into the path described by the pattern to. The wildcards
+// of to are replaced with the text matched by the corresponding wildcards of
+// from, so both patterns must hold the same wildcards, in the same order. For
+// example, the mapping from 'gen/**/*.pb.go' to 'proto/**/*.proto' transforms
+// 'gen/a/b/c.pb.go' into 'proto/a/b/c.proto'. The Mapping returns false if the
+// path does not match from.
+func NewMapping(from, to string) (Mapping, error) {
+	fromLiterals, fromWildcards := splitWildcards(from)
+	toLiterals, toWildcards := splitWildcards(to)
+	if strings.Join(fromWildcards, " ") != strings.Join(toWildcards, " ") {
+		return nil, fmt.Errorf("Patterns '%v' and '%v' must hold the same wildcards in the same order", from, to)
+	}
+
+	regex := strings.Builder{}
+	regex.WriteString("(?s)^")
+	for i, literal := range fromLiterals {
+		regex.WriteString(regexp.QuoteMeta(literal))
+		if i < len(fromWildcards) {
+			switch fromWildcards[i] {
+			case "**":
+				regex.WriteString("(.*)")
+			case "*":
+				regex.WriteString("([^/]*)")
+			case "?":
+				regex.WriteString("([^/])")
+			}
+		}
+	}
+	regex.WriteString("$")
+	re, err := regexp.Compile(regex.String())
+	if err != nil {
+		return nil, fmt.Errorf(`Failed to compile regex "%v" for pattern "%v": %w`, regex.String(), from, err)
+	}
+
+	return func(path string) (string, bool) {
+		captures := re.FindStringSubmatch(path)
+		if captures == nil {
+			return "", false
+		}
+		out := strings.Builder{}
+		for i, literal := range toLiterals {
+			out.WriteString(literal)
+			if i < len(toWildcards) {
+				out.WriteString(captures[i+1])
+			}
+		}
+		return out.String(), true
+	}, nil
+}
+
+// splitWildcards splits the pattern into its literal text and its '**', '*'
+// and '?' wildcards. There is always one more literal than wildcards.
+func splitWildcards(pattern string) (literals, wildcards []string) {
+	literal := strings.Builder{}
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**"):
+			wildcards = append(wildcards, "**")
+			i++
+		case pattern[i] == '*' || pattern[i] == '?':
+			wildcards = append(wildcards, pattern[i:i+1])
+		default:
+			literal.WriteByte(pattern[i])
+			continue
+		}
+		literals = append(literals, literal.String())
+		literal.Reset()
+	}
+	return append(literals, literal.String()), wildcards
+}
@@ -62,8 +62,9 @@ func New(pattern string) (Test, error) {
 	subbed = strings.ReplaceAll(subbed, "?", questionmark)
 	// Escape any remaining regex characters
 	escaped := regexp.QuoteMeta(subbed)
-	// Insert regex matchers for the subtituted tokens
-	regex := "^" + escaped + "$"
+	// Insert regex matchers for the subtituted tokens. The 's' flag lets '.'
+	// match newlines, which are valid in file names.
+	regex := "(?s)^" + escaped + "$"
 	regex = strings.ReplaceAll(regex, starstar, ".*")
 	regex = strings.ReplaceAll(regex, star, "[^/]*")
 	regex = strings.ReplaceAll(regex, questionmark, "[^/]")
@@ -75,6 +75,12 @@ func TestMatch(t *testing.T) {
 		{"xxx/**.foo", "xxx/aaa.foo", true},
 		{"xxx/**.foo", "xxx/yyy/zzz/.foo", true},
 		{"xxx/**.foo", "xxx/yyy/zzz/bar.foo", true},
+
+		{"a/**", "a/new\nline/c", true},
+		{"a/*/c", "a/new\nline/c", true},
+		{"a/?/c", "a/\xff/c", true},
+		{"a/*.png", "a/caf\xe9.png", true},
+		{"a/**/c", "a/\xfe\xff/c", true},
 	} {
 		f, err := match.New(test.pattern)
 		if err != nil {
@@ -104,3 +110,30 @@ func TestErrOnPlaceholder(t *testing.T) {
 		}
 	}
 }
+
+func TestMapping(t *testing.T) {
+	for _, test := range []struct {
+		from, to string
+		path     string
+		expect   string
+		ok       bool
+	}{
+		{"gen/**/*.pb.go", "proto/**/*.proto", "gen/a/b/c.pb.go", "proto/a/b/c.proto", true},
+		{"gen/**/*.pb.go", "proto/**/*.proto", "gen/a/c.go", "", false},
+		{"**/*_pb2.py", "**/*.proto", "api/v1/service_pb2.py", "api/v1/service.proto", true},
+		{"out/?.h", "idl/?.idl", "out/a.h", "idl/a.idl", true},
+		{"gen/*.h", "idl/*.idl", "gen/a/b.h", "", false},
+	} {
+		m, err := match.NewMapping(test.from, test.to)
+		if err != nil {
+			t.Fatalf("NewMapping(%q, %q) returned %v", test.from, test.to, err)
+		}
+		if got, ok := m(test.path); got != test.expect || ok != test.ok {
+			t.Errorf("NewMapping(%q, %q)(%q) returned (%q, %v), expected (%q, %v)",
+				test.from, test.to, test.path, got, ok, test.expect, test.ok)
+		}
+	}
+	if _, err := match.NewMapping("gen/**/*.h", "idl/*.idl"); err == nil {
+		t.Errorf("NewMapping() with differing wildcards returned no error")
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package owners reads CODEOWNERS files, which assign the files of a
+// repository to the users and teams that review their changes, and splits
+// large sets of changed files into batches that each have a single set of
+// owners.
+package owners
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"../match"
+)
+
+// Locations are the project relative paths searched for the CODEOWNERS file,
+// in order, as by GitHub and GitLab.
+var Locations = []string{"CODEOWNERS", ".github/CODEOWNERS", ".gitlab/CODEOWNERS", "docs/CODEOWNERS"}
+
+// Owners is a parsed CODEOWNERS file.
+type Owners struct {
+	rules []rule
+}
+
+type rule struct {
+	owners []string
+	tests  []match.Test
+}
+
+// Load loads the CODEOWNERS file of the project at root, from the first of
+// Locations that exists. If there is no CODEOWNERS file, Load returns Owners
+// that assign no file to an owner.
+func Load(root string) (*Owners, error) {
+	for _, loc := range Locations {
+		p := filepath.Join(root, filepath.FromSlash(loc))
+		if _, err := os.Stat(p); err == nil {
+			return LoadFile(p)
+		}
+	}
+	return &Owners{}, nil
+}
+
+// LoadFile loads the CODEOWNERS file at path.
+func LoadFile(path string) (*Owners, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read CODEOWNERS: %w", err)
+	}
+	o, err := Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse '%v': %w", path, err)
+	}
+	return o, nil
+}
+
+// Parse parses the body of a CODEOWNERS file. Each line holds a gitignore
+// style path pattern followed by its owners, and '#' starts a comment. GitLab
+// section headers, such as '[Docs]', are ignored.
+func Parse(body []byte) (*Owners, error) {
+	o := &Owners{}
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if i := strings.Index(text, "#"); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "[") || strings.HasPrefix(fields[0], "^[") {
+			continue
+		}
+		r, err := newRule(fields[0], fields[1:])
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %w", line, err)
+		}
+		o.rules = append(o.rules, r)
+	}
+	return o, scanner.Err()
+}
+
+// newRule returns the rule for the CODEOWNERS pattern. As in gitignore, a
+// pattern that starts with or contains a '/' is relative to the project root,
+// otherwise it matches at any depth, and a pattern matches the files under the
+// directories that it matches.
+func newRule(pattern string, owners []string) (rule, error) {
+	p := strings.TrimSuffix(pattern, "/")
+	patterns := []string{}
+	if strings.Contains(p, "/") {
+		p = strings.TrimPrefix(p, "/")
+		patterns = append(patterns, p, p+"/**")
+	} else {
+		patterns = append(patterns, p, p+"/**", "**/"+p, "**/"+p+"/**")
+	}
+	r := rule{owners: owners}
+	for _, p := range patterns {
+		test, err := match.New(p)
+		if err != nil {
+			return rule{}, err
+		}
+		r.tests = append(r.tests, test)
+	}
+	return r, nil
+}
+
+// Of returns the owners of the file at the '/' separated project relative
+// path, from the last rule that matches it. Of returns nil if no rule matches,
+// or if the matching rule has no owners.
+func (o *Owners) Of(path string) []string {
+	for i := len(o.rules) - 1; i >= 0; i-- {
+		for _, test := range o.rules[i].tests {
+			if test(path) {
+				if len(o.rules[i].owners) == 0 {
+					return nil
+				}
+				return o.rules[i].owners
+			}
+		}
+	}
+	return nil
+}
+
+// Batch is a set of files with the same owners.
+type Batch struct {
+	Owners []string // the owners of every file of the batch, nil if unowned
+	Dir    string   // the deepest directory holding every file of the batch
+	Paths  []string // the '/' separated project relative file paths
+}
+
+// Batch splits the '/' separated project relative paths into batches of at
+// most size files, so that the files of each batch have the same owners. The
+// files of a directory are kept in the same batch, unless there are more than
+// size of them, and small directories of the same owners share a batch.
+// Batches are ordered by owners, then by directory.
+func (o *Owners) Batch(paths []string, size int) []Batch {
+	byOwners := map[string][]string{}
+	owners := map[string][]string{}
+	for _, p := range paths {
+		of := o.Of(p)
+		key := strings.Join(of, " ")
+		byOwners[key] = append(byOwners[key], p)
+		owners[key] = of
+	}
+	keys := make([]string, 0, len(byOwners))
+	for key := range byOwners {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	out := []Batch{}
+	for _, key := range keys {
+		files := byOwners[key]
+		sort.Slice(files, func(i, j int) bool {
+			a, b := path.Dir(files[i]), path.Dir(files[j])
+			if a != b {
+				return a < b
+			}
+			return files[i] < files[j]
+		})
+		current := []string{}
+		flush := func() {
+			if len(current) > 0 {
+				out = append(out, Batch{Owners: owners[key], Dir: commonDir(current), Paths: current})
+				current = []string{}
+			}
+		}
+		for len(files) > 0 {
+			dir := path.Dir(files[0])
+			n := 1
+			for n < len(files) && path.Dir(files[n]) == dir {
+				n++
+			}
+			group := files[:n]
+			files = files[n:]
+			if len(current)+len(group) > size {
+				flush()
+			}
+			for len(group) > size {
+				current = group[:size]
+				flush()
+				group = group[size:]
+			}
+			current = append(current, group...)
+		}
+		flush()
+	}
+	return out
+}
+
+// commonDir returns the deepest directory that holds all the paths, or "." if
+// they have no common directory.
+func commonDir(paths []string) string {
+	dir := path.Dir(paths[0])
+	for _, p := range paths[1:] {
+		for dir != "." && !strings.HasPrefix(p, dir+"/") {
+			dir = path.Dir(dir)
+		}
+	}
+	return dir
+}
@@ -0,0 +1,87 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package owners_test
+
+import (
+	"reflect"
+	"testing"
+
+	owners "."
+)
+
+const codeowners = `
+# Default owners
+*                @org/everyone
+*.md             @org/docs
+/src/            @org/core
+src/gpu/**       @org/gpu @alice
+build            @org/infra
+
+[Ignored section]
+/third_party/    # no owners
+`
+
+func TestOf(t *testing.T) {
+	o, err := owners.Parse([]byte(codeowners))
+	if err != nil {
+		t.Fatalf("Parse() returned %v", err)
+	}
+	for _, test := range []struct {
+		path   string
+		expect []string
+	}{
+		{"main.go", []string{"@org/everyone"}},
+		{"README.md", []string{"@org/docs"}},
+		{"docs/a/guide.md", []string{"@org/docs"}},
+		{"src/a.cc", []string{"@org/core"}},
+		{"src/README.md", []string{"@org/core"}},
+		{"src/gpu/vk/a.cc", []string{"@org/gpu", "@alice"}},
+		{"tools/build/x.py", []string{"@org/infra"}},
+		{"build/x.py", []string{"@org/infra"}},
+		{"third_party/z/z.c", nil},
+	} {
+		if got := o.Of(test.path); !reflect.DeepEqual(got, test.expect) {
+			t.Errorf("Of(%v) returned %v, expected %v", test.path, got, test.expect)
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	o, err := owners.Parse([]byte("/a/ @a\n/b/ @b\n"))
+	if err != nil {
+		t.Fatalf("Parse() returned %v", err)
+	}
+	paths := []string{
+		"b/1.c", "a/x/1.c", "a/1.c", "a/x/2.c", "a/2.c", "a/x/3.c",
+		"a/y/1.c", "c/1.c", "b/2.c",
+	}
+	got := o.Batch(paths, 3)
+	expect := []owners.Batch{
+		{Owners: nil, Dir: "c", Paths: []string{"c/1.c"}},
+		{Owners: []string{"@a"}, Dir: "a", Paths: []string{"a/1.c", "a/2.c"}},
+		{Owners: []string{"@a"}, Dir: "a/x", Paths: []string{"a/x/1.c", "a/x/2.c", "a/x/3.c"}},
+		{Owners: []string{"@a"}, Dir: "a/y", Paths: []string{"a/y/1.c"}},
+		{Owners: []string{"@b"}, Dir: "b", Paths: []string{"b/1.c", "b/2.c"}},
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("Batch() returned:\n%+v\nexpected:\n%+v", got, expect)
+	}
+
+	// Directories larger than the batch size are split.
+	got = o.Batch([]string{"a/1.c", "a/2.c", "a/3.c", "a/4.c", "a/5.c"}, 2)
+	if len(got) != 3 || len(got[2].Paths) != 1 {
+		t.Errorf("Batch() of a large directory returned %+v", got)
+	}
+}
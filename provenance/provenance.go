@@ -0,0 +1,209 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package provenance finds the files of a corpus of known third-party code that
+// a file was likely copied from, by comparing winnowed fingerprints of the
+// files' content.
+//
+// Fingerprints are computed with the winnowing algorithm of Schleimer, Wilkerson
+// and Aiken: the content is split into tokens, every run of K tokens is hashed,
+// and the minimum hash of each window of W consecutive hashes is selected.
+// As tokens ignore whitespace and letter case, fingerprints are robust to
+// reformatting, and any copied run of at least K+W-1 tokens is guaranteed to
+// share a fingerprint with its source.
+package provenance
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// K is the number of tokens hashed by each fingerprint.
+	K = 8
+	// W is the number of consecutive hashes that a fingerprint is selected
+	// from.
+	W = 8
+)
+
+// Fingerprints returns the set of winnowed fingerprints of body.
+func Fingerprints(body []byte) map[uint64]bool {
+	tokens := tokenize(body)
+	hashes := make([]uint64, 0, len(tokens))
+	for i := 0; i+K <= len(tokens); i++ {
+		h := fnv.New64a()
+		for _, t := range tokens[i : i+K] {
+			h.Write(t)
+			h.Write([]byte{0})
+		}
+		hashes = append(hashes, h.Sum64())
+	}
+
+	out := map[uint64]bool{}
+	if len(hashes) > 0 && len(hashes) < W {
+		out[minHash(hashes)] = true
+	}
+	for i := 0; i+W <= len(hashes); i++ {
+		out[minHash(hashes[i:i+W])] = true
+	}
+	return out
+}
+
+// minHash returns the smallest of the hashes.
+func minHash(hashes []uint64) uint64 {
+	m := hashes[0]
+	for _, h := range hashes[1:] {
+		if h < m {
+			m = h
+		}
+	}
+	return m
+}
+
+// tokenize splits body into lower-case words and numbers, and single
+// punctuation characters. Whitespace is dropped.
+func tokenize(body []byte) [][]byte {
+	body = bytes.ToLower(body)
+	tokens := [][]byte{}
+	for i := 0; i < len(body); {
+		r, n := utf8.DecodeRune(body[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += n
+		case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			start := i
+			for i < len(body) {
+				r, n := utf8.DecodeRune(body[i:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				i += n
+			}
+			tokens = append(tokens, body[start:i])
+		default:
+			tokens = append(tokens, body[i:i+n])
+			i += n
+		}
+	}
+	return tokens
+}
+
+// Match is a corpus file that shares fingerprints with the examined file.
+type Match struct {
+	File       string  // the path of the corpus file, relative to the corpus
+	Similarity float64 // the fraction of the examined file's fingerprints in File
+}
+
+// Corpus is an index of the fingerprints of a directory of known code. The
+// zero value is an empty corpus.
+type Corpus struct {
+	files []string
+	index map[uint64][]int // fingerprint to indices of files
+}
+
+// LoadCorpus fingerprints each file under dir. Binary files, and version
+// control metadata directories, are skipped.
+func LoadCorpus(dir string) (*Corpus, error) {
+	c := &Corpus{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			switch info.Name() {
+			case ".git", ".hg", ".svn", ".bzr", "CVS":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		body, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if isBinary(body) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		c.Add(filepath.ToSlash(rel), body)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load corpus: %w", err)
+	}
+	return c, nil
+}
+
+// Add adds the file with the given name and content to the corpus.
+func (c *Corpus) Add(name string, body []byte) {
+	if c.index == nil {
+		c.index = map[uint64][]int{}
+	}
+	idx := len(c.files)
+	c.files = append(c.files, name)
+	for f := range Fingerprints(body) {
+		c.index[f] = append(c.index[f], idx)
+	}
+}
+
+// Len returns the number of files in the corpus.
+func (c *Corpus) Len() int { return len(c.files) }
+
+// Match returns the corpus files that hold at least the given fraction of the
+// fingerprints of body, most similar first.
+func (c *Corpus) Match(body []byte, threshold float64) []Match {
+	fps := Fingerprints(body)
+	if len(fps) == 0 {
+		return nil
+	}
+	shared := map[int]int{}
+	for f := range fps {
+		for _, idx := range c.index[f] {
+			shared[idx]++
+		}
+	}
+	out := []Match{}
+	for idx, n := range shared {
+		if s := float64(n) / float64(len(fps)); s >= threshold {
+			out = append(out, Match{File: c.files[idx], Similarity: s})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Similarity != out[j].Similarity {
+			return out[i].Similarity > out[j].Similarity
+		}
+		return out[i].File < out[j].File
+	})
+	return out
+}
+
+// isBinary returns true if the start of body holds a NUL byte.
+func isBinary(body []byte) bool {
+	if len(body) > 512 {
+		body = body[:512]
+	}
+	return bytes.IndexByte(body, 0) >= 0
+}
@@ -0,0 +1,92 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provenance_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	provenance "."
+)
+
+const crc = `uint32_t crc32(uint32_t crc, const uint8_t *buf, size_t len) {
+	crc = ~crc;
+	while (len--) {
+		crc ^= *buf++;
+		for (int k = 0; k < 8; k++)
+			crc = crc & 1 ? (crc >> 1) ^ 0xedb88320 : crc >> 1;
+	}
+	return ~crc;
+}
+`
+
+const adler = `uint32_t adler32(uint32_t adler, const uint8_t *buf, size_t len) {
+	uint32_t a = adler & 0xffff, b = adler >> 16;
+	for (size_t i = 0; i < len; i++) {
+		a = (a + buf[i]) % 65521;
+		b = (b + a) % 65521;
+	}
+	return (b << 16) | a;
+}
+`
+
+func TestFingerprints(t *testing.T) {
+	reformatted := strings.NewReplacer("\t", "    ", "{\n", "\n{\n", "crc", "CRC").Replace(crc)
+	a, b := provenance.Fingerprints([]byte(crc)), provenance.Fingerprints([]byte(reformatted))
+	if len(a) == 0 || !reflect.DeepEqual(a, b) {
+		t.Errorf("Fingerprints() of reformatted code differ: %v, %v", a, b)
+	}
+	if got := provenance.Fingerprints([]byte("int a;")); len(got) != 0 {
+		t.Errorf("Fingerprints() of a short file returned %v", got)
+	}
+}
+
+func TestCorpus(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"zlib/crc32.c":   crc,
+		"zlib/adler32.c": adler,
+		"zlib/data.bin":  "\x00" + crc,
+		".git/HEAD":      crc,
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(body), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	corpus, err := provenance.LoadCorpus(dir)
+	if err != nil {
+		t.Fatalf("LoadCorpus() returned %v", err)
+	}
+	if corpus.Len() != 2 {
+		t.Errorf("LoadCorpus() loaded %v files, expected 2", corpus.Len())
+	}
+
+	copied := "// checksum.c\n\n#include <stdint.h>\n\n" + crc + "\nint main() { return 0; }\n"
+	got := corpus.Match([]byte(copied), 0.5)
+	if len(got) != 1 || got[0].File != "zlib/crc32.c" {
+		t.Errorf("Match() of copied code returned %+v", got)
+	}
+	if got := corpus.Match([]byte("int main() {\n\tprintf(\"hello world\\n\");\n\treturn 0;\n}\n"), 0.5); len(got) != 0 {
+		t.Errorf("Match() of original code returned %+v", got)
+	}
+}
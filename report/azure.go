@@ -0,0 +1,57 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// writeAzure writes each violation as an Azure DevOps logging command, which
+// Azure Pipelines shows as an issue on the file.
+func writeAzure(w io.Writer, in Input) error {
+	warnings := in.Results.Warnings(in.Options)
+	failures := in.Results.Failures(in.Options)
+	sb := strings.Builder{}
+	if in.Risk != nil {
+		fmt.Fprintf(&sb, "##[section]License risk: %v\n", in.Risk)
+	}
+	for i, res := range in.Results {
+		level, err := "error", failures[i].Err
+		if err == nil {
+			level, err = "warning", warnings[i].Err
+		}
+		if err == nil {
+			continue
+		}
+		fmt.Fprintf(&sb, "##vso[task.logissue type=%v;sourcepath=%v;linenumber=1;code=%v]%v\n", level,
+			escapeAzureProperty(in.Options.DisplayPath(in.Root, res.Path)),
+			escapeAzureProperty(string(res.Kind)),
+			escapeAzureData(err.Error()))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// escapeAzureData escapes the message of a logging command.
+func escapeAzureData(s string) string {
+	return strings.NewReplacer("%", "%AZP25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeAzureProperty escapes a property value of a logging command.
+func escapeAzureProperty(s string) string {
+	return strings.NewReplacer(";", "%3B", "]", "%5D").Replace(escapeAzureData(s))
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// bitbucketDocument is the top-level object of the Bitbucket report. Report is
+// the body of the Code Insights 'create report' request, and Annotations the
+// body of the 'add annotations' request.
+type bitbucketDocument struct {
+	Report      bitbucketReport       `json:"report"`
+	Annotations []bitbucketAnnotation `json:"annotations"`
+}
+
+// bitbucketReport is a Bitbucket Code Insights report.
+type bitbucketReport struct {
+	Title      string          `json:"title"`
+	Details    string          `json:"details"`
+	ReportType string          `json:"report_type"`
+	Reporter   string          `json:"reporter"`
+	Result     string          `json:"result"`
+	Data       []bitbucketData `json:"data"`
+}
+
+// bitbucketData is a single metric of a bitbucketReport.
+type bitbucketData struct {
+	Title string `json:"title"`
+	Type  string `json:"type"`
+	Value int    `json:"value"`
+}
+
+// bitbucketAnnotation is a Bitbucket Code Insights annotation on a file.
+type bitbucketAnnotation struct {
+	ExternalID     string `json:"external_id"`
+	AnnotationType string `json:"annotation_type"`
+	Summary        string `json:"summary"`
+	Severity       string `json:"severity"`
+	Path           string `json:"path"`
+	Line           int    `json:"line"`
+}
+
+// writeBitbucket writes the results as a Bitbucket Code Insights report with
+// an annotation for each violation. The annotations are identified by the
+// violation fingerprints, so they are updated in place on each run.
+func writeBitbucket(w io.Writer, in Input) error {
+	warnings := in.Results.Warnings(in.Options)
+	failures := in.Results.Failures(in.Options)
+	nErrors, nWarnings := len(failures.Errs()), len(warnings.Errs())
+
+	doc := bitbucketDocument{
+		Report: bitbucketReport{
+			Title:      "License check",
+			Details:    fmt.Sprintf("%d errors, %d warnings", nErrors, nWarnings),
+			ReportType: "BUG",
+			Reporter:   "license-checker",
+			Result:     "PASSED",
+			Data: []bitbucketData{
+				{Title: "Errors", Type: "NUMBER", Value: nErrors},
+				{Title: "Warnings", Type: "NUMBER", Value: nWarnings},
+			},
+		},
+		Annotations: []bitbucketAnnotation{},
+	}
+	if in.Risk != nil {
+		doc.Report.Details = fmt.Sprintf("Risk: %v. %v", in.Risk, doc.Report.Details)
+		doc.Report.Data = append(doc.Report.Data, bitbucketData{Title: "Risk score", Type: "PERCENTAGE", Value: int(in.Risk.Score + 0.5)})
+	}
+	if nErrors > 0 {
+		doc.Report.Result = "FAILED"
+	}
+	for i, res := range in.Results {
+		severity, err := "HIGH", failures[i].Err
+		if err == nil {
+			severity, err = "LOW", warnings[i].Err
+		}
+		if err == nil {
+			continue
+		}
+		doc.Annotations = append(doc.Annotations, bitbucketAnnotation{
+			ExternalID:     res.Fingerprint,
+			AnnotationType: "BUG",
+			Summary:        err.Error(),
+			Severity:       severity,
+			Path:           in.Options.DisplayPath(in.Root, res.Path),
+			Line:           1,
+		})
+	}
+
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(doc)
+}
@@ -0,0 +1,87 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+)
+
+// decision is a single line of the decision log.
+type decision struct {
+	File        string   `json:"file"`
+	SHA256      string   `json:"sha256,omitempty"` // hash of the examined file content
+	Project     string   `json:"project,omitempty"`
+	Config      string   `json:"config"`                 // the config file of the project
+	ConfigIndex *int     `json:"config_index,omitempty"` // the config of the config file that examined the file
+	Licenses    []string `json:"licenses"`
+	Rules       []string `json:"rules,omitempty"`
+	Allowed     bool     `json:"allowed"`
+	Warning     bool     `json:"warning,omitempty"`
+	Kind        string   `json:"kind,omitempty"`
+	Violation   string   `json:"violation,omitempty"`
+	Fingerprint string   `json:"fingerprint,omitempty"`
+	Skipped     string   `json:"skipped,omitempty"`
+}
+
+// writeDecisions writes the decision log of the results as newline delimited
+// JSON, with one object per result recording the inputs and the config rules
+// that allowed or denied it, so that an audit can replay the check.
+func writeDecisions(w io.Writer, in Input) error {
+	warnings := in.Results.Warnings(in.Options)
+	sources := in.Results.ConfigSources()
+	e := json.NewEncoder(w)
+	for i, res := range in.Results {
+		source, ok := sources[res.Project]
+		if res.Decision != nil && res.Decision.Source != "" {
+			source = res.Decision.Source
+		} else if !ok {
+			source = path.Join(res.Project, in.Options.ConfigFile())
+		}
+		d := decision{
+			File:     in.Options.DisplayPath(in.Root, res.Path),
+			Project:  res.Project,
+			Config:   in.Options.DisplayPath(in.Root, source),
+			Licenses: res.Licenses,
+			Allowed:  res.Err == nil,
+			Warning:  warnings[i].Err != nil,
+			Skipped:  res.Skipped,
+		}
+		if d.Licenses == nil {
+			d.Licenses = []string{}
+		}
+		if res.Decision != nil {
+			index := res.Decision.Config
+			d.ConfigIndex, d.Rules = &index, res.Decision.Rules
+		}
+		if res.Err != nil {
+			d.Kind, d.Violation, d.Fingerprint = string(res.Kind), res.Err.Error(), res.Fingerprint
+		}
+		if res.Skipped == "" && res.Kind.IsFile() {
+			if body, err := ioutil.ReadFile(filepath.Join(in.Root, filepath.FromSlash(res.Path))); err == nil {
+				d.SHA256 = fmt.Sprintf("%x", sha256.Sum256(body))
+			}
+		}
+		if err := e.Encode(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
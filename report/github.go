@@ -0,0 +1,166 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"../checker"
+)
+
+// writeGitHub writes each violation as a GitHub Actions workflow command, which
+// GitHub shows as an annotation on the file.
+func writeGitHub(w io.Writer, in Input) error {
+	warnings := in.Results.Warnings(in.Options)
+	failures := in.Results.Failures(in.Options)
+	sb := strings.Builder{}
+	if in.Risk != nil {
+		fmt.Fprintf(&sb, "::notice title=License risk::%v\n", escapeData(in.Risk.String()))
+	}
+	for i, res := range in.Results {
+		level, err := "error", failures[i].Err
+		if err == nil {
+			level, err = "warning", warnings[i].Err
+		}
+		if err == nil {
+			continue
+		}
+		fmt.Fprintf(&sb, "::%v file=%v,line=1,title=%v::%v\n", level,
+			escapeProperty(in.Options.DisplayPath(in.Root, res.Path)),
+			escapeProperty(fmt.Sprintf("License %v [%v]", res.Kind, res.Fingerprint)),
+			escapeData(err.Error()))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// escapeData escapes the message of a workflow command.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a property value of a workflow command.
+func escapeProperty(s string) string {
+	return strings.NewReplacer(":", "%3A", ",", "%2C").Replace(escapeData(s))
+}
+
+// writeMarkdown writes a summary of the results as markdown, suitable for a
+// GitHub Actions job summary. The summary holds the check status, the risk and
+// coverage if known, a table of the violations, statistics by file type and a
+// breakdown of the licenses found.
+func writeMarkdown(w io.Writer, in Input) error {
+	warnings := in.Results.Warnings(in.Options)
+	failures := in.Results.Failures(in.Options)
+	nErrors, nWarnings := len(failures.Errs()), len(warnings.Errs())
+
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "## License check\n\n")
+	if in.Results.Partial() {
+		fmt.Fprintf(&sb, ":hourglass: **Partial results**: the scan stopped before all files were examined\n\n")
+	}
+	switch {
+	case nErrors > 0:
+		fmt.Fprintf(&sb, ":x: %d errors, %d warnings\n\n", nErrors, nWarnings)
+	case nWarnings > 0:
+		fmt.Fprintf(&sb, ":warning: %d warnings\n\n", nWarnings)
+	default:
+		fmt.Fprintf(&sb, ":white_check_mark: No license issues found\n\n")
+	}
+	if in.Risk != nil {
+		fmt.Fprintf(&sb, "Risk: **%v**\n\n", in.Risk)
+	}
+	if in.Coverage != nil {
+		fmt.Fprintf(&sb, "Coverage: %v\n\n", in.Coverage)
+	}
+
+	if nErrors+nWarnings > 0 {
+		fmt.Fprintf(&sb, "### Violations\n\n")
+		fmt.Fprintf(&sb, "| Level | File | Violation | Fingerprint |\n")
+		fmt.Fprintf(&sb, "| --- | --- | --- | --- |\n")
+		for i, res := range in.Results {
+			level, err := "error", failures[i].Err
+			if err == nil {
+				level, err = "warning", warnings[i].Err
+			}
+			if err == nil {
+				continue
+			}
+			fmt.Fprintf(&sb, "| %v | `%v` | %v | `%v` |\n", level,
+				in.Options.DisplayPath(in.Root, res.Path), escapeCell(err.Error()), res.Fingerprint)
+		}
+		fmt.Fprintf(&sb, "\n")
+	}
+
+	fmt.Fprintf(&sb, "### File types\n\n")
+	fmt.Fprintf(&sb, "| Extension | Files | Violations | Dominant license |\n")
+	fmt.Fprintf(&sb, "| --- | --- | --- | --- |\n")
+	for _, t := range in.Results.FileTypes() {
+		ext, license := t.Extension, t.License
+		if ext == "" {
+			ext = "(none)"
+		}
+		if license == "" {
+			license = "none"
+		}
+		fmt.Fprintf(&sb, "| `%v` | %d | %d | %v |\n", ext, t.Files, t.Violations, escapeCell(license))
+	}
+	fmt.Fprintf(&sb, "\n")
+
+	fmt.Fprintf(&sb, "### Licenses\n\n")
+	fmt.Fprintf(&sb, "| License | Files |\n")
+	fmt.Fprintf(&sb, "| --- | --- |\n")
+	counts := licenseCounts(in.Results)
+	for _, l := range sortedKeys(toSet(counts)) {
+		fmt.Fprintf(&sb, "| %v | %d |\n", escapeCell(l), counts[l])
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// licenseCounts returns the number of examined files that hold each license,
+// counting files without a license as "(none)".
+func licenseCounts(results checker.Results) map[string]int {
+	counts := map[string]int{}
+	for _, res := range results.Files() {
+		if len(res.Licenses) == 0 {
+			counts["(none)"]++
+		}
+		seen := map[string]bool{}
+		for _, l := range res.Licenses {
+			if !seen[l] {
+				seen[l] = true
+				counts[l]++
+			}
+		}
+	}
+	return counts
+}
+
+// escapeCell escapes the text for use in a markdown table cell.
+func escapeCell(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(s)
+}
+
+// toSet returns the keys of the map as a set.
+func toSet(m map[string]int) map[string]bool {
+	out := make(map[string]bool, len(m))
+	for k := range m {
+		out[k] = true
+	}
+	return out
+}
@@ -0,0 +1,200 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"io"
+
+	"../checker"
+)
+
+// jsonReport is the top-level object of the JSON report.
+type jsonReport struct {
+	// Partial is true if the scan stopped at the deadline, or was
+	// interrupted, before all files were examined.
+	Partial bool `json:"partial,omitempty"`
+
+	// Risk is the overall risk of the project, if measured.
+	Risk *jsonRisk `json:"risk,omitempty"`
+
+	// Baseline counts the new, fixed and remaining violations against the
+	// baseline of known violations, if the scan used one.
+	Baseline *jsonBaseline `json:"baseline,omitempty"`
+
+	Errors   int        `json:"errors"`   // number of violations failing the check
+	Warnings int        `json:"warnings"` // number of advisory violations
+	Files    []jsonFile `json:"files"`    // all the examined files
+
+	// Skipped lists the files and directories that were not examined, if
+	// checker.Options.ListSkipped was set.
+	Skipped []jsonSkipped `json:"skipped,omitempty"`
+
+	// ExtraLicenses lists the licenses permitted for this scan only, by
+	// checker.Options.ExtraLicenses.
+	ExtraLicenses []string `json:"extra_licenses,omitempty"`
+
+	// Projects holds the status of each project, if the scan covered several
+	// projects.
+	Projects []jsonProject `json:"projects,omitempty"`
+
+	// Coverage is the proportion of the project's files that were examined,
+	// if requested.
+	Coverage *jsonCoverage `json:"coverage,omitempty"`
+
+	// FileTypes holds the statistics of the examined files by extension.
+	FileTypes []jsonFileType `json:"file_types"`
+}
+
+// jsonFileType is the JSON report entry for the files with an extension.
+type jsonFileType struct {
+	Extension  string `json:"extension"`
+	Files      int    `json:"files"`
+	Violations int    `json:"violations"`
+	License    string `json:"license,omitempty"` // the license found in the most files
+}
+
+// jsonRisk is the JSON report entry for the risk of the project.
+type jsonRisk struct {
+	Score   float64        `json:"score"`
+	Level   string         `json:"level"`
+	Files   int            `json:"files"`
+	Factors map[string]int `json:"factors"`
+}
+
+// jsonBaseline is the JSON report entry for a checker.BaselineSummary.
+type jsonBaseline struct {
+	New       int `json:"new"`
+	Fixed     int `json:"fixed"`
+	Remaining int `json:"remaining"`
+}
+
+// jsonCoverage is the JSON report entry for the scan coverage.
+type jsonCoverage struct {
+	Examined int     `json:"examined"`
+	Total    int     `json:"total"`
+	Percent  float64 `json:"percent"`
+}
+
+// jsonProject is the JSON report entry for a project of the scan.
+type jsonProject struct {
+	Path     string `json:"path"`
+	Passed   bool   `json:"passed"`
+	Errors   int    `json:"errors"`
+	Warnings int    `json:"warnings"`
+}
+
+// jsonSkipped is the JSON report entry for a skipped file or directory.
+type jsonSkipped struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// jsonFile is the JSON report entry for a single examined file.
+type jsonFile struct {
+	Path        string   `json:"path"`
+	Project     string   `json:"project,omitempty"`
+	Licenses    []string `json:"licenses"`
+	Extra       []string `json:"extra_licenses,omitempty"` // licenses only allowed by checker.Options.ExtraLicenses
+	Generated   string   `json:"generated_from,omitempty"` // the source file of a generated file
+	Violation   string   `json:"violation,omitempty"`
+	Kind        string   `json:"kind,omitempty"`
+	Fingerprint string   `json:"fingerprint,omitempty"`
+	Warning     bool     `json:"warning,omitempty"`
+
+	// AddedBy identifies the commit that introduced a file with a violation,
+	// if checker.Result.Attribution was set.
+	AddedBy *jsonAttribution `json:"added_by,omitempty"`
+}
+
+// jsonAttribution is the JSON report entry for a checker.Attribution.
+type jsonAttribution struct {
+	Commit string `json:"commit"`
+	Author string `json:"author"`
+	Email  string `json:"email"`
+}
+
+// writeJSON writes the results as a JSON object, listing every examined file
+// with its licenses and any violation.
+func writeJSON(w io.Writer, in Input) error {
+	results := in.Results.Examined()
+	warnings := results.Warnings(in.Options)
+	out := jsonReport{
+		Errors:   len(results.Failures(in.Options).Errs()),
+		Warnings: len(warnings.Errs()),
+		Files:    make([]jsonFile, len(results)),
+	}
+	out.ExtraLicenses = in.Options.ExtraLicenses
+	out.Partial = in.Results.Partial()
+	if r := in.Risk; r != nil {
+		out.Risk = &jsonRisk{Score: r.Score, Level: string(r.Level), Files: r.Files, Factors: r.Factors}
+	}
+	if b := in.Baseline; b != nil {
+		out.Baseline = &jsonBaseline{New: b.New, Fixed: b.Fixed, Remaining: b.Remaining}
+	}
+	if c := in.Coverage; c != nil {
+		out.Coverage = &jsonCoverage{Examined: c.Examined, Total: c.Total, Percent: c.Percent()}
+	}
+	out.FileTypes = []jsonFileType{}
+	for _, t := range in.Results.FileTypes() {
+		out.FileTypes = append(out.FileTypes, jsonFileType{
+			Extension:  t.Extension,
+			Files:      t.Files,
+			Violations: t.Violations,
+			License:    t.License,
+		})
+	}
+	for _, p := range in.Results.Projects(in.Options) {
+		out.Projects = append(out.Projects, jsonProject{
+			Path:     checker.EscapePath(p.Path),
+			Passed:   p.Passed(),
+			Errors:   p.Errors,
+			Warnings: p.Warnings,
+		})
+	}
+	for _, res := range in.Results.Skipped() {
+		out.Skipped = append(out.Skipped, jsonSkipped{
+			Path:   in.Options.DisplayPath(in.Root, res.Path),
+			Reason: res.Skipped,
+		})
+	}
+	for i, res := range results {
+		f := jsonFile{
+			Path:     in.Options.DisplayPath(in.Root, res.Path),
+			Project:  res.Project,
+			Licenses: res.Licenses,
+			Extra:    res.ExtraLicenses,
+			Warning:  warnings[i].Err != nil,
+		}
+		if res.GeneratedFrom != "" {
+			f.Generated = in.Options.DisplayPath(in.Root, res.GeneratedFrom)
+		}
+		if f.Licenses == nil {
+			f.Licenses = []string{}
+		}
+		if res.Err != nil {
+			f.Violation = res.Err.Error()
+			f.Kind = string(res.Kind)
+			f.Fingerprint = res.Fingerprint
+			if a := res.Attribution; a != nil {
+				f.AddedBy = &jsonAttribution{Commit: a.Commit, Author: a.Author, Email: a.Email}
+			}
+		}
+		out.Files[i] = f
+	}
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(out)
+}
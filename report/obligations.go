@@ -0,0 +1,103 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"../detector"
+)
+
+// writeObligations writes a markdown briefing of the obligations implied by
+// the licenses found in the examined files, for release managers. The report
+// lists each obligation with the licenses that impose it, followed by the
+// obligations of each license, and the licenses whose obligations are not
+// known and must be reviewed manually.
+func writeObligations(w io.Writer, in Input) error {
+	files := map[string]int{} // license to number of files
+	for _, res := range in.Results.Examined() {
+		seen := map[string]bool{}
+		for _, l := range res.Licenses {
+			if id := detector.Normalize(l); !seen[id] {
+				seen[id] = true
+				files[id]++
+			}
+		}
+	}
+	licenses := make([]string, 0, len(files))
+	for l := range files {
+		licenses = append(licenses, l)
+	}
+	sort.Strings(licenses)
+
+	imposedBy := map[detector.Obligation][]string{}
+	order := []detector.Obligation{}
+	unknown := []string{}
+	for _, l := range licenses {
+		obligations, ok := detector.ObligationsOf(l)
+		if !ok {
+			unknown = append(unknown, l)
+			continue
+		}
+		for _, o := range obligations {
+			if _, ok := imposedBy[o]; !ok {
+				order = append(order, o)
+			}
+			imposedBy[o] = append(imposedBy[o], l)
+		}
+	}
+	sort.Slice(order, func(i, j int) bool { return order[i] < order[j] })
+
+	b := strings.Builder{}
+	b.WriteString("# License obligations\n\n")
+	if in.Risk != nil {
+		fmt.Fprintf(&b, "Risk: **%v**\n\n", in.Risk)
+	}
+	if len(licenses) == 0 {
+		b.WriteString("No licenses were found.\n")
+	} else {
+		b.WriteString("Shipping this tree requires:\n\n")
+		if len(order) == 0 {
+			b.WriteString("* Nothing, for the known licenses\n")
+		}
+		for _, o := range order {
+			fmt.Fprintf(&b, "* **%v**: %v. Required by %v.\n", o, o.Describe(), strings.Join(imposedBy[o], ", "))
+		}
+		b.WriteString("\n## Licenses\n\n| License | Files | Obligations |\n| --- | --- | --- |\n")
+		for _, l := range licenses {
+			obligations, ok := detector.ObligationsOf(l)
+			names := make([]string, len(obligations))
+			for i, o := range obligations {
+				names[i] = string(o)
+			}
+			desc := strings.Join(names, ", ")
+			switch {
+			case !ok:
+				desc = "unknown"
+			case len(names) == 0:
+				desc = "none"
+			}
+			fmt.Fprintf(&b, "| %v | %d | %v |\n", l, files[l], desc)
+		}
+	}
+	if len(unknown) > 0 {
+		fmt.Fprintf(&b, "\nThe obligations of %v are not known, and must be reviewed manually.\n", strings.Join(unknown, ", "))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,152 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package report writes the results of a license check in a number of output
+// formats.
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"../checker"
+)
+
+// Input holds the data that is reported.
+type Input struct {
+	Root    string          // absolute path to the project root directory
+	Results checker.Results // the results of the scan
+	Options checker.Options // the options used for the scan
+
+	// Coverage, if not nil, is the coverage of the scan, which is included in
+	// the report.
+	Coverage *checker.Coverage
+
+	// Risk, if not nil, is the overall risk of the project, which is
+	// summarized at the top of the report.
+	Risk *checker.Risk
+
+	// Baseline, if not nil, counts the violations of the scan against the
+	// baseline of known violations.
+	Baseline *checker.BaselineSummary
+}
+
+// Writer writes the report for the Input to w.
+type Writer func(w io.Writer, in Input) error
+
+// writers is a map of format name to Writer.
+var writers = map[string]Writer{
+	"text":        writeText,
+	"json":        writeJSON,
+	"spdx":        writeSPDX,
+	"github":      writeGitHub,
+	"markdown":    writeMarkdown,
+	"azure":       writeAzure,
+	"bitbucket":   writeBitbucket,
+	"obligations": writeObligations,
+	"decisions":   writeDecisions,
+}
+
+// Formats returns the sorted list of supported format names.
+func Formats() []string {
+	out := make([]string, 0, len(writers))
+	for name := range writers {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Write writes the report for the Input to w using the named format.
+func Write(w io.Writer, format string, in Input) error {
+	writer, ok := writers[format]
+	if !ok {
+		return fmt.Errorf("Unknown report format '%v'. Must be one of: %v", format, Formats())
+	}
+	return writer(w, in)
+}
+
+// writeText writes the human readable list of warnings and errors, as printed
+// by checker.Results.Check.
+func writeText(w io.Writer, in Input) error {
+	warnings := in.Results.Warnings(in.Options)
+	failures := in.Results.Failures(in.Options)
+	if in.Results.Partial() {
+		if _, err := fmt.Fprintf(w, "PARTIAL RESULTS: the scan stopped before all files were examined\n"); err != nil {
+			return err
+		}
+	}
+	if in.Risk != nil {
+		if _, err := fmt.Fprintf(w, "Risk: %v\n", in.Risk); err != nil {
+			return err
+		}
+	}
+	if in.Coverage != nil {
+		if _, err := fmt.Fprintf(w, "Coverage: %v\n", in.Coverage); err != nil {
+			return err
+		}
+	}
+	if skipped := in.Results.Skipped(); len(skipped) > 0 {
+		if _, err := fmt.Fprintf(w, "%d skipped:\n%v", len(skipped), skipped.ListSkipped()); err != nil {
+			return err
+		}
+	}
+	if extra := in.Results.Extra(); len(extra) > 0 {
+		if _, err := fmt.Fprintf(w, "%d files allowed by extra licenses:\n%v", len(extra), extra.ListExtra()); err != nil {
+			return err
+		}
+	}
+	if generated := in.Results.Generated(); in.Options.ListSkipped && len(generated) > 0 {
+		if _, err := fmt.Fprintf(w, "%d generated files checked by their source:\n%v", len(generated), generated.ListGenerated()); err != nil {
+			return err
+		}
+	}
+	if projects := in.Results.Projects(in.Options); len(projects) > 0 {
+		if _, err := fmt.Fprintf(w, "%d projects:\n", len(projects)); err != nil {
+			return err
+		}
+		for _, p := range projects {
+			if _, err := fmt.Fprintf(w, "* %v\n", p); err != nil {
+				return err
+			}
+		}
+	}
+	if types := in.Results.FileTypes(); len(types) > 1 {
+		if _, err := fmt.Fprintf(w, "%d file types:\n", len(types)); err != nil {
+			return err
+		}
+		for _, t := range types {
+			if _, err := fmt.Fprintf(w, "* %v\n", t); err != nil {
+				return err
+			}
+		}
+	}
+	if n := len(warnings.Errs()); n > 0 {
+		if _, err := fmt.Fprintf(w, "%d warnings:\n%v", n, warnings.List(in.Options)); err != nil {
+			return err
+		}
+	}
+	if n := len(failures.Errs()); n > 0 {
+		if _, err := fmt.Fprintf(w, "%d errors:\n%v", n, failures.List(in.Options)); err != nil {
+			return err
+		}
+	}
+	if len(in.Results.Errs()) == 0 {
+		if _, err := fmt.Fprintf(w, "No license issues found\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
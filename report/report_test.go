@@ -0,0 +1,284 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report_test
+
+import (
+	"encoding/json"
+	"errors"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	report "."
+	"../checker"
+)
+
+var testcases = filepath.Join(sourceDirectory(), "..", "checker", "testcases")
+
+// scan returns the report Input for the named checker testcase.
+func scan(t *testing.T, name string) report.Input {
+	root := filepath.Join(testcases, name)
+	opts := checker.Options{Quiet: true}
+	results, err := checker.Scan(root, opts)
+	if err != nil {
+		t.Fatalf("checker.Scan() returned %v", err)
+	}
+	return report.Input{Root: root, Results: results, Options: opts}
+}
+
+func TestText(t *testing.T) {
+	sb := strings.Builder{}
+	if err := report.Write(&sb, "text", scan(t, "bad-missing-license")); err != nil {
+		t.Fatalf("Write() returned %v", err)
+	}
+	if expect := "1 errors:\n* src/missing-license.cpp has no license [e8c82aa523351bfa]\n"; sb.String() != expect {
+		t.Errorf("Text report was:\n%v\nExpected:\n%v", sb.String(), expect)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	sb := strings.Builder{}
+	if err := report.Write(&sb, "json", scan(t, "bad-missing-license")); err != nil {
+		t.Fatalf("Write() returned %v", err)
+	}
+	got := struct {
+		Errors int
+		Files  []struct {
+			Path        string
+			Licenses    []string
+			Violation   string
+			Kind        string
+			Fingerprint string
+		}
+	}{}
+	if err := json.Unmarshal([]byte(sb.String()), &got); err != nil {
+		t.Fatalf("Failed to parse JSON report: %v\n%v", err, sb.String())
+	}
+	if got.Errors != 1 || len(got.Files) != 2 {
+		t.Fatalf("Unexpected JSON report:\n%v", sb.String())
+	}
+	for _, f := range got.Files {
+		switch f.Path {
+		case "src/missing-license.cpp":
+			if f.Violation != "src/missing-license.cpp has no license" || len(f.Licenses) != 0 ||
+				f.Kind != "no-license" || f.Fingerprint != "e8c82aa523351bfa" {
+				t.Errorf("Unexpected JSON entry: %+v", f)
+			}
+		case "src/source.cpp":
+			if f.Violation != "" || f.Fingerprint != "" || len(f.Licenses) != 1 || f.Licenses[0] != "Apache-2.0" {
+				t.Errorf("Unexpected JSON entry: %+v", f)
+			}
+		default:
+			t.Errorf("Unexpected JSON entry: %+v", f)
+		}
+	}
+}
+
+func TestJSONBaseline(t *testing.T) {
+	in := scan(t, "bad-missing-license")
+	in.Baseline = &checker.BaselineSummary{New: 1, Fixed: 2, Remaining: 3}
+	sb := strings.Builder{}
+	if err := report.Write(&sb, "json", in); err != nil {
+		t.Fatalf("Write() returned %v", err)
+	}
+	if !strings.Contains(sb.String(), `"baseline": {
+    "new": 1,
+    "fixed": 2,
+    "remaining": 3
+  }`) {
+		t.Errorf("JSON report has no baseline summary:\n%v", sb.String())
+	}
+}
+
+func TestJSONSkipped(t *testing.T) {
+	root := filepath.Join(testcases, "good-filter")
+	opts := checker.Options{Quiet: true, ListSkipped: true}
+	results, err := checker.Scan(root, opts)
+	if err != nil {
+		t.Fatalf("checker.Scan() returned %v", err)
+	}
+	sb := strings.Builder{}
+	if err := report.Write(&sb, "json", report.Input{Root: root, Results: results, Options: opts}); err != nil {
+		t.Fatalf("Write() returned %v", err)
+	}
+	got := struct {
+		Files   []struct{ Path string }
+		Skipped []struct{ Path, Reason string }
+	}{}
+	if err := json.Unmarshal([]byte(sb.String()), &got); err != nil {
+		t.Fatalf("Failed to parse JSON report: %v\n%v", err, sb.String())
+	}
+	if len(got.Files) != 2 || len(got.Skipped) != 3 {
+		t.Fatalf("Unexpected JSON report:\n%v", sb.String())
+	}
+	if s := got.Skipped[1]; s.Path != "src/ignore/" || s.Reason != "excluded by paths[0] pattern '**/ignore/**'" {
+		t.Errorf("Unexpected skipped entry: %+v", s)
+	}
+}
+
+func TestSPDX(t *testing.T) {
+	sb := strings.Builder{}
+	if err := report.Write(&sb, "spdx", scan(t, "bad-missing-license")); err != nil {
+		t.Fatalf("Write() returned %v", err)
+	}
+	for _, expect := range []string{
+		"SPDXVersion: SPDX-2.2\n",
+		"PackageName: bad-missing-license\n",
+		"PackageLicenseInfoFromFiles: Apache-2.0\n",
+		"FileName: ./src/source.cpp\n",
+		"LicenseInfoInFile: Apache-2.0\n",
+		"FileName: ./src/missing-license.cpp\n",
+		"LicenseInfoInFile: NONE\n",
+	} {
+		if !strings.Contains(sb.String(), expect) {
+			t.Errorf("SPDX report did not contain '%v':\n%v", expect, sb.String())
+		}
+	}
+}
+
+func TestGitHub(t *testing.T) {
+	sb := strings.Builder{}
+	if err := report.Write(&sb, "github", scan(t, "bad-missing-license")); err != nil {
+		t.Fatalf("Write() returned %v", err)
+	}
+	expect := "::error file=src/missing-license.cpp,line=1,title=License no-license [e8c82aa523351bfa]::src/missing-license.cpp has no license\n"
+	if sb.String() != expect {
+		t.Errorf("GitHub report was:\n%v\nExpected:\n%v", sb.String(), expect)
+	}
+}
+
+func TestAzure(t *testing.T) {
+	sb := strings.Builder{}
+	if err := report.Write(&sb, "azure", scan(t, "bad-missing-license")); err != nil {
+		t.Fatalf("Write() returned %v", err)
+	}
+	expect := "##vso[task.logissue type=error;sourcepath=src/missing-license.cpp;linenumber=1;code=no-license]src/missing-license.cpp has no license\n"
+	if sb.String() != expect {
+		t.Errorf("Azure report was:\n%v\nExpected:\n%v", sb.String(), expect)
+	}
+}
+
+func TestBitbucket(t *testing.T) {
+	sb := strings.Builder{}
+	if err := report.Write(&sb, "bitbucket", scan(t, "bad-missing-license")); err != nil {
+		t.Fatalf("Write() returned %v", err)
+	}
+	got := struct {
+		Report struct {
+			Result string
+		}
+		Annotations []struct {
+			ExternalID string `json:"external_id"`
+			Severity   string
+			Path       string
+		}
+	}{}
+	if err := json.Unmarshal([]byte(sb.String()), &got); err != nil {
+		t.Fatalf("Failed to parse Bitbucket report: %v\n%v", err, sb.String())
+	}
+	if got.Report.Result != "FAILED" || len(got.Annotations) != 1 {
+		t.Fatalf("Unexpected Bitbucket report:\n%v", sb.String())
+	}
+	if a := got.Annotations[0]; a.ExternalID != "e8c82aa523351bfa" || a.Severity != "HIGH" || a.Path != "src/missing-license.cpp" {
+		t.Errorf("Unexpected Bitbucket annotation: %+v", a)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	in := scan(t, "bad-missing-license")
+	in.Coverage = &checker.Coverage{Examined: 2, Total: 2}
+	sb := strings.Builder{}
+	if err := report.Write(&sb, "markdown", in); err != nil {
+		t.Fatalf("Write() returned %v", err)
+	}
+	for _, expect := range []string{
+		":x: 1 errors, 0 warnings\n",
+		"Coverage: 100.0% of files (2/2) checked\n",
+		"| error | `src/missing-license.cpp` | src/missing-license.cpp has no license | `e8c82aa523351bfa` |\n",
+		"| (none) | 1 |\n| Apache-2.0 | 1 |\n",
+	} {
+		if !strings.Contains(sb.String(), expect) {
+			t.Errorf("Markdown report did not contain '%v':\n%v", expect, sb.String())
+		}
+	}
+}
+
+func TestObligations(t *testing.T) {
+	in := report.Input{Results: checker.Results{
+		{Path: "a.cpp", Licenses: []string{"Apache-2.0"}},
+		{Path: "b.cpp", Licenses: []string{"Apache-2.0", "MIT"}},
+		{Path: "c.cpp", Licenses: []string{"LicenseRef-Acme"}},
+		{Path: "d.cpp", Licenses: []string{"GPL-2.0-only"}, Skipped: "excluded"},
+	}}
+	sb := strings.Builder{}
+	if err := report.Write(&sb, "obligations", in); err != nil {
+		t.Fatalf("Write() returned %v", err)
+	}
+	for _, expect := range []string{
+		"* **attribution**: Reproduce the copyright notices and the license text with every distribution. Required by Apache-2.0, MIT.\n",
+		"* **notice**: Reproduce the content of the NOTICE files in the distribution's NOTICE. Required by Apache-2.0.\n",
+		"| Apache-2.0 | 2 | attribution, notice, patent-grant, state-changes |\n",
+		"| LicenseRef-Acme | 1 | unknown |\n",
+		"The obligations of LicenseRef-Acme are not known, and must be reviewed manually.\n",
+	} {
+		if !strings.Contains(sb.String(), expect) {
+			t.Errorf("Obligations report did not contain '%v':\n%v", expect, sb.String())
+		}
+	}
+	if strings.Contains(sb.String(), "source-offer") {
+		t.Errorf("Obligations report holds the obligations of a skipped file:\n%v", sb.String())
+	}
+}
+
+func TestDecisions(t *testing.T) {
+	in := report.Input{Results: checker.Results{
+		{Path: "a.cpp", Licenses: []string{"Apache-2.0"}, Decision: &checker.Decision{Source: "pyproject.toml", Rules: []string{"licenses: Apache-2.0"}}},
+		{Path: "b.cpp", Licenses: []string{"GPL-3.0"}, Err: errors.New("b.cpp uses unsupported license 'GPL-3.0'"),
+			Kind: checker.UnsupportedLicense, Fingerprint: "0123456789abcdef",
+			Decision: &checker.Decision{Config: 1, Rules: []string{"licenses: denied GPL-3.0"}}},
+		{Path: "c.cpp", Skipped: "excluded"},
+		{Path: "sub/d.cpp", Project: "sub", Skipped: "excluded"},
+	}}
+	sb := strings.Builder{}
+	if err := report.Write(&sb, "decisions", in); err != nil {
+		t.Fatalf("Write() returned %v", err)
+	}
+	expect := `{"file":"a.cpp","config":"pyproject.toml","config_index":0,"licenses":["Apache-2.0"],"rules":["licenses: Apache-2.0"],"allowed":true}
+{"file":"b.cpp","config":"pyproject.toml","config_index":1,"licenses":["GPL-3.0"],"rules":["licenses: denied GPL-3.0"],"allowed":false,"kind":"unsupported-license","violation":"b.cpp uses unsupported license 'GPL-3.0'","fingerprint":"0123456789abcdef"}
+{"file":"c.cpp","config":"pyproject.toml","licenses":[],"allowed":true,"skipped":"excluded"}
+{"file":"sub/d.cpp","project":"sub","config":"sub/license-checker.cfg","licenses":[],"allowed":true,"skipped":"excluded"}
+`
+	if got := sb.String(); got != expect {
+		t.Errorf("Decision log was:\n%v\nExpected:\n%v", got, expect)
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	err := report.Write(&strings.Builder{}, "xml", report.Input{})
+	if err == nil || !strings.Contains(err.Error(), "Unknown report format 'xml'") {
+		t.Errorf("Write() with unknown format returned %v", err)
+	}
+}
+
+// sourceDirectory returns the path to the directory that holds this .go file
+func sourceDirectory() string {
+	_, filename, _, ok := runtime.Caller(1)
+	if !ok {
+		panic("runtime.Caller(1) failed")
+	}
+	return path.Dir(filename)
+}
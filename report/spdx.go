@@ -0,0 +1,149 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"../checker"
+)
+
+// writeSPDX writes an SPDX 2.2 tag-value document describing the project as a
+// single package, listing each examined file with its checksum and the
+// licenses found in it.
+func writeSPDX(w io.Writer, in Input) error {
+	type file struct {
+		path     string
+		sha1     string
+		licenses []string
+		comment  string
+	}
+	results := in.Results.Files()
+	files := make([]file, len(results))
+	allLicenses := map[string]bool{}
+	for i, res := range results {
+		body, err := ioutil.ReadFile(filepath.Join(in.Root, filepath.FromSlash(res.Path)))
+		if err != nil {
+			return err
+		}
+		files[i] = file{
+			path:     checker.EscapePath("./" + res.Path),
+			sha1:     fmt.Sprintf("%x", sha1.Sum(body)),
+			licenses: res.Licenses,
+		}
+		if res.Err != nil {
+			files[i].comment = fmt.Sprintf("%v [%v]", res.Err, res.Fingerprint)
+		}
+		for _, l := range res.Licenses {
+			allLicenses[l] = true
+		}
+	}
+
+	// The package verification code is the SHA1 of the sorted, concatenated
+	// file SHA1s.
+	sums := make([]string, len(files))
+	for i, f := range files {
+		sums[i] = f.sha1
+	}
+	sort.Strings(sums)
+	verification := fmt.Sprintf("%x", sha1.Sum([]byte(strings.Join(sums, ""))))
+
+	name := filepath.Base(in.Root)
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "SPDXVersion: SPDX-2.2\n")
+	fmt.Fprintf(&sb, "DataLicense: CC0-1.0\n")
+	fmt.Fprintf(&sb, "SPDXID: SPDXRef-DOCUMENT\n")
+	fmt.Fprintf(&sb, "DocumentName: %v\n", name)
+	fmt.Fprintf(&sb, "DocumentNamespace: https://spdx.org/spdxdocs/%v-%v\n", name, verification)
+	fmt.Fprintf(&sb, "Creator: Tool: license-checker\n")
+	fmt.Fprintf(&sb, "Created: %v\n", time.Now().UTC().Format(time.RFC3339))
+	if in.Risk != nil {
+		fmt.Fprintf(&sb, "CreatorComment: <text>License risk: %v</text>\n", in.Risk)
+	}
+	fmt.Fprintf(&sb, "\n")
+	fmt.Fprintf(&sb, "PackageName: %v\n", name)
+	fmt.Fprintf(&sb, "SPDXID: SPDXRef-Package\n")
+	fmt.Fprintf(&sb, "PackageDownloadLocation: NOASSERTION\n")
+	fmt.Fprintf(&sb, "FilesAnalyzed: true\n")
+	fmt.Fprintf(&sb, "PackageVerificationCode: %v\n", verification)
+	fmt.Fprintf(&sb, "PackageLicenseConcluded: NOASSERTION\n")
+	if len(allLicenses) == 0 {
+		fmt.Fprintf(&sb, "PackageLicenseInfoFromFiles: NONE\n")
+	}
+	for _, l := range sortedKeys(allLicenses) {
+		fmt.Fprintf(&sb, "PackageLicenseInfoFromFiles: %v\n", licenseRef(l))
+	}
+	fmt.Fprintf(&sb, "PackageLicenseDeclared: NOASSERTION\n")
+	fmt.Fprintf(&sb, "PackageCopyrightText: NOASSERTION\n")
+	fmt.Fprintf(&sb, "Relationship: SPDXRef-DOCUMENT DESCRIBES SPDXRef-Package\n")
+
+	for i, f := range files {
+		fmt.Fprintf(&sb, "\n")
+		fmt.Fprintf(&sb, "FileName: %v\n", f.path)
+		fmt.Fprintf(&sb, "SPDXID: SPDXRef-File-%d\n", i)
+		fmt.Fprintf(&sb, "FileChecksum: SHA1: %v\n", f.sha1)
+		fmt.Fprintf(&sb, "LicenseConcluded: NOASSERTION\n")
+		if len(f.licenses) == 0 {
+			fmt.Fprintf(&sb, "LicenseInfoInFile: NONE\n")
+		}
+		for _, l := range f.licenses {
+			fmt.Fprintf(&sb, "LicenseInfoInFile: %v\n", licenseRef(l))
+		}
+		fmt.Fprintf(&sb, "FileCopyrightText: NOASSERTION\n")
+		if f.comment != "" {
+			fmt.Fprintf(&sb, "FileComment: <text>%v</text>\n", f.comment)
+		}
+		fmt.Fprintf(&sb, "Relationship: SPDXRef-Package CONTAINS SPDXRef-File-%d\n", i)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// licenseRef returns the SPDX license reference for the license identifier.
+// Identifiers that are not valid SPDX identifiers are returned as a
+// LicenseRef-.
+func licenseRef(id string) string {
+	valid := func(r rune) bool {
+		return r == '-' || r == '.' || r == '+' ||
+			(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+	}
+	if strings.IndexFunc(id, func(r rune) bool { return !valid(r) }) >= 0 {
+		return "LicenseRef-" + strings.Map(func(r rune) rune {
+			if valid(r) && r != '+' {
+				return r
+			}
+			return '-'
+		}, id)
+	}
+	return id
+}
+
+// sortedKeys returns the sorted keys of the map.
+func sortedKeys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
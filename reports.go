@@ -0,0 +1,81 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"./report"
+)
+
+// reportRequest is a single --format / --output pair.
+type reportRequest struct {
+	format    string // report format name
+	output    string // path to the output file, or '-' for stdout
+	hasOutput bool   // true if --output was specified
+	append    bool   // true if the report is appended to the output file
+}
+
+// reportRequests is the list of reports requested on the command line.
+type reportRequests []reportRequest
+
+// formatFlag is the flag.Value for --format, which requests a new report.
+type formatFlag struct{ reports *reportRequests }
+
+func (f formatFlag) String() string { return "" }
+func (f formatFlag) Set(v string) error {
+	for _, format := range report.Formats() {
+		if v == format {
+			*f.reports = append(*f.reports, reportRequest{format: v, output: "-"})
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of: %v", report.Formats())
+}
+
+// outputFlag is the flag.Value for --output, which sets the output of the
+// report requested by the preceding --format.
+type outputFlag struct{ reports *reportRequests }
+
+func (f outputFlag) String() string { return "" }
+func (f outputFlag) Set(v string) error {
+	l := *f.reports
+	if len(l) == 0 || l[len(l)-1].hasOutput {
+		return fmt.Errorf("each --output must follow a --format")
+	}
+	l[len(l)-1].output, l[len(l)-1].hasOutput = v, true
+	return nil
+}
+
+// write writes the report to the requested output.
+func (r reportRequest) write(in report.Input) error {
+	if r.output == "-" {
+		return report.Write(os.Stdout, r.format, in)
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if r.append {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	f, err := os.OpenFile(r.output, flags, 0666)
+	if err != nil {
+		return fmt.Errorf("Failed to create report file: %w", err)
+	}
+	defer f.Close()
+	if err := report.Write(f, r.format, in); err != nil {
+		return fmt.Errorf("Failed to write %v report: %w", r.format, err)
+	}
+	return f.Close()
+}
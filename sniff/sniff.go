@@ -0,0 +1,105 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sniff determines the MIME type of a file from its content.
+package sniff
+
+import (
+	"bytes"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Len is the maximum number of leading bytes of a file considered by Type.
+const Len = 512
+
+// interpreterTypes maps script interpreter names to MIME types.
+var interpreterTypes = map[string]string{
+	"sh":      "text/x-shellscript",
+	"bash":    "text/x-shellscript",
+	"dash":    "text/x-shellscript",
+	"ksh":     "text/x-shellscript",
+	"zsh":     "text/x-shellscript",
+	"python":  "text/x-python",
+	"python2": "text/x-python",
+	"python3": "text/x-python",
+	"perl":    "text/x-perl",
+	"ruby":    "text/x-ruby",
+	"node":    "text/javascript",
+	"php":     "text/x-php",
+	"lua":     "text/x-lua",
+}
+
+// cInclude matches a C-family preprocessor #include directive.
+var cInclude = regexp.MustCompile(`(?m)^\s*#\s*include\s*[<"]`)
+
+// Interpreter returns the name of the interpreter declared by the shebang line
+// at the start of head, with any path and version suffix removed. For example,
+// both '#!/bin/bash' and '#!/usr/bin/env bash' return 'bash'.
+// Interpreter returns an empty string if head does not start with a shebang.
+func Interpreter(head []byte) string {
+	if !bytes.HasPrefix(head, []byte("#!")) {
+		return ""
+	}
+	line := string(head[2:])
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	name := path.Base(fields[0])
+	if name == "env" {
+		// Skip any flags passed to env, such as '-S'
+		fields = fields[1:]
+		for len(fields) > 0 && strings.HasPrefix(fields[0], "-") {
+			fields = fields[1:]
+		}
+		if len(fields) == 0 {
+			return ""
+		}
+		name = path.Base(fields[0])
+	}
+	return name
+}
+
+// Type returns the MIME type of a file, given at least the first Len bytes of
+// its content. Type recognizes scripts by their shebang line and C-family
+// sources by their #include directives, otherwise it falls back to the
+// algorithm used by http.DetectContentType. Any MIME type parameters, such as
+// the charset, are removed.
+func Type(head []byte) string {
+	if len(head) > Len {
+		head = head[:Len]
+	}
+	if name := Interpreter(head); name != "" {
+		if typ, ok := interpreterTypes[name]; ok {
+			return typ
+		}
+		if typ, ok := interpreterTypes[strings.TrimRight(name, "0123456789.")]; ok {
+			return typ // For example: 'python3.8'
+		}
+	}
+	typ := http.DetectContentType(head)
+	if i := strings.IndexByte(typ, ';'); i >= 0 {
+		typ = typ[:i]
+	}
+	if typ == "text/plain" && cInclude.Match(head) {
+		return "text/x-c"
+	}
+	return typ
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sniff_test
+
+import (
+	"testing"
+
+	sniff "."
+)
+
+func TestInterpreter(t *testing.T) {
+	for _, test := range []struct {
+		head   string
+		expect string
+	}{
+		{"#!/bin/bash\necho hi", "bash"},
+		{"#!/usr/bin/env python3\n", "python3"},
+		{"#! /usr/bin/env -S perl -w\n", "perl"},
+		{"#!/usr/bin/env\n", ""},
+		{"echo hi\n", ""},
+		{"", ""},
+	} {
+		if got := sniff.Interpreter([]byte(test.head)); got != test.expect {
+			t.Errorf("Interpreter(%q) returned '%v', expected '%v'", test.head, got, test.expect)
+		}
+	}
+}
+
+func TestType(t *testing.T) {
+	for _, test := range []struct {
+		head   string
+		expect string
+	}{
+		{"#!/bin/sh\nset -e\n", "text/x-shellscript"},
+		{"#!/usr/bin/env python3.8\nimport os\n", "text/x-python"},
+		{"#!/usr/bin/unknown\n", "text/plain"},
+		{"// Comment\n#include <stdio.h>\nint main() {}\n", "text/x-c"},
+		{"  #  include \"foo.h\"\n", "text/x-c"},
+		{"Some notes\n", "text/plain"},
+		{"<html><body></body></html>", "text/html"},
+		{"\x00\x01\x02\x03", "application/octet-stream"},
+	} {
+		if got := sniff.Type([]byte(test.head)); got != test.expect {
+			t.Errorf("Type(%q) returned '%v', expected '%v'", test.head, got, test.expect)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package spdx parses SPDX-License-Identifier tags from file content.
+package spdx
+
+import (
+	"regexp"
+	"strings"
+)
+
+// tagRE matches an SPDX-License-Identifier tag, capturing the license
+// expression up to the end of the line.
+var tagRE = regexp.MustCompile(`SPDX-License-Identifier:[ \t]*([^\r\n]*)`)
+
+// commentEnds are block comment terminators that may follow the expression on
+// the same line.
+var commentEnds = []string{"*/", "-->", "#>"}
+
+// Identifiers returns the license identifiers referenced by all the
+// SPDX-License-Identifier tags in body, in the order they appear.
+// Operators (AND, OR, WITH), license exceptions and parentheses are removed,
+// so the tag 'SPDX-License-Identifier: (MIT OR Apache-2.0 WITH LLVM-exception)'
+// returns [MIT, Apache-2.0].
+func Identifiers(body []byte) []string {
+	out := []string{}
+	for _, m := range tagRE.FindAllSubmatch(body, -1) {
+		expr := string(m[1])
+		for _, end := range commentEnds {
+			if i := strings.Index(expr, end); i >= 0 {
+				expr = expr[:i]
+			}
+		}
+		expr = strings.NewReplacer("(", " ", ")", " ").Replace(expr)
+		fields := strings.Fields(expr)
+		for i := 0; i < len(fields); i++ {
+			switch fields[i] {
+			case "AND", "OR", "and", "or":
+			case "WITH", "with":
+				i++ // Skip the exception identifier
+			default:
+				out = append(out, fields[i])
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,42 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spdx_test
+
+import (
+	"reflect"
+	"testing"
+
+	spdx "."
+)
+
+func TestIdentifiers(t *testing.T) {
+	for _, test := range []struct {
+		body   string
+		expect []string
+	}{
+		{"# SPDX-License-Identifier: Apache-2.0\n", []string{"Apache-2.0"}},
+		{"// SPDX-License-Identifier:MIT", []string{"MIT"}},
+		{"/* SPDX-License-Identifier: BSD-3-Clause */\n", []string{"BSD-3-Clause"}},
+		{"<!-- SPDX-License-Identifier: CC-BY-4.0 -->\n", []string{"CC-BY-4.0"}},
+		{"# SPDX-License-Identifier: (MIT OR Apache-2.0 WITH LLVM-exception)\n", []string{"MIT", "Apache-2.0"}},
+		{"# SPDX-License-Identifier: MIT\n# SPDX-License-Identifier: Zlib\n", []string{"MIT", "Zlib"}},
+		{"# SPDX-License-Identifier:\n", []string{}},
+		{"No tags here\n", []string{}},
+	} {
+		if got := spdx.Identifiers([]byte(test.body)); !reflect.DeepEqual(got, test.expect) {
+			t.Errorf("Identifiers(%q) returned %v, expected %v", test.body, got, test.expect)
+		}
+	}
+}
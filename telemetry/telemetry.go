@@ -0,0 +1,106 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package telemetry posts anonymous usage metrics of license-checker runs to
+// an endpoint chosen by the user, so that the adoption and performance of the
+// tool can be measured across many projects. Telemetry is strictly opt-in:
+// nothing is sent unless an endpoint is set. The metrics never hold paths,
+// file content, license texts, config settings or flag values.
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// EnvVar is the environment variable that sets the telemetry endpoint, if
+// not set by a flag, so that a CI system can opt in all of its projects.
+const EnvVar = "LICENSE_CHECKER_TELEMETRY"
+
+// httpClient is the client used to post the metrics. Its short timeout keeps
+// an unreachable endpoint from delaying the run.
+var httpClient = &http.Client{Timeout: 5 * time.Second}
+
+// Metrics are the anonymous usage metrics of a run.
+type Metrics struct {
+	Tool      string `json:"tool,omitempty"` // the module path and version of the tool, if known
+	GoVersion string `json:"go_version"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+
+	Command  string   `json:"command"`     // the subcommand, or "check"
+	Flags    []string `json:"flags"`       // the names of the flags set, without their values
+	Duration int64    `json:"duration_ms"` // the duration of the run, in milliseconds
+
+	Files    int  `json:"files"`    // the number of examined files
+	Errors   int  `json:"errors"`   // the number of violations failing the check
+	Warnings int  `json:"warnings"` // the number of advisory violations
+	Partial  bool `json:"partial"`  // true if the scan stopped before examining every file
+	Passed   bool `json:"passed"`   // true if the run succeeded
+}
+
+// New returns the Metrics of a run of the command with the named flags, that
+// took duration, with the tool and platform fields set.
+func New(command string, flags []string, duration time.Duration) Metrics {
+	m := Metrics{
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Command:   command,
+		Flags:     flags,
+		Duration:  duration.Milliseconds(),
+	}
+	if m.Flags == nil {
+		m.Flags = []string{}
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		m.Tool = info.Main.Path + "@" + info.Main.Version
+	}
+	return m
+}
+
+// ValidateEndpoint returns an error if endpoint is not an http or https URL.
+func ValidateEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("Telemetry endpoint '%v' must be an http or https URL", endpoint)
+	}
+	return nil
+}
+
+// Send posts the metrics to the endpoint as a JSON object.
+func Send(endpoint string, m Metrics) error {
+	if err := ValidateEndpoint(endpoint); err != nil {
+		return err
+	}
+	body, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("Failed to send telemetry: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("Failed to send telemetry: %v returned %v", endpoint, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	telemetry "."
+)
+
+func TestSend(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Got request %v with content type '%v'", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("Failed to decode metrics: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	m := telemetry.New("check", []string{"dir", "format"}, 1500*time.Millisecond)
+	m.Files, m.Errors, m.Warnings, m.Passed = 10, 1, 2, false
+	if err := telemetry.Send(server.URL, m); err != nil {
+		t.Fatalf("Send() returned %v", err)
+	}
+	for field, expect := range map[string]interface{}{
+		"command":     "check",
+		"flags":       []interface{}{"dir", "format"},
+		"duration_ms": 1500.0,
+		"files":       10.0,
+		"errors":      1.0,
+		"warnings":    2.0,
+		"partial":     false,
+		"passed":      false,
+		"os":          runtime.GOOS,
+		"arch":        runtime.GOARCH,
+	} {
+		if !reflect.DeepEqual(got[field], expect) {
+			t.Errorf("Metrics field %v was %v, expected %v", field, got[field], expect)
+		}
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	if err := telemetry.Send(failing.URL, m); err == nil {
+		t.Errorf("Send() returned no error for a failed request")
+	}
+}
+
+func TestValidateEndpoint(t *testing.T) {
+	for endpoint, valid := range map[string]bool{
+		"https://metrics.example.com/license-checker": true,
+		"http://localhost:8080/":                      true,
+		"metrics.example.com":                         false,
+		"file:///tmp/metrics":                         false,
+		"https://":                                    false,
+	} {
+		if err := telemetry.ValidateEndpoint(endpoint); (err == nil) != valid {
+			t.Errorf("ValidateEndpoint(%v) returned %v", endpoint, err)
+		}
+	}
+}
@@ -0,0 +1,503 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package toml parses the subset of TOML used by pyproject.toml files:
+// tables, arrays of tables, dotted and quoted keys, strings, integers, floats,
+// booleans, arrays and inline tables. Other syntax, such as dates and times,
+// is reported as an error rather than misread.
+package toml
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Parse parses the TOML document s. Tables are returned as
+// map[string]interface{}, arrays and arrays of tables as []interface{},
+// strings as string, integers as int64, floats as float64 and booleans as
+// bool.
+func Parse(s string) (map[string]interface{}, error) {
+	p := parser{s: s, line: 1, defined: map[uintptr]bool{}}
+	root := map[string]interface{}{}
+	table := root
+	for {
+		p.skip(true)
+		if p.eof() {
+			return root, nil
+		}
+		if p.consume("[") {
+			array := p.consume("[")
+			keys, err := p.key()
+			if err != nil {
+				return nil, err
+			}
+			if !p.consume("]") || (array && !p.consume("]")) {
+				return nil, p.errorf("expected ']' to close the table header")
+			}
+			if table, err = p.table(root, keys, array); err != nil {
+				return nil, err
+			}
+		} else {
+			keys, err := p.key()
+			if err != nil {
+				return nil, err
+			}
+			if !p.consume("=") {
+				return nil, p.errorf("expected '=' after key '%v'", strings.Join(keys, "."))
+			}
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			if err = p.set(table, keys, value); err != nil {
+				return nil, err
+			}
+		}
+		p.skip(false)
+		if !p.eof() && !p.newline() {
+			return nil, p.errorf("expected a new line")
+		}
+	}
+}
+
+// parser is the state of Parse.
+type parser struct {
+	s    string
+	pos  int
+	line int
+
+	// defined holds the tables declared by a table header or an inline
+	// table, which may not be declared again, by map pointer.
+	defined map[uintptr]bool
+}
+
+func (p *parser) eof() bool { return p.pos >= len(p.s) }
+
+func (p *parser) peek(c byte) bool { return !p.eof() && p.s[p.pos] == c }
+
+func (p *parser) errorf(msg string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %v", p.line, fmt.Sprintf(msg, args...))
+}
+
+// consume skips over prefix if the input continues with it, returning true if
+// it did.
+func (p *parser) consume(prefix string) bool {
+	if !strings.HasPrefix(p.s[p.pos:], prefix) {
+		return false
+	}
+	p.line += strings.Count(prefix, "\n")
+	p.pos += len(prefix)
+	return true
+}
+
+// newline skips over a '\n' or '\r\n' line ending, returning true if there
+// was one.
+func (p *parser) newline() bool {
+	return p.consume("\n") || p.consume("\r\n")
+}
+
+// skip skips over spaces, tabs and comments, and line endings if newlines is
+// true.
+func (p *parser) skip(newlines bool) {
+	for !p.eof() {
+		switch {
+		case p.peek(' ') || p.peek('\t'):
+			p.pos++
+		case p.peek('#'):
+			for !p.eof() && !p.peek('\n') {
+				p.pos++
+			}
+		case newlines && p.newline():
+		default:
+			return
+		}
+	}
+}
+
+// key parses a dotted key, and the whitespace that follows it.
+func (p *parser) key() ([]string, error) {
+	keys := []string{}
+	for {
+		p.skip(false)
+		var key string
+		switch {
+		case p.consume(`"`):
+			s, err := p.basicString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		case p.consume(`'`):
+			s, err := p.literalString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		default:
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.s[p.pos]) {
+				p.pos++
+			}
+			if p.pos == start {
+				return nil, p.errorf("expected a key")
+			}
+			key = p.s[start:p.pos]
+		}
+		keys = append(keys, key)
+		p.skip(false)
+		if !p.consume(".") {
+			return keys, nil
+		}
+	}
+}
+
+// isBareKeyChar returns true if c may be used in a bare key.
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// table returns the table of the header with the keys, creating it if needed.
+// If array is true, a new table is appended to the array of tables.
+func (p *parser) table(root map[string]interface{}, keys []string, array bool) (map[string]interface{}, error) {
+	parent, err := p.parent(root, keys)
+	if err != nil {
+		return nil, err
+	}
+	last := keys[len(keys)-1]
+	if array {
+		list, ok := parent[last].([]interface{})
+		if _, exists := parent[last]; exists && !ok {
+			return nil, p.errorf("'%v' is not an array of tables", strings.Join(keys, "."))
+		}
+		table := map[string]interface{}{}
+		parent[last] = append(list, table)
+		return table, nil
+	}
+	switch v := parent[last].(type) {
+	case nil:
+		table := map[string]interface{}{}
+		parent[last] = table
+		p.defined[reflect.ValueOf(table).Pointer()] = true
+		return table, nil
+	case map[string]interface{}:
+		if p.defined[reflect.ValueOf(v).Pointer()] {
+			return nil, p.errorf("duplicate table '%v'", strings.Join(keys, "."))
+		}
+		p.defined[reflect.ValueOf(v).Pointer()] = true
+		return v, nil
+	default:
+		return nil, p.errorf("'%v' is not a table", strings.Join(keys, "."))
+	}
+}
+
+// parent returns the table that holds the last of the keys, starting at table,
+// and creating the intermediate tables if needed. For arrays of tables, the
+// last table of the array is used.
+func (p *parser) parent(table map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	for i, key := range keys[:len(keys)-1] {
+		switch v := table[key].(type) {
+		case nil:
+			next := map[string]interface{}{}
+			table[key] = next
+			table = next
+		case map[string]interface{}:
+			table = v
+		case []interface{}:
+			last, ok := map[string]interface{}(nil), false
+			if len(v) > 0 {
+				last, ok = v[len(v)-1].(map[string]interface{})
+			}
+			if !ok {
+				return nil, p.errorf("'%v' is not a table", strings.Join(keys[:i+1], "."))
+			}
+			table = last
+		default:
+			return nil, p.errorf("'%v' is not a table", strings.Join(keys[:i+1], "."))
+		}
+	}
+	return table, nil
+}
+
+// set sets the dotted keys of table to value.
+func (p *parser) set(table map[string]interface{}, keys []string, value interface{}) error {
+	parent, err := p.parent(table, keys)
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	if _, exists := parent[last]; exists {
+		return p.errorf("duplicate key '%v'", strings.Join(keys, "."))
+	}
+	if table, ok := value.(map[string]interface{}); ok {
+		p.defined[reflect.ValueOf(table).Pointer()] = true
+	}
+	parent[last] = value
+	return nil
+}
+
+// value parses a value.
+func (p *parser) value() (interface{}, error) {
+	p.skip(false)
+	switch {
+	case p.consume(`"""`):
+		return p.multilineBasicString()
+	case p.consume(`'''`):
+		return p.multilineLiteralString()
+	case p.consume(`"`):
+		return p.basicString()
+	case p.consume(`'`):
+		return p.literalString()
+	case p.consume("["):
+		return p.array()
+	case p.consume("{"):
+		return p.inlineTable()
+	}
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(",]}# \t\r\n", rune(p.s[p.pos])) {
+		p.pos++
+	}
+	return p.scalar(p.s[start:p.pos])
+}
+
+// array parses the rest of an array.
+func (p *parser) array() (interface{}, error) {
+	list := []interface{}{}
+	for {
+		p.skip(true)
+		if p.consume("]") {
+			return list, nil
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+		p.skip(true)
+		if !p.consume(",") && !p.peek(']') {
+			return nil, p.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+// inlineTable parses the rest of an inline table.
+func (p *parser) inlineTable() (interface{}, error) {
+	table := map[string]interface{}{}
+	p.skip(false)
+	if p.consume("}") {
+		return table, nil
+	}
+	for {
+		keys, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume("=") {
+			return nil, p.errorf("expected '=' after key '%v'", strings.Join(keys, "."))
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.set(table, keys, v); err != nil {
+			return nil, err
+		}
+		p.skip(false)
+		if p.consume("}") {
+			return table, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expected ',' or '}' in inline table")
+		}
+	}
+}
+
+var (
+	decimalRE = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)$`)
+	prefixRE  = regexp.MustCompile(`^0(x[0-9a-fA-F](_?[0-9a-fA-F])*|o[0-7](_?[0-7])*|b[01](_?[01])*)$`)
+	floatRE   = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)(\.[0-9](_?[0-9])*)?([eE][+-]?[0-9](_?[0-9])*)?$`)
+)
+
+// scalar parses a boolean or number token.
+func (p *parser) scalar(token string) (interface{}, error) {
+	number := strings.ReplaceAll(token, "_", "")
+	switch {
+	case token == "":
+		return nil, p.errorf("expected a value")
+	case token == "true":
+		return true, nil
+	case token == "false":
+		return false, nil
+	case decimalRE.MatchString(token):
+		return p.integer(number, 10)
+	case prefixRE.MatchString(token):
+		return p.integer(number[2:], map[byte]int{'x': 16, 'o': 8, 'b': 2}[number[1]])
+	case floatRE.MatchString(token):
+		f, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return nil, p.errorf("invalid float '%v': %v", token, err)
+		}
+		return f, nil
+	}
+	switch strings.TrimLeft(token, "+-") {
+	case "inf":
+		if strings.HasPrefix(token, "-") {
+			return math.Inf(-1), nil
+		}
+		return math.Inf(1), nil
+	case "nan":
+		return math.NaN(), nil
+	}
+	return nil, p.errorf("unsupported value '%v'. Dates, times and bare strings are not supported", token)
+}
+
+// integer parses the digits of an integer in the given base.
+func (p *parser) integer(digits string, base int) (interface{}, error) {
+	i, err := strconv.ParseInt(digits, base, 64)
+	if err != nil {
+		return nil, p.errorf("invalid integer '%v': %v", digits, err)
+	}
+	return i, nil
+}
+
+// basicString parses the rest of a single-line basic string.
+func (p *parser) basicString() (string, error) {
+	out := strings.Builder{}
+	for {
+		if p.eof() || p.peek('\n') {
+			return "", p.errorf("unterminated string")
+		}
+		switch c := p.s[p.pos]; c {
+		case '"':
+			p.pos++
+			return out.String(), nil
+		case '\\':
+			if err := p.escape(&out); err != nil {
+				return "", err
+			}
+		default:
+			out.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// multilineBasicString parses the rest of a multi-line basic string. A
+// backslash at the end of a line trims the line ending and the whitespace
+// that follows it.
+func (p *parser) multilineBasicString() (string, error) {
+	p.newline() // A line ending that follows the delimiter is trimmed
+	out := strings.Builder{}
+	for {
+		switch {
+		case p.eof():
+			return "", p.errorf("unterminated string")
+		case p.consume(`"""`):
+			// Up to two quotes may precede the closing delimiter.
+			for i := 0; i < 2 && p.consume(`"`); i++ {
+				out.WriteByte('"')
+			}
+			return out.String(), nil
+		case p.peek('\\') && p.continuation():
+		case p.peek('\\'):
+			if err := p.escape(&out); err != nil {
+				return "", err
+			}
+		case p.newline():
+			out.WriteByte('\n')
+		default:
+			out.WriteByte(p.s[p.pos])
+			p.pos++
+		}
+	}
+}
+
+// continuation skips over a line ending backslash, and the whitespace and
+// line endings that follow it, returning true if the backslash at the current
+// position ends the line.
+func (p *parser) continuation() bool {
+	end := p.pos + 1
+	for end < len(p.s) && (p.s[end] == ' ' || p.s[end] == '\t') {
+		end++
+	}
+	if !strings.HasPrefix(p.s[end:], "\n") && !strings.HasPrefix(p.s[end:], "\r\n") {
+		return false
+	}
+	p.pos = end
+	for !p.eof() && (p.peek(' ') || p.peek('\t') || p.newline()) {
+		if p.peek(' ') || p.peek('\t') {
+			p.pos++
+		}
+	}
+	return true
+}
+
+// escape parses the escape sequence at the current position, writing the
+// character it represents to out.
+func (p *parser) escape(out *strings.Builder) error {
+	if p.pos+1 >= len(p.s) {
+		return p.errorf("unterminated string")
+	}
+	c := p.s[p.pos+1]
+	p.pos += 2
+	if r, ok := map[byte]byte{'b': '\b', 't': '\t', 'n': '\n', 'f': '\f', 'r': '\r', '"': '"', '\\': '\\'}[c]; ok {
+		out.WriteByte(r)
+		return nil
+	}
+	digits := map[byte]int{'u': 4, 'U': 8}[c]
+	if digits == 0 {
+		return p.errorf("invalid escape sequence '\\%c'", c)
+	}
+	if p.pos+digits > len(p.s) {
+		return p.errorf("invalid escape sequence '\\%c'", c)
+	}
+	code, err := strconv.ParseUint(p.s[p.pos:p.pos+digits], 16, 32)
+	if err != nil || !utf8.ValidRune(rune(code)) {
+		return p.errorf("invalid escape sequence '\\%c%v'", c, p.s[p.pos:p.pos+digits])
+	}
+	p.pos += digits
+	out.WriteRune(rune(code))
+	return nil
+}
+
+// literalString parses the rest of a single-line literal string.
+func (p *parser) literalString() (string, error) {
+	end := strings.IndexAny(p.s[p.pos:], "'\n")
+	if end < 0 || p.s[p.pos+end] != '\'' {
+		return "", p.errorf("unterminated string")
+	}
+	s := p.s[p.pos : p.pos+end]
+	p.pos += end + 1
+	return s, nil
+}
+
+// multilineLiteralString parses the rest of a multi-line literal string.
+func (p *parser) multilineLiteralString() (string, error) {
+	p.newline() // A line ending that follows the delimiter is trimmed
+	end := strings.Index(p.s[p.pos:], `'''`)
+	if end < 0 {
+		return "", p.errorf("unterminated string")
+	}
+	// Up to two quotes may precede the closing delimiter.
+	for i := 0; i < 2 && strings.HasPrefix(p.s[p.pos+end+1:], `'''`); i++ {
+		end++
+	}
+	s := p.s[p.pos : p.pos+end]
+	p.consume(s + `'''`)
+	return strings.ReplaceAll(s, "\r\n", "\n"), nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toml_test
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	toml "."
+)
+
+func TestParse(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		doc    string
+		expect map[string]interface{}
+	}{
+		{"empty", "# Just a comment\n\n", map[string]interface{}{}},
+		{"keys", "a = 1\n\"b.c\" = 2\n'd' = 3\ne.f = 4 # comment\n", map[string]interface{}{
+			"a": int64(1), "b.c": int64(2), "d": int64(3), "e": map[string]interface{}{"f": int64(4)},
+		}},
+		{"integers", "a = +10\nb = -1_000\nc = 0\nd = 0xff\ne = 0o17\nf = 0b101\n", map[string]interface{}{
+			"a": int64(10), "b": int64(-1000), "c": int64(0), "d": int64(255), "e": int64(15), "f": int64(5),
+		}},
+		{"floats", "a = 1.5\nb = -2e3\nc = 6.25E-1\nd = 1_000.5\n", map[string]interface{}{
+			"a": 1.5, "b": -2000.0, "c": 0.625, "d": 1000.5,
+		}},
+		{"booleans", "a = true\nb = false\n", map[string]interface{}{"a": true, "b": false}},
+		{"strings", `a = "tab\there \"quoted\" \u00e9 \U0001F600"` + "\nb = 'C:\\no\\escapes'\n", map[string]interface{}{
+			"a": "tab\there \"quoted\" \u00e9 \U0001F600", "b": `C:\no\escapes`,
+		}},
+		{"multi-line basic strings", "a = \"\"\"\nline one\r\nline two\"\"\"\nb = \"\"\"joined \\\n    together\"\"\"\nc = \"\"\"quote\"\"\"\"\"\n", map[string]interface{}{
+			"a": "line one\nline two", "b": "joined together", "c": `quote""`,
+		}},
+		{"multi-line literal strings", "a = '''\nraw \\n\ntext'''\n", map[string]interface{}{
+			"a": "raw \\n\ntext",
+		}},
+		{"arrays", "a = [\n  1, # one\n  2,\n]\nb = [ [ \"x\" ], [] ]\n", map[string]interface{}{
+			"a": []interface{}{int64(1), int64(2)},
+			"b": []interface{}{[]interface{}{"x"}, []interface{}{}},
+		}},
+		{"inline tables", "a = { b = 1, c.d = \"e\" }\nf = {}\n", map[string]interface{}{
+			"a": map[string]interface{}{"b": int64(1), "c": map[string]interface{}{"d": "e"}},
+			"f": map[string]interface{}{},
+		}},
+		{"tables", "[tool.x]\na = 1\n[tool.\"y.z\"]\nb = 2\n[tool]\nc = 3\n", map[string]interface{}{
+			"tool": map[string]interface{}{
+				"x": map[string]interface{}{"a": int64(1)}, "y.z": map[string]interface{}{"b": int64(2)}, "c": int64(3),
+			},
+		}},
+		{"arrays of tables", "[[a]]\nb = 1\n[a.c]\nd = 2\n[[a]]\nb = 3\n", map[string]interface{}{
+			"a": []interface{}{
+				map[string]interface{}{"b": int64(1), "c": map[string]interface{}{"d": int64(2)}},
+				map[string]interface{}{"b": int64(3)},
+			},
+		}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := toml.Parse(test.doc)
+			if err != nil {
+				t.Fatalf("Parse() returned %v", err)
+			}
+			if !reflect.DeepEqual(got, test.expect) {
+				t.Errorf("Parse() returned:\n%#v\nexpected:\n%#v", got, test.expect)
+			}
+		})
+	}
+}
+
+func TestParseSpecialFloats(t *testing.T) {
+	got, err := toml.Parse("a = inf\nb = -inf\nc = nan\n")
+	if err != nil {
+		t.Fatalf("Parse() returned %v", err)
+	}
+	if a, b, c := got["a"].(float64), got["b"].(float64), got["c"].(float64); !math.IsInf(a, 1) || !math.IsInf(b, -1) || !math.IsNaN(c) {
+		t.Errorf("Parse() returned %v, %v, %v", a, b, c)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, test := range []struct {
+		doc    string
+		expect string
+	}{
+		{"a = 010\n", "line 1: unsupported value '010'"},
+		{"a = 1979-05-27\n", "line 1: unsupported value '1979-05-27'. Dates, times and bare strings are not supported"},
+		{"a = 07:32:00\n", "line 1: unsupported value '07:32:00'"},
+		{"a = bare\n", "line 1: unsupported value 'bare'"},
+		{"a = 1__0\n", "line 1: unsupported value '1__0'"},
+		{"a = 0x\n", "line 1: unsupported value '0x'"},
+		{"a = 99999999999999999999\n", "line 1: invalid integer"},
+		{"\n\na = \"open\n", "line 3: unterminated string"},
+		{"a = \"bad \\x41\"\n", "line 1: invalid escape sequence '\\x'"},
+		{"a = \"\"\"never closed\n", "unterminated string"},
+		{"a = 1\na = 2\n", "line 2: duplicate key 'a'"},
+		{"[a]\nb = 1\n[a]\nc = 2\n", "line 3: duplicate table 'a'"},
+		{"[a.b]\n[a]\n[a]\n", "line 3: duplicate table 'a'"},
+		{"a = { b = 1 }\n[a]\n", "line 2: duplicate table 'a'"},
+		{"a = 1\n[a]\n", "line 2: 'a' is not a table"},
+		{"[a]\n[[a]]\n", "line 2: 'a' is not an array of tables"},
+		{"[a\n", "line 1: expected ']' to close the table header"},
+		{"a 1\n", "line 1: expected '=' after key 'a'"},
+		{"a = 1 b = 2\n", "line 1: expected a new line"},
+		{"a = [ 1 2 ]\n", "line 1: expected ',' or ']' in array"},
+		{"a = { b = 1 c = 2 }\n", "line 1: expected ',' or '}' in inline table"},
+		{"= 1\n", "line 1: expected a key"},
+		{"a =\n", "line 1: expected a value"},
+	} {
+		if _, err := toml.Parse(test.doc); err == nil || !strings.Contains(err.Error(), test.expect) {
+			t.Errorf("Parse(%q) returned %v, expected '%v'", test.doc, err, test.expect)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// JS bindings for license-checker.wasm. Requires the wasm_exec.js support file
+// shipped with the Go toolchain (`$(go env GOROOT)/misc/wasm/wasm_exec.js`, or
+// `lib/wasm/wasm_exec.js` for newer releases) to be loaded first.
+//
+// Usage:
+//
+//   const checker = await LicenseChecker.load('license-checker.wasm');
+//   const results = checker.check(configJSON, 'src/foo.cpp', fileContent);
+//   const licenses = checker.detect(fileContent);
+
+class LicenseChecker {
+  // load fetches and starts the WebAssembly module at url, returning a
+  // LicenseChecker once it is ready.
+  static async load(url) {
+    const go = new Go();
+    const source = await WebAssembly.instantiateStreaming(fetch(url), go.importObject);
+    go.run(source.instance);
+    return new LicenseChecker(globalThis.licenseChecker);
+  }
+
+  constructor(impl) {
+    this.impl = impl;
+  }
+
+  // check checks the content of the file at the project relative path against
+  // the license-checker.cfg content config, returning an array of results with
+  // path, licenses, advisory, and for violations, error, kind and fingerprint.
+  // Files not examined by the config have a skipped reason.
+  check(config, path, content) {
+    return unwrap(this.impl.check(config, path, content)).results;
+  }
+
+  // detect returns the license identifiers found in content, using the named
+  // detector, or the default detector if omitted.
+  detect(content, detector) {
+    return unwrap(this.impl.detect(content, detector)).licenses;
+  }
+}
+
+// unwrap throws the error of a result object returned by the WebAssembly
+// module, or returns the object if it holds no error.
+function unwrap(result) {
+  if (result.error !== undefined) {
+    throw new Error(result.error);
+  }
+  return result;
+}
+
+if (typeof module !== 'undefined') {
+  module.exports = LicenseChecker;
+}
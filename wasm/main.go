@@ -0,0 +1,116 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build js && wasm
+// +build js,wasm
+
+// wasm is the WebAssembly build of the license checker core. It registers a
+// global 'licenseChecker' object with 'check' and 'detect' functions, which
+// are wrapped by license-checker.js.
+//
+// Build with:
+//
+//	GOOS=js GOARCH=wasm go build -o license-checker.wasm ./wasm
+package main
+
+import (
+	"syscall/js"
+
+	"../checker"
+	"../detector"
+)
+
+func main() {
+	js.Global().Set("licenseChecker", js.ValueOf(map[string]interface{}{
+		"check":  js.FuncOf(check),
+		"detect": js.FuncOf(detect),
+	}))
+	select {} // Keep the functions alive
+}
+
+// check implements licenseChecker.check(config, path, content), which checks
+// the file content at the project relative path against the JSON config.
+// It returns an object with either a 'results' array or an 'error' string.
+func check(this js.Value, args []js.Value) interface{} {
+	if len(args) != 3 {
+		return failure("check() requires config, path and content arguments")
+	}
+	cfgs, err := checker.ParseConfigs([]byte(args[0].String()))
+	if err != nil {
+		return failure("Failed to parse config: " + err.Error())
+	}
+	results, err := checker.CheckContent(cfgs, args[1].String(), content(args[2]), nil)
+	if err != nil {
+		return failure(err.Error())
+	}
+	out := []interface{}{}
+	for _, res := range results {
+		r := map[string]interface{}{
+			"path":     res.Path,
+			"licenses": strings(res.Licenses),
+			"advisory": res.Advisory,
+		}
+		if res.Err != nil {
+			r["error"] = res.Err.Error()
+			r["kind"] = string(res.Kind)
+			r["fingerprint"] = res.Fingerprint
+		}
+		if res.Skipped != "" {
+			r["skipped"] = res.Skipped
+		}
+		out = append(out, r)
+	}
+	return map[string]interface{}{"results": out}
+}
+
+// detect implements licenseChecker.detect(content, [detector]), which
+// returns an object with the 'licenses' found in the content, or an 'error'.
+func detect(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return failure("detect() requires a content argument")
+	}
+	name := ""
+	if len(args) > 1 && args[1].Type() == js.TypeString {
+		name = args[1].String()
+	}
+	d, err := detector.New(name, nil)
+	if err != nil {
+		return failure(err.Error())
+	}
+	return map[string]interface{}{"licenses": strings(d.Detect(content(args[0])))}
+}
+
+// content returns the bytes of a JS string or Uint8Array.
+func content(v js.Value) []byte {
+	if v.Type() == js.TypeString {
+		return []byte(v.String())
+	}
+	body := make([]byte, v.Get("length").Int())
+	js.CopyBytesToGo(body, v)
+	return body
+}
+
+// strings converts the string slice to a slice that js.ValueOf accepts.
+func strings(l []string) []interface{} {
+	out := make([]interface{}, len(l))
+	for i, s := range l {
+		out[i] = s
+	}
+	return out
+}
+
+// failure returns the result object for the error message msg.
+func failure(msg string) interface{} {
+	return map[string]interface{}{"error": msg}
+}